@@ -0,0 +1,255 @@
+package archive
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ManifestName is the name manifests are written under, in zips and
+// output directories
+const ManifestName = "manifest.json"
+
+// Manifest describes the files in an export or archive
+type Manifest struct {
+	Generator string         `json:"generator,omitempty"` // Tool and version that last wrote the files
+	Files     []ManifestFile `json:"files"`
+
+	// Watermark is the text stamped on watermarked exports
+	Watermark string `json:"watermark,omitempty"`
+}
+
+// ManifestFile is a single file entry in a manifest
+type ManifestFile struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+
+	// Source and Options record where an exported file came from and the
+	// export options that shaped it
+	Source  *Source                `json:"source,omitempty"`
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// Source identifies the session file an export was made from
+type Source struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// NewSource describes session data read from the file name
+func NewSource(name string, data []byte) *Source {
+	sum := sha256.Sum256(data)
+	return &Source{Name: name, SHA256: hex.EncodeToString(sum[:]), Size: int64(len(data))}
+}
+
+// HashFile returns the manifest entry of the file at path, listed as rel
+func HashFile(path, rel string) (ManifestFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	return ManifestFile{Path: filepath.ToSlash(rel), SHA256: hex.EncodeToString(h.Sum(nil)), Size: size}, nil
+}
+
+// UpdateManifest adds files to the manifest in dir, replacing the entries
+// of files exported there before, and drops entries whose files are gone
+func UpdateManifest(dir, generator string, files []ManifestFile) error {
+	path := filepath.Join(dir, ManifestName)
+	var m Manifest
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &m); err != nil {
+			return fmt.Errorf("parsing %s: %w", path, err)
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	updated := make(map[string]bool)
+	for _, f := range files {
+		updated[f.Path] = true
+	}
+	kept := m.Files[:0]
+	for _, f := range m.Files {
+		if updated[f.Path] {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(f.Path))); err == nil {
+			kept = append(kept, f)
+		}
+	}
+	m.Files = append(kept, files...)
+	sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Path < m.Files[j].Path })
+	m.Generator = generator
+
+	data, err = json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Inventory maps session file paths (slash-separated, relative to the
+// archive root) to their SHA-256 checksums
+type Inventory map[string]string
+
+// isSessionFile reports whether a file holds a session (raw or rendered)
+func isSessionFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jsonl", ".html":
+		return true
+	}
+	return false
+}
+
+// Load builds an inventory from an archive directory, a zip file, or a
+// manifest.json
+func Load(path string) (Inventory, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case info.IsDir():
+		return loadDir(path)
+	case strings.EqualFold(filepath.Ext(path), ".zip"):
+		return loadZip(path)
+	case strings.EqualFold(filepath.Ext(path), ".json"):
+		return loadManifest(path)
+	}
+	return nil, fmt.Errorf("%s: expected a directory, .zip or manifest .json", path)
+}
+
+func loadDir(root string) (Inventory, error) {
+	inv := make(Inventory)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isSessionFile(path) {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		sum, err := hashReader(f)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		inv[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading archive directory: %w", err)
+	}
+	return inv, nil
+}
+
+func loadZip(path string) (Inventory, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening zip: %w", err)
+	}
+	defer r.Close()
+
+	inv := make(Inventory)
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || !isSessionFile(f.Name) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", f.Name, err)
+		}
+		sum, err := hashReader(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", f.Name, err)
+		}
+		inv[f.Name] = sum
+	}
+	return inv, nil
+}
+
+func loadManifest(path string) (Inventory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+
+	inv := make(Inventory)
+	for _, f := range m.Files {
+		if isSessionFile(f.Path) {
+			inv[f.Path] = f.SHA256
+		}
+	}
+	return inv, nil
+}
+
+func hashReader(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// DiffResult lists how the sessions in two inventories differ
+type DiffResult struct {
+	Added     []string
+	Removed   []string
+	Changed   []string
+	Unchanged int
+}
+
+// Diff compares an old and new inventory
+func Diff(old, new Inventory) DiffResult {
+	var result DiffResult
+	for path, sum := range new {
+		oldSum, ok := old[path]
+		switch {
+		case !ok:
+			result.Added = append(result.Added, path)
+		case oldSum != sum:
+			result.Changed = append(result.Changed, path)
+		default:
+			result.Unchanged++
+		}
+	}
+	for path := range old {
+		if _, ok := new[path]; !ok {
+			result.Removed = append(result.Removed, path)
+		}
+	}
+
+	sort.Strings(result.Added)
+	sort.Strings(result.Removed)
+	sort.Strings(result.Changed)
+	return result
+}
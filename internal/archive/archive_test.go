@@ -0,0 +1,94 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+}
+
+func TestDiffDirectories(t *testing.T) {
+	oldDir, _ := os.MkdirTemp("", "archive-old-*")
+	defer os.RemoveAll(oldDir)
+	newDir, _ := os.MkdirTemp("", "archive-new-*")
+	defer os.RemoveAll(newDir)
+
+	writeFiles(t, oldDir, map[string]string{
+		"proj/a.jsonl": "a",
+		"proj/b.jsonl": "b",
+		"proj/c.jsonl": "c",
+		"notes.txt":    "ignored",
+	})
+	writeFiles(t, newDir, map[string]string{
+		"proj/a.jsonl": "a",
+		"proj/b.jsonl": "b changed",
+		"proj/d.jsonl": "d",
+	})
+
+	old, err := Load(oldDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	new, err := Load(newDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	result := Diff(old, new)
+
+	if len(result.Added) != 1 || result.Added[0] != "proj/d.jsonl" {
+		t.Errorf("Expected proj/d.jsonl added, got %v", result.Added)
+	}
+	if len(result.Removed) != 1 || result.Removed[0] != "proj/c.jsonl" {
+		t.Errorf("Expected proj/c.jsonl removed, got %v", result.Removed)
+	}
+	if len(result.Changed) != 1 || result.Changed[0] != "proj/b.jsonl" {
+		t.Errorf("Expected proj/b.jsonl changed, got %v", result.Changed)
+	}
+	if result.Unchanged != 1 {
+		t.Errorf("Expected 1 unchanged, got %d", result.Unchanged)
+	}
+}
+
+func TestUpdateManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.html": "a", "b.html": "b", "gone.html": "x"})
+	entry := func(name string) ManifestFile {
+		f, err := HashFile(filepath.Join(dir, name), name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Source = NewSource("s.jsonl", []byte("{}"))
+		return f
+	}
+
+	if err := UpdateManifest(dir, "tool 1", []ManifestFile{entry("b.html"), entry("gone.html")}); err != nil {
+		t.Fatalf("UpdateManifest failed: %v", err)
+	}
+	os.Remove(filepath.Join(dir, "gone.html"))
+	writeFiles(t, dir, map[string]string{"b.html": "b changed"})
+	if err := UpdateManifest(dir, "tool 2", []ManifestFile{entry("a.html"), entry("b.html")}); err != nil {
+		t.Fatalf("UpdateManifest failed: %v", err)
+	}
+
+	inv, err := Load(filepath.Join(dir, ManifestName))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	current, _ := Load(dir)
+	if len(inv) != 2 || inv["a.html"] != current["a.html"] || inv["b.html"] != current["b.html"] {
+		t.Errorf("Expected the manifest to match the directory, got %v want %v", inv, current)
+	}
+}
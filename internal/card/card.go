@@ -0,0 +1,168 @@
+package card
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+)
+
+// Size of a card, the 1.91:1 shape link previews use
+const (
+	Width  = 1200
+	Height = 630
+)
+
+// margin is the space around the card's text
+const margin = 80
+
+var (
+	background = color.RGBA{0x11, 0x11, 0x13, 0xff}
+	accent     = color.RGBA{0x3b, 0x82, 0xf6, 0xff}
+	primary    = color.RGBA{0xfa, 0xfa, 0xfa, 0xff}
+	secondary  = color.RGBA{0xa1, 0xa1, 0xaa, 0xff}
+	muted      = color.RGBA{0x71, 0x71, 0x7a, 0xff}
+)
+
+// Card is what a session's preview image shows
+type Card struct {
+	Title    string
+	Project  string
+	Duration string // e.g. "1h 5m"
+	Tokens   string // e.g. "1.2M"
+}
+
+// PNG draws the card as a Width by Height PNG: the title large, wrapped
+// to three lines, the project under it and the duration and token count
+// at the bottom. Text outside printable ASCII is shown as '?'.
+func PNG(c Card) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{background}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 12, Height), &image.Uniform{accent}, image.Point{}, draw.Src)
+
+	drawText(img, margin, margin, 3, accent, "CLAUDE CODE SESSION")
+
+	title := c.Title
+	if title == "" {
+		title = "Untitled session"
+	}
+	y := margin + 60
+	for _, line := range wrap(title, columns(7), 3) {
+		drawText(img, margin, y, 7, primary, line)
+		y += 7 * 10
+	}
+	if c.Project != "" {
+		drawText(img, margin, y+16, 4, secondary, fit(c.Project, columns(4)))
+	}
+
+	x := margin
+	for _, stat := range [][2]string{{"DURATION", c.Duration}, {"TOKENS", c.Tokens}} {
+		if stat[1] == "" {
+			continue
+		}
+		drawText(img, x, Height-margin-70, 3, muted, stat[0])
+		drawText(img, x, Height-margin-42, 6, primary, fit(stat[1], 10))
+		x += 420
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// columns is how many characters fit across the card at a scale
+func columns(scale int) int {
+	return (Width - 2*margin) / (6 * scale)
+}
+
+// drawText draws text with its top left corner at x, y, each font pixel
+// scale pixels square
+func drawText(img *image.RGBA, x, y, scale int, c color.Color, text string) {
+	fill := &image.Uniform{c}
+	for _, r := range text {
+		if r < ' ' || r > '~' {
+			r = '?'
+		}
+		for row, bits := range glyphs[r-' '] {
+			for col := 0; col < 5; col++ {
+				if bits&(0x10>>col) == 0 {
+					continue
+				}
+				px, py := x+col*scale, y+row*scale
+				draw.Draw(img, image.Rect(px, py, px+scale, py+scale), fill, image.Point{}, draw.Src)
+			}
+		}
+		x += 6 * scale
+	}
+}
+
+// wrap breaks text into at most lines lines of width characters, at
+// spaces where it can, ending the last with "..." if text is cut short
+func wrap(text string, width, lines int) []string {
+	var out []string
+	line := ""
+	for _, word := range strings.Fields(ascii(text)) {
+		// Words longer than a line are split
+		for len(word) > width {
+			if line != "" {
+				out = append(out, line)
+				line = ""
+			}
+			out = append(out, word[:width])
+			word = word[width:]
+		}
+		switch {
+		case word == "":
+		case line == "":
+			line = word
+		case len(line)+1+len(word) <= width:
+			line += " " + word
+		default:
+			out = append(out, line)
+			line = word
+		}
+	}
+	if line != "" {
+		out = append(out, line)
+	}
+	if len(out) > lines {
+		rest := strings.Join(out[lines-1:], " ")
+		out = out[:lines]
+		out[lines-1] = fit(rest, width)
+	}
+	return out
+}
+
+// fit shortens text to width characters, ending it with "..." if cut
+func fit(text string, width int) string {
+	text = ascii(text)
+	if len(text) <= width {
+		return text
+	}
+	return strings.TrimSpace(text[:width-3]) + "..."
+}
+
+// ascii replaces typographic punctuation with its ASCII look-alike, and
+// anything else the font lacks with '?', so lengths count characters
+func ascii(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '‘', '’':
+			return '\''
+		case '“', '”':
+			return '"'
+		case '–', '—':
+			return '-'
+		case '\t', '\n':
+			return ' '
+		}
+		if r < ' ' || r > '~' {
+			return '?'
+		}
+		return r
+	}, text)
+}
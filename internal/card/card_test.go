@@ -0,0 +1,40 @@
+package card
+
+import (
+	"bytes"
+	"image/png"
+	"reflect"
+	"testing"
+)
+
+func TestPNG(t *testing.T) {
+	data, err := PNG(Card{Title: "Fix the flaky upload test", Project: "api", Duration: "1h 5m", Tokens: "1.2M"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != Width || b.Dy() != Height {
+		t.Errorf("Expected a %dx%d image, got %v", Width, Height, b)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	for _, tc := range []struct {
+		text  string
+		width int
+		lines int
+		want  []string
+	}{
+		{"Fix the upload test", 10, 3, []string{"Fix the", "upload", "test"}},
+		{"Fix the upload test and the retries", 10, 2, []string{"Fix the", "upload..."}},
+		{"internationalization", 8, 3, []string{"internat", "ionaliza", "tion"}},
+		{"Tidy “quotes” — café", 30, 1, []string{`Tidy "quotes" - caf?`}},
+	} {
+		if got := wrap(tc.text, tc.width, tc.lines); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("wrap(%q, %d, %d) = %q, want %q", tc.text, tc.width, tc.lines, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/robzolkos/claude-session-export/internal/archive"
+)
+
+func runArchive(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: claude-session-export archive diff <old> <new>")
+	}
+
+	switch args[0] {
+	case "diff":
+		return runArchiveDiff(args[1:])
+	default:
+		return fmt.Errorf("unknown archive command %q", args[0])
+	}
+}
+
+func runArchiveDiff(args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: claude-session-export archive diff <old> <new>")
+	}
+
+	old, err := archive.Load(args[0])
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", args[0], err)
+	}
+	new, err := archive.Load(args[1])
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", args[1], err)
+	}
+
+	result := archive.Diff(old, new)
+
+	for _, path := range result.Added {
+		fmt.Printf("%s+ %s%s\n", colorCyan, path, colorReset)
+	}
+	for _, path := range result.Removed {
+		fmt.Printf("%s- %s%s\n", colorYellow, path, colorReset)
+	}
+	for _, path := range result.Changed {
+		fmt.Printf("~ %s\n", path)
+	}
+
+	fmt.Printf("\n%d added, %d removed, %d changed, %d unchanged\n",
+		len(result.Added), len(result.Removed), len(result.Changed), result.Unchanged)
+	return nil
+}
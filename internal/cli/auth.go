@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/robzolkos/claude-session-export/internal/web"
+)
+
+const authUsage = "usage: claude-session-export auth login [--no-verify] | status | logout"
+
+func runAuth(args []string) error {
+	if len(args) == 0 {
+		return errors.New(authUsage)
+	}
+
+	switch args[0] {
+	case "login":
+		return runAuthLogin(args[1:])
+	case "status":
+		return runAuthStatus()
+	case "logout":
+		if err := web.DeleteToken(); err != nil {
+			return fmt.Errorf("removing token: %w", err)
+		}
+		fmt.Printf("Removed the token from %s\n", web.KeyringName())
+		return nil
+	default:
+		return fmt.Errorf("unknown auth command %q", args[0])
+	}
+}
+
+// runAuthLogin reads a token from stdin, checks it against the API and
+// saves it in the OS credential store
+func runAuthLogin(args []string) error {
+	fs := flag.NewFlagSet("auth login", flag.ExitOnError)
+	noVerify := fs.Bool("no-verify", false, "Save the token without checking it against the API")
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(os.Stderr, "Paste your Claude access token: ")
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	token := strings.TrimSpace(line)
+	if token == "" {
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("reading token: %w", err)
+		}
+		return errors.New("no token given")
+	}
+
+	if !*noVerify {
+		if err := web.CheckToken(token); err != nil {
+			return fmt.Errorf("checking token: %w", err)
+		}
+	}
+	if err := web.StoreToken(token); err != nil {
+		return fmt.Errorf("saving token: %w", err)
+	}
+	fmt.Printf("Saved the token in %s\n", web.KeyringName())
+	return nil
+}
+
+// runAuthStatus says where the token comes from and whether the API
+// accepts it
+func runAuthStatus() error {
+	token, source, err := web.AccessToken()
+	if err != nil {
+		return err
+	}
+	if source == web.SourceKeyring {
+		source = web.KeyringName()
+	}
+	fmt.Printf("Token:  from %s\n", source)
+	if err := web.CheckToken(token); err != nil {
+		fmt.Println("API:    failed")
+		return fmt.Errorf("checking token: %w", err)
+	}
+	fmt.Println("API:    ok")
+	return nil
+}
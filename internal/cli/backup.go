@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"archive/zip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/config"
+	"github.com/robzolkos/claude-session-export/internal/searchindex"
+)
+
+func runBackup(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+
+	dir, err := config.Dir()
+	if err != nil {
+		return err
+	}
+
+	zipPath := fmt.Sprintf("claude-session-export-backup-%s.zip", time.Now().Format("2006-01-02"))
+	if fs.NArg() > 0 {
+		zipPath = fs.Arg(0)
+	}
+
+	count, err := writeBackup(dir, zipPath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Backed up %d file(s) from %s to %s\n", count, dir, zipPath)
+	return nil
+}
+
+func runRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	force := fs.Bool("force", false, "Overwrite existing files")
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 1 {
+		return errors.New("usage: claude-session-export restore <backup.zip> [--force]")
+	}
+
+	dir, err := config.Dir()
+	if err != nil {
+		return err
+	}
+
+	count, err := restoreBackup(fs.Arg(0), dir, *force)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Restored %d file(s) to %s\n", count, dir)
+	return nil
+}
+
+// writeBackup zips every file under dir (config, history, tags, cache)
+func writeBackup(dir, zipPath string) (int, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return 0, fmt.Errorf("nothing to back up: %w", err)
+	}
+
+	zipFile, err := os.Create(zipPath)
+	if err != nil {
+		return 0, fmt.Errorf("creating backup file: %w", err)
+	}
+	defer zipFile.Close()
+
+	zipWriter := zip.NewWriter(zipFile)
+
+	absZip, _ := filepath.Abs(zipPath)
+	count := 0
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		// Don't back up the backup if it's being written inside dir
+		if abs, _ := filepath.Abs(path); abs == absZip {
+			return nil
+		}
+		// The search index can be rebuilt, and holds whole transcripts
+		if d.Name() == searchindex.FileName {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		w, err := zipWriter.Create(filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if _, err := io.Copy(w, f); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		zipWriter.Close()
+		return 0, fmt.Errorf("writing backup: %w", err)
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return 0, fmt.Errorf("writing backup: %w", err)
+	}
+	return count, nil
+}
+
+// restoreBackup extracts a backup made by writeBackup into dir. Existing
+// files are only replaced when force is set.
+func restoreBackup(zipPath, dir string, force bool) (int, error) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return 0, fmt.Errorf("opening backup: %w", err)
+	}
+	defer r.Close()
+
+	// Validate every entry before writing anything
+	var conflicts []string
+	for _, f := range r.File {
+		name := filepath.FromSlash(f.Name)
+		if !filepath.IsLocal(name) {
+			return 0, fmt.Errorf("backup contains invalid path %q", f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			conflicts = append(conflicts, f.Name)
+		}
+	}
+	if len(conflicts) > 0 && !force {
+		return 0, fmt.Errorf("%s already contains %s (use --force to overwrite)", dir, strings.Join(conflicts, ", "))
+	}
+
+	count := 0
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if err := extractBackupFile(f, filepath.Join(dir, filepath.FromSlash(f.Name))); err != nil {
+			return count, fmt.Errorf("restoring %s: %w", f.Name, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+func extractBackupFile(f *zip.File, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
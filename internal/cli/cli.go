@@ -1,8 +1,14 @@
 package cli
 
 import (
+	"archive/tar"
 	"archive/zip"
-	"encoding/base64"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -12,12 +18,34 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/robzolkos/claude-session-export/internal/archive"
+	"github.com/robzolkos/claude-session-export/internal/card"
+	"github.com/robzolkos/claude-session-export/internal/config"
+	"github.com/robzolkos/claude-session-export/internal/confluence"
+	"github.com/robzolkos/claude-session-export/internal/convert"
+	"github.com/robzolkos/claude-session-export/internal/email"
+	"github.com/robzolkos/claude-session-export/internal/encrypt"
 	"github.com/robzolkos/claude-session-export/internal/gist"
+	"github.com/robzolkos/claude-session-export/internal/gitlab"
+	"github.com/robzolkos/claude-session-export/internal/history"
+	"github.com/robzolkos/claude-session-export/internal/normalize"
+	"github.com/robzolkos/claude-session-export/internal/patch"
+	"github.com/robzolkos/claude-session-export/internal/redact"
 	"github.com/robzolkos/claude-session-export/internal/session"
+	"github.com/robzolkos/claude-session-export/internal/site"
+	"github.com/robzolkos/claude-session-export/internal/summary"
+	"github.com/robzolkos/claude-session-export/internal/transform"
 	"github.com/robzolkos/claude-session-export/internal/web"
+	"github.com/robzolkos/claude-session-export/internal/webhook"
+	"github.com/robzolkos/claude-session-export/internal/zipaes"
+	"github.com/robzolkos/claude-session-export/render"
 )
 
 var version = "dev"
@@ -27,6 +55,29 @@ func reorderArgs(args []string) []string {
 	valueFlags := map[string]bool{
 		"-o": true, "--output": true,
 		"--limit": true, "--max-matches": true,
+		"--redact": true, "--tool-output-limit": true, "--max-block-size": true,
+		"--prompts": true, "--weeks": true,
+		"--addr": true, "--access-log": true, "--user-header": true, "--admin-users": true,
+		"--theme": true, "--top": true,
+		"--header": true, "--footer": true, "--period": true,
+		"--watermark": true, "--commit-url-template": true, "--editor-links": true, "--site-url": true, "--reaction": true, "--from": true, "--to": true,
+		"--format": true, "--profile": true, "--wait-idle": true,
+		"--older-than": true, "--archive-dir": true, "--backup": true, "--move-to": true,
+		"--gist-id":     true,
+		"--description": true,
+		"--max-size":    true,
+		"--repo":        true,
+		"--upload":      true,
+		"--webhook-url": true, "--webhook-header": true,
+		"--encrypt": true, "--zip-password": true,
+		"--role": true, "--tool": true, "--project": true, "--since": true, "--until": true,
+		"--color":        true,
+		"--input-format": true,
+		"--include":      true,
+		"--timeout":      true,
+		"--branch":       true,
+		"--message":      true,
+		"--at":           true,
 	}
 
 	var flags, positional []string
@@ -60,8 +111,50 @@ func Run(args []string) error {
 		return runWeb(args[1:])
 	case "search":
 		return runSearch(args[1:])
+	case "auth":
+		return runAuth(args[1:])
+	case "grep":
+		return runGrep(args[1:])
+	case "index":
+		return runIndex(args[1:])
 	case "open":
 		return runOpen(args[1:])
+	case "import":
+		return runImport(args[1:])
+	case "preview":
+		return runPreview(args[1:])
+	case "clip":
+		return runClip(args[1:])
+	case "extract":
+		return runExtract(args[1:])
+	case "pr-description":
+		return runPRDescription(args[1:])
+	case "schema":
+		return runSchema(args[1:])
+	case "lint":
+		return runLint(args[1:])
+	case "gists":
+		return runGists(args[1:])
+	case "usage":
+		return runUsage(args[1:])
+	case "stats":
+		return runStats(args[1:])
+	case "report":
+		return runReport(args[1:])
+	case "flags":
+		return runFlags(args[1:])
+	case "archive":
+		return runArchive(args[1:])
+	case "serve":
+		return runServe(args[1:])
+	case "publish":
+		return runPublish(args[1:])
+	case "prune":
+		return runPrune(args[1:])
+	case "backup":
+		return runBackup(args[1:])
+	case "restore":
+		return runRestore(args[1:])
 	case "version", "--version", "-v":
 		fmt.Printf("claude-session-export %s\n", version)
 		return nil
@@ -75,7 +168,7 @@ func Run(args []string) error {
 }
 
 func printHelp() {
-	fmt.Println(`claude-session-export - Export Claude Code sessions to GitHub Gist
+	fmt.Println(`claude-session-export - Export Claude Code sessions to a local viewer or GitHub Gist
 
 USAGE:
     claude-session-export [COMMAND] [OPTIONS]
@@ -83,34 +176,268 @@ USAGE:
 COMMANDS:
     local    Browse and export local Claude Code sessions (default)
     json     Export a specific JSONL file
-    web      Fetch and export sessions from Claude API
+    web      Fetch and export sessions from Claude API (pick one, or web --list [--json])
+    auth     Save the Claude API token in the OS credential store (auth login|status|logout)
     search   Search across all sessions for a term
+    grep     Print matches as path:line: snippet, for piping into other tools
+    index    Keep a search index so search stays fast (index build|status|clear)
     open     Open a gist URL in the session viewer
+    import   Download the session from a gist and save it, or re-export it with --render
+    gists    List, open or delete uploaded gists (gists list|open|delete)
+    preview  Show a session's stats and first prompts without exporting
+    clip     Export only the messages between two times (clip FILE --from 14:00 --to 15:30)
+    extract  Pull the prompts out of a session (extract prompts FILE [--timestamps] [-o FILE]),
+             or rebuild the files it wrote (extract files FILE [-o DIR] [--at TIME])
+    pr-description  Write a pull request body from a session (goal, approach, commits, files, tests)
+    schema   Print the JSON Schema of the --format json export
+    lint     Check exports for broken links, oversized pages and escaping problems
+    usage    Summarize past exports and what was uploaded
+    stats    Show token usage and estimated cost across sessions, or
+             tokens, tools, files and cost for one (stats FILE [--json])
+    report   Write a usage dashboard (HTML) and CSV rolled up by day, week or month
+    flags    Export conversations reviewers flagged (thumbs up/down, follow-up)
+    archive  Compare archives (archive diff <old> <new>)
+    serve    Host session archives over HTTP with combined search
+    publish  Push a directory of exports to a GitHub Pages branch, with a sitemap
+             (publish [DIR] --repo owner/name [--site-url URL] [--noindex])
+    prune    Delete or move sessions older than AGE, optionally archiving them first
+             (prune --older-than 90d [--archive-first] [--backup FILE.tar.gz] [--move-to DIR])
+    backup   Save config, history and cache to a zip file
+    restore  Restore a backup made with the backup command
 
 OPTIONS:
-    -o, --output DIR     Save JSONL locally instead of uploading to Gist
+    --gist               Upload to a secret GitHub Gist
+    --upload TARGET      Upload to gist, gitlab for a GitLab snippet (GITLAB_TOKEN), or
+                         webhook to POST the JSONL (or --zip) to --webhook-url, with its
+                         SHA-256 as X-Claude-Delivery-Id to spot retries, or
+                         confluence for a page in the configured space (CONFLUENCE_TOKEN)
+    -o, --output DIR     Save the JSONL to a directory
     --zip                Create a zip file with viewer and session data
-    --no-open            Don't open viewer after uploading
+    --with-jsonl         Add the session JSONL to the zip or tar.gz next to the viewer
+    --zip-password PASS  Protect the zip with a password (AES-256; open with 7-Zip, Keka...)
+    --encrypt SPEC       Encrypt the zip, -o JSONL, gist or webhook upload with age, for
+                         age:RECIPIENT[,...] (keys or recipients files) or passphrase
+    --no-open            Don't open the viewer after exporting
+    -y, --yes            Upload without asking for confirmation
+    --redact PATTERN     Redact text matching a regex (repeatable)
+    --anonymize          Replace paths, usernames, hostnames and emails with placeholders
+    --no-tool-output     Drop tool output, keeping only the tool calls
+    --tool-output-limit N  Truncate tool output to N characters
+    --since TIME         Only export messages from TIME on (2024-06-01, 14:00 on the
+                         session's day, 7d, 12h)
+    --until TIME         Only export messages before TIME (a date alone includes that day)
+    --prompts N-M        Only export prompts N to M and their replies (N-, -M or N)
+    --max-block-size N   Show N characters of each tool output in the viewer, then a
+                         button for the rest (default: 500-1000)
+    --no-truncate        Show tool output in full in the viewer
+    --theme NAME         Viewer colors: dark, colorblind, high-contrast, plain
+    --plain              Accessible viewer: same as --theme plain
+    --show-meta          Show meta, hook and API error entries in the viewer
+    --no-emoji           Use plain text instead of emoji in output and viewers
+    --header HTML        HTML snippet (or @file) shown at the top of every page
+    --footer HTML        HTML snippet (or @file) shown at the bottom of every page
+    --watermark TEXT     Overlay TEXT diagonally across the viewer and stamp it in zips
+    --commit-url-template URL
+                         Link commits to URL, with {hash} and {repo} filled in
+                         (default: from the GitHub, GitLab or Bitbucket remote)
+    --editor-links EDITOR
+                         Make file paths open in vscode, cursor, zed or idea, or
+                         a URL with {path} and {line} filled in
+    --preview-image      Write a PNG card (title, project, duration, tokens) next to
+                         HTML exports, or as preview.png in archives, for link previews
+    --site-url URL       Where the export will be served, so its og:image link is absolute
+    --noindex            Ask search engines not to index the viewer (also --robots-noindex)
+    --format FORMAT      html, json for the parsed session as one JSON document, site for
+                         a Hugo/Jekyll page, mbox/eml for an email thread, tar.gz
+                         for the zip's contents as a tarball, or patch for the files
+                         Claude changed as git patches
+    --profile NAME       Use a named bundle of these options from the config file
+    --stdout             Write the viewer, or --format json, site, mbox or patch, to stdout
+    --json               Print the export summary (location, size, next steps) as JSON
+    --wait-idle DURATION Wait for a live session to pause for DURATION before exporting
     -h, --help           Show this help message
     -v, --version        Show version
 
 EXAMPLES:
-    claude-session-export                          # Interactive picker, upload to Gist
-    claude-session-export json session.jsonl      # Upload specific file to Gist
+    claude-session-export                          # Interactive picker, open local viewer
+    claude-session-export --gist                   # Interactive picker, upload to Gist
+    claude-session-export json session.jsonl      # Open a specific file in the viewer
     claude-session-export --zip                   # Create shareable zip file
-    claude-session-export web SESSION_ID          # Fetch from API, upload to Gist
+    claude-session-export web SESSION_ID --gist   # Fetch from API, upload to Gist
     claude-session-export search "error"          # Search sessions
-    claude-session-export open https://gist.github.com/user/id`)
+    claude-session-export open https://gist.github.com/user/id
+    claude-session-export preview 3                # Preview the 3rd session in the picker
+    claude-session-export serve alice=/srv/alice bob=/srv/bob --addr :8080
+    claude-session-export flags --reaction follow-up -o retro.md`)
+}
+
+// exportOptions holds the flags shared by all exporting commands
+type exportOptions struct {
+	outputDir  string
+	uploadGist bool
+	upload     string // --upload target: gist, gitlab, webhook or confluence
+	gistID     string // Gist to update, as an ID or URL
+	public     bool   // Create a public gist instead of a secret one
+	gistDesc   string // Gist description; default from the session's title and date
+	createZip  bool
+	encrypt    string // --encrypt: age:RECIPIENT[,...] or passphrase
+
+	zipPassword string // Encrypt the zip's files with AES-256
+	withJSONL   bool   // Add the session JSONL to the zip next to the viewer
+
+	webhookURL     string
+	webhookHeaders stringList // "Name: value"
+
+	noOpen    bool
+	redact    stringList
+	anonymize bool
+
+	noToolOutput    bool
+	toolOutputLimit int
+	maxBlockSize    int  // Characters of tool output the viewer shows before "show full"
+	noTruncate      bool // Show tool output in full in the viewer
+
+	since   string // Only export messages from this time on
+	until   string // Only export messages before this time
+	prompts string // Only export these prompts and their replies, e.g. 10-25
+
+	theme    string
+	showMeta bool
+	noEmoji  bool
+
+	header    string
+	footer    string
+	watermark string
+
+	commitURLTemplate string
+	editorLinks       string
+
+	previewImage bool   // Write a link preview card and reference it with og:image
+	siteURL      string // Where the export will be served, for absolute og:image links
+	noIndex      bool   // Ask search engines not to index the viewer
+
+	format  string
+	profile string
+	local   bool // The profile keeps the export local, whatever the config's default
+
+	waitIdle time.Duration
+	snapshot bool // The path is a temporary copy (clip, web, URL), never live
+
+	highlight *render.Highlight // Search query that found the session, marked in the viewer
+
+	encryption *encrypt.Spec   // Parsed from encrypt; nil when not encrypting
+	source     *archive.Source // The session file as read, for manifests
+
+	yes    bool
+	quiet  bool // Skip the exit summary, for exports made along the way
+	json   bool // Print the exit summary as JSON
+	stdout bool // Write a single-file export to stdout instead of disk
+}
+
+// maxIdleWait caps how long --wait-idle waits for a live session
+const maxIdleWait = 10 * time.Minute
+
+// progress is where status messages go: stdout, or stderr when --json
+// keeps stdout for the summary or --stdout for the export
+func (o *exportOptions) progress() io.Writer {
+	if o.json || o.stdout {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// Output formats for --format. Without one, sessions are exported as HTML,
+// or copied as JSONL with -o.
+const (
+	formatHTML  = "html"
+	formatJSON  = "json"
+	formatSite  = "site"
+	formatMbox  = "mbox"
+	formatEML   = "eml"
+	formatTarGz = "tar.gz"
+	formatPatch = "patch"
+)
+
+var exportFormats = []string{formatHTML, formatJSON, formatSite, formatMbox, formatEML, formatTarGz, formatPatch}
+
+// Targets for --upload
+const (
+	uploadGist       = "gist"
+	uploadGitLab     = "gitlab"
+	uploadWebhook    = "webhook"
+	uploadConfluence = "confluence"
+)
+
+var uploadTargets = []string{uploadGist, uploadGitLab, uploadWebhook, uploadConfluence}
+
+// stringList is a flag.Value that collects repeated string flags
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// addExportFlags registers the shared export flags on a flag set
+func addExportFlags(fs *flag.FlagSet) *exportOptions {
+	opts := &exportOptions{}
+	fs.StringVar(&opts.outputDir, "o", "", "Output directory")
+	fs.StringVar(&opts.outputDir, "output", "", "Output directory")
+	fs.BoolVar(&opts.uploadGist, "gist", false, "Upload to GitHub Gist")
+	fs.StringVar(&opts.upload, "upload", "", "Upload to: "+strings.Join(uploadTargets, ", "))
+	fs.StringVar(&opts.webhookURL, "webhook-url", "", "URL --upload webhook POSTs the export to")
+	fs.Var(&opts.webhookHeaders, "webhook-header", "Header sent with --upload webhook, as \"Name: value\" (repeatable)")
+	fs.StringVar(&opts.gistID, "gist-id", "", "Update this gist (ID or URL) instead of creating one; implies --gist")
+	fs.BoolVar(&opts.public, "public", false, "Make the gist or snippet public instead of secret")
+	fs.StringVar(&opts.gistDesc, "description", "", "Gist description or snippet title (default: the session's title and date)")
+	fs.BoolVar(&opts.createZip, "zip", false, "Create a zip file with viewer and session")
+	fs.BoolVar(&opts.withJSONL, "with-jsonl", false, "Add the session JSONL to the zip or tar.gz next to the viewer")
+	fs.StringVar(&opts.zipPassword, "zip-password", "", "Encrypt the files in the zip with AES-256 using this password")
+	fs.StringVar(&opts.encrypt, "encrypt", "", "Encrypt the zip or JSONL with age: age:RECIPIENT[,...] or passphrase")
+	fs.BoolVar(&opts.noOpen, "no-open", false, "Don't open viewer after uploading")
+	fs.Var(&opts.redact, "redact", "Redact text matching a regex pattern (repeatable)")
+	fs.BoolVar(&opts.anonymize, "anonymize", false, "Replace paths, usernames, hostnames, emails and repo names with placeholders")
+	fs.BoolVar(&opts.noToolOutput, "no-tool-output", false, "Drop tool output, keeping only the tool calls")
+	fs.IntVar(&opts.toolOutputLimit, "tool-output-limit", 0, "Truncate tool output to N characters")
+	fs.IntVar(&opts.maxBlockSize, "max-block-size", 0, "Show N characters of each tool output in the viewer before a \"show full output\" button")
+	fs.BoolVar(&opts.noTruncate, "no-truncate", false, "Show tool output in full in the viewer")
+	fs.StringVar(&opts.since, "since", "", "Only include messages from this time on (2024-06-01, 2024-06-01 14:00, 7d, 12h)")
+	fs.StringVar(&opts.until, "until", "", "Only include messages before this time (a date alone includes that day)")
+	fs.StringVar(&opts.prompts, "prompts", "", "Only export these prompts and their replies, e.g. 10-25, 10- or 7")
+	fs.StringVar(&opts.theme, "theme", "", "Viewer color theme: "+strings.Join(render.Themes, ", "))
+	fs.BoolFunc("plain", "Accessible viewer: same as --theme plain", func(string) error {
+		opts.theme = "plain"
+		return nil
+	})
+	fs.BoolVar(&opts.showMeta, "show-meta", false, "Show meta, hook and API error entries in the viewer")
+	fs.BoolVar(&opts.noEmoji, "no-emoji", false, "Use plain text instead of emoji in output and viewers")
+	fs.StringVar(&opts.header, "header", "", "HTML snippet (or @file) shown at the top of every page")
+	fs.StringVar(&opts.footer, "footer", "", "HTML snippet (or @file) shown at the bottom of every page")
+	fs.StringVar(&opts.watermark, "watermark", "", "Text overlaid diagonally across the viewer and stamped in zip manifests")
+	fs.StringVar(&opts.commitURLTemplate, "commit-url-template", "", "URL commits link to, with {hash} and {repo} filled in")
+	fs.StringVar(&opts.editorLinks, "editor-links", "", "Editor file paths open in (vscode, cursor, zed, idea) or a URL with {path} and {line}")
+	fs.BoolVar(&opts.previewImage, "preview-image", false, "Write a PNG card for link previews and reference it with og:image")
+	fs.StringVar(&opts.siteURL, "site-url", "", "URL the export will be served from, so its og:image link is absolute")
+	fs.BoolVar(&opts.noIndex, "noindex", false, "Ask search engines not to index the viewer")
+	fs.BoolVar(&opts.noIndex, "robots-noindex", false, "Ask search engines not to index the viewer")
+	fs.StringVar(&opts.format, "format", "", "Output format: "+strings.Join(exportFormats, ", "))
+	fs.StringVar(&opts.profile, "profile", "", "Named option bundle from the config file")
+	fs.BoolVar(&opts.yes, "yes", false, "Skip the confirmation before uploading")
+	fs.BoolVar(&opts.yes, "y", false, "Skip the confirmation before uploading")
+	fs.BoolVar(&opts.json, "json", false, "Print the export summary as JSON")
+	fs.BoolVar(&opts.stdout, "stdout", false, "Write the export to stdout: html, json, site (Markdown) or mbox")
+	fs.DurationVar(&opts.waitIdle, "wait-idle", 0, "Wait until a live session hasn't changed for this long, e.g. 30s")
+	return opts
 }
 
 func runLocal(args []string) error {
 	fs := flag.NewFlagSet("local", flag.ExitOnError)
-	outputDir := fs.String("o", "", "Output directory")
-	fs.StringVar(outputDir, "output", "", "Output directory")
-	uploadGist := fs.Bool("gist", false, "Upload to GitHub Gist")
-	createZip := fs.Bool("zip", false, "Create a zip file with viewer and session")
-	noOpen := fs.Bool("no-open", false, "Don't open viewer after uploading")
-	limit := fs.Int("limit", 30, "Maximum number of sessions to show")
+	opts := addExportFlags(fs)
+	limit := fs.Int("limit", 100, "Maximum number of sessions to show")
 
 	if err := fs.Parse(reorderArgs(args)); err != nil {
 		return err
@@ -125,23 +452,28 @@ func runLocal(args []string) error {
 		return errors.New("no sessions found in ~/.claude/projects")
 	}
 
-	session.LoadSessionSummaries(sessions)
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	title, err := sessionTitler(cfg)
+	if err != nil {
+		return err
+	}
+	session.LoadSessionSummaries(sessions, title)
 
-	selected, err := selectSession(sessions)
+	selected, err := selectSession(sessions, opts.progress())
 	if err != nil {
 		return err
 	}
 
-	return exportSession(selected.Path, *outputDir, *uploadGist, *createZip, !*noOpen)
+	return exportSession(selected.Path, opts)
 }
 
 func runJSON(args []string) error {
 	fs := flag.NewFlagSet("json", flag.ExitOnError)
-	outputDir := fs.String("o", "", "Output directory")
-	fs.StringVar(outputDir, "output", "", "Output directory")
-	uploadGist := fs.Bool("gist", false, "Upload to GitHub Gist")
-	createZip := fs.Bool("zip", false, "Create a zip file with viewer and session")
-	noOpen := fs.Bool("no-open", false, "Don't open viewer after uploading")
+	opts := addExportFlags(fs)
+	inputFormat := fs.String("input-format", "auto", "Transcript format: auto, "+strings.Join(convert.Formats, ", "))
 
 	if err := fs.Parse(reorderArgs(args)); err != nil {
 		return err
@@ -154,36 +486,70 @@ func runJSON(args []string) error {
 	path := fs.Arg(0)
 
 	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
-		return exportURL(path, *outputDir, *uploadGist, *createZip, !*noOpen)
+		return exportURL(path, opts)
+	}
+
+	if *inputFormat != "auto" && !slices.Contains(convert.Formats, *inputFormat) {
+		return fmt.Errorf("unknown --input-format %q (available: auto, %s)", *inputFormat, strings.Join(convert.Formats, ", "))
+	}
+	if convs, ok, err := readConversations(path, *inputFormat); err != nil {
+		return err
+	} else if ok {
+		return exportConversations(convs, opts)
 	}
 
-	return exportSession(path, *outputDir, *uploadGist, *createZip, !*noOpen)
+	return exportSession(path, opts)
 }
 
 func runWeb(args []string) error {
 	fs := flag.NewFlagSet("web", flag.ExitOnError)
-	outputDir := fs.String("o", "", "Output directory")
-	fs.StringVar(outputDir, "output", "", "Output directory")
-	uploadGist := fs.Bool("gist", false, "Upload to GitHub Gist")
-	createZip := fs.Bool("zip", false, "Create a zip file with viewer and session")
-	noOpen := fs.Bool("no-open", false, "Don't open viewer after uploading")
+	opts := addExportFlags(fs)
+	list := fs.Bool("list", false, "List API sessions instead of picking one (with --json, as JSON)")
+	fs.DurationVar(&web.Timeout, "timeout", web.Timeout, "Give up on an API request after this long (e.g. 90s)")
+	refresh := fs.Bool("refresh", false, "Fetch the session again even if it's cached")
 
 	if err := fs.Parse(reorderArgs(args)); err != nil {
 		return err
 	}
 
-	if fs.NArg() == 0 {
-		return errors.New("usage: claude-session-export web <session-id>")
+	if fs.NArg() > 0 {
+		return exportWebSession(fs.Arg(0), time.Time{}, *refresh, opts)
 	}
 
-	sessionID := fs.Arg(0)
+	fmt.Fprintln(opts.progress(), "Fetching sessions from API...")
+	metas, err := web.FetchSessions()
+	if err != nil {
+		return fmt.Errorf("fetching sessions: %w", err)
+	}
+	sort.SliceStable(metas, func(i, j int) bool {
+		return metas[i].UpdatedAt.After(metas[j].UpdatedAt)
+	})
+
+	if *list {
+		return printWebSessions(os.Stdout, metas, opts.json)
+	}
+	if len(metas) == 0 {
+		return errors.New("no sessions found in the Claude API")
+	}
+	selected, err := selectSession(webSessionInfos(metas), opts.progress())
+	if err != nil {
+		return err
+	}
+	return exportWebSession(selected.SessionID, selected.EndTime, *refresh, opts)
+}
 
-	fmt.Printf("Fetching session %s from API...\n", sessionID)
+// exportWebSession fetches a session from the API, or the cache if it
+// hasn't changed since updatedAt, and exports it
+func exportWebSession(sessionID string, updatedAt time.Time, refresh bool, opts *exportOptions) error {
+	fmt.Fprintf(opts.progress(), "Fetching session %s from API...\n", sessionID)
 
-	sess, err := web.FetchSession(sessionID)
+	sess, cached, err := web.FetchSessionCached(sessionID, updatedAt, refresh)
 	if err != nil {
 		return fmt.Errorf("fetching session: %w", err)
 	}
+	if cached {
+		fmt.Fprintln(opts.progress(), "Unchanged since last fetched; using the cached copy (--refresh to fetch again)")
+	}
 
 	// Create temp file with session data
 	tmpFile, err := os.CreateTemp("", "session-*.jsonl")
@@ -197,17 +563,114 @@ func runWeb(args []string) error {
 	}
 	tmpFile.Close()
 
-	return exportSession(tmpFile.Name(), *outputDir, *uploadGist, *createZip, !*noOpen)
+	opts.snapshot = true
+	return exportSession(tmpFile.Name(), opts)
+}
+
+// webSessionInfos shows API sessions in the local picker. They're never
+// marked live, since the picker can't tell.
+func webSessionInfos(metas []web.SessionMeta) []session.SessionInfo {
+	infos := make([]session.SessionInfo, len(metas))
+	for i, m := range metas {
+		infos[i] = session.SessionInfo{
+			ProjectName:  "claude.ai",
+			SessionID:    m.ID,
+			Summary:      m.Name,
+			StartTime:    m.CreatedAt,
+			EndTime:      m.UpdatedAt,
+			MessageCount: m.MessageCount,
+		}
+	}
+	return infos
+}
+
+// printWebSessions lists API sessions, newest first, as a table or JSON
+func printWebSessions(w io.Writer, metas []web.SessionMeta, asJSON bool) error {
+	if asJSON {
+		if metas == nil {
+			metas = []web.SessionMeta{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(metas)
+	}
+	for _, m := range metas {
+		name := m.Name
+		if name == "" {
+			name = "(untitled)"
+		}
+		messages := ""
+		if m.MessageCount > 0 {
+			messages = fmt.Sprintf("%d msgs", m.MessageCount)
+		}
+		fmt.Fprintf(w, "%s  %s  %9s  %s\n", m.ID, m.UpdatedAt.Local().Format("2006-01-02 15:04"), messages, name)
+	}
+	return nil
+}
+
+// searchFlags are the flags narrowing search and grep
+type searchFlags struct {
+	opts    session.SearchOptions
+	since   *string
+	until   *string
+	noIndex bool
+}
+
+// addSearchFlags adds the search flags to fs. A command that also exports
+// passes its export options, whose --since and --until then narrow both
+// the search and the exported session.
+func addSearchFlags(fs *flag.FlagSet, export *exportOptions) *searchFlags {
+	f := &searchFlags{}
+	if export != nil {
+		f.since, f.until = &export.since, &export.until
+	} else {
+		f.since = fs.String("since", "", "Only search messages from this time on (2024-06-01, 2024-06-01 14:00, 7d, 12h)")
+		f.until = fs.String("until", "", "Only search messages before this time (a date alone includes that day)")
+	}
+	fs.BoolVar(&f.opts.Regex, "regex", false, "Treat the query as a regular expression")
+	fs.BoolVar(&f.opts.CaseSensitive, "case-sensitive", false, "Match case")
+	fs.StringVar(&f.opts.Role, "role", "", "Only search messages from user or assistant")
+	fs.StringVar(&f.opts.Tool, "tool", "", "Search this tool's inputs and output (e.g. Bash) instead of the text")
+	fs.StringVar(&f.opts.Project, "project", "", "Only search projects whose name contains this")
+	fs.BoolVar(&f.noIndex, "no-index", false, "Parse every session instead of using the search index")
+	return f
+}
+
+// options checks the flags and loads the search index, if there is one.
+// misses counts the sessions the index was out of date for.
+func (f *searchFlags) options() (search session.SearchOptions, misses *int, err error) {
+	search = f.opts
+	if search.Role != "" && search.Role != "user" && search.Role != "assistant" {
+		return search, nil, fmt.Errorf("--role must be user or assistant, not %q", search.Role)
+	}
+	now := time.Now()
+	if search.Since, err = parseSearchTime(*f.since, now, now, false); err != nil {
+		return search, nil, fmt.Errorf("invalid --since: %w", err)
+	}
+	if search.Until, err = parseSearchTime(*f.until, now, now, true); err != nil {
+		return search, nil, fmt.Errorf("invalid --until: %w", err)
+	}
+	if !f.noIndex {
+		if search.Lookup, misses, err = searchLookup(); err != nil {
+			return search, nil, err
+		}
+	}
+	return search, misses, nil
+}
+
+// warnStaleIndex suggests rebuilding the search index when it was out of
+// date for some sessions
+func warnStaleIndex(misses *int) {
+	if misses != nil && *misses > 0 {
+		fmt.Fprintf(os.Stderr, "Note: %d session(s) changed since the search index was built; run 'claude-session-export index build' to update it\n", *misses)
+	}
 }
 
 func runSearch(args []string) error {
 	fs := flag.NewFlagSet("search", flag.ExitOnError)
-	outputDir := fs.String("o", "", "Output directory")
-	fs.StringVar(outputDir, "output", "", "Output directory")
-	uploadGist := fs.Bool("gist", false, "Upload to GitHub Gist")
-	createZip := fs.Bool("zip", false, "Create a zip file with viewer and session")
-	noOpen := fs.Bool("no-open", false, "Don't open viewer after uploading")
+	opts := addExportFlags(fs)
 	maxMatches := fs.Int("max-matches", 3, "Maximum matches to show per session")
+	flags := addSearchFlags(fs, opts)
 
 	if err := fs.Parse(reorderArgs(args)); err != nil {
 		return err
@@ -216,15 +679,27 @@ func runSearch(args []string) error {
 	if fs.NArg() == 0 {
 		return errors.New("usage: claude-session-export search <query>")
 	}
+	search, misses, err := flags.options()
+	if err != nil {
+		return err
+	}
 
 	query := fs.Arg(0)
 
-	fmt.Printf("Searching for \"%s\"...\n", query)
+	if !opts.json {
+		fmt.Printf("Searching for \"%s\"...\n", query)
+	}
 
-	results, err := session.SearchSessions(query)
+	results, err := session.SearchSessions(query, search)
 	if err != nil {
 		return fmt.Errorf("searching sessions: %w", err)
 	}
+	if !opts.json {
+		warnStaleIndex(misses)
+	}
+	if opts.json {
+		return printSearchJSON(os.Stdout, results)
+	}
 
 	if len(results) == 0 {
 		fmt.Printf("No sessions found containing \"%s\"\n", query)
@@ -274,7 +749,78 @@ func runSearch(args []string) error {
 	}
 
 	selected := results[idx-1].SessionInfo
-	return exportSession(selected.Path, *outputDir, *uploadGist, *createZip, !*noOpen)
+	opts.highlight = &render.Highlight{Query: query, Regex: search.Regex, CaseSensitive: search.CaseSensitive}
+	return exportSession(selected.Path, opts)
+}
+
+// searchHit is one match in search --json output
+type searchHit struct {
+	Path      string     `json:"path"`
+	Project   string     `json:"project"`
+	SessionID string     `json:"session_id"`
+	Role      string     `json:"role"`
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+	Text      string     `json:"text"`   // The match with some context
+	Match     string     `json:"match"`  // Just the matching text
+	Line      int        `json:"line"`   // 1-based line of the entry in the file
+	Offset    int64      `json:"offset"` // Byte offset of that line
+}
+
+// printSearchJSON writes every match, newest sessions first, as a JSON
+// array
+func printSearchJSON(w io.Writer, results []session.SearchResult) error {
+	hits := []searchHit{}
+	for _, result := range results {
+		for _, m := range result.Matches {
+			hit := searchHit{
+				Path:      result.SessionInfo.Path,
+				Project:   result.SessionInfo.ProjectName,
+				SessionID: result.SessionInfo.SessionID,
+				Role:      m.Context,
+				Text:      m.Text,
+				Match:     m.Match,
+				Line:      m.Line,
+				Offset:    m.Offset,
+			}
+			if !m.Timestamp.IsZero() {
+				ts := m.Timestamp
+				hit.Timestamp = &ts
+			}
+			hits = append(hits, hit)
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(hits)
+}
+
+// parseSearchTime parses a --since/--until value: a time as clip takes
+// it, on day's date if only a time of day, or a duration back from now
+// like 7d or 12h. With end, a date alone means the end of that day.
+func parseSearchTime(value string, now, day time.Time, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	t, err := parseClipTime(value, day.In(now.Location()))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a date, time or duration like 2024-06-01, 14:00 or 7d", value)
+	}
+	return t, nil
 }
 
 func runOpen(args []string) error {
@@ -287,232 +833,1595 @@ func runOpen(args []string) error {
 	return openGistInViewer(gistURL)
 }
 
-func exportSession(path, outputDir string, uploadGist, createZip, openBrowser bool) error {
+func exportSession(path string, opts *exportOptions) error {
 	// Validate file exists and is readable
 	if _, err := os.Stat(path); err != nil {
 		return fmt.Errorf("cannot access file: %w", err)
 	}
 
-	// Handle zip export
-	if createZip {
-		return exportAsZip(path, outputDir)
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if opts.profile != "" {
+		if err := applyProfile(opts, cfg); err != nil {
+			return err
+		}
+	}
+
+	switch opts.upload {
+	case "":
+	case uploadGist:
+		opts.uploadGist = true
+	case uploadGitLab, uploadWebhook, uploadConfluence:
+		if opts.uploadGist || opts.gistID != "" {
+			return fmt.Errorf("--upload %s can't be combined with --gist or --gist-id", opts.upload)
+		}
+	default:
+		return fmt.Errorf("unknown upload target %q (available: %s)", opts.upload, strings.Join(uploadTargets, ", "))
+	}
+	if opts.gistID != "" {
+		opts.uploadGist = true
+		if opts.public {
+			return errors.New("--public only applies to new gists; GitHub can't change an existing gist's visibility")
+		}
+	}
+
+	if opts.format != "" && !slices.Contains(exportFormats, opts.format) {
+		return fmt.Errorf("unknown format %q (available: %s)", opts.format, strings.Join(exportFormats, ", "))
+	}
+	if opts.format != "" && opts.format != formatHTML && (opts.createZip || opts.uploadGist || opts.upload != "") {
+		return fmt.Errorf("--format %s can't be combined with --zip or uploads", opts.format)
+	}
+	if opts.stdout {
+		if err := checkStdout(opts); err != nil {
+			return err
+		}
+	}
+	if opts.withJSONL && !opts.createZip && opts.format != formatTarGz && !(opts.upload == uploadWebhook && cfg.Webhook.Format == "zip") {
+		return errors.New("--with-jsonl applies to --zip exports")
+	}
+	if opts.zipPassword != "" && !opts.createZip && !(opts.upload == uploadWebhook && cfg.Webhook.Format == "zip") {
+		return errors.New("--zip-password applies to --zip exports")
+	}
+	if opts.encrypt != "" {
+		if err := checkEncryption(opts, cfg); err != nil {
+			return err
+		}
+	}
+	if opts.previewImage {
+		viewer := opts.format == formatHTML || (opts.format == "" && opts.outputDir == "")
+		if opts.stdout || opts.uploadGist || opts.upload != "" || !(viewer || opts.createZip || opts.format == formatTarGz) {
+			return errors.New("--preview-image applies to HTML, zip and tar.gz exports")
+		}
+	} else if opts.siteURL != "" {
+		return errors.New("--site-url applies to --preview-image exports")
+	}
+
+	if err := waitForSession(path, opts); err != nil {
+		return err
+	}
+	srcData, err := session.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading source file: %w", err)
+	}
+	opts.source = archive.NewSource(filepath.Base(path), srcData)
+	srcData, err = withSubagents(path, srcData)
+	if err != nil {
+		return err
+	}
+
+	data, err := prepareSessionData(srcData, opts, cfg)
+	if err != nil {
+		return err
+	}
+	view, err := viewerOptions(data, opts, cfg)
+	if err != nil {
+		return err
 	}
 
-	// Default to gist upload unless output dir is specified
-	if !uploadGist && outputDir == "" {
-		uploadGist = true
-		openBrowser = true
+	if opts.stdout {
+		return writeStdout(path, data, view, opts.format)
 	}
 
-	if uploadGist {
-		// Create temp dir with just the JSONL file
-		tmpDir, err := os.MkdirTemp("", "claude-gist-*")
+	dest := defaultDestination(opts, cfg)
+	var summary exportSummary
+	switch {
+	case opts.format == formatJSON:
+		jsonPath, err := exportAsJSON(path, data, opts.outputDir)
+		if err != nil {
+			return err
+		}
+		summary = localSummary("json", jsonPath, data)
+
+	case opts.format == formatSite:
+		pagePath, err := exportAsSitePage(path, data, opts.outputDir)
 		if err != nil {
-			return fmt.Errorf("creating temp directory: %w", err)
+			return err
 		}
-		defer os.RemoveAll(tmpDir)
+		summary = localSummary("site", pagePath, data)
 
-		// Copy JSONL file to temp dir
-		srcData, err := os.ReadFile(path)
+	case opts.format == formatTarGz:
+		tarPath, err := writeArchive(path, data, opts.outputDir, view, opts)
 		if err != nil {
-			return fmt.Errorf("reading source file: %w", err)
+			return err
 		}
+		summary = localSummary(formatTarGz, tarPath, data)
 
-		destPath := filepath.Join(tmpDir, "session.jsonl")
-		if err := os.WriteFile(destPath, srcData, 0644); err != nil {
-			return fmt.Errorf("writing temp file: %w", err)
+	case opts.format == formatPatch:
+		patchPath, size, err := exportAsPatch(path, data, opts.outputDir)
+		if err != nil {
+			return err
 		}
+		summary = localSummary(formatPatch, patchPath, data)
+		summary.Size = size
 
-		fmt.Println("Uploading to GitHub Gist...")
+	case opts.format == formatMbox || opts.format == formatEML:
+		mailPath, size, err := exportAsMail(path, data, opts.outputDir, opts.format)
+		if err != nil {
+			return err
+		}
+		summary = localSummary(opts.format, mailPath, data)
+		summary.Size = size
+		if opts.format == formatEML {
+			summary.Delete = removeDirCommand(mailPath)
+		}
 
-		gistURL, err := gist.Upload(tmpDir, false)
+	case opts.upload == uploadWebhook || dest == config.DestinationWebhook:
+		sent, err := sendSessionWebhook(path, data, opts, cfg, view)
 		if err != nil {
-			return fmt.Errorf("uploading gist: %w", err)
+			return err
 		}
+		summary = sent
 
-		fmt.Printf("Gist created: %s\n", gistURL)
+	case opts.upload == uploadConfluence || dest == config.DestinationConfluence:
+		page, err := publishSessionPage(path, data, opts, cfg)
+		if err != nil {
+			return err
+		}
+		summary = confluenceSummary(page, data)
+		if !opts.noOpen {
+			if err := openInBrowser(page.URL); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not open page: %v\n", err)
+			} else {
+				summary.Opened = true
+			}
+		}
 
-		if openBrowser {
+	case opts.createZip:
+		zipPath, err := writeArchive(path, data, opts.outputDir, view, opts)
+		if err != nil {
+			return err
+		}
+		summary = localSummary("zip", zipPath, data)
+
+	// Uploading requires --gist, unless the config restores the old
+	// upload-by-default behaviour
+	case opts.uploadGist || dest == config.DestinationGist:
+		gistURL, err := uploadSessionGist(path, data, opts)
+		if errors.Is(err, gist.ErrNoAuth) {
+			// Nothing can upload; offer a zip to share by hand instead
+			if opts.yes || !confirm("Neither GITHUB_TOKEN nor the gh CLI is available, so the session can't be uploaded.\nCreate a zip with the viewer to share instead? [y/N]: ") {
+				return errors.New("can't upload: set GITHUB_TOKEN to a token with the gist scope, or install gh (https://cli.github.com/) and run gh auth login; use --zip or -o to export locally")
+			}
+			zipPath, err := writeArchive(path, data, opts.outputDir, view, opts)
+			if err != nil {
+				return err
+			}
+			summary = localSummary("zip", zipPath, data)
+			break
+		}
+		if err != nil {
+			return err
+		}
+		summary = gistSummary(gistURL, path, data)
+		if opts.encryption != nil {
+			// The viewer can't read it; recipients download and decrypt
+			summary.Open = "Download " + gistEncryptedFile + " from the gist, then: " + decryptCommand(gistEncryptedFile, opts.encryption)
+		} else if !opts.noOpen {
 			if err := openGistInViewer(gistURL); err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: could not open viewer: %v\n", err)
+			} else {
+				summary.Opened = true
 			}
 		}
-	} else {
-		// Just copy to output dir if specified
-		if outputDir != "" {
-			if err := os.MkdirAll(outputDir, 0755); err != nil {
-				return fmt.Errorf("creating output directory: %w", err)
+
+	case opts.upload == uploadGitLab || dest == config.DestinationGitLab:
+		snippet, err := uploadSessionSnippet(path, data, opts, cfg)
+		if err != nil {
+			return err
+		}
+		summary = snippetSummary(snippet, gitlab.InstanceURL(cfg.GitLab.URL), data)
+		if !opts.noOpen {
+			if err := openInBrowser(snippet.WebURL); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not open snippet: %v\n", err)
+			} else {
+				summary.Opened = true
 			}
+		}
 
-			srcData, err := os.ReadFile(path)
-			if err != nil {
-				return fmt.Errorf("reading source file: %w", err)
+	// Copy the JSONL to the output dir if specified
+	case opts.outputDir != "" && opts.format != formatHTML:
+		if err := os.MkdirAll(opts.outputDir, 0755); err != nil {
+			return fmt.Errorf("creating output directory: %w", err)
+		}
+		destPath := filepath.Join(opts.outputDir, filepath.Base(path))
+		if opts.encryption != nil {
+			destPath += encrypt.Ext
+			if err := encrypt.Bytes(data, destPath, *opts.encryption, false); err != nil {
+				return fmt.Errorf("encrypting output file: %w", err)
 			}
+		} else if err := os.WriteFile(destPath, data, 0644); err != nil {
+			return fmt.Errorf("writing output file: %w", err)
+		}
+		summary = localSummary("jsonl", destPath, data)
 
-			destPath := filepath.Join(outputDir, filepath.Base(path))
-			if err := os.WriteFile(destPath, srcData, 0644); err != nil {
-				return fmt.Errorf("writing output file: %w", err)
+	// Default: write a self-contained HTML viewer locally
+	default:
+		htmlDir := cfg.HTMLDir
+		if opts.outputDir != "" {
+			htmlDir = opts.outputDir
+		}
+		htmlPath, err := exportAsHTML(path, data, htmlDir, view, opts)
+		if err != nil {
+			return err
+		}
+		summary = localSummary("html", htmlPath, data)
+		if !opts.noOpen {
+			if err := openInBrowser(htmlPath); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not open viewer: %v\n", err)
+			} else {
+				summary.Opened = true
 			}
+		}
+	}
 
-			fmt.Printf("Session exported: %s\n", destPath)
-		} else {
-			fmt.Printf("Session: %s\n", path)
-			fmt.Println("Use --gist to upload to GitHub Gist, or -o to specify output directory")
+	if opts.encryption != nil {
+		summary.Encrypted = true
+		if summary.Path != "" {
+			summary.Open = decryptCommand(summary.Path, opts.encryption)
+		}
+	}
+	// Archives carry their own manifest
+	if summary.Destination == history.DestinationLocal && summary.Format != "zip" && summary.Format != formatTarGz {
+		if err := updateOutputManifest(summary, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not update %s: %v\n", archive.ManifestName, err)
 		}
 	}
 
-	return nil
+	location := summary.Path
+	if summary.URL != "" {
+		location = summary.URL
+	}
+	recordExport(path, summary.Format, summary.Destination, location, data)
+	if opts.quiet {
+		return nil
+	}
+	return printSummary(os.Stdout, summary, opts.json)
 }
 
-func exportAsZip(sessionPath, outputDir string) error {
-	// Read session data
-	sessionData, err := os.ReadFile(sessionPath)
-	if err != nil {
-		return fmt.Errorf("reading session file: %w", err)
+// uploadSessionGist uploads the session to a gist after confirming,
+// returning the gist's URL. A session uploaded before updates its gist, as
+// does --gist-id. It uses the API when GITHUB_TOKEN is set and gh otherwise,
+// and returns gist.ErrNoAuth if neither is available.
+func uploadSessionGist(path string, data []byte, opts *exportOptions) (string, error) {
+	if !gist.HasToken() && !gist.HasGH() {
+		return "", gist.ErrNoAuth
 	}
 
-	// Parse session to get project name and timestamp for filename
-	sess, _ := session.ParseFile(sessionPath)
-	details, _ := session.GetSessionDetails(sessionPath)
-
-	// Build filename: project-date-time.zip
-	projectName := "session"
-	if sess != nil && len(sess.Messages) > 0 && sess.Messages[0].Cwd != "" {
-		// Extract project name from cwd
-		projectName = filepath.Base(sess.Messages[0].Cwd)
+	state, err := gist.LoadState()
+	if err != nil {
+		return "", err
 	}
-	// Clean project name for filename
-	projectName = strings.ReplaceAll(projectName, " ", "-")
-	projectName = strings.ReplaceAll(projectName, "/", "-")
-
-	timestamp := time.Now()
-	if details != nil && !details.EndTime.IsZero() {
-		timestamp = details.EndTime
+	sessionID := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	gistID := gist.IDFromURL(opts.gistID)
+	explicit := gistID != ""
+	// Temporary copies (clips, fetched sessions) are named after the session
+	// but aren't it, so they never update the session's gist on their own
+	// Encrypted uploads always get a new gist: updating one would leave
+	// its plaintext session.jsonl in place
+	if !explicit && !opts.snapshot && opts.encryption == nil {
+		if rec, ok := state.Lookup(sessionID); ok {
+			if rec.Public == opts.public {
+				gistID = rec.GistID
+			} else {
+				fmt.Fprintf(opts.progress(), "This session's gist %s has the other visibility, which GitHub can't change; creating a new gist\n", rec.GistID)
+			}
+		}
 	}
-	dateStr := timestamp.Local().Format("2006-01-02-1504")
-
-	zipFilename := fmt.Sprintf("%s-%s.zip", projectName, dateStr)
 
-	// Generate local viewer HTML with embedded session data
-	localViewer := generateLocalViewerHTML(sessionData)
-
-	// Determine output path
-	zipPath := zipFilename
-	if outputDir != "" {
-		if err := os.MkdirAll(outputDir, 0755); err != nil {
-			return fmt.Errorf("creating output directory: %w", err)
+	visibility := "secret"
+	if opts.public {
+		visibility = "public"
+	}
+	if !opts.yes {
+		prompt := fmt.Sprintf("Upload %s session (%s) to a secret GitHub Gist? Anyone with the link can view it. [y/N]: ",
+			formatBytes(len(data)), filepath.Base(path))
+		switch {
+		case gistID != "":
+			prompt = fmt.Sprintf("Update gist %s with %s session (%s)? Anyone with the link can view it. [y/N]: ",
+				gistID, formatBytes(len(data)), filepath.Base(path))
+		case opts.public:
+			prompt = fmt.Sprintf("Upload %s session (%s) to a PUBLIC GitHub Gist? It will be listed on your profile and searchable. [y/N]: ",
+				formatBytes(len(data)), filepath.Base(path))
+		}
+		if !confirm(prompt) {
+			return "", errors.New("upload cancelled (use -o to save locally, or --yes to skip this prompt)")
 		}
-		zipPath = filepath.Join(outputDir, zipFilename)
 	}
 
-	// Create zip file
-	zipFile, err := os.Create(zipPath)
+	// Create temp dir with just the JSONL file
+	tmpDir, err := os.MkdirTemp("", "claude-gist-*")
 	if err != nil {
-		return fmt.Errorf("creating zip file: %w", err)
+		return "", fmt.Errorf("creating temp directory: %w", err)
 	}
-	defer zipFile.Close()
-
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
+	defer os.RemoveAll(tmpDir)
 
-	// Add viewer.html to zip (session data is embedded in the HTML)
-	viewerWriter, err := zipWriter.Create("viewer.html")
-	if err != nil {
-		return fmt.Errorf("adding viewer to zip: %w", err)
-	}
-	if _, err := viewerWriter.Write([]byte(localViewer)); err != nil {
-		return fmt.Errorf("writing viewer to zip: %w", err)
+	if opts.encryption != nil {
+		// Gists only hold text, so the ciphertext is armored
+		if err := encrypt.Bytes(data, filepath.Join(tmpDir, gistEncryptedFile), *opts.encryption, true); err != nil {
+			return "", fmt.Errorf("encrypting session: %w", err)
+		}
+	} else if err := os.WriteFile(filepath.Join(tmpDir, "session.jsonl"), data, 0644); err != nil {
+		return "", fmt.Errorf("writing temp file: %w", err)
 	}
 
-	fmt.Printf("Created: %s\n", zipPath)
-	fmt.Println("Extract the zip and open viewer.html in a browser.")
-
-	return nil
-}
-
-func generateLocalViewerHTML(sessionData []byte) string {
-	// Start with the embedded viewer HTML
-	html := string(viewerHTML)
-
-	// Remove the URL input form
-	html = strings.Replace(html,
-		`<div class="url-form">`,
-		`<div class="url-form" style="display:none;">`, 1)
-
-	// Escape the session data for embedding in JavaScript
-	// We'll base64 encode it to avoid any escaping issues
-	encodedData := base64.StdEncoding.EncodeToString(sessionData)
-
-	// Inject JavaScript to load embedded data directly (no fetch needed)
-	localLoadScript := fmt.Sprintf(`
-	<script>
-		window.LOCAL_MODE = true;
-		window.EMBEDDED_SESSION = atob("%s");
-		window.addEventListener('DOMContentLoaded', function() {
-			try {
-				// Parse and render the embedded session data
-				parseJsonl(window.EMBEDDED_SESSION);
-				calculateActiveTime();
-				renderStats();
-				renderMessages();
-				document.getElementById('session-stats').classList.add('visible');
-			} catch (err) {
-				document.getElementById('status').textContent = 'Error: ' + err.message;
-				document.getElementById('status').className = 'status error';
-			}
-		});
-	</script>`, encodedData)
-
-	// Insert before </head>
-	html = strings.Replace(html, "</head>", localLoadScript+"</head>", 1)
+	gistOpts := gist.Options{Description: opts.gistDesc, Public: opts.public}
+	if gistOpts.Description == "" {
+		gistOpts.Description = gistDescription(data)
+	}
 
-	// Remove the URL param auto-load at the end since we handle it ourselves
-	html = strings.Replace(html,
-		`// URL param support (from query string or injected by CLI)
-		const params = new URLSearchParams(window.location.search);
-		const urlParam = params.get('url') || window.GIST_URL;
-		if (urlParam) {
-			document.getElementById('gist-url').value = urlParam;
-			loadSession();
-		}`,
-		`// Local mode - loading handled by LOCAL_MODE script`, 1)
+	var gistURL string
+	if gistID != "" {
+		fmt.Fprintf(opts.progress(), "Updating gist %s...\n", gistID)
+		gistURL, err = gist.Update(gistID, tmpDir, gistOpts)
+		if errors.Is(err, gist.ErrGistNotFound) && !explicit {
+			fmt.Fprintf(opts.progress(), "Gist %s no longer exists; uploading a new one...\n", gistID)
+			gistURL, err = gist.Upload(tmpDir, gistOpts)
+		}
+	} else {
+		fmt.Fprintf(opts.progress(), "Uploading to a %s GitHub Gist...\n", visibility)
+		gistURL, err = gist.Upload(tmpDir, gistOpts)
+	}
+	if err != nil {
+		return "", fmt.Errorf("uploading gist: %w", err)
+	}
 
-	return html
+	if !opts.snapshot && opts.encryption == nil {
+		state.Put(gist.Record{SessionID: sessionID, GistID: gist.IDFromURL(gistURL), URL: gistURL, Source: path, Public: opts.public})
+		if err := state.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not remember the gist for this session: %v\n", err)
+		}
+	}
+	return gistURL, nil
 }
 
-func exportURL(url, outputDir string, uploadGist, createZip, openBrowser bool) error {
-	fmt.Printf("Fetching %s...\n", url)
-
-	resp, err := http.Get(url)
-	if err != nil {
-		return fmt.Errorf("fetching URL: %w", err)
+// uploadSessionSnippet uploads the session to a GitLab snippet after
+// confirming. Snippets are private unless --public or the config says
+// otherwise.
+func uploadSessionSnippet(path string, data []byte, opts *exportOptions, cfg *config.Config) (*gitlab.Snippet, error) {
+	if !gitlab.HasToken() {
+		return nil, gitlab.ErrNoToken
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	instance := gitlab.InstanceURL(cfg.GitLab.URL)
+	visibility := cfg.GitLab.Visibility
+	if opts.public {
+		visibility = gitlab.VisibilityPublic
+	}
+	if visibility == "" {
+		visibility = gitlab.VisibilityPrivate
 	}
 
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("reading response: %w", err)
+	if !opts.yes {
+		who := map[string]string{
+			gitlab.VisibilityPrivate:  "Only you can view it.",
+			gitlab.VisibilityInternal: "Anyone signed in to " + instance + " can view it.",
+			gitlab.VisibilityPublic:   "Anyone can view it.",
+		}[visibility]
+		prompt := fmt.Sprintf("Upload %s session (%s) to a %s GitLab snippet on %s? %s [y/N]: ",
+			formatBytes(len(data)), filepath.Base(path), visibility, instance, who)
+		if !confirm(prompt) {
+			return nil, errors.New("upload cancelled (use -o to save locally, or --yes to skip this prompt)")
+		}
 	}
 
-	tmpFile, err := os.CreateTemp("", "session-*.jsonl")
+	tmpDir, err := os.MkdirTemp("", "claude-snippet-*")
 	if err != nil {
-		return fmt.Errorf("creating temp file: %w", err)
+		return nil, fmt.Errorf("creating temp directory: %w", err)
 	}
-	defer os.Remove(tmpFile.Name())
-
-	if _, err := tmpFile.Write(data); err != nil {
-		return fmt.Errorf("writing temp file: %w", err)
+	defer os.RemoveAll(tmpDir)
+	if err := os.WriteFile(filepath.Join(tmpDir, "session.jsonl"), data, 0644); err != nil {
+		return nil, fmt.Errorf("writing temp file: %w", err)
 	}
-	tmpFile.Close()
 
-	return exportSession(tmpFile.Name(), outputDir, uploadGist, createZip, openBrowser)
+	title := opts.gistDesc
+	if title == "" {
+		title = gistDescription(data)
+	}
+	fmt.Fprintf(opts.progress(), "Uploading to a %s GitLab snippet...\n", visibility)
+	snippet, err := gitlab.Upload(tmpDir, gitlab.Options{URL: instance, Title: title, Visibility: visibility})
+	if err != nil {
+		return nil, fmt.Errorf("uploading snippet: %w", err)
+	}
+	return snippet, nil
 }
 
-// ANSI color codes
+// sendSessionWebhook POSTs the session, as JSONL or with --zip as a zip
+// with the viewer, to the configured URL after confirming
+func sendSessionWebhook(path string, data []byte, opts *exportOptions, cfg *config.Config, view render.Options) (exportSummary, error) {
+	url := cfg.Webhook.URL
+	if opts.webhookURL != "" {
+		url = opts.webhookURL
+	}
+	if url == "" {
+		return exportSummary{}, webhook.ErrNoURL
+	}
+	headers := http.Header{}
+	for name, value := range cfg.Webhook.Headers {
+		headers.Set(name, os.ExpandEnv(value))
+	}
+	for _, h := range opts.webhookHeaders {
+		name, value, err := webhook.ParseHeader(h)
+		if err != nil {
+			return exportSummary{}, fmt.Errorf("invalid --webhook-header: %w", err)
+		}
+		headers.Set(name, value)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "claude-webhook-*")
+	if err != nil {
+		return exportSummary{}, fmt.Errorf("creating temp directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	format := "jsonl"
+	var file string
+	if opts.createZip || cfg.Webhook.Format == "zip" {
+		format = "zip"
+		if file, err = exportArchive(path, data, tmpDir, view, opts); err != nil {
+			return exportSummary{}, err
+		}
+	} else {
+		file = filepath.Join(tmpDir, exportBaseName(path, data)+".jsonl")
+		if err := os.WriteFile(file, data, 0644); err != nil {
+			return exportSummary{}, fmt.Errorf("writing temp file: %w", err)
+		}
+	}
+	if opts.encryption != nil {
+		if err := encrypt.File(file, file+encrypt.Ext, *opts.encryption, false); err != nil {
+			return exportSummary{}, fmt.Errorf("encrypting %s: %w", format, err)
+		}
+		file += encrypt.Ext
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return exportSummary{}, err
+	}
+
+	if !opts.yes {
+		prompt := fmt.Sprintf("Send %s session (%s) as %s to %s? [y/N]: ",
+			formatBytes(int(info.Size())), filepath.Base(path), format, url)
+		if !confirm(prompt) {
+			return exportSummary{}, errors.New("upload cancelled (use -o to save locally, or --yes to skip this prompt)")
+		}
+	}
+
+	fmt.Fprintf(opts.progress(), "Sending to %s...\n", url)
+	result, err := webhook.Send(file, webhook.Options{
+		URL:       url,
+		Headers:   headers,
+		SessionID: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		UserAgent: "claude-session-export/" + version,
+	})
+	if err != nil {
+		return exportSummary{}, fmt.Errorf("sending to webhook: %w", err)
+	}
+	return webhookSummary(format, url, result, info.Size(), data), nil
+}
+
+// publishSessionPage creates or updates the session's Confluence page after
+// confirming. Pages are found again by title, which ends in the session ID.
+func publishSessionPage(path string, data []byte, opts *exportOptions, cfg *config.Config) (*confluence.Result, error) {
+	if !confluence.HasToken() {
+		return nil, confluence.ErrNoToken
+	}
+	if cfg.Confluence.URL == "" || cfg.Confluence.Space == "" {
+		return nil, errors.New("no Confluence site or space: set confluence.url and confluence.space in the config file")
+	}
+	sess, err := session.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing session: %w", err)
+	}
+	sessionID := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	title := opts.gistDesc
+	if title == "" {
+		title = confluenceTitle(sess, sessionID)
+	}
+
+	if !opts.yes {
+		prompt := fmt.Sprintf("Publish %s session (%s) to the %s space on %s as %q? Everyone who can see the space can read it. [y/N]: ",
+			formatBytes(len(data)), filepath.Base(path), cfg.Confluence.Space, cfg.Confluence.URL, title)
+		if !confirm(prompt) {
+			return nil, errors.New("upload cancelled (use -o to save locally, or --yes to skip this prompt)")
+		}
+	}
+
+	fmt.Fprintf(opts.progress(), "Publishing to Confluence...\n")
+	page, err := confluence.Publish(title, confluence.Render(sess, sessionID), confluence.Options{
+		URL:      cfg.Confluence.URL,
+		Space:    cfg.Confluence.Space,
+		ParentID: cfg.Confluence.ParentID,
+		User:     cfg.Confluence.User,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("publishing to Confluence: %w", err)
+	}
+	return page, nil
+}
+
+// confluenceTitle names a session's page. Titles are unique within a space,
+// so it ends in the session's date and short ID.
+func confluenceTitle(sess *session.Session, sessionID string) string {
+	title := session.DefaultTitle(sess)
+	if title == "" {
+		title = "Claude Code session"
+	}
+	var suffix []string
+	if meta := sess.Metadata; meta != nil && !meta.StartTime.IsZero() {
+		suffix = append(suffix, meta.StartTime.Local().Format("2006-01-02"))
+	}
+	if len(sessionID) > 8 {
+		sessionID = sessionID[:8]
+	}
+	suffix = append(suffix, sessionID)
+	return title + " (" + strings.Join(suffix, ", ") + ")"
+}
+
+// gistDescription is the default gist description: the tag that lets gists
+// list find the gist, then the session's title and date. The title comes
+// from the data being uploaded, so redaction applies to it too.
+func gistDescription(data []byte) string {
+	sess, err := session.Parse(data)
+	if err != nil {
+		return gist.DescriptionTag
+	}
+	desc := gist.DescriptionTag
+	if title := session.DefaultTitle(sess); title != "" {
+		desc += ": " + title
+	}
+	if meta := sess.Metadata; meta != nil && !meta.StartTime.IsZero() {
+		desc += " (" + meta.StartTime.Local().Format("2006-01-02") + ")"
+	}
+	return desc
+}
+
+// waitForSession handles sessions Claude Code is still writing: with
+// --wait-idle it waits for a pause, otherwise it says the export is partial
+func waitForSession(path string, opts *exportOptions) error {
+	if opts.snapshot || !session.IsLiveFile(path, time.Now()) {
+		return nil
+	}
+	if opts.waitIdle <= 0 {
+		fmt.Fprintln(opts.progress(), "Note: this session is still being written; exporting it as it is now (--wait-idle 30s waits for a pause)")
+		return nil
+	}
+	fmt.Fprintf(opts.progress(), "Waiting until the session has been idle for %s...\n", opts.waitIdle)
+	if err := session.WaitIdle(path, opts.waitIdle, maxIdleWait); err != nil {
+		return fmt.Errorf("waiting for session: %w", err)
+	}
+	return nil
+}
+
+// applyProfile fills in the options a named profile fixes. Flags given on
+// the command line win; the profile's redaction rules add to the config's.
+func applyProfile(opts *exportOptions, cfg *config.Config) error {
+	p, ok := cfg.Profiles[opts.profile]
+	if !ok {
+		names := cfg.ProfileNames()
+		if len(names) == 0 {
+			return fmt.Errorf("unknown profile %q (no profiles in the config file)", opts.profile)
+		}
+		return fmt.Errorf("unknown profile %q (available: %s)", opts.profile, strings.Join(names, ", "))
+	}
+
+	if opts.format == "" {
+		opts.format = p.Format
+	}
+	if opts.theme == "" {
+		opts.theme = p.Theme
+	}
+	cfg.Redact = append(cfg.Redact, p.Redact...)
+	opts.anonymize = opts.anonymize || p.Anonymize
+	opts.noToolOutput = opts.noToolOutput || p.NoToolOutput
+
+	// Only pick a destination if the command line didn't
+	if opts.outputDir == "" && !opts.createZip && !opts.uploadGist && opts.upload == "" {
+		opts.outputDir = p.OutputDir
+		switch p.Destination {
+		case config.DestinationLocal:
+			opts.local = true
+		case config.DestinationGist:
+			opts.uploadGist = true
+		case config.DestinationGitLab:
+			opts.upload = uploadGitLab
+		case config.DestinationWebhook:
+			opts.upload = uploadWebhook
+		case config.DestinationConfluence:
+			opts.upload = uploadConfluence
+		case config.DestinationZip:
+			opts.createZip = true
+		}
+	}
+	return nil
+}
+
+// recordExport adds an export to the local history used by the usage command
+func recordExport(sessionPath, format, destination, location string, data []byte) {
+	err := history.Record(history.Entry{
+		SessionID:   strings.TrimSuffix(filepath.Base(sessionPath), filepath.Ext(sessionPath)),
+		Source:      sessionPath,
+		Format:      format,
+		Destination: destination,
+		Location:    location,
+		Size:        len(data),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record export history: %v\n", err)
+	}
+}
+
+// exportAsHTML writes the viewer with the session embedded to a local file.
+// Nothing leaves the machine.
+func exportAsHTML(sessionPath string, sessionData []byte, dir string, view render.Options, opts *exportOptions) (string, error) {
+	// Without a directory, viewers go in a private one in the user's cache,
+	// since they hold whole transcripts
+	dirPerm, filePerm := os.FileMode(0755), os.FileMode(0644)
+	if dir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("getting cache directory: %w", err)
+		}
+		dir = filepath.Join(base, "claude-session-export", "html")
+		dirPerm, filePerm = 0700, 0600
+	}
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return "", fmt.Errorf("creating output directory: %w", err)
+	}
+
+	htmlPath := filepath.Join(dir, exportBaseName(sessionPath, sessionData)+".html")
+	if opts.previewImage {
+		image, err := previewCard(sessionData, view.Title)
+		if err != nil {
+			return "", err
+		}
+		imagePath := previewImagePath(htmlPath)
+		if err := os.WriteFile(imagePath, image, filePerm); err != nil {
+			return "", fmt.Errorf("writing preview image: %w", err)
+		}
+		view.PreviewImage = previewImageURL(opts.siteURL, filepath.Base(imagePath))
+	}
+	if err := writeViewerFile(htmlPath, sessionData, view, filePerm); err != nil {
+		return "", fmt.Errorf("writing viewer: %w", err)
+	}
+	return htmlPath, nil
+}
+
+// previewImageName is the link preview card's name in archives
+const previewImageName = "preview.png"
+
+// previewImagePath is where the link preview card of an HTML export goes
+func previewImagePath(htmlPath string) string {
+	return strings.TrimSuffix(htmlPath, ".html") + ".preview.png"
+}
+
+// previewImageURL is how the viewer links its preview card: under siteURL
+// when the export's address is known, or else relative to the page
+func previewImageURL(siteURL, name string) string {
+	if siteURL == "" {
+		return name
+	}
+	return strings.TrimSuffix(siteURL, "/") + "/" + name
+}
+
+// previewCard draws the session's link preview card: its title (or first
+// prompt), project, duration and input and output tokens
+func previewCard(sessionData []byte, title string) ([]byte, error) {
+	sess, err := session.Parse(sessionData)
+	if err != nil {
+		return nil, fmt.Errorf("parsing session: %w", err)
+	}
+	c := card.Card{Title: title}
+	if c.Title == "" {
+		if prompts := session.GetUserPrompts(sess); len(prompts) > 0 {
+			c.Title = session.ExtractText(&prompts[0])
+		}
+	}
+	if meta := sess.Metadata; meta != nil {
+		if meta.Cwd != "" {
+			c.Project = filepath.Base(meta.Cwd)
+		}
+		if !meta.StartTime.IsZero() && !meta.EndTime.IsZero() {
+			c.Duration = formatDuration(meta.EndTime.Sub(meta.StartTime))
+		}
+	}
+	tokens := 0
+	for _, u := range session.UsageByModel(sess) {
+		tokens += u.InputTokens + u.OutputTokens
+	}
+	if tokens > 0 {
+		c.Tokens = formatTokenCount(tokens)
+	}
+	image, err := card.PNG(c)
+	if err != nil {
+		return nil, fmt.Errorf("drawing preview image: %w", err)
+	}
+	return image, nil
+}
+
+// exportAsJSON writes the normalized session document to dir (default: the
+// current directory)
+func exportAsJSON(sessionPath string, sessionData []byte, dir string) (string, error) {
+	sess, err := session.Parse(sessionData)
+	if err != nil {
+		return "", fmt.Errorf("parsing session: %w", err)
+	}
+	id := strings.TrimSuffix(filepath.Base(sessionPath), filepath.Ext(sessionPath))
+	doc := normalize.Build(sess, id)
+
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("creating output directory: %w", err)
+	}
+	jsonPath := filepath.Join(dir, exportBaseName(sessionPath, sessionData)+".json")
+	err = writeReportFile(jsonPath, func(w io.Writer) error {
+		return writeNormalized(w, doc)
+	})
+	if err != nil {
+		return "", err
+	}
+	return jsonPath, nil
+}
+
+func writeNormalized(w io.Writer, doc *normalize.Document) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(doc)
+}
+
+// exportAsSitePage writes the session as a front-mattered Markdown page
+// under dir, at project/date-title-id.md so it can go straight into a Hugo or
+// Jekyll content directory. Re-exporting a session overwrites its page.
+func exportAsSitePage(sessionPath string, sessionData []byte, dir string) (string, error) {
+	sess, err := session.Parse(sessionData)
+	if err != nil {
+		return "", fmt.Errorf("parsing session: %w", err)
+	}
+	id := strings.TrimSuffix(filepath.Base(sessionPath), filepath.Ext(sessionPath))
+	page := site.Build(sess, id)
+
+	if dir == "" {
+		dir = "."
+	}
+	pagePath := filepath.Join(dir, filepath.FromSlash(page.Path))
+	if err := os.MkdirAll(filepath.Dir(pagePath), 0755); err != nil {
+		return "", fmt.Errorf("creating output directory: %w", err)
+	}
+	if err := os.WriteFile(pagePath, page.Content, 0644); err != nil {
+		return "", fmt.Errorf("writing page: %w", err)
+	}
+	return pagePath, nil
+}
+
+// exportAsMail writes the session as an email thread, one message per
+// turn: a single mbox file, or a directory of .eml files. It returns the
+// path and the bytes written.
+func exportAsMail(sessionPath string, sessionData []byte, dir, format string) (string, int64, error) {
+	sess, err := session.Parse(sessionData)
+	if err != nil {
+		return "", 0, fmt.Errorf("parsing session: %w", err)
+	}
+	id := strings.TrimSuffix(filepath.Base(sessionPath), filepath.Ext(sessionPath))
+	messages := email.Build(sess, id)
+	if len(messages) == 0 {
+		return "", 0, errors.New("session has no messages to export")
+	}
+
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", 0, fmt.Errorf("creating output directory: %w", err)
+	}
+	base := filepath.Join(dir, exportBaseName(sessionPath, sessionData))
+
+	if format == formatMbox {
+		mbox := email.Mbox(messages)
+		if err := os.WriteFile(base+".mbox", mbox, 0644); err != nil {
+			return "", 0, fmt.Errorf("writing mbox: %w", err)
+		}
+		return base + ".mbox", int64(len(mbox)), nil
+	}
+
+	// Numbered so the files sort in conversation order
+	if err := os.MkdirAll(base, 0755); err != nil {
+		return "", 0, fmt.Errorf("creating output directory: %w", err)
+	}
+	var size int64
+	for i, msg := range messages {
+		name := fmt.Sprintf("%03d-%s.eml", i+1, map[string]string{"user": "user", "assistant": "claude"}[msg.Role])
+		if err := os.WriteFile(filepath.Join(base, name), msg.Data, 0644); err != nil {
+			return "", 0, fmt.Errorf("writing message: %w", err)
+		}
+		size += int64(len(msg.Data))
+	}
+	return base, size, nil
+}
+
+// exportAsPatch writes the session's file changes as a series of git
+// patches, one per prompt, in one file git am can apply. It returns the
+// path and the bytes written.
+func exportAsPatch(sessionPath string, sessionData []byte, dir string) (string, int64, error) {
+	sess, err := session.Parse(sessionData)
+	if err != nil {
+		return "", 0, fmt.Errorf("parsing session: %w", err)
+	}
+	series, err := patchSeries(sess)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", 0, fmt.Errorf("creating output directory: %w", err)
+	}
+	patchPath := filepath.Join(dir, exportBaseName(sessionPath, sessionData)+".patch")
+	if err := os.WriteFile(patchPath, series, 0644); err != nil {
+		return "", 0, fmt.Errorf("writing patch: %w", err)
+	}
+	return patchPath, int64(len(series)), nil
+}
+
+// patchSeries builds the patches of a session's file changes, warning
+// about the files they leave out
+func patchSeries(sess *session.Session) ([]byte, error) {
+	patches, skipped := patch.Build(sess)
+	if len(skipped) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: left out %d files edited without being written or read in full first: %s\n", len(skipped), strings.Join(skipped, ", "))
+	}
+	if len(patches) == 0 {
+		return nil, errors.New("session has no file changes to export")
+	}
+	return patch.Series(patches), nil
+}
+
+// stdoutFormats are the exports --stdout can write: the ones that are a
+// single file
+var stdoutFormats = []string{formatHTML, formatJSON, formatSite, formatMbox, formatPatch}
+
+// checkStdout makes sure --stdout asks for a single file and nothing that
+// writes elsewhere
+func checkStdout(opts *exportOptions) error {
+	switch {
+	case opts.format != "" && !slices.Contains(stdoutFormats, opts.format):
+		return fmt.Errorf("--format %s can't be written to stdout (available: %s)", opts.format, strings.Join(stdoutFormats, ", "))
+	case opts.createZip, opts.uploadGist, opts.gistID != "", opts.upload != "":
+		return errors.New("--stdout can't be combined with --zip or uploads")
+	case opts.outputDir != "":
+		return errors.New("--stdout can't be combined with -o")
+	case opts.encrypt != "":
+		return errors.New("--stdout can't be combined with --encrypt")
+	case opts.json:
+		return errors.New("--stdout can't be combined with --json")
+	}
+	return nil
+}
+
+// writeStdout writes the export to stdout: the viewer by default, or the
+// normalized JSON, Markdown page or mbox
+func writeStdout(sessionPath string, sessionData []byte, view render.Options, format string) error {
+	w := bufio.NewWriter(os.Stdout)
+	if err := writeExport(w, sessionPath, sessionData, view, format); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func writeExport(w io.Writer, sessionPath string, sessionData []byte, view render.Options, format string) error {
+	if format == "" || format == formatHTML {
+		return render.RenderTo(w, bytes.NewReader(sessionData), view)
+	}
+
+	sess, err := session.Parse(sessionData)
+	if err != nil {
+		return fmt.Errorf("parsing session: %w", err)
+	}
+	id := strings.TrimSuffix(filepath.Base(sessionPath), filepath.Ext(sessionPath))
+	switch format {
+	case formatJSON:
+		return writeNormalized(w, normalize.Build(sess, id))
+	case formatSite:
+		_, err = w.Write(site.Build(sess, id).Content)
+		return err
+	case formatPatch:
+		series, err := patchSeries(sess)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(series)
+		return err
+	default:
+		messages := email.Build(sess, id)
+		if len(messages) == 0 {
+			return errors.New("session has no messages to export")
+		}
+		_, err = w.Write(email.Mbox(messages))
+		return err
+	}
+}
+
+// confirm asks a yes/no question on the terminal, defaulting to no. The
+// prompt goes to stderr so it never mixes with --json output.
+func confirm(prompt string) bool {
+	fmt.Fprint(os.Stderr, prompt)
+	var input string
+	fmt.Scanln(&input)
+	input = strings.ToLower(strings.TrimSpace(input))
+	return input == "y" || input == "yes"
+}
+
+// formatBytes formats a byte count as e.g. "1.2 MB", "3.4 KB" or "512 B"
+func formatBytes(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// prepareSessionData applies filtering, redaction and anonymization to the
+// raw session data before it leaves the machine, so every output format gets
+// the same treatment
+func prepareSessionData(data []byte, opts *exportOptions, cfg *config.Config) ([]byte, error) {
+	data, err := sliceSession(data, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var filters []transform.Filter
+	if opts.noToolOutput {
+		filters = append(filters, transform.DropToolOutput())
+	} else if opts.toolOutputLimit > 0 {
+		filters = append(filters, transform.LimitToolOutput(opts.toolOutputLimit))
+	}
+	data = transform.Apply(data, filters...)
+
+	var rules []redact.Rule
+	for _, r := range cfg.Redact {
+		rule, err := redact.ParseRule(r.Pattern, r.Replacement)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	for _, pattern := range opts.redact {
+		rule, err := redact.ParseRule(pattern, "")
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	if opts.anonymize {
+		rules = append(rules, redact.AnonymizeRules(data)...)
+	}
+
+	data, count := redact.New(rules).Apply(data)
+	if count > 0 {
+		noun := "redaction"
+		if count > 1 {
+			noun = "redactions"
+		}
+		fmt.Fprintf(opts.progress(), "Applied %d %s\n", count, noun)
+	}
+
+	return data, nil
+}
+
+// exportBaseName builds a descriptive file name (project-date-time) for an
+// exported session
+func exportBaseName(sessionPath string, sessionData []byte) string {
+	// Parse session to get project name and timestamp
+	sess, _ := session.Parse(sessionData)
+	details, _ := session.GetSessionDetails(sessionPath)
+
+	projectName := "session"
+	if sess != nil && len(sess.Messages) > 0 && sess.Messages[0].Cwd != "" {
+		// Extract project name from cwd
+		projectName = filepath.Base(sess.Messages[0].Cwd)
+	}
+	// Clean project name for filename
+	projectName = strings.ReplaceAll(projectName, " ", "-")
+	projectName = strings.ReplaceAll(projectName, "/", "-")
+
+	timestamp := time.Now()
+	if details != nil && !details.EndTime.IsZero() {
+		timestamp = details.EndTime
+	}
+
+	return fmt.Sprintf("%s-%s", projectName, timestamp.Local().Format("2006-01-02-1504"))
+}
+
+// withSubagents appends any separately stored subagent transcripts so the
+// viewer can nest them under the Task calls that started them
+func withSubagents(path string, data []byte) ([]byte, error) {
+	if !session.IsJSONL(path) {
+		return data, nil
+	}
+	sub, err := session.LoadSubagentTranscripts(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading subagent transcripts: %w", err)
+	}
+	if len(sub) == 0 {
+		return data, nil
+	}
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		data = append(data, '\n')
+	}
+	return append(data, sub...), nil
+}
+
+// defaultDestination is the config file's default_destination, which
+// applies only when neither the flags (-o, --zip, --gist, --gist-id,
+// --upload or --format) nor the profile name one
+func defaultDestination(opts *exportOptions, cfg *config.Config) string {
+	if opts.local || opts.outputDir != "" || opts.createZip || opts.uploadGist || opts.gistID != "" || opts.upload != "" || opts.format != "" {
+		return ""
+	}
+	return cfg.DefaultDestination
+}
+
+// gistEncryptedFile is the file an encrypted gist holds
+const gistEncryptedFile = "session.jsonl" + encrypt.Ext
+
+// checkEncryption parses --encrypt and makes sure the export is one that
+// can be encrypted: a single file written or sent as a whole
+func checkEncryption(opts *exportOptions, cfg *config.Config) error {
+	spec, err := encrypt.Parse(opts.encrypt)
+	if err != nil {
+		return err
+	}
+	dest := defaultDestination(opts, cfg)
+	supported := opts.createZip || opts.format == formatTarGz || opts.uploadGist || opts.upload == uploadWebhook ||
+		(opts.outputDir != "" && opts.format == "") ||
+		dest == config.DestinationGist || dest == config.DestinationWebhook
+	if !supported || (opts.format != "" && opts.format != formatTarGz) || opts.upload == uploadGitLab || opts.upload == uploadConfluence {
+		return errors.New("--encrypt applies to --zip, --format tar.gz, -o, --gist and --upload webhook exports")
+	}
+	if opts.gistID != "" {
+		return errors.New("--encrypt always creates a new gist, so it can't be combined with --gist-id")
+	}
+	if !encrypt.Available() {
+		return encrypt.ErrNoAge
+	}
+	opts.encryption = &spec
+	return nil
+}
+
+// writeArchive writes the zip or tar.gz to outputDir, or with --encrypt
+// only its encrypted copy; the plaintext archive is built in a temp
+// directory and removed
+func writeArchive(sessionPath string, sessionData []byte, outputDir string, view render.Options, opts *exportOptions) (string, error) {
+	if opts.encryption == nil {
+		return exportArchive(sessionPath, sessionData, outputDir, view, opts)
+	}
+	tmpDir, err := os.MkdirTemp("", "claude-archive-*")
+	if err != nil {
+		return "", fmt.Errorf("creating temp directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	plain, err := exportArchive(sessionPath, sessionData, tmpDir, view, opts)
+	if err != nil {
+		return "", err
+	}
+
+	archivePath := filepath.Base(plain) + encrypt.Ext
+	if outputDir != "" {
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return "", fmt.Errorf("creating output directory: %w", err)
+		}
+		archivePath = filepath.Join(outputDir, archivePath)
+	}
+	if err := encrypt.File(plain, archivePath, *opts.encryption, false); err != nil {
+		return "", fmt.Errorf("encrypting archive: %w", err)
+	}
+	return archivePath, nil
+}
+
+// archiveWriter is what exportArchive writes with: a plain zip.Writer, a
+// zipaes.Writer for password-protected zips, or a tarGzWriter
+type archiveWriter interface {
+	Create(name string) (io.Writer, error)
+	Close() error
+}
+
+// exportArchive writes a zip, or a tar.gz with --format tar.gz, with the
+// viewer and, with --with-jsonl, the session itself. Zips are encrypted
+// with --zip-password.
+func exportArchive(sessionPath string, sessionData []byte, outputDir string, view render.Options, opts *exportOptions) (string, error) {
+	filename := exportBaseName(sessionPath, sessionData) + ".zip"
+	if opts.format == formatTarGz {
+		filename = exportBaseName(sessionPath, sessionData) + ".tar.gz"
+	}
+
+	// Determine output path
+	archivePath := filename
+	if outputDir != "" {
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return "", fmt.Errorf("creating output directory: %w", err)
+		}
+		archivePath = filepath.Join(outputDir, filename)
+	}
+
+	// Create archive file
+	file, err := os.Create(archivePath)
+	if err != nil {
+		return "", fmt.Errorf("creating archive file: %w", err)
+	}
+	defer file.Close()
+
+	var aw archiveWriter
+	switch {
+	case opts.format == formatTarGz:
+		// Files keep the session file's time and permissions
+		info, err := os.Stat(sessionPath)
+		if err != nil {
+			return "", err
+		}
+		aw = newTarGzWriter(file, info.ModTime(), info.Mode().Perm())
+	case opts.zipPassword != "":
+		aw = zipaes.NewWriter(file, opts.zipPassword)
+	default:
+		aw = zip.NewWriter(file)
+	}
+
+	format := "zip"
+	if opts.format == formatTarGz {
+		format = formatTarGz
+	}
+	options := manifestOptions(opts, format)
+	var files []archive.ManifestFile
+
+	// The link preview card goes first, so the viewer can point to it
+	if opts.previewImage {
+		image, err := previewCard(sessionData, view.Title)
+		if err != nil {
+			return "", err
+		}
+		w, err := aw.Create(previewImageName)
+		if err != nil {
+			return "", fmt.Errorf("adding preview image to archive: %w", err)
+		}
+		if _, err := w.Write(image); err != nil {
+			return "", fmt.Errorf("writing preview image to archive: %w", err)
+		}
+		sum := sha256.Sum256(image)
+		files = append(files, archive.ManifestFile{Path: previewImageName, SHA256: hex.EncodeToString(sum[:]), Size: int64(len(image)), Source: opts.source, Options: options})
+		view.PreviewImage = previewImageURL(opts.siteURL, previewImageName)
+	}
+
+	// Add viewer.html to zip (session data is embedded in the HTML)
+	viewerWriter, err := aw.Create("viewer.html")
+	if err != nil {
+		return "", fmt.Errorf("adding viewer to archive: %w", err)
+	}
+	hash := sha256.New()
+	size := &countingWriter{}
+	if err := render.RenderTo(io.MultiWriter(viewerWriter, hash, size), bytes.NewReader(sessionData), view); err != nil {
+		return "", fmt.Errorf("writing viewer to archive: %w", err)
+	}
+	files = append(files, archive.ManifestFile{Path: "viewer.html", SHA256: hex.EncodeToString(hash.Sum(nil)), Size: size.n, Source: opts.source, Options: options})
+
+	// The JSONL lets recipients re-export, search or import the session
+	if opts.withJSONL {
+		w, err := aw.Create("session.jsonl")
+		if err != nil {
+			return "", fmt.Errorf("adding session to archive: %w", err)
+		}
+		if _, err := w.Write(sessionData); err != nil {
+			return "", fmt.Errorf("writing session to archive: %w", err)
+		}
+		sum := sha256.Sum256(sessionData)
+		files = append(files, archive.ManifestFile{Path: "session.jsonl", SHA256: hex.EncodeToString(sum[:]), Size: int64(len(sessionData)), Source: opts.source, Options: options})
+	}
+
+	// The manifest lets recipients check the files and where they came
+	// from; watermarked exports also say so here
+	manifest := archive.Manifest{
+		Generator: manifestGenerator(),
+		Files:     files,
+		Watermark: view.Watermark,
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	w, err := aw.Create(archive.ManifestName)
+	if err != nil {
+		return "", fmt.Errorf("adding manifest to archive: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return "", fmt.Errorf("writing manifest to archive: %w", err)
+	}
+
+	if err := aw.Close(); err != nil {
+		return "", fmt.Errorf("writing archive: %w", err)
+	}
+	return archivePath, nil
+}
+
+// manifestGenerator names this tool and version in manifests
+func manifestGenerator() string {
+	return "claude-session-export " + version
+}
+
+// manifestOptions lists the options that shaped an export, leaving out
+// defaults. Redaction patterns are only counted, since they can say what
+// was hidden.
+func manifestOptions(opts *exportOptions, format string) map[string]interface{} {
+	o := map[string]interface{}{"format": format}
+	set := func(key string, value interface{}, isSet bool) {
+		if isSet {
+			o[key] = value
+		}
+	}
+	set("profile", opts.profile, opts.profile != "")
+	set("redactions", len(opts.redact), len(opts.redact) > 0)
+	set("anonymize", true, opts.anonymize)
+	set("no_tool_output", true, opts.noToolOutput)
+	set("tool_output_limit", opts.toolOutputLimit, opts.toolOutputLimit > 0)
+	set("since", opts.since, opts.since != "")
+	set("until", opts.until, opts.until != "")
+	set("prompts", opts.prompts, opts.prompts != "")
+	set("theme", opts.theme, opts.theme != "")
+	set("show_meta", true, opts.showMeta)
+	set("no_emoji", true, opts.noEmoji)
+	set("watermark", opts.watermark, opts.watermark != "")
+	set("preview_image", true, opts.previewImage)
+	set("noindex", true, opts.noIndex)
+	set("with_jsonl", true, opts.withJSONL)
+	set("zip_password", true, opts.zipPassword != "")
+	set("encrypted", true, opts.encryption != nil)
+	return o
+}
+
+// updateOutputManifest lists a local export in the manifest.json of its
+// output directory: the directory of .eml files itself, the -o directory
+// of a site page, or the directory of any other file
+func updateOutputManifest(summary exportSummary, opts *exportOptions) error {
+	dir := filepath.Dir(summary.Path)
+	paths := []string{summary.Path}
+	switch summary.Format {
+	case formatEML:
+		dir = summary.Path
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		paths = nil
+		for _, e := range entries {
+			if !e.IsDir() && e.Name() != archive.ManifestName {
+				paths = append(paths, filepath.Join(dir, e.Name()))
+			}
+		}
+	case formatSite:
+		dir = opts.outputDir
+		if dir == "" {
+			dir = "."
+		}
+	case "html":
+		if opts.previewImage {
+			paths = append(paths, previewImagePath(summary.Path))
+		}
+	}
+
+	options := manifestOptions(opts, summary.Format)
+	var files []archive.ManifestFile
+	for _, path := range paths {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		f, err := archive.HashFile(path, rel)
+		if err != nil {
+			return err
+		}
+		f.Source, f.Options = opts.source, options
+		files = append(files, f)
+	}
+	return archive.UpdateManifest(dir, manifestGenerator(), files)
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+// tarGzWriter writes a gzipped tar through the archiveWriter interface.
+// Tar headers come before the data and hold its size, so each file is
+// buffered until the next one is created.
+type tarGzWriter struct {
+	gz      *gzip.Writer
+	tw      *tar.Writer
+	modTime time.Time
+	mode    os.FileMode
+	name    string
+	buf     *bytes.Buffer
+}
+
+func newTarGzWriter(w io.Writer, modTime time.Time, mode os.FileMode) *tarGzWriter {
+	gz := gzip.NewWriter(w)
+	gz.ModTime = modTime
+	return &tarGzWriter{gz: gz, tw: tar.NewWriter(gz), modTime: modTime, mode: mode}
+}
+
+func (t *tarGzWriter) Create(name string) (io.Writer, error) {
+	if err := t.flush(); err != nil {
+		return nil, err
+	}
+	t.name = name
+	t.buf = &bytes.Buffer{}
+	return t.buf, nil
+}
+
+func (t *tarGzWriter) flush() error {
+	if t.buf == nil {
+		return nil
+	}
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     t.name,
+		Mode:     int64(t.mode),
+		Size:     int64(t.buf.Len()),
+		ModTime:  t.modTime,
+	}
+	if err := t.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := t.tw.Write(t.buf.Bytes())
+	t.buf = nil
+	return err
+}
+
+func (t *tarGzWriter) Close() error {
+	if err := t.flush(); err != nil {
+		return err
+	}
+	if err := t.tw.Close(); err != nil {
+		return err
+	}
+	return t.gz.Close()
+}
+
+// writeViewerFile writes a standalone viewer page with the session embedded
+func writeViewerFile(path string, sessionData []byte, view render.Options, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if err := render.RenderTo(f, bytes.NewReader(sessionData), view); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// viewerOptions sets up the exported page: titled after the session's
+// summary, if it has one, in the theme from the flags or config
+func viewerOptions(sessionData []byte, opts *exportOptions, cfg *config.Config) (render.Options, error) {
+	view := render.Options{
+		ShowMeta:  opts.showMeta,
+		NoEmoji:   opts.noEmoji || cfg.NoEmoji,
+		Pricing:   cfg.Pricing,
+		Watermark: opts.watermark,
+		Highlight: opts.highlight,
+		NoIndex:   opts.noIndex,
+
+		MaxBlockSize: opts.maxBlockSize,
+		NoTruncate:   opts.noTruncate,
+	}
+	var repo *session.Repo
+	if sess, err := session.Parse(sessionData); err == nil {
+		if sess.Metadata != nil {
+			view.Title = sess.Metadata.Title
+		}
+		if repo = session.DetectRepo(sess); repo != nil {
+			view.IssueURLTemplate = repo.IssueURLTemplate()
+			view.MergeRequestURLTemplate = repo.MergeRequestURLTemplate()
+			// Taking the working directory for the repository's root
+			if meta := sess.Metadata; meta != nil && meta.GitBranch != "" && meta.GitBranch != "HEAD" && meta.Cwd != "" {
+				view.BlobURLTemplate = repo.BlobURLTemplate(meta.GitBranch)
+				view.BlobRoot = meta.Cwd
+			}
+		}
+	}
+
+	view.Theme = opts.theme
+	if view.Theme == "" {
+		view.Theme = cfg.Theme
+	}
+	if view.Theme != "" && !render.ValidTheme(view.Theme) {
+		return view, fmt.Errorf("unknown theme %q (available: %s)", view.Theme, strings.Join(render.Themes, ", "))
+	}
+	if opts.maxBlockSize < 0 {
+		return view, errors.New("--max-block-size must be a positive number of characters")
+	}
+	if opts.maxBlockSize > 0 && opts.noTruncate {
+		return view, errors.New("--max-block-size can't be combined with --no-truncate")
+	}
+
+	var err error
+	if view.CommitURLTemplate, err = commitURLTemplate(repo, opts.commitURLTemplate, cfg.CommitURLTemplate); err != nil {
+		return view, err
+	}
+	if view.EditorURLTemplate, err = editorURLTemplate(opts.editorLinks, cfg.EditorLinks); err != nil {
+		return view, err
+	}
+	if view.Header, err = brandingSnippet(opts.header, cfg.Header); err != nil {
+		return view, err
+	}
+	if view.Footer, err = brandingSnippet(opts.footer, cfg.Footer); err != nil {
+		return view, err
+	}
+	return view, nil
+}
+
+// commitURLTemplate returns where the viewer links commits: the template
+// from the flag or config with the session's repository's path as {repo},
+// or the repository's own commit pages. It's empty when the repository
+// isn't known.
+func commitURLTemplate(repo *session.Repo, flagValue, configValue string) (string, error) {
+	template := flagValue
+	if template == "" {
+		template = configValue
+	}
+	if template != "" && !strings.Contains(template, "{hash}") {
+		return "", fmt.Errorf("commit URL template %q has no {hash}", template)
+	}
+
+	switch {
+	case template == "" && repo == nil:
+		return "", nil
+	case template == "":
+		return repo.CommitURLTemplate(), nil
+	case strings.Contains(template, "{repo}"):
+		if repo == nil {
+			return "", nil
+		}
+		return strings.ReplaceAll(template, "{repo}", repo.Path), nil
+	}
+	return template, nil
+}
+
+// editorURLTemplate returns the URL that opens a file in the editor named
+// by the flag or config, which may also be a URL template of its own
+func editorURLTemplate(flagValue, configValue string) (string, error) {
+	editor := flagValue
+	if editor == "" {
+		editor = configValue
+	}
+	if template, ok := render.Editors[editor]; ok {
+		return template, nil
+	}
+	if editor == "" || strings.Contains(editor, "{path}") {
+		return editor, nil
+	}
+	names := make([]string, 0, len(render.Editors))
+	for name := range render.Editors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return "", fmt.Errorf("unknown editor %q (available: %s, or a URL with {path})", editor, strings.Join(names, ", "))
+}
+
+// brandingSnippet returns the header or footer HTML from a flag, falling
+// back to the config. Values starting with @ name a file to read.
+func brandingSnippet(flagValue, configValue string) (string, error) {
+	value := flagValue
+	if value == "" {
+		value = configValue
+	}
+	path, ok := strings.CutPrefix(value, "@")
+	if !ok {
+		return value, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading branding snippet: %w", err)
+	}
+	return string(data), nil
+}
+
+// sessionTitler builds the summary providers chosen in the config, or
+// returns nil for the default titles. Each failing provider is reported
+// once and then skipped over.
+func sessionTitler(cfg *config.Config) (session.TitleFunc, error) {
+	if len(cfg.Summaries.Providers) == 0 {
+		return nil, nil
+	}
+	providers, err := summary.New(cfg.Summaries.Providers, summary.Settings{
+		OllamaURL:      cfg.Summaries.OllamaURL,
+		OllamaModel:    cfg.Summaries.OllamaModel,
+		AnthropicKey:   os.Getenv("ANTHROPIC_API_KEY"),
+		AnthropicModel: cfg.Summaries.AnthropicModel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("config summaries: %w", err)
+	}
+
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+	chain := summary.NewChain(providers, filepath.Join(dir, "summaries.json"))
+	var mu sync.Mutex
+	warned := make(map[string]bool)
+	chain.OnError = func(provider string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !warned[provider] {
+			warned[provider] = true
+			fmt.Fprintf(os.Stderr, "Warning: %s summaries unavailable: %v\n", provider, err)
+		}
+	}
+	return chain.Title, nil
+}
+
+func exportURL(url string, opts *exportOptions) error {
+	fmt.Fprintf(opts.progress(), "Fetching %s...\n", url)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return fmt.Errorf("fetching URL: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+
+	tmpFile, err := os.CreateTemp("", "session-*.jsonl")
+	if err != nil {
+		return fmt.Errorf("creating temp file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		return fmt.Errorf("writing temp file: %w", err)
+	}
+	tmpFile.Close()
+
+	opts.snapshot = true
+	return exportSession(tmpFile.Name(), opts)
+}
+
+// ANSI color codes
 const (
 	colorReset  = "\033[0m"
 	colorDim    = "\033[2m"
@@ -521,7 +2430,11 @@ const (
 	colorYellow = "\033[33m"
 )
 
-func selectSession(sessions []session.SessionInfo) (*session.SessionInfo, error) {
+// pickerPageSize is the number of sessions shown per picker page
+const pickerPageSize = 20
+
+// selectSession shows the interactive picker on w and reads the choice
+func selectSession(sessions []session.SessionInfo, w io.Writer) (*session.SessionInfo, error) {
 	if len(sessions) == 0 {
 		return nil, errors.New("no sessions to select")
 	}
@@ -540,62 +2453,138 @@ func selectSession(sessions []session.SessionInfo) (*session.SessionInfo, error)
 		maxProjectWidth = 30
 	}
 
-	fmt.Println("\nSelect a session:")
-	fmt.Println()
+	pages := (len(sessions) + pickerPageSize - 1) / pickerPageSize
+	page := 0
 
-	for i, s := range sessions {
-		projectName := projectNames[i]
-		if len(projectName) > 30 {
-			projectName = projectName[:27] + "..."
+	for {
+		start := page * pickerPageSize
+		end := start + pickerPageSize
+		if end > len(sessions) {
+			end = len(sessions)
 		}
 
-		// Format time (use EndTime - last message time) in local timezone
-		displayTime := s.ModTime.Local()
-		if !s.EndTime.IsZero() {
-			displayTime = s.EndTime.Local()
-		}
-		timeStr := displayTime.Format("Jan 02 3:04pm")
+		fmt.Fprintln(w, "\nSelect a session:")
 
-		// Build prompt count string
-		promptStr := ""
-		if s.UserMsgCount > 0 {
-			promptStr = fmt.Sprintf("%4d prompts", s.UserMsgCount)
-		}
+		now := time.Now()
+		lastLabel := ""
+		for i := start; i < end; i++ {
+			s := sessions[i]
+			displayTime := sessionDisplayTime(s)
 
-		// Summary - truncate to fit
-		summary := s.Summary
-		if summary == "" {
-			summary = "(No summary available)"
+			// Date separator whenever the day group changes
+			if label := dayLabel(displayTime, now); label != lastLabel {
+				fmt.Fprintf(w, "\n  %s%s%s\n", colorYellow, label, colorReset)
+				lastLabel = label
+			}
+
+			projectName := projectNames[i]
+			if len(projectName) > 30 {
+				projectName = projectName[:27] + "..."
+			}
+
+			timeStr := displayTime.Format("Jan 02 3:04pm")
+
+			// Build prompt count string
+			promptStr := ""
+			if s.UserMsgCount > 0 {
+				promptStr = fmt.Sprintf("%4d prompts", s.UserMsgCount)
+			} else if s.MessageCount > 0 {
+				promptStr = fmt.Sprintf("%4d msgs", s.MessageCount)
+			}
+
+			// Summary - truncate to fit
+			summary := s.Summary
+			if summary == "" {
+				summary = "(No summary available)"
+			}
+			if len(summary) > 50 {
+				summary = summary[:47] + "..."
+			}
+
+			// Sessions Claude Code is still writing
+			live := ""
+			if s.IsLive(now) {
+				live = colorYellow + "live " + colorReset
+			}
+
+			// Columnar: num | date | project (padded) | prompts | summary
+			fmt.Fprintf(w, "  %2d. %s%14s%s %s%-*s%s %s%11s%s  %s%s%s%s\n",
+				i+1,
+				colorDim, timeStr, colorReset,
+				colorCyan+colorBold, maxProjectWidth, projectName, colorReset,
+				colorDim, promptStr, colorReset,
+				live, colorDim, summary, colorReset)
 		}
-		if len(summary) > 50 {
-			summary = summary[:47] + "..."
+
+		fmt.Fprintln(w)
+		if pages > 1 {
+			fmt.Fprintf(w, "%sPage %d of %d (%d sessions)%s\n", colorDim, page+1, pages, len(sessions), colorReset)
+			fmt.Fprint(w, "Enter number, n/p for next/previous page (or q to quit): ")
+		} else {
+			fmt.Fprint(w, "Enter number (or q to quit): ")
 		}
 
-		// Columnar: num | date | project (padded) | prompts | summary
-		fmt.Printf("  %2d. %s%14s%s %s%-*s%s %s%11s%s  %s%s%s\n",
-			i+1,
-			colorDim, timeStr, colorReset,
-			colorCyan+colorBold, maxProjectWidth, projectName, colorReset,
-			colorDim, promptStr, colorReset,
-			colorDim, summary, colorReset)
-	}
+		var input string
+		if _, err := fmt.Scanln(&input); errors.Is(err, io.EOF) {
+			return nil, errors.New("cancelled")
+		}
 
-	fmt.Println()
-	fmt.Print("Enter number (or q to quit): ")
+		switch strings.ToLower(input) {
+		case "q":
+			return nil, errors.New("cancelled")
+		case "n", "":
+			if page < pages-1 {
+				page++
+			}
+			continue
+		case "p":
+			if page > 0 {
+				page--
+			}
+			continue
+		}
 
-	var input string
-	fmt.Scanln(&input)
+		var idx int
+		if _, err := fmt.Sscanf(input, "%d", &idx); err != nil || idx < 1 || idx > len(sessions) {
+			return nil, errors.New("invalid selection")
+		}
 
-	if input == "q" || input == "Q" {
-		return nil, errors.New("cancelled")
+		return &sessions[idx-1], nil
 	}
+}
 
-	var idx int
-	if _, err := fmt.Sscanf(input, "%d", &idx); err != nil || idx < 1 || idx > len(sessions) {
-		return nil, errors.New("invalid selection")
+// sessionDisplayTime returns the time shown for a session in the picker:
+// the last message time when known, otherwise the file modification time
+func sessionDisplayTime(s session.SessionInfo) time.Time {
+	if !s.EndTime.IsZero() {
+		return s.EndTime.Local()
 	}
+	return s.ModTime.Local()
+}
 
-	return &sessions[idx-1], nil
+// dayLabel groups a time into a relative day bucket for picker separators
+func dayLabel(t, now time.Time) string {
+	t = t.In(now.Location())
+	// Count calendar days in UTC, where every day has 24 hours, so a DST
+	// change between the two doesn't shift the count
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(today.Sub(day).Hours() / 24)
+
+	switch {
+	case days <= 0:
+		return "Today"
+	case days == 1:
+		return "Yesterday"
+	case days < 7:
+		return "This week"
+	case days < 14:
+		return "Last week"
+	case t.Year() == now.Year():
+		return t.Format("January")
+	default:
+		return t.Format("January 2006")
+	}
 }
 
 // formatProjectName cleans up project path for display
@@ -650,7 +2639,7 @@ func openGistInViewer(gistURL string) error {
 
 	// Inject the gist URL into the HTML so it auto-loads
 	// Replace a placeholder or inject a script that sets the URL
-	html := string(viewerHTML)
+	html := string(render.ViewerHTML)
 	injection := fmt.Sprintf(`<script>window.GIST_URL = %q;</script>`, gistURL)
 	html = strings.Replace(html, "</head>", injection+"</head>", 1)
 
@@ -1,11 +1,43 @@
 package cli
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/config"
+	"github.com/robzolkos/claude-session-export/internal/history"
+	"github.com/robzolkos/claude-session-export/internal/publish"
+	"github.com/robzolkos/claude-session-export/internal/session"
+	"github.com/robzolkos/claude-session-export/internal/web"
+	"github.com/robzolkos/claude-session-export/render"
 )
 
+func TestMain(m *testing.M) {
+	// Keep config, export history and viewers out of the real user
+	// directories
+	dir, err := os.MkdirTemp("", "cse-home-*")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("CLAUDE_SESSION_EXPORT_HOME", dir)
+	os.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
 func TestRun_Help(t *testing.T) {
 	// Just verify it doesn't error
 	if err := Run([]string{"help"}); err != nil {
@@ -78,6 +110,42 @@ func TestRun_JSON_OutputDir(t *testing.T) {
 	if _, err := os.Stat(outputPath); os.IsNotExist(err) {
 		t.Error("JSONL file was not copied to output directory")
 	}
+
+	// Verify the export was recorded in the history
+	entries, err := history.Load()
+	if err != nil {
+		t.Fatalf("Loading history failed: %v", err)
+	}
+	if len(entries) == 0 || entries[len(entries)-1].Location != outputPath {
+		t.Errorf("Expected export of %s to be recorded, got %v", outputPath, entries)
+	}
+}
+
+func TestExportAsHTML_DefaultDirIsPrivate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes aren't enforced on Windows")
+	}
+	cache := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cache)
+	t.Setenv("HOME", cache)
+
+	data := []byte(`{"type":"user","message":{"role":"user","content":"Hello"},"timestamp":"2024-01-15T10:00:00Z"}`)
+	htmlPath, err := exportAsHTML("session.jsonl", data, "", render.Options{}, &exportOptions{})
+	if err != nil {
+		t.Fatalf("exportAsHTML failed: %v", err)
+	}
+	if !strings.HasPrefix(htmlPath, cache) {
+		t.Errorf("Expected the viewer under the cache directory %s, got %s", cache, htmlPath)
+	}
+	for path, want := range map[string]os.FileMode{filepath.Dir(htmlPath): 0700, htmlPath: 0600} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("Expected %s to have mode %v, got %v", path, want, got)
+		}
+	}
 }
 
 func TestReorderArgs(t *testing.T) {
@@ -160,3 +228,855 @@ func TestRun_Web_NoSessionID(t *testing.T) {
 		t.Error("Expected error when no session ID provided")
 	}
 }
+
+func TestDayLabel(t *testing.T) {
+	now := time.Date(2025, 3, 20, 15, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		t        time.Time
+		expected string
+	}{
+		{"earlier today", time.Date(2025, 3, 20, 1, 0, 0, 0, time.Local), "Today"},
+		{"yesterday", time.Date(2025, 3, 19, 23, 0, 0, 0, time.Local), "Yesterday"},
+		{"this week", time.Date(2025, 3, 16, 12, 0, 0, 0, time.Local), "This week"},
+		{"last week", time.Date(2025, 3, 10, 12, 0, 0, 0, time.Local), "Last week"},
+		{"same year", time.Date(2025, 1, 5, 12, 0, 0, 0, time.Local), "January"},
+		{"previous year", time.Date(2024, 11, 5, 12, 0, 0, 0, time.Local), "November 2024"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dayLabel(tt.t, now); got != tt.expected {
+				t.Errorf("dayLabel(%v) = %q, expected %q", tt.t, got, tt.expected)
+			}
+		})
+	}
+
+	// Days around a DST change are 23 or 25 hours long
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("No time zone data: %v", err)
+	}
+	for _, tt := range []struct {
+		t, now   time.Time
+		expected string
+	}{
+		{time.Date(2024, 3, 10, 0, 30, 0, 0, ny), time.Date(2024, 3, 11, 0, 30, 0, 0, ny), "Yesterday"},
+		{time.Date(2024, 3, 4, 0, 30, 0, 0, ny), time.Date(2024, 3, 11, 0, 30, 0, 0, ny), "Last week"},
+		{time.Date(2024, 11, 3, 23, 30, 0, 0, ny), time.Date(2024, 11, 4, 0, 30, 0, 0, ny), "Yesterday"},
+	} {
+		if got := dayLabel(tt.t, tt.now); got != tt.expected {
+			t.Errorf("dayLabel(%v, %v) = %q, expected %q", tt.t, tt.now, got, tt.expected)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n        int
+		expected string
+	}{
+		{512, "512 B"},
+		{2048, "2.0 KB"},
+		{3 * 1024 * 1024, "3.0 MB"},
+	}
+
+	for _, tt := range tests {
+		if got := formatBytes(tt.n); got != tt.expected {
+			t.Errorf("formatBytes(%d) = %q, expected %q", tt.n, got, tt.expected)
+		}
+	}
+}
+
+func TestRun_JSON_DefaultLocalHTML(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "session-*.jsonl")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	tmpFile.WriteString(`{"type":"user","cwd":"/tmp/myproject","message":{"role":"user","content":"Hello"},"timestamp":"2024-01-15T10:00:00Z"}`)
+	tmpFile.Close()
+
+	// Point the config at a directory that sets where HTML is written
+	configDir, err := os.MkdirTemp("", "config-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(configDir)
+
+	htmlDir := filepath.Join(configDir, "html")
+	configJSON := `{"html_dir": "` + filepath.ToSlash(htmlDir) + `"}`
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), []byte(configJSON), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	oldHome := os.Getenv("CLAUDE_SESSION_EXPORT_HOME")
+	os.Setenv("CLAUDE_SESSION_EXPORT_HOME", configDir)
+	defer os.Setenv("CLAUDE_SESSION_EXPORT_HOME", oldHome)
+
+	// No flags: nothing is uploaded, a viewer is written locally
+	if err := Run([]string{"json", "--no-open", tmpFile.Name()}); err != nil {
+		t.Fatalf("json command failed: %v", err)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(htmlDir, "myproject-*.html"))
+	if len(matches) != 1 {
+		t.Fatalf("Expected 1 HTML file in %s, got %v", htmlDir, matches)
+	}
+
+	data, err := os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatalf("Failed to read HTML: %v", err)
+	}
+	if !strings.Contains(string(data), "window.EMBEDDED_SESSION") {
+		t.Error("Expected session data to be embedded in the viewer")
+	}
+}
+
+func TestRun_JSON_FlagsOverrideDefaultDestination(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "session-*.jsonl")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	tmpFile.WriteString(`{"type":"user","cwd":"/tmp/myproject","message":{"role":"user","content":"Hello"},"timestamp":"2024-01-15T10:00:00Z"}`)
+	tmpFile.Close()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	defer os.Chdir(wd)
+
+	// The destinations aren't configured, so using one would fail
+	for _, dest := range []string{"webhook", "confluence"} {
+		configDir, err := os.MkdirTemp("", "config-*")
+		if err != nil {
+			t.Fatalf("Failed to create temp dir: %v", err)
+		}
+		defer os.RemoveAll(configDir)
+
+		configJSON := `{"default_destination": "` + dest + `"}`
+		if err := os.WriteFile(filepath.Join(configDir, "config.json"), []byte(configJSON), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		oldHome := os.Getenv("CLAUDE_SESSION_EXPORT_HOME")
+		os.Setenv("CLAUDE_SESSION_EXPORT_HOME", configDir)
+		defer os.Setenv("CLAUDE_SESSION_EXPORT_HOME", oldHome)
+
+		// --zip writes to the working directory
+		if err := os.Chdir(configDir); err != nil {
+			t.Fatalf("Failed to change directory: %v", err)
+		}
+		if err := Run([]string{"json", "--zip", "--no-open", "--yes", tmpFile.Name()}); err != nil {
+			t.Fatalf("%s default: json --zip failed: %v", dest, err)
+		}
+		if matches, _ := filepath.Glob(filepath.Join(configDir, "myproject-*.zip")); len(matches) != 1 {
+			t.Errorf("%s default: expected 1 zip in %s, got %v", dest, configDir, matches)
+		}
+
+		if err := Run([]string{"json", "--format", "json", tmpFile.Name()}); err != nil {
+			t.Fatalf("%s default: json --format json failed: %v", dest, err)
+		}
+		if matches, _ := filepath.Glob(filepath.Join(configDir, "myproject-*.json")); len(matches) != 1 {
+			t.Errorf("%s default: expected 1 JSON export in %s, got %v", dest, configDir, matches)
+		}
+	}
+}
+
+func TestRun_JSON_Profile(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "session-*.jsonl")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	tmpFile.WriteString(`{"type":"user","cwd":"/tmp/myproject","message":{"role":"user","content":"Deploy to acme-prod"},"timestamp":"2024-01-15T10:00:00Z"}`)
+	tmpFile.Close()
+
+	configDir, err := os.MkdirTemp("", "config-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(configDir)
+
+	outDir := filepath.Join(configDir, "archive")
+	configJSON := `{"profiles": {"archive": {"format": "json", "output_dir": "` + filepath.ToSlash(outDir) + `",
+		"redact": [{"pattern": "acme-[a-z]+", "replacement": "<env>"}]}}}`
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), []byte(configJSON), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	oldHome := os.Getenv("CLAUDE_SESSION_EXPORT_HOME")
+	os.Setenv("CLAUDE_SESSION_EXPORT_HOME", configDir)
+	defer os.Setenv("CLAUDE_SESSION_EXPORT_HOME", oldHome)
+
+	if err := Run([]string{"json", "--profile", "missing", tmpFile.Name()}); err == nil || !strings.Contains(err.Error(), "archive") {
+		t.Errorf("Expected an unknown profile error listing the profiles, got %v", err)
+	}
+
+	if err := Run([]string{"json", "--profile", "archive", tmpFile.Name()}); err != nil {
+		t.Fatalf("json command failed: %v", err)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(outDir, "myproject-*.json"))
+	if len(matches) != 1 {
+		t.Fatalf("Expected 1 JSON file in %s, got %v", outDir, matches)
+	}
+	data, err := os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+	if !strings.Contains(string(data), `"schema_version": 1`) || !strings.Contains(string(data), "Deploy to <env>") {
+		t.Errorf("Expected a redacted normalized export, got:\n%s", data)
+	}
+}
+
+func TestRun_JSON_LocalProfileOverridesDefaultDestination(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "session-*.jsonl")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	tmpFile.WriteString(`{"type":"user","cwd":"/tmp/myproject","message":{"role":"user","content":"Hello"},"timestamp":"2024-01-15T10:00:00Z"}`)
+	tmpFile.Close()
+
+	configDir, err := os.MkdirTemp("", "config-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(configDir)
+
+	htmlDir := filepath.Join(configDir, "html")
+	configJSON := `{"default_destination": "gist", "html_dir": "` + filepath.ToSlash(htmlDir) + `",
+		"profiles": {"private": {"destination": "local"}}}`
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), []byte(configJSON), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	oldHome := os.Getenv("CLAUDE_SESSION_EXPORT_HOME")
+	os.Setenv("CLAUDE_SESSION_EXPORT_HOME", configDir)
+	defer os.Setenv("CLAUDE_SESSION_EXPORT_HOME", oldHome)
+
+	// Nothing can upload, so an attempt to would fail
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("PATH", configDir)
+
+	if err := Run([]string{"json", "--profile", "private", "--no-open", "--yes", tmpFile.Name()}); err != nil {
+		t.Fatalf("json --profile private failed: %v", err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(htmlDir, "myproject-*.html")); len(matches) != 1 {
+		t.Errorf("Expected 1 HTML file in %s, got %v", htmlDir, matches)
+	}
+}
+
+func TestBackupRestore(t *testing.T) {
+	src, _ := os.MkdirTemp("", "cse-backup-src-*")
+	defer os.RemoveAll(src)
+	dst, _ := os.MkdirTemp("", "cse-backup-dst-*")
+	defer os.RemoveAll(dst)
+
+	os.WriteFile(filepath.Join(src, "config.json"), []byte(`{"html_dir": "/tmp"}`), 0644)
+	os.MkdirAll(filepath.Join(src, "cache"), 0755)
+	os.WriteFile(filepath.Join(src, "cache", "s1.json"), []byte("cached"), 0644)
+
+	zipPath := filepath.Join(dst, "backup.zip")
+	count, err := writeBackup(src, zipPath)
+	if err != nil {
+		t.Fatalf("writeBackup failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 files backed up, got %d", count)
+	}
+
+	restoreDir := filepath.Join(dst, "restored")
+	if _, err := restoreBackup(zipPath, restoreDir, false); err != nil {
+		t.Fatalf("restoreBackup failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(restoreDir, "cache", "s1.json"))
+	if err != nil || string(data) != "cached" {
+		t.Errorf("Expected cache file restored, got %q (%v)", data, err)
+	}
+
+	// Restoring over existing files requires force
+	if _, err := restoreBackup(zipPath, restoreDir, false); err == nil {
+		t.Error("Expected error restoring over existing files without force")
+	}
+	if _, err := restoreBackup(zipPath, restoreDir, true); err != nil {
+		t.Errorf("Expected forced restore to succeed, got %v", err)
+	}
+}
+
+func TestRun_Clip_KeepsLinesByteForByte(t *testing.T) {
+	dir := t.TempDir()
+	lines := []string{
+		`{"type":"user","message":{"role":"user","content":"First"},"timestamp":"2024-01-15T10:00:00Z"}`,
+		`{"uuid":"u2","type":"user","timestamp":"2024-01-15T11:00:00Z","message":{"content":"<b>Second</b> & more","role":"user"},"tokens":12345678901234567890,"cost":1.50}`,
+		`{"type":"user","message":{"role":"user","content":"Third"},"timestamp":"2024-01-15T12:00:00Z"}`,
+	}
+	path := filepath.Join(dir, "session.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	outDir := filepath.Join(dir, "out")
+	if err := Run([]string{"clip", path, "--from", "2024-01-15T10:30:00Z", "--to", "2024-01-15T11:30:00Z", "-o", outDir}); err != nil {
+		t.Fatalf("clip failed: %v", err)
+	}
+
+	clips, _ := filepath.Glob(filepath.Join(outDir, "session-clip-*.jsonl"))
+	if len(clips) != 1 {
+		t.Fatalf("Expected one clip, got %v", clips)
+	}
+	got, err := os.ReadFile(clips[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != lines[1] {
+		t.Errorf("Expected the clipped line unchanged, got:\n%s\nwant:\n%s", got, lines[1])
+	}
+}
+
+func TestParseClipTime(t *testing.T) {
+	day := time.Date(2024, 6, 1, 9, 30, 0, 0, time.UTC)
+
+	got, err := parseClipTime("14:00", day)
+	if err != nil || !got.Equal(time.Date(2024, 6, 1, 14, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected time of day on the session's day, got %v (%v)", got, err)
+	}
+	got, err = parseClipTime("2024-06-02 08:15", day)
+	if err != nil || !got.Equal(time.Date(2024, 6, 2, 8, 15, 0, 0, time.UTC)) {
+		t.Errorf("Expected full date honored, got %v (%v)", got, err)
+	}
+	if got, err := parseClipTime("", day); err != nil || !got.IsZero() {
+		t.Errorf("Expected empty value to be an open bound, got %v (%v)", got, err)
+	}
+	if _, err := parseClipTime("2pm", day); err == nil {
+		t.Error("Expected error for unrecognized time")
+	}
+}
+
+func TestPrintSummary(t *testing.T) {
+	s := gistSummary("https://gist.github.com/alice/abc123", "/tmp/s.jsonl", []byte("{}"))
+	if s.Update != "claude-session-export json /tmp/s.jsonl --gist-id abc123" || s.Delete != "claude-session-export gists delete abc123" {
+		t.Errorf("Expected gh commands for the gist id, got %q and %q", s.Update, s.Delete)
+	}
+
+	var text strings.Builder
+	if err := printSummary(&text, s, false); err != nil {
+		t.Fatalf("printSummary failed: %v", err)
+	}
+	for _, want := range []string{"URL:      https://gist.github.com/alice/abc123", "Delete:   claude-session-export gists delete abc123"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, text.String())
+		}
+	}
+
+	var out strings.Builder
+	if err := printSummary(&out, s, true); err != nil {
+		t.Fatalf("printSummary failed: %v", err)
+	}
+	var decoded exportSummary
+	if err := json.Unmarshal([]byte(out.String()), &decoded); err != nil {
+		t.Fatalf("Expected JSON, got %q: %v", out.String(), err)
+	}
+	if decoded != s {
+		t.Errorf("Expected %+v, got %+v", s, decoded)
+	}
+}
+
+func TestResolveGist(t *testing.T) {
+	rows := []gistRow{
+		{GistID: "abc", URL: "https://gist.github.com/alice/abc", Tracked: true},
+		{GistID: "def", URL: "https://gist.github.com/alice/def", Tracked: true},
+	}
+	if got := resolveGist("2", rows); got.GistID != "def" {
+		t.Errorf("Expected number 2 to pick def, got %+v", got)
+	}
+	if got := resolveGist("https://gist.github.com/alice/abc", rows); got.URL != rows[0].URL {
+		t.Errorf("Expected the URL to match the tracked gist, got %+v", got)
+	}
+	if got := resolveGist("xyz", rows); got.GistID != "xyz" || got.URL != "https://gist.github.com/xyz" || got.Tracked {
+		t.Errorf("Expected an untracked gist by ID, got %+v", got)
+	}
+}
+
+func TestGistDescription(t *testing.T) {
+	data := []byte(`{"type":"summary","summary":"Fix login bug"}
+{"type":"user","message":{"role":"user","content":"Please fix it"},"timestamp":"2025-01-15T12:00:00Z"}
+`)
+	want := "Claude Code Transcript: Fix login bug (" + time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC).Local().Format("2006-01-02") + ")"
+	if got := gistDescription(data); got != want {
+		t.Errorf("gistDescription = %q, want %q", got, want)
+	}
+	if got := gistDescription([]byte("not json")); got != "Claude Code Transcript" {
+		t.Errorf("Expected just the tag for unparseable data, got %q", got)
+	}
+}
+
+func TestConfluenceTitle(t *testing.T) {
+	sess, err := session.Parse([]byte(`{"type":"summary","summary":"Fix login bug"}
+{"type":"user","message":{"role":"user","content":"Please fix it"},"timestamp":"2025-01-15T12:00:00Z"}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Fix login bug (" + time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC).Local().Format("2006-01-02") + ", 0123abcd)"
+	if got := confluenceTitle(sess, "0123abcd-ef01-2345"); got != want {
+		t.Errorf("confluenceTitle = %q, want %q", got, want)
+	}
+}
+
+func TestCheckEncryption(t *testing.T) {
+	cfg := &config.Config{}
+	for _, opts := range []*exportOptions{
+		{encrypt: "passphrase"},
+		{encrypt: "passphrase", outputDir: "out", format: formatJSON},
+		{encrypt: "passphrase", upload: uploadConfluence},
+	} {
+		err := checkEncryption(opts, cfg)
+		if err == nil || !strings.Contains(err.Error(), "--encrypt applies to") {
+			t.Errorf("Expected %+v to be refused, got %v", opts, err)
+		}
+	}
+	err := checkEncryption(&exportOptions{encrypt: "passphrase", uploadGist: true, gistID: "abc"}, cfg)
+	if err == nil || !strings.Contains(err.Error(), "--gist-id") {
+		t.Errorf("Expected --gist-id to be refused, got %v", err)
+	}
+}
+
+func TestExportAsZipWithJSONL(t *testing.T) {
+	data := []byte(`{"type":"user","message":{"role":"user","content":"Please fix it"},"timestamp":"2025-01-15T12:00:00Z"}
+`)
+	dir := t.TempDir()
+	zipPath, err := exportArchive(filepath.Join(dir, "abc.jsonl"), data, dir, render.Options{Watermark: "INTERNAL"}, &exportOptions{withJSONL: true})
+	if err != nil {
+		t.Fatalf("exportArchive failed: %v", err)
+	}
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+	if strings.Join(names, ",") != "viewer.html,session.jsonl,manifest.json" {
+		t.Fatalf("Unexpected files %v", names)
+	}
+	rc, err := r.File[1].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(rc)
+	rc.Close()
+	if !bytes.Equal(got, data) {
+		t.Errorf("Expected the session JSONL in the zip, got %q", got)
+	}
+}
+
+func TestExportArchiveTarGz(t *testing.T) {
+	data := []byte(`{"type":"user","message":{"role":"user","content":"Please fix it"},"timestamp":"2025-01-15T12:00:00Z"}
+`)
+	dir := t.TempDir()
+	sessionPath := filepath.Join(dir, "abc.jsonl")
+	if err := os.WriteFile(sessionPath, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2025, 1, 15, 12, 30, 0, 0, time.UTC)
+	if err := os.Chtimes(sessionPath, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	tarPath, err := exportArchive(sessionPath, data, dir, render.Options{}, &exportOptions{format: formatTarGz, withJSONL: true})
+	if err != nil {
+		t.Fatalf("exportArchive failed: %v", err)
+	}
+	if !strings.HasSuffix(tarPath, ".tar.gz") {
+		t.Errorf("Expected a .tar.gz, got %s", tarPath)
+	}
+	f, err := os.Open(tarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+		if hdr.Mode != 0600 || !hdr.ModTime.Equal(modTime) {
+			t.Errorf("Expected %s with the session's mode and time, got %o %v", hdr.Name, hdr.Mode, hdr.ModTime)
+		}
+	}
+	if strings.Join(names, ",") != "viewer.html,session.jsonl,manifest.json" {
+		t.Errorf("Unexpected files %v", names)
+	}
+}
+
+func TestParseSearchTime(t *testing.T) {
+	now := time.Date(2025, 6, 10, 15, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		value string
+		end   bool
+		want  time.Time
+	}{
+		{"", false, time.Time{}},
+		{"7d", false, now.AddDate(0, 0, -7)},
+		{"12h", false, now.Add(-12 * time.Hour)},
+		{"2025-06-01", false, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"2025-06-01", true, time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)},
+		{"09:30", false, time.Date(2025, 6, 10, 9, 30, 0, 0, time.UTC)},
+		{"2025-06-01 14:00", true, time.Date(2025, 6, 1, 14, 0, 0, 0, time.UTC)},
+	} {
+		got, err := parseSearchTime(tt.value, now, now, tt.end)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseSearchTime(%q, %v) = %v, %v; want %v", tt.value, tt.end, got, err, tt.want)
+		}
+	}
+	// A time of day is on the given day, durations still count back from now
+	day := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	if got, _ := parseSearchTime("14:00", now, day, false); !got.Equal(time.Date(2025, 6, 1, 14, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected 14:00 on the session's day, got %v", got)
+	}
+	if got, _ := parseSearchTime("12h", now, day, false); !got.Equal(now.Add(-12 * time.Hour)) {
+		t.Errorf("Expected 12h back from now, got %v", got)
+	}
+	if _, err := parseSearchTime("last week", now, now, false); err == nil {
+		t.Error("Expected an error for an unknown time")
+	}
+}
+
+func TestRecordPublished(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("CLAUDE_SESSION_EXPORT_HOME", home)
+
+	jsonl := `{"type":"user","message":{"role":"user","content":"hi"}}`
+	page := `<html><script>atob("` + base64.StdEncoding.EncodeToString([]byte(jsonl)) + `")</script></html>`
+	files := []publish.File{
+		{Path: "index.html", Data: []byte("<html></html>")},
+		{Path: "myproj/abc123.html", Data: []byte(page)},
+	}
+	recordPublished("/srv/t", files, "https://acme.github.io/t/")
+
+	entries, err := history.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected the one session page recorded, got %+v", entries)
+	}
+	e := entries[0]
+	if e.SessionID != "abc123" || e.Destination != history.DestinationPages || e.Location != "https://acme.github.io/t/myproj/abc123.html" {
+		t.Errorf("Unexpected entry %+v", e)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	for value, want := range map[string]time.Duration{"90d": 90 * 24 * time.Hour, "36h": 36 * time.Hour} {
+		if got, err := parseAge(value); err != nil || got != want {
+			t.Errorf("parseAge(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"0d", "-5d", "3 months"} {
+		if _, err := parseAge(value); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	projects := t.TempDir()
+	project := filepath.Join(projects, "-home-me-app")
+	os.MkdirAll(filepath.Join(project, "old", "subagents"), 0755)
+	os.WriteFile(filepath.Join(project, "old.jsonl"), []byte(`{"sessionId":"old"}`+"\n"), 0644)
+	os.WriteFile(filepath.Join(project, "old", "subagents", "agent-a.jsonl"), []byte("{}\n"), 0644)
+	os.WriteFile(filepath.Join(project, "agent-b.jsonl"), []byte(`{"sessionId":"old"}`+"\n"), 0644)
+	os.WriteFile(filepath.Join(project, "new.jsonl"), []byte("{}\n"), 0644)
+	long := time.Now().AddDate(0, 0, -100)
+	for _, name := range []string{"old.jsonl", "agent-b.jsonl", filepath.Join("old", "subagents", "agent-a.jsonl")} {
+		os.Chtimes(filepath.Join(project, name), long, long)
+	}
+
+	sessions, err := findPrunable(projects, time.Now().AddDate(0, 0, -90))
+	if err != nil {
+		t.Fatalf("findPrunable failed: %v", err)
+	}
+	if len(sessions) != 1 || len(sessions[0].paths) != 3 {
+		t.Fatalf("Expected the old session with its subagent directory and transcript, got %+v", sessions)
+	}
+
+	backup := filepath.Join(t.TempDir(), "old.tar.gz")
+	if err := writePruneBackup(projects, sessions, backup); err != nil {
+		t.Fatalf("writePruneBackup failed: %v", err)
+	}
+	f, _ := os.Open(backup)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, hdr.Name)
+	}
+	want := "-home-me-app/old.jsonl -home-me-app/old/ -home-me-app/old/subagents/ -home-me-app/old/subagents/agent-a.jsonl -home-me-app/agent-b.jsonl"
+	if got := strings.Join(names, " "); got != want {
+		t.Errorf("Expected the backup to hold %s, got %s", want, got)
+	}
+
+	moved := t.TempDir()
+	if err := pruneSession(projects, sessions[0], moved); err != nil {
+		t.Fatalf("pruneSession failed: %v", err)
+	}
+	for _, name := range []string{"old.jsonl", "agent-b.jsonl", filepath.Join("old", "subagents", "agent-a.jsonl")} {
+		if _, err := os.Stat(filepath.Join(project, name)); err == nil {
+			t.Errorf("Expected %s moved out of the projects directory", name)
+		}
+		if _, err := os.Stat(filepath.Join(moved, "-home-me-app", name)); err != nil {
+			t.Errorf("Expected %s moved under the same path: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(project, "new.jsonl")); err != nil {
+		t.Errorf("Expected the recent session kept: %v", err)
+	}
+}
+
+func TestSliceSession(t *testing.T) {
+	data := []byte(`{"type":"user","uuid":"u1","timestamp":"2025-06-01T10:00:00Z","message":{"role":"user","content":"First"}}
+{"type":"assistant","uuid":"a1","timestamp":"2025-06-01T10:00:05Z","message":{"role":"assistant","content":[{"type":"text","text":"One"}]}}
+{"type":"user","uuid":"u2","timestamp":"2025-06-01T11:00:00Z","message":{"role":"user","content":"Second"}}
+{"type":"assistant","uuid":"a2","timestamp":"2025-06-01T11:00:05Z","message":{"role":"assistant","content":[{"type":"text","text":"Two"}]}}
+{"type":"user","uuid":"u3","timestamp":"2025-06-01T12:00:00Z","message":{"role":"user","content":"Third"}}
+{"type":"assistant","uuid":"a3","timestamp":"2025-06-01T12:00:05Z","message":{"role":"assistant","content":[{"type":"text","text":"Three"}]}}
+`)
+	for _, tt := range []struct {
+		opts exportOptions
+		want []string
+	}{
+		{exportOptions{prompts: "2"}, []string{"u2", "a2"}},
+		{exportOptions{prompts: "2-"}, []string{"u2", "a2", "u3", "a3"}},
+		{exportOptions{prompts: "-2"}, []string{"u1", "a1", "u2", "a2"}},
+		{exportOptions{since: "2025-06-01T11:00:00Z", until: "2025-06-01T12:00:00Z"}, []string{"u2", "a2"}},
+		{exportOptions{prompts: "1-2", since: "2025-06-01T10:30:00Z"}, []string{"u2", "a2"}},
+	} {
+		tt.opts.json = true // Keep progress off stdout
+		sliced, err := sliceSession(data, &tt.opts)
+		if err != nil {
+			t.Fatalf("sliceSession(%+v): %v", tt.opts, err)
+		}
+		var got []string
+		for _, line := range strings.Split(strings.TrimSpace(string(sliced)), "\n") {
+			var entry struct{ UUID string }
+			json.Unmarshal([]byte(line), &entry)
+			got = append(got, entry.UUID)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("sliceSession(%+v) kept %v, want %v", tt.opts, got, tt.want)
+		}
+	}
+
+	for _, prompts := range []string{"4", "3-2", "x", "0-1"} {
+		if _, err := sliceSession(data, &exportOptions{prompts: prompts, json: true}); err == nil {
+			t.Errorf("Expected an error for --prompts %s", prompts)
+		}
+	}
+}
+
+func TestWritePrompts(t *testing.T) {
+	sess, err := session.Parse([]byte(`{"type":"user","timestamp":"2025-06-01T10:00:00Z","message":{"role":"user","content":"Add a login page\n\nUse the existing form styles."}}
+{"type":"assistant","timestamp":"2025-06-01T10:00:05Z","message":{"role":"assistant","content":[{"type":"text","text":"Done."}]}}
+{"type":"user","timestamp":"2025-06-01T10:01:00Z","message":{"role":"user","content":"<command-name>/clear</command-name>"}}
+{"type":"user","timestamp":"2025-06-01T10:01:00Z","message":{"role":"user","content":"<local-command-stdout></local-command-stdout>"}}
+{"type":"user","isSidechain":true,"timestamp":"2025-06-01T10:02:00Z","message":{"role":"user","content":"Find the form styles"}}
+{"type":"user","timestamp":"2025-06-01T10:05:00Z","message":{"role":"user","content":"Now add tests"}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writePrompts(&buf, session.GetUserPrompts(sess), false); err != nil {
+		t.Fatal(err)
+	}
+	want := "## Prompt 1\n\nAdd a login page\n\nUse the existing form styles.\n\n## Prompt 2\n\nNow add tests\n"
+	if buf.String() != want {
+		t.Errorf("Unexpected prompts:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	writePrompts(&buf, session.GetUserPrompts(sess)[:1], true)
+	if !strings.Contains(buf.String(), "## Prompt 1 ("+time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC).Local().Format("2006-01-02 15:04")+")") {
+		t.Errorf("Expected the prompt's time, got:\n%s", buf.String())
+	}
+}
+
+func TestPrintGrep(t *testing.T) {
+	results := []session.SearchResult{{
+		SessionInfo: session.SessionInfo{Path: "/p/a.jsonl"},
+		Matches: []session.SearchMatch{
+			{Text: "...make deploy fail...", Match: "deploy", Line: 3},
+			{Text: "a multi line match", Match: "multi\nline", Line: 7},
+		},
+	}}
+
+	var buf bytes.Buffer
+	printGrep(&buf, results, false)
+	if want := "/p/a.jsonl:3: ...make deploy fail...\n/p/a.jsonl:7: a multi line match\n"; buf.String() != want {
+		t.Errorf("Got %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	printGrep(&buf, results, true)
+	if !strings.Contains(buf.String(), colorBold+colorYellow+"multi line"+colorReset) {
+		t.Errorf("Expected the match colored, got %q", buf.String())
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if colored, _ := useColor("auto", os.Stdout); colored {
+		t.Error("Expected NO_COLOR to turn color off")
+	}
+	if colored, _ := useColor("always", os.Stdout); !colored {
+		t.Error("Expected --color always to win over NO_COLOR")
+	}
+}
+
+func TestPrintWebSessions(t *testing.T) {
+	updated := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	metas := []web.SessionMeta{{ID: "s1", Name: "Fix login", UpdatedAt: updated, MessageCount: 12}, {ID: "s2", UpdatedAt: updated}}
+
+	var buf bytes.Buffer
+	printWebSessions(&buf, metas, false)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "s1  ") || !strings.HasSuffix(lines[0], "12 msgs  Fix login") || !strings.HasSuffix(lines[1], "(untitled)") {
+		t.Errorf("Unexpected listing %q", buf.String())
+	}
+
+	buf.Reset()
+	printWebSessions(&buf, nil, true)
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("Expected an empty JSON array, got %q", buf.String())
+	}
+
+	infos := webSessionInfos(metas)
+	if infos[0].SessionID != "s1" || infos[0].MessageCount != 12 || infos[0].IsLive(time.Now()) {
+		t.Errorf("Unexpected picker entry %+v", infos[0])
+	}
+}
+
+func TestGistSession(t *testing.T) {
+	name, data, err := gistSession(map[string][]byte{"index.html": nil, "session.jsonl": []byte(`{"sessionId":"abc"}`)})
+	if err != nil || name != "session.jsonl" || sessionIDOf(data) != "abc" {
+		t.Errorf("Expected session.jsonl and its ID, got %q %q %v", name, data, err)
+	}
+	if name, _, _ := gistSession(map[string][]byte{"notes.md": nil, "fix.jsonl": nil}); name != "fix.jsonl" {
+		t.Errorf("Expected the only JSONL file, got %q", name)
+	}
+	if _, _, err := gistSession(map[string][]byte{"a.jsonl": nil, "b.jsonl": nil}); err == nil {
+		t.Error("Expected an error when the session is ambiguous")
+	}
+	if _, _, err := gistSession(map[string][]byte{"notes.md": nil}); err == nil {
+		t.Error("Expected an error for a gist without a session")
+	}
+}
+
+func TestWriteExport(t *testing.T) {
+	for _, opts := range []*exportOptions{
+		{stdout: true, format: formatEML},
+		{stdout: true, createZip: true},
+		{stdout: true, outputDir: "out"},
+		{stdout: true, json: true},
+	} {
+		if err := checkStdout(opts); err == nil {
+			t.Errorf("Expected %+v to be refused", opts)
+		}
+	}
+	if err := checkStdout(&exportOptions{stdout: true, format: formatSite}); err != nil {
+		t.Errorf("Expected --format site to be allowed, got %v", err)
+	}
+
+	data := []byte(`{"type":"user","uuid":"u1","message":{"role":"user","content":"Fix the build"},"timestamp":"2025-01-15T10:00:00Z"}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"role":"assistant","content":[{"type":"text","text":"Done"}]},"timestamp":"2025-01-15T10:01:00Z"}`)
+	for format, want := range map[string]string{
+		"":         "<!DOCTYPE html>",
+		formatJSON: `"schema_version"`,
+		formatSite: "---\ntitle:",
+		formatMbox: "From claude-session-export",
+	} {
+		var buf bytes.Buffer
+		if err := writeExport(&buf, "/tmp/abc.jsonl", data, render.Options{}, format); err != nil {
+			t.Fatalf("writeExport(%q) failed: %v", format, err)
+		}
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %q output to contain %q, got %.200q", format, want, buf.String())
+		}
+	}
+}
+
+func TestCommitURLTemplate(t *testing.T) {
+	pushed := &session.Repo{Host: "git.example.com", Path: "team/app"}
+	var none *session.Repo
+	for _, tt := range []struct {
+		repo         *session.Repo
+		flag, config string
+		want         string
+	}{
+		{pushed, "", "", "https://git.example.com/team/app/commit/{hash}"},
+		{pushed, "https://code.example.com/{repo}/-/commit/{hash}", "", "https://code.example.com/team/app/-/commit/{hash}"},
+		{pushed, "", "https://cgit.example.com/app/commit/?id={hash}", "https://cgit.example.com/app/commit/?id={hash}"},
+		{none, "https://code.example.com/{repo}/commit/{hash}", "", ""},
+		{none, "https://code.example.com/app/commit/{hash}", "", "https://code.example.com/app/commit/{hash}"},
+		{none, "", "", ""},
+	} {
+		got, err := commitURLTemplate(tt.repo, tt.flag, tt.config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("commitURLTemplate(%q, %q) = %q, want %q", tt.flag, tt.config, got, tt.want)
+		}
+	}
+	if _, err := commitURLTemplate(pushed, "https://code.example.com/commit/", ""); err == nil {
+		t.Error("Expected an error for a template without {hash}")
+	}
+}
+
+func TestEditorURLTemplate(t *testing.T) {
+	for _, tt := range []struct {
+		flag, config, want string
+	}{
+		{"", "", ""},
+		{"vscode", "", "vscode://file{path}:{line}"},
+		{"", "idea", "idea://open?file={path}&line={line}"},
+		{"subl://open?url=file://{path}&line={line}", "vscode", "subl://open?url=file://{path}&line={line}"},
+	} {
+		got, err := editorURLTemplate(tt.flag, tt.config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("editorURLTemplate(%q, %q) = %q, want %q", tt.flag, tt.config, got, tt.want)
+		}
+	}
+	if _, err := editorURLTemplate("emacs", ""); err == nil {
+		t.Error("Expected an error for an unknown editor")
+	}
+}
@@ -0,0 +1,259 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+	"github.com/robzolkos/claude-session-export/internal/transform"
+)
+
+// clipTimeLayouts are the accepted --from/--to formats. Times of day are
+// taken to be on the day the session started.
+var clipTimeLayouts = []string{
+	"15:04",
+	"15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02T15:04:05",
+}
+
+// runClip exports only the part of a session between two times, as a new
+// session written through the usual export options
+func runClip(args []string) error {
+	fs := flag.NewFlagSet("clip", flag.ExitOnError)
+	opts := addExportFlags(fs)
+	from := fs.String("from", "", "Start of the window, e.g. 14:00 or 2024-06-01 14:00")
+	to := fs.String("to", "", "End of the window, e.g. 15:30 or 2024-06-01 15:30")
+
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 || (*from == "" && *to == "") {
+		return errors.New("usage: claude-session-export clip <file|number|id> --from TIME --to TIME")
+	}
+
+	path := fs.Arg(0)
+	if _, err := os.Stat(path); err != nil {
+		info, err := resolveSession(path, 100)
+		if err != nil {
+			return err
+		}
+		path = info.Path
+	}
+
+	if err := waitForSession(path, opts); err != nil {
+		return err
+	}
+	data, err := session.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading session: %w", err)
+	}
+	if data, err = withSubagents(path, data); err != nil {
+		return err
+	}
+
+	sess, err := session.Parse(data)
+	if err != nil {
+		return fmt.Errorf("parsing session: %w", err)
+	}
+	day := sessionDay(sess, time.Now())
+
+	start, err := parseClipTime(*from, day.Local())
+	if err != nil {
+		return fmt.Errorf("invalid --from: %w", err)
+	}
+	end, err := parseClipTime(*to, day.Local())
+	if err != nil {
+		return fmt.Errorf("invalid --to: %w", err)
+	}
+	// A window like 23:00 to 01:00 runs past midnight
+	if !start.IsZero() && !end.IsZero() && end.Before(start) && !strings.Contains(*to, "-") {
+		end = end.AddDate(0, 0, 1)
+	}
+
+	clipped := transform.Apply(data, transform.TimeWindow(start, end))
+	kept, total := countEntries(clipped), countEntries(data)
+	clippedSession, err := session.Parse(clipped)
+	if err != nil || len(clippedSession.Messages) == 0 {
+		return fmt.Errorf("no messages %s", describeWindow(start, end))
+	}
+	fmt.Fprintf(opts.progress(), "Kept %d of %d entries %s\n", kept, total, describeWindow(start, end))
+
+	// Export the clip as a session of its own, named after the original
+	tmpDir, err := os.MkdirTemp("", "claude-clip-*")
+	if err != nil {
+		return fmt.Errorf("creating temp directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) + "-clip" + clipSuffix(start, end) + ".jsonl"
+	clipPath := filepath.Join(tmpDir, name)
+	if err := os.WriteFile(clipPath, clipped, 0644); err != nil {
+		return fmt.Errorf("writing clip: %w", err)
+	}
+	opts.snapshot = true
+	return exportSession(clipPath, opts)
+}
+
+// sessionDay is the time the session started, which times of day are
+// taken to be on, or now if it has none
+func sessionDay(sess *session.Session, now time.Time) time.Time {
+	if sess.Metadata != nil && !sess.Metadata.StartTime.IsZero() {
+		return sess.Metadata.StartTime
+	}
+	return now
+}
+
+// parseClipTime parses a --from/--to value in the local time zone. An
+// empty value is an open bound.
+func parseClipTime(value string, day time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range clipTimeLayouts {
+		t, err := time.ParseInLocation(layout, value, day.Location())
+		if err != nil {
+			continue
+		}
+		if !strings.Contains(layout, "2006") {
+			t = time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), t.Second(), 0, day.Location())
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a time like 14:00 or 2024-06-01 14:00", value)
+}
+
+// describeWindow describes a clip window, e.g. "from Jun 01 14:00"
+func describeWindow(start, end time.Time) string {
+	const layout = "Jan 02 15:04"
+	switch {
+	case start.IsZero():
+		return "until " + end.Local().Format(layout)
+	case end.IsZero():
+		return "from " + start.Local().Format(layout)
+	default:
+		return "from " + start.Local().Format(layout) + " to " + end.Local().Format(layout)
+	}
+}
+
+// clipSuffix describes the window in the clip's file name, e.g. -1400-1530
+func clipSuffix(start, end time.Time) string {
+	switch {
+	case start.IsZero():
+		return "-until-" + end.Local().Format("1504")
+	case end.IsZero():
+		return "-" + start.Local().Format("1504")
+	default:
+		return "-" + start.Local().Format("1504") + "-" + end.Local().Format("1504")
+	}
+}
+
+// countEntries counts the non-blank JSONL lines in data
+func countEntries(data []byte) int {
+	n := 0
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// sliceSession cuts a session to the --since/--until window and the
+// --prompts range, so exports and their stats cover only that part. Times
+// of day are taken to be on the day the session started, as in clip.
+func sliceSession(data []byte, opts *exportOptions) ([]byte, error) {
+	if opts.since == "" && opts.until == "" && opts.prompts == "" {
+		return data, nil
+	}
+	sess, err := session.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing session: %w", err)
+	}
+	now := time.Now()
+	day := sessionDay(sess, now)
+	start, err := parseSearchTime(opts.since, now, day, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --since: %w", err)
+	}
+	end, err := parseSearchTime(opts.until, now, day, true)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --until: %w", err)
+	}
+	// --until is exclusive, but TimeWindow keeps entries at its end
+	if !end.IsZero() {
+		end = end.Add(-time.Nanosecond)
+	}
+
+	what := ""
+	if opts.prompts != "" {
+		prompts := session.GetUserPrompts(sess)
+		from, to, err := parsePromptRange(opts.prompts, len(prompts))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --prompts: %w", err)
+		}
+		// From the first prompt up to the one after the last
+		if t := prompts[from-1].Timestamp; t.After(start) {
+			start = t
+		}
+		if to < len(prompts) {
+			if t := prompts[to].Timestamp.Add(-time.Nanosecond); end.IsZero() || t.Before(end) {
+				end = t
+			}
+		}
+		what = fmt.Sprintf("prompts %d-%d of %d", from, to, len(prompts))
+		if from == to {
+			what = fmt.Sprintf("prompt %d of %d", from, len(prompts))
+		}
+	}
+
+	sliced := transform.Apply(data, transform.TimeWindow(start, end))
+	slicedSession, err := session.Parse(sliced)
+	if err != nil || len(slicedSession.Messages) == 0 {
+		return nil, errors.New("no messages in the selected range")
+	}
+	if what == "" {
+		what = describeWindow(start, end)
+	}
+	fmt.Fprintf(opts.progress(), "Kept %d of %d entries (%s)\n", countEntries(sliced), countEntries(data), what)
+	return sliced, nil
+}
+
+// parsePromptRange parses a 1-based --prompts range: N-M, N- (to the last
+// prompt), -M or a single N
+func parsePromptRange(value string, count int) (from, to int, err error) {
+	first, last, isRange := strings.Cut(value, "-")
+	if !isRange {
+		last = first
+	}
+	from, to = 1, count
+	if first != "" {
+		if from, err = strconv.Atoi(first); err != nil || from < 1 {
+			return 0, 0, fmt.Errorf("%q is not a prompt range like 10-25", value)
+		}
+	}
+	if last != "" {
+		if to, err = strconv.Atoi(last); err != nil || to < 1 {
+			return 0, 0, fmt.Errorf("%q is not a prompt range like 10-25", value)
+		}
+	}
+	switch {
+	case from > to:
+		return 0, 0, fmt.Errorf("%q ends before it starts", value)
+	case from > count:
+		return 0, 0, fmt.Errorf("the session has only %d prompts", count)
+	}
+	return from, min(to, count), nil
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/robzolkos/claude-session-export/internal/convert"
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+// readConversations converts the file at path if it's a transcript from
+// another tool, in the given format or, for "auto", the one it looks like.
+// ok is false for Claude Code sessions, which are exported as they are.
+func readConversations(path, format string) (convs []convert.Conversation, ok bool, err error) {
+	if format == convert.FormatClaude {
+		return nil, false, nil
+	}
+	if format == "auto" {
+		// Claude Code sessions start with {, as do Codex and Gemini CLI
+		// ones; don't read whole sessions just to tell them apart
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, false, err
+		}
+		head, _ := bufio.NewReader(f).Peek(512)
+		f.Close()
+		trimmed := bytes.TrimSpace(head)
+		if len(trimmed) == 0 || trimmed[0] == '{' && !convert.IsCodex(trimmed) && !convert.IsGemini(trimmed) {
+			return nil, false, nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false, err
+	}
+	if format == "auto" {
+		if format = convert.Detect(data); format == convert.FormatClaude {
+			return nil, false, nil
+		}
+	}
+	convs, err = convert.Convert(format, data, filepath.Base(path))
+	if err != nil {
+		return nil, false, err
+	}
+	return convs, true, nil
+}
+
+// exportConversations exports one of the converted conversations, asking
+// which when there are several
+func exportConversations(convs []convert.Conversation, opts *exportOptions) error {
+	if len(convs) == 0 {
+		return errors.New("no conversations found")
+	}
+	conv := convs[0]
+	if len(convs) > 1 {
+		convs = append([]convert.Conversation(nil), convs...)
+		sort.SliceStable(convs, func(i, j int) bool {
+			return convs[i].Updated.After(convs[j].Updated)
+		})
+		infos := make([]session.SessionInfo, len(convs))
+		for i, c := range convs {
+			infos[i] = session.SessionInfo{
+				ProjectName:  c.Source,
+				SessionID:    c.ID,
+				Summary:      c.Title,
+				EndTime:      c.Updated,
+				MessageCount: c.Messages,
+			}
+		}
+		selected, err := selectSession(infos, opts.progress())
+		if err != nil {
+			return err
+		}
+		for _, c := range convs {
+			if c.ID == selected.SessionID {
+				conv = c
+			}
+		}
+	}
+
+	// Named for the conversation, so exports are too
+	dir, err := os.MkdirTemp("", "converted-*")
+	if err != nil {
+		return fmt.Errorf("creating temp directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := conv.ID
+	if name == "" || strings.ContainsAny(name, `/\:`) || strings.HasPrefix(name, ".") {
+		name = "session"
+	}
+	path := filepath.Join(dir, name+".jsonl")
+	if err := os.WriteFile(path, conv.Data, 0600); err != nil {
+		return fmt.Errorf("writing temp file: %w", err)
+	}
+
+	opts.snapshot = true
+	return exportSession(path, opts)
+}
@@ -1,6 +0,0 @@
-package cli
-
-import _ "embed"
-
-//go:embed viewer.html
-var viewerHTML []byte
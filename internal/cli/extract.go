@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+const extractUsage = "usage: claude-session-export extract prompts <file|number|id> [--timestamps] [-o FILE]\n" +
+	"       claude-session-export extract files <file|number|id> [-o DIR] [--at TIME]"
+
+// runExtract pulls the prompts or the files written out of a session
+func runExtract(args []string) error {
+	if len(args) == 0 {
+		return errors.New(extractUsage)
+	}
+	switch args[0] {
+	case "prompts":
+		return runExtractPrompts(args[1:])
+	case "files":
+		return runExtractFiles(args[1:])
+	default:
+		return fmt.Errorf("unknown extract command %q", args[0])
+	}
+}
+
+// runExtractPrompts prints a session's prompts in order, as Markdown that
+// can be kept as a playbook and replayed
+func runExtractPrompts(args []string) error {
+	fs := flag.NewFlagSet("extract prompts", flag.ExitOnError)
+	timestamps := fs.Bool("timestamps", false, "Show when each prompt was sent")
+	output := fs.String("output", "", "File to write the prompts to (default: stdout)")
+	fs.StringVar(output, "o", "", "File to write the prompts to (default: stdout)")
+
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New(extractUsage)
+	}
+
+	path, err := sessionPath(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	sess, err := session.ParseFile(path)
+	if err != nil {
+		return fmt.Errorf("parsing session: %w", err)
+	}
+	prompts := session.GetUserPrompts(sess)
+	if len(prompts) == 0 {
+		return fmt.Errorf("no prompts in %s", path)
+	}
+
+	write := func(w io.Writer) error {
+		return writePrompts(w, prompts, *timestamps)
+	}
+	if *output == "" {
+		return write(os.Stdout)
+	}
+	if err := writeReportFile(*output, write); err != nil {
+		return err
+	}
+	fmt.Printf("Created: %s (%d prompts)\n", *output, len(prompts))
+	return nil
+}
+
+// runExtractFiles rebuilds the files a session wrote and edited into a
+// directory, laid out as they were under the session's working directory
+func runExtractFiles(args []string) error {
+	fs := flag.NewFlagSet("extract files", flag.ExitOnError)
+	output := fs.String("output", "", "Directory to write the files to (default: SESSION-files)")
+	fs.StringVar(output, "o", "", "Directory to write the files to (default: SESSION-files)")
+	at := fs.String("at", "", "Rebuild the files as they were at this time, e.g. 14:30 or 2024-06-01 14:30")
+
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New(extractUsage)
+	}
+
+	path, err := sessionPath(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	data, err := session.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading session: %w", err)
+	}
+	if data, err = withSubagents(path, data); err != nil {
+		return err
+	}
+	sess, err := session.Parse(data)
+	if err != nil {
+		return fmt.Errorf("parsing session: %w", err)
+	}
+
+	var cwd string
+	day := time.Now()
+	if sess.Metadata != nil {
+		cwd = sess.Metadata.Cwd
+		if !sess.Metadata.StartTime.IsZero() {
+			day = sess.Metadata.StartTime
+		}
+	}
+	until, err := parseClipTime(*at, day.Local())
+	if err != nil {
+		return fmt.Errorf("invalid --at: %w", err)
+	}
+
+	files, skipped := session.ReconstructFiles(sess, until)
+	if len(files) == 0 && len(skipped) == 0 {
+		return errors.New("the session didn't write or edit any files")
+	}
+	dir := *output
+	if dir == "" {
+		dir = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) + "-files"
+	}
+
+	for _, file := range files {
+		dest := filepath.Join(dir, session.LocalPath(file.Path, cwd))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return fmt.Errorf("creating directory: %w", err)
+		}
+		if err := os.WriteFile(dest, []byte(file.Content), 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", dest, err)
+		}
+		note := ""
+		if file.Failed > 0 {
+			note = fmt.Sprintf(" (%d edits didn't apply)", file.Failed)
+		}
+		fmt.Printf("  %s%s\n", dest, note)
+	}
+	fmt.Printf("Wrote %d files to %s\n", len(files), dir)
+	if len(skipped) > 0 {
+		fmt.Fprintf(os.Stderr, "Skipped %d files the session edited without writing or reading them in full first:\n", len(skipped))
+		for _, path := range skipped {
+			fmt.Fprintf(os.Stderr, "  %s\n", path)
+		}
+	}
+	return nil
+}
+
+// sessionPath resolves a session given as a file, a picker number or a
+// session ID
+func sessionPath(ref string) (string, error) {
+	if _, err := os.Stat(ref); err == nil {
+		return ref, nil
+	}
+	info, err := resolveSession(ref, 100)
+	if err != nil {
+		return "", err
+	}
+	return info.Path, nil
+}
+
+// writePrompts writes each prompt under a numbered heading, with the
+// local time it was sent when timestamps is set
+func writePrompts(w io.Writer, prompts []session.Message, timestamps bool) error {
+	for i, msg := range prompts {
+		heading := fmt.Sprintf("## Prompt %d", i+1)
+		if timestamps && !msg.Timestamp.IsZero() {
+			heading += " (" + msg.Timestamp.Local().Format("2006-01-02 15:04") + ")"
+		}
+		if i > 0 {
+			heading = "\n" + heading
+		}
+		if _, err := fmt.Fprintf(w, "%s\n\n%s\n", heading, strings.TrimSpace(session.ExtractText(&msg))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/robzolkos/claude-session-export/internal/review"
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+// runFlags exports the conversations reviewers have flagged, from sidecars
+// saved by serve --flags or downloaded from a viewer
+func runFlags(args []string) error {
+	fs := flag.NewFlagSet("flags", flag.ExitOnError)
+	reaction := fs.String("reaction", "", "Only export one reaction: "+strings.Join(review.Reactions, ", "))
+	asJSON := fs.Bool("json", false, "Export as JSON instead of Markdown")
+	output := fs.String("output", "", "File to write the export to (default: stdout)")
+	fs.StringVar(output, "o", "", "File to write the export to (default: stdout)")
+
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+	if *reaction != "" && !review.ValidReaction(*reaction) {
+		return fmt.Errorf("unknown reaction %q (available: %s)", *reaction, strings.Join(review.Reactions, ", "))
+	}
+
+	paths, err := findFlagSidecars(fs.Args())
+	if err != nil {
+		return err
+	}
+	items, err := review.Collect(paths, func(path string) string {
+		return formatProjectName(filepath.Base(filepath.Dir(path)))
+	})
+	if err != nil {
+		return err
+	}
+	if *reaction != "" {
+		var filtered []review.Item
+		for _, item := range items {
+			if item.Reaction == *reaction {
+				filtered = append(filtered, item)
+			}
+		}
+		items = filtered
+	}
+
+	write := func(w io.Writer) error {
+		if *asJSON {
+			if items == nil {
+				items = []review.Item{}
+			}
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(items)
+		}
+		return review.WriteMarkdown(w, items)
+	}
+
+	if *output == "" {
+		return write(os.Stdout)
+	}
+	if err := writeReportFile(*output, write); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d flagged conversations to %s\n", len(items), *output)
+	return nil
+}
+
+// findFlagSidecars expands directory arguments (default: the Claude
+// projects directory) into the sidecars under them
+func findFlagSidecars(args []string) ([]string, error) {
+	if len(args) == 0 {
+		dir, err := session.GetClaudeProjectsDir()
+		if err != nil {
+			return nil, err
+		}
+		args = []string{dir}
+	}
+
+	var paths []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			paths = append(paths, arg)
+			continue
+		}
+		found, err := review.FindSidecars(arg)
+		if err != nil {
+			return nil, fmt.Errorf("finding flags in %s: %w", arg, err)
+		}
+		paths = append(paths, found...)
+	}
+	return paths, nil
+}
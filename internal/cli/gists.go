@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/gist"
+)
+
+const gistsUsage = "usage: claude-session-export gists list [--remote] | open <n|id|url> | delete <n|id|url>... [--all] [--remote]"
+
+// gistRow is a gist shown by gists list: one the CLI remembers uploading,
+// or with --remote, one found on GitHub by its description
+type gistRow struct {
+	GistID    string    `json:"gist_id"`
+	URL       string    `json:"url"`
+	SessionID string    `json:"session_id,omitempty"`
+	Source    string    `json:"source,omitempty"`
+	Updated   time.Time `json:"updated"`
+	Tracked   bool      `json:"tracked"` // In the local state file
+}
+
+func runGists(args []string) error {
+	if len(args) == 0 {
+		return errors.New(gistsUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		return runGistsList(args[1:])
+	case "open":
+		return runGistsOpen(args[1:])
+	case "delete":
+		return runGistsDelete(args[1:])
+	default:
+		return fmt.Errorf("unknown gists command %q", args[0])
+	}
+}
+
+// gistRows lists the remembered gists, most recently updated first, then
+// with remote the untracked ones found on GitHub
+func gistRows(state *gist.State, remote bool) ([]gistRow, error) {
+	var rows []gistRow
+	tracked := make(map[string]bool)
+	for _, rec := range state.Records() {
+		rows = append(rows, gistRow{GistID: rec.GistID, URL: rec.URL, SessionID: rec.SessionID, Source: rec.Source, Updated: rec.Updated, Tracked: true})
+		tracked[rec.GistID] = true
+	}
+	if !remote {
+		return rows, nil
+	}
+
+	found, err := gist.List()
+	if err != nil {
+		return nil, fmt.Errorf("listing gists: %w", err)
+	}
+	for _, g := range found {
+		if !tracked[g.ID] {
+			rows = append(rows, gistRow{GistID: g.ID, URL: g.HTMLURL, Updated: g.UpdatedAt})
+		}
+	}
+	return rows, nil
+}
+
+func runGistsList(args []string) error {
+	fs := flag.NewFlagSet("gists list", flag.ExitOnError)
+	remote := fs.Bool("remote", false, "Also list gists on GitHub made by this tool but not remembered locally")
+	asJSON := fs.Bool("json", false, "Print the list as JSON")
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+
+	state, err := gist.LoadState()
+	if err != nil {
+		return err
+	}
+	rows, err := gistRows(state, *remote)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		if rows == nil {
+			rows = []gistRow{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+
+	if len(rows) == 0 {
+		fmt.Println("No gists uploaded yet.")
+		return nil
+	}
+	for i, row := range rows {
+		label := colorYellow + "not tracked" + colorReset
+		if row.Tracked {
+			label = colorCyan + colorBold + formatProjectName(filepath.Base(filepath.Dir(row.Source))) + colorReset + " " + row.SessionID
+		}
+		fmt.Printf("%3d. %s%s%s  %s\n     %s\n", i+1, colorDim, row.Updated.Local().Format("Jan 02 15:04"), colorReset, label, row.URL)
+	}
+	return nil
+}
+
+// resolveGist finds the gist an argument names: a number from gists list,
+// or a gist ID or URL
+func resolveGist(arg string, rows []gistRow) gistRow {
+	if n, err := strconv.Atoi(arg); err == nil && n >= 1 && n <= len(rows) {
+		return rows[n-1]
+	}
+	id := gist.IDFromURL(arg)
+	for _, row := range rows {
+		if row.GistID == id {
+			return row
+		}
+	}
+	return gistRow{GistID: id, URL: "https://gist.github.com/" + id}
+}
+
+func runGistsOpen(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: claude-session-export gists open <n|id|url>")
+	}
+	state, err := gist.LoadState()
+	if err != nil {
+		return err
+	}
+	rows, _ := gistRows(state, false)
+	row := resolveGist(args[0], rows)
+
+	fmt.Printf("Opening viewer for: %s\n", row.URL)
+	return openGistInViewer(row.URL)
+}
+
+func runGistsDelete(args []string) error {
+	fs := flag.NewFlagSet("gists delete", flag.ExitOnError)
+	all := fs.Bool("all", false, "Delete every gist listed by gists list")
+	remote := fs.Bool("remote", false, "With --all, include gists on GitHub that aren't remembered locally")
+	yes := fs.Bool("yes", false, "Delete without asking for confirmation")
+	fs.BoolVar(yes, "y", false, "Delete without asking for confirmation")
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+	if *all == (fs.NArg() > 0) {
+		return errors.New("usage: claude-session-export gists delete <n|id|url>... | --all [--remote]")
+	}
+	if !gist.HasToken() && !gist.HasGH() {
+		return gist.ErrNoAuth
+	}
+
+	state, err := gist.LoadState()
+	if err != nil {
+		return err
+	}
+	rows, err := gistRows(state, *all && *remote)
+	if err != nil {
+		return err
+	}
+
+	targets := rows
+	if !*all {
+		targets = nil
+		for _, arg := range fs.Args() {
+			targets = append(targets, resolveGist(arg, rows))
+		}
+	}
+	if len(targets) == 0 {
+		fmt.Println("No gists to delete.")
+		return nil
+	}
+
+	if !*yes {
+		noun := "gist"
+		if len(targets) > 1 {
+			noun = "gists"
+		}
+		if !confirm(fmt.Sprintf("Delete %d %s from GitHub? This can't be undone. [y/N]: ", len(targets), noun)) {
+			return errors.New("delete cancelled")
+		}
+	}
+
+	failed := 0
+	for _, row := range targets {
+		err := gist.Delete(row.GistID)
+		switch {
+		case errors.Is(err, gist.ErrGistNotFound):
+			fmt.Printf("Already gone: %s\n", row.URL)
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Error deleting %s: %v\n", row.URL, err)
+			failed++
+			continue
+		default:
+			fmt.Printf("Deleted %s\n", row.URL)
+		}
+		state.Remove(row.GistID)
+	}
+
+	if err := state.Save(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d gists could not be deleted", failed, len(targets))
+	}
+	return nil
+}
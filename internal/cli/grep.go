@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+const colorMagenta = "\033[35m"
+
+func runGrep(args []string) error {
+	fs := flag.NewFlagSet("grep", flag.ExitOnError)
+	flags := addSearchFlags(fs, nil)
+	color := fs.String("color", "auto", "Color the output: auto (on a terminal, unless NO_COLOR is set), always or never")
+
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("usage: claude-session-export grep <query> [--regex] [--color auto|always|never]")
+	}
+	colored, err := useColor(*color, os.Stdout)
+	if err != nil {
+		return err
+	}
+	search, misses, err := flags.options()
+	if err != nil {
+		return err
+	}
+
+	results, err := session.SearchSessions(fs.Arg(0), search)
+	if err != nil {
+		return fmt.Errorf("searching sessions: %w", err)
+	}
+	warnStaleIndex(misses)
+	printGrep(os.Stdout, results, colored)
+	return nil
+}
+
+// useColor decides whether to color output to f. By default it's colored
+// on a terminal, unless NO_COLOR is set (https://no-color.org).
+func useColor(mode string, f *os.File) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		info, err := f.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0, nil
+	default:
+		return false, fmt.Errorf("--color must be auto, always or never, not %q", mode)
+	}
+}
+
+// printGrep writes one line per match, as path:line: snippet, like grep -n
+func printGrep(w io.Writer, results []session.SearchResult, colored bool) {
+	for _, result := range results {
+		for _, m := range result.Matches {
+			path, line, text := result.SessionInfo.Path, fmt.Sprint(m.Line), m.Text
+			if colored {
+				path = colorMagenta + path + colorReset
+				line = colorCyan + line + colorReset
+				text = highlightMatch(text, m.Match)
+			}
+			fmt.Fprintf(w, "%s:%s: %s\n", path, line, text)
+		}
+	}
+}
+
+// highlightMatch colors the match where it appears in the snippet, which
+// has its whitespace collapsed
+func highlightMatch(snippet, match string) string {
+	match = strings.Join(strings.Fields(match), " ")
+	i := strings.Index(snippet, match)
+	if match == "" || i < 0 {
+		return snippet
+	}
+	return snippet[:i] + colorBold + colorYellow + match + colorReset + snippet[i+len(match):]
+}
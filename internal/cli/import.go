@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/robzolkos/claude-session-export/internal/encrypt"
+	"github.com/robzolkos/claude-session-export/internal/gist"
+)
+
+const importUsage = "usage: claude-session-export import <gist-url|id> [-o DIR] [--force] | --render [export options]"
+
+// runImport downloads the session from a gist made by this tool, or by
+// hand, and saves it, or with --render exports it like json does
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	opts := addExportFlags(fs)
+	render := fs.Bool("render", false, "Export the session (to the viewer, or with the usual export options) instead of saving it")
+	force := fs.Bool("force", false, "Overwrite an existing file")
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New(importUsage)
+	}
+
+	id := gist.IDFromURL(fs.Arg(0))
+	fmt.Fprintf(opts.progress(), "Downloading gist %s...\n", id)
+	files, err := gist.Download(id)
+	if err != nil {
+		return fmt.Errorf("downloading gist: %w", err)
+	}
+	name, data, err := gistSession(files)
+	if err != nil {
+		return fmt.Errorf("gist %s: %w", id, err)
+	}
+	encrypted := strings.HasSuffix(name, encrypt.Ext)
+
+	fileName := "gist-" + id + ".jsonl"
+	if sid := sessionIDOf(data); sid != "" && !strings.ContainsAny(sid, `/\:`) && !strings.HasPrefix(sid, ".") {
+		fileName = sid + ".jsonl"
+	}
+	if encrypted {
+		fileName += encrypt.Ext
+	}
+
+	if *render {
+		if encrypted {
+			return fmt.Errorf("gist %s holds an encrypted session: import it without --render and decrypt it with age -d", id)
+		}
+		// Named for the session, so exports are too
+		dir, err := os.MkdirTemp("", "imported-*")
+		if err != nil {
+			return fmt.Errorf("creating temp directory: %w", err)
+		}
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, fileName)
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return fmt.Errorf("writing temp file: %w", err)
+		}
+		opts.snapshot = true
+		return exportSession(path, opts)
+	}
+
+	dir := opts.outputDir
+	if dir == "" {
+		dir = "."
+	}
+	path := filepath.Join(dir, fileName)
+	if _, err := os.Stat(path); err == nil && !*force {
+		return fmt.Errorf("%s already exists (use --force to overwrite it)", path)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	fmt.Printf("Saved: %s\n", path)
+	if !encrypted {
+		fmt.Printf("  Open:     claude-session-export json %s\n", path)
+	}
+	return nil
+}
+
+// gistSession picks the session from a gist's files: the session.jsonl
+// uploads are named, its encrypted form, or failing those the only JSONL
+// file
+func gistSession(files map[string][]byte) (string, []byte, error) {
+	for _, name := range []string{"session.jsonl", gistEncryptedFile} {
+		if data, ok := files[name]; ok {
+			return name, data, nil
+		}
+	}
+	var jsonl []string
+	for name := range files {
+		if strings.HasSuffix(name, ".jsonl") {
+			jsonl = append(jsonl, name)
+		}
+	}
+	switch len(jsonl) {
+	case 0:
+		return "", nil, errors.New("no session.jsonl in the gist")
+	case 1:
+		return jsonl[0], files[jsonl[0]], nil
+	}
+	sort.Strings(jsonl)
+	return "", nil, fmt.Errorf("several JSONL files and no session.jsonl: %s", strings.Join(jsonl, ", "))
+}
+
+// sessionIDOf returns the sessionId of the first entry that has one
+func sessionIDOf(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		var entry struct {
+			SessionID string `json:"sessionId"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil && entry.SessionID != "" {
+			return entry.SessionID
+		}
+	}
+	return ""
+}
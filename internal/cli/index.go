@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/searchindex"
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+const indexUsage = "usage: claude-session-export index build | status | clear"
+
+func runIndex(args []string) error {
+	if len(args) == 0 {
+		return errors.New(indexUsage)
+	}
+
+	fs := flag.NewFlagSet("index "+args[0], flag.ExitOnError)
+	if err := fs.Parse(reorderArgs(args[1:])); err != nil {
+		return err
+	}
+	path, err := searchindex.Path()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "build":
+		return runIndexBuild(path)
+	case "status":
+		return runIndexStatus(path)
+	case "clear":
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("removing index: %w", err)
+		}
+		fmt.Printf("Removed %s\n", path)
+		return nil
+	default:
+		return fmt.Errorf("unknown index command %q", args[0])
+	}
+}
+
+func runIndexBuild(path string) error {
+	projectsDir, err := session.GetClaudeProjectsDir()
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	idx, err := searchindex.Build(projectsDir)
+	if err != nil {
+		return err
+	}
+	if err := idx.Save(path); err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Indexed %d session(s) in %s (%s, %s)\n", len(idx.Sessions), time.Since(start).Round(time.Millisecond), path, formatBytes(int(info.Size())))
+	return nil
+}
+
+func runIndexStatus(path string) error {
+	idx, err := searchindex.Load(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		fmt.Println("No search index; run 'claude-session-export index build' to make one")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	st, err := idx.Status()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Index:   %s\n", path)
+	fmt.Printf("Built:   %s\n", idx.Built.Local().Format("2006-01-02 15:04"))
+	fmt.Printf("Current: %d session(s)\n", st.Current)
+	fmt.Printf("Stale:   %d changed, %d new, %d removed\n", st.Stale, st.New, st.Removed)
+	if st.Stale+st.New > 0 {
+		fmt.Println("Changed and new sessions are searched directly; run 'claude-session-export index build' to update")
+	}
+	return nil
+}
+
+// searchLookup loads the search index for search, returning a lookup that
+// counts the files it couldn't answer for. Without an index it returns nil.
+func searchLookup() (func(string, fs.FileInfo) ([]session.SearchEntry, bool), *int, error) {
+	path, err := searchindex.Path()
+	if err != nil {
+		return nil, nil, err
+	}
+	idx, err := searchindex.Load(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	misses := new(int)
+	var mu sync.Mutex
+	return func(path string, info fs.FileInfo) ([]session.SearchEntry, bool) {
+		entries, ok := idx.Lookup(path, info)
+		if !ok {
+			mu.Lock()
+			*misses++
+			mu.Unlock()
+		}
+		return entries, ok
+	}, misses, nil
+}
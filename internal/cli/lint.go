@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/robzolkos/claude-session-export/internal/lint"
+)
+
+// runLint checks generated exports for problems and fails if it finds any,
+// for use in publishing pipelines
+func runLint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	maxSize := fs.String("max-size", "10MB", "Largest page allowed, e.g. 5MB or 500KB")
+	checkURLs := fs.Bool("check-urls", false, "Request commit URLs to find dead ones (needs network access)")
+	asJSON := fs.Bool("json", false, "Print the issues as JSON")
+
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("usage: claude-session-export lint <viewer.html|export.zip|dir>... [--max-size 10MB] [--check-urls]")
+	}
+	limit, err := parseSize(*maxSize)
+	if err != nil {
+		return fmt.Errorf("invalid --max-size: %w", err)
+	}
+
+	var issues []lint.Issue
+	for _, target := range fs.Args() {
+		found, err := lint.Check(target, lint.Options{MaxPageSize: limit, CheckURLs: *checkURLs})
+		if err != nil {
+			return fmt.Errorf("linting %s: %w", target, err)
+		}
+		for i := range found {
+			if len(fs.Args()) > 1 {
+				found[i].File = target + ": " + found[i].File
+			}
+		}
+		issues = append(issues, found...)
+	}
+
+	if *asJSON {
+		if issues == nil {
+			issues = []lint.Issue{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(issues); err != nil {
+			return err
+		}
+	} else {
+		for _, issue := range issues {
+			fmt.Printf("%s: %s [%s] %s\n", issue.File, issue.Severity, issue.Check, issue.Message)
+		}
+		if len(issues) == 0 {
+			fmt.Println("No issues found.")
+		}
+	}
+
+	if n := lint.Errors(issues); n > 0 {
+		noun := "error"
+		if n > 1 {
+			noun = "errors"
+		}
+		return fmt.Errorf("lint found %d %s", n, noun)
+	}
+	return nil
+}
+
+// parseSize parses a byte count with an optional B, KB, MB or GB suffix
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		n      int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s, multiplier = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix)), unit.n
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%q is not a size like 10MB", s)
+	}
+	return int64(n * float64(multiplier)), nil
+}
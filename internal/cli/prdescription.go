@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/robzolkos/claude-session-export/internal/prdesc"
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+// runPRDescription writes a pull request body summarizing a session
+func runPRDescription(args []string) error {
+	fs := flag.NewFlagSet("pr-description", flag.ExitOnError)
+	output := fs.String("output", "", "File to write the description to (default: stdout)")
+	fs.StringVar(output, "o", "", "File to write the description to (default: stdout)")
+
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("usage: claude-session-export pr-description <file|number|id> [-o FILE]")
+	}
+
+	path := fs.Arg(0)
+	if _, err := os.Stat(path); err != nil {
+		info, err := resolveSession(path, 100)
+		if err != nil {
+			return err
+		}
+		path = info.Path
+	}
+
+	sess, err := session.ParseFile(path)
+	if err != nil {
+		return fmt.Errorf("parsing session: %w", err)
+	}
+	desc := prdesc.Build(sess)
+
+	if *output == "" {
+		return desc.WriteMarkdown(os.Stdout)
+	}
+	if err := writeReportFile(*output, desc.WriteMarkdown); err != nil {
+		return err
+	}
+	fmt.Printf("Created: %s\n", *output)
+	fmt.Printf("Open a PR with it: gh pr create --body-file %s\n", *output)
+	return nil
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/config"
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+func runPreview(args []string) error {
+	fs := flag.NewFlagSet("preview", flag.ExitOnError)
+	prompts := fs.Int("prompts", 5, "Number of prompts to show")
+	limit := fs.Int("limit", 100, "Maximum number of sessions to consider when selecting by number")
+
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return errors.New("usage: claude-session-export preview <number|session-id>")
+	}
+
+	info, err := resolveSession(fs.Arg(0), *limit)
+	if err != nil {
+		return err
+	}
+
+	sess, err := session.ParseFile(info.Path)
+	if err != nil {
+		return fmt.Errorf("parsing session: %w", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	printSessionPreview(info, sess, pricingFor(cfg), *prompts)
+	return nil
+}
+
+// resolveSession finds a local session by its picker number (as shown by
+// the local command) or by session ID
+func resolveSession(ref string, limit int) (*session.SessionInfo, error) {
+	if n, err := strconv.Atoi(ref); err == nil {
+		sessions, err := session.FindLocalSessions(limit)
+		if err != nil {
+			return nil, fmt.Errorf("finding sessions: %w", err)
+		}
+		session.LoadSessionSummaries(sessions, nil)
+		if n < 1 || n > len(sessions) {
+			return nil, fmt.Errorf("session number %d out of range (1-%d)", n, len(sessions))
+		}
+		return &sessions[n-1], nil
+	}
+
+	return session.FindSessionByID(ref)
+}
+
+func printSessionPreview(info *session.SessionInfo, sess *session.Session, pricing session.Pricing, maxPrompts int) {
+	meta := sess.Metadata
+	if meta == nil {
+		meta = &session.SessionMetadata{}
+	}
+	prompts := session.GetUserPrompts(sess)
+
+	fmt.Printf("%s%s%s\n", colorCyan+colorBold, formatProjectName(info.ProjectName), colorReset)
+	fmt.Printf("  Session:  %s\n", info.SessionID)
+	fmt.Printf("  Path:     %s\n", info.Path)
+	if meta.GitBranch != "" {
+		fmt.Printf("  Branch:   %s\n", meta.GitBranch)
+	}
+	if !meta.StartTime.IsZero() {
+		fmt.Printf("  Started:  %s\n", meta.StartTime.Local().Format("Jan 02 2006 3:04pm"))
+		fmt.Printf("  Duration: %s (active %s)\n",
+			formatDuration(meta.EndTime.Sub(meta.StartTime)),
+			formatDuration(meta.ActiveTime))
+	}
+	fmt.Printf("  Messages: %d (%d prompts)\n", len(sess.Messages), len(prompts))
+	if len(meta.Models) > 0 {
+		fmt.Printf("  Models:   %s\n", strings.Join(meta.Models, ", "))
+	}
+	fmt.Printf("  Tokens:   %s in, %s out, %s cache\n",
+		formatTokenCount(meta.TotalInput),
+		formatTokenCount(meta.TotalOutput),
+		formatTokenCount(meta.TotalCache))
+	if estimate := pricing.Estimate(session.UsageByModel(sess)); estimate.Total > 0 {
+		fmt.Printf("  Cost:     ~%s\n", session.FormatCost(estimate.Total))
+	}
+
+	if len(prompts) == 0 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("  Prompts:")
+	for i, msg := range prompts {
+		if i >= maxPrompts {
+			fmt.Printf("  %s... and %d more%s\n", colorDim, len(prompts)-maxPrompts, colorReset)
+			break
+		}
+		text := strings.Join(strings.Fields(session.ExtractText(&msg)), " ")
+		if len(text) > 100 {
+			text = text[:97] + "..."
+		}
+		timeStr := ""
+		if !msg.Timestamp.IsZero() {
+			timeStr = msg.Timestamp.Local().Format("3:04pm") + " "
+		}
+		fmt.Printf("  %2d. %s%s%s%s\n", i+1, colorDim, timeStr, colorReset, text)
+	}
+}
+
+// formatDuration formats a duration as e.g. "1h 5m", "12m" or "40s"
+func formatDuration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	switch {
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm", minutes)
+	default:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+}
+
+// formatTokenCount formats a token count as e.g. "1.2M", "3.4K" or "512"
+func formatTokenCount(count int) string {
+	switch {
+	case count >= 1000000:
+		return fmt.Sprintf("%.1fM", float64(count)/1000000)
+	case count >= 1000:
+		return fmt.Sprintf("%.1fK", float64(count)/1000)
+	default:
+		return strconv.Itoa(count)
+	}
+}
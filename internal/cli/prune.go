@@ -0,0 +1,357 @@
+package cli
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/config"
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+const pruneUsage = "usage: claude-session-export prune --older-than AGE [--archive-first] [--backup FILE.tar.gz] [--move-to DIR] [--dry-run]"
+
+// prunable is a session old enough to prune, with the files that go with
+// it: its subagent transcripts and its own directory, if it has one
+type prunable struct {
+	info  session.SessionInfo
+	paths []string // The session file first
+	size  int64
+}
+
+// runPrune deletes, or moves, local sessions not touched for a while, so
+// the projects directory doesn't grow without bound. They can be exported
+// to the archive or put in a tarball first.
+func runPrune(args []string) error {
+	fs := flag.NewFlagSet("prune", flag.ExitOnError)
+	olderThan := fs.String("older-than", "", "Prune sessions last changed more than AGE ago, e.g. 90d or 720h")
+	archiveFirst := fs.Bool("archive-first", false, "Export each session as a viewer to the archive directory before pruning it")
+	archiveDir := fs.String("archive-dir", "", "Archive directory for --archive-first (default: publish.dir or html_dir from the config file)")
+	backup := fs.String("backup", "", "Write the sessions to this tar.gz before pruning them")
+	moveTo := fs.String("move-to", "", "Move the sessions under this directory instead of deleting them")
+	dryRun := fs.Bool("dry-run", false, "List the sessions that would be pruned and stop")
+	yes := fs.Bool("yes", false, "Prune without asking for confirmation")
+	fs.BoolVar(yes, "y", false, "Prune without asking for confirmation")
+
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 || *olderThan == "" {
+		return errors.New(pruneUsage)
+	}
+	age, err := parseAge(*olderThan)
+	if err != nil {
+		return fmt.Errorf("invalid --older-than: %w", err)
+	}
+
+	dir := *archiveDir
+	if *archiveFirst && dir == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		dir = cfg.Publish.Dir
+		if dir == "" {
+			dir = cfg.HTMLDir
+		}
+		if dir == "" {
+			return errors.New("no archive directory: use --archive-dir, or set publish.dir or html_dir in the config file")
+		}
+	}
+
+	projectsDir, err := session.GetClaudeProjectsDir()
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-age)
+	sessions, err := findPrunable(projectsDir, cutoff)
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		fmt.Printf("No sessions last changed before %s.\n", cutoff.Format("2006-01-02"))
+		return nil
+	}
+
+	var total int64
+	for _, p := range sessions {
+		total += p.size
+	}
+	fmt.Printf("%d sessions (%s) last changed before %s:\n", len(sessions), formatBytes(int(total)), cutoff.Format("2006-01-02"))
+	for _, p := range sessions {
+		rel, _ := filepath.Rel(projectsDir, p.info.Path)
+		fmt.Printf("  %s  %s  %s\n", p.info.ModTime.Format("2006-01-02"), rel, formatBytes(int(p.size)))
+	}
+	if *dryRun {
+		return nil
+	}
+	if !*yes {
+		prompt := fmt.Sprintf("Delete these %d sessions from %s? [y/N]: ", len(sessions), projectsDir)
+		if *moveTo != "" {
+			prompt = fmt.Sprintf("Move these %d sessions to %s? [y/N]: ", len(sessions), *moveTo)
+		}
+		if !confirm(prompt) {
+			return errors.New("prune cancelled")
+		}
+	}
+
+	if *backup != "" {
+		if err := writePruneBackup(projectsDir, sessions, *backup); err != nil {
+			return err
+		}
+		fmt.Printf("Backed up %d sessions to %s\n", len(sessions), *backup)
+	}
+
+	pruned, freed := 0, int64(0)
+	var failed []string
+	for _, p := range sessions {
+		if *archiveFirst {
+			if err := archiveSession(p.info, dir); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: kept %s, which couldn't be archived: %v\n", p.info.Path, err)
+				failed = append(failed, p.info.Path)
+				continue
+			}
+		}
+		if err := pruneSession(projectsDir, p, *moveTo); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			failed = append(failed, p.info.Path)
+			continue
+		}
+		pruned++
+		freed += p.size
+	}
+
+	verb := "Deleted"
+	if *moveTo != "" {
+		verb = "Moved"
+	}
+	fmt.Printf("%s %d sessions, freeing %s", verb, pruned, formatBytes(int(freed)))
+	if *archiveFirst {
+		fmt.Printf("; viewers are in %s", dir)
+	}
+	fmt.Println()
+	if len(failed) > 0 {
+		return fmt.Errorf("%d sessions were left in place", len(failed))
+	}
+	return nil
+}
+
+// parseAge parses an --older-than value: days like 90d, or a duration
+// like 720h
+func parseAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("%q is not an age like 90d or 720h", value)
+}
+
+// findPrunable lists the sessions under projectsDir last changed before
+// cutoff, oldest first. Subagent transcripts go with their session rather
+// than being listed themselves.
+func findPrunable(projectsDir string, cutoff time.Time) ([]prunable, error) {
+	infos, err := session.FindSessionsIn(projectsDir, 0)
+	if err != nil {
+		return nil, err
+	}
+	var sessions []prunable
+	for i := len(infos) - 1; i >= 0; i-- {
+		info := infos[i]
+		rel, err := filepath.Rel(projectsDir, info.Path)
+		if err != nil || strings.Count(filepath.ToSlash(rel), "/") != 1 || strings.HasPrefix(filepath.Base(rel), "agent-") {
+			continue
+		}
+		if !info.ModTime.Before(cutoff) {
+			continue
+		}
+
+		p := prunable{info: info, paths: []string{info.Path}}
+		own := filepath.Join(filepath.Dir(info.Path), info.SessionID)
+		if fi, err := os.Stat(own); err == nil && fi.IsDir() {
+			p.paths = append(p.paths, own)
+		}
+		for _, sub := range session.FindSubagentFiles(info.Path) {
+			if !strings.HasPrefix(sub, own+string(filepath.Separator)) {
+				p.paths = append(p.paths, sub)
+			}
+		}
+		for _, path := range p.paths {
+			filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+				if err == nil && !d.IsDir() {
+					if fi, err := d.Info(); err == nil {
+						p.size += fi.Size()
+					}
+				}
+				return nil
+			})
+		}
+		sessions = append(sessions, p)
+	}
+	return sessions, nil
+}
+
+// archiveSession exports a session as a viewer into the archive, in a
+// directory named for its project as in the projects directory
+func archiveSession(info session.SessionInfo, archiveDir string) error {
+	opts := addExportFlags(flag.NewFlagSet("archive", flag.ContinueOnError))
+	opts.format = formatHTML
+	opts.outputDir = filepath.Join(archiveDir, info.ProjectName)
+	opts.noOpen = true
+	opts.quiet = true
+	return exportSession(info.Path, opts)
+}
+
+// writePruneBackup writes the sessions' files to a gzipped tarball, laid
+// out as under projectsDir so they can be unpacked back into it
+func writePruneBackup(projectsDir string, sessions []prunable, tarPath string) error {
+	f, err := os.Create(tarPath)
+	if err != nil {
+		return fmt.Errorf("creating backup file: %w", err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	add := func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(projectsDir, path)
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(tw, src)
+		return err
+	}
+	for _, p := range sessions {
+		for _, path := range p.paths {
+			if err := filepath.WalkDir(path, add); err != nil {
+				f.Close()
+				return fmt.Errorf("writing backup: %w", err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		f.Close()
+		return fmt.Errorf("writing backup: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		f.Close()
+		return fmt.Errorf("writing backup: %w", err)
+	}
+	return f.Close()
+}
+
+// pruneSession deletes a session's files, or with moveTo, moves them to
+// the same place under it as they had under projectsDir
+func pruneSession(projectsDir string, p prunable, moveTo string) error {
+	for _, path := range p.paths {
+		if moveTo == "" {
+			if err := os.RemoveAll(path); err != nil {
+				return fmt.Errorf("deleting %s: %w", path, err)
+			}
+			continue
+		}
+		rel, err := filepath.Rel(projectsDir, path)
+		if err != nil {
+			return err
+		}
+		if err := moveTree(path, filepath.Join(moveTo, rel)); err != nil {
+			return fmt.Errorf("moving %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// moveTree moves a file or directory, copying it and deleting the
+// original when it can't simply be renamed, as across file systems
+func moveTree(src, dest string) error {
+	if _, err := os.Lstat(dest); err == nil {
+		return fmt.Errorf("%s already exists", dest)
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	if os.Rename(src, dest) == nil {
+		return nil
+	}
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		return copyFile(path, target)
+	})
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dest, info.ModTime(), info.ModTime())
+}
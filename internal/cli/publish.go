@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/robzolkos/claude-session-export/internal/config"
+	"github.com/robzolkos/claude-session-export/internal/history"
+	"github.com/robzolkos/claude-session-export/internal/publish"
+)
+
+// runPublish pushes a directory of exports to a branch GitHub Pages serves,
+// so a team can browse transcripts at a stable URL
+func runPublish(args []string) error {
+	fs := flag.NewFlagSet("publish", flag.ExitOnError)
+	repo := fs.String("repo", "", "Repository to publish to, as owner/name")
+	branch := fs.String("branch", "", "Branch to publish to (default: gh-pages)")
+	message := fs.String("message", publish.DefaultMessage, "Commit message")
+	useGit := fs.Bool("git", false, "Push with git and its credentials instead of the GitHub API")
+	siteURL := fs.String("site-url", "", "URL the pages are served from, for the sitemap (default: the GitHub Pages URL)")
+	noIndex := fs.Bool("noindex", false, "Keep the pages out of search engines instead of adding a sitemap")
+	fs.BoolVar(noIndex, "robots-noindex", false, "Keep the pages out of search engines instead of adding a sitemap")
+	yes := fs.Bool("yes", false, "Publish without asking for confirmation")
+	fs.BoolVar(yes, "y", false, "Publish without asking for confirmation")
+	asJSON := fs.Bool("json", false, "Print the result as JSON")
+
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return errors.New("usage: claude-session-export publish [DIR] [--repo owner/name] [--branch gh-pages]")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	dir := cfg.Publish.Dir
+	if dir == "" {
+		dir = cfg.HTMLDir
+	}
+	if fs.NArg() == 1 {
+		dir = fs.Arg(0)
+	}
+	if dir == "" {
+		return errors.New("no directory to publish: give one, or set publish.dir in the config file")
+	}
+	opts := publish.Options{Repo: cfg.Publish.Repo, Branch: cfg.Publish.Branch, Message: *message, UseGit: *useGit}
+	if *repo != "" {
+		opts.Repo = *repo
+	}
+	if *branch != "" {
+		opts.Branch = *branch
+	}
+	if opts.Repo == "" {
+		return errors.New("no repository to publish to: use --repo owner/name, or set publish.repo in the config file")
+	}
+	if !publish.ValidRepo(opts.Repo) {
+		return fmt.Errorf("--repo %q should look like owner/name", opts.Repo)
+	}
+	if opts.Branch == "" {
+		opts.Branch = publish.DefaultBranch
+	}
+
+	files, err := publish.Files(dir, pricingFor(cfg))
+	if err != nil {
+		return err
+	}
+	// Crawlers get either a sitemap or a request to stay away
+	if *noIndex || cfg.Publish.NoIndex {
+		files, err = publish.NoIndex(files)
+	} else {
+		site := cmp.Or(*siteURL, cfg.Publish.SiteURL, publish.PagesURL(opts.Repo))
+		files, err = publish.Sitemap(files, site)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !*yes {
+		prompt := fmt.Sprintf("Publish %d files from %s to %s (branch %s)? Anyone who can see the repository can read them. [y/N]: ", len(files), dir, opts.Repo, opts.Branch)
+		if !confirm(prompt) {
+			return errors.New("publish cancelled")
+		}
+	}
+
+	if !*asJSON {
+		fmt.Fprintf(os.Stderr, "Publishing to %s...\n", opts.Repo)
+	}
+	result, err := publish.Publish(files, opts)
+	if err != nil {
+		return fmt.Errorf("publishing to %s: %w", opts.Repo, err)
+	}
+
+	if result.Changed {
+		recordPublished(dir, files, cmp.Or(*siteURL, cfg.Publish.SiteURL, result.URL))
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	if result.Changed {
+		fmt.Printf("Published %d files (%s) to %s, commit %s\n", result.Files, formatBytes(int(result.Size)), opts.Branch, shortSHA(result.Commit))
+	} else {
+		fmt.Printf("Nothing to publish: %s already matches %s\n", opts.Branch, dir)
+	}
+	fmt.Printf("%sURL:%s %s\n", colorBold, colorReset, result.URL)
+	if result.PagesEnabled != nil && !*result.PagesEnabled {
+		fmt.Printf("%sGitHub Pages isn't enabled yet: in the repository's Settings > Pages, serve from the %s branch.%s\n", colorYellow, opts.Branch, colorReset)
+	}
+	return nil
+}
+
+// recordPublished adds each published session to the export history, so
+// usage lists them among the uploads
+func recordPublished(dir string, files []publish.File, siteURL string) {
+	pages, err := publish.SessionPages(files)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record export history: %v\n", err)
+		return
+	}
+	for _, p := range pages {
+		err := history.Record(history.Entry{
+			SessionID:   strings.TrimSuffix(path.Base(p.Path), path.Ext(p.Path)),
+			Source:      filepath.Join(dir, filepath.FromSlash(p.Path)),
+			Format:      "html",
+			Destination: history.DestinationPages,
+			Location:    strings.TrimSuffix(siteURL, "/") + "/" + p.Path,
+			Size:        p.Size,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not record export history: %v\n", err)
+			return
+		}
+	}
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/config"
+	"github.com/robzolkos/claude-session-export/internal/convert"
+	"github.com/robzolkos/claude-session-export/internal/report"
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+func runReport(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	period := fs.String("period", "week", "Rollup period: "+strings.Join(report.Periods, ", "))
+	outputDir := fs.String("output", ".", "Directory to write report.html and report.csv to")
+	fs.StringVar(outputDir, "o", ".", "Directory to write report.html and report.csv to")
+	noOpen := fs.Bool("no-open", false, "Don't open the dashboard after writing it")
+	include := fs.String("include", "", "Also include other agents' sessions, comma-separated: "+strings.Join(convert.Agents, ", "))
+
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+	if !report.ValidPeriod(*period) {
+		return fmt.Errorf("unknown period %q (available: %s)", *period, strings.Join(report.Periods, ", "))
+	}
+	var agents []string
+	for _, agent := range strings.Split(*include, ",") {
+		if agent = strings.TrimSpace(agent); agent == "" {
+			continue
+		}
+		if !slices.Contains(convert.Agents, agent) {
+			return fmt.Errorf("unknown agent %q (available: %s)", agent, strings.Join(convert.Agents, ", "))
+		}
+		agents = append(agents, agent)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	pricing := pricingFor(cfg)
+
+	sessions, err := session.FindLocalSessions(0)
+	if err != nil {
+		return fmt.Errorf("finding sessions: %w", err)
+	}
+	others := make(map[string][]string)
+	total := len(sessions)
+	for _, agent := range agents {
+		paths, err := convert.SessionFiles(agent)
+		if err != nil {
+			return fmt.Errorf("finding %s sessions: %w", agent, err)
+		}
+		others[agent] = paths
+		total += len(paths)
+	}
+	if total == 0 {
+		fmt.Println("No sessions found.")
+		return nil
+	}
+
+	fmt.Printf("Scanning %d sessions...\n", total)
+	var entries []report.Entry
+	for _, info := range sessions {
+		sess, err := session.ParseFile(info.Path)
+		if err != nil {
+			continue
+		}
+		stats := session.SessionStats(sess, pricing)
+		start := stats.Start
+		if start.IsZero() {
+			start = info.ModTime
+		}
+		entries = append(entries, report.Entry{
+			Project: formatProjectName(info.ProjectName),
+			Time:    start.Local(),
+			Stats:   stats,
+		})
+	}
+
+	for _, agent := range agents {
+		for _, path := range others[agent] {
+			entries = append(entries, convertedEntries(agent, path, pricing)...)
+		}
+	}
+
+	r := report.Build(entries, *period, time.Now())
+
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+	htmlPath := filepath.Join(*outputDir, "report.html")
+	csvPath := filepath.Join(*outputDir, "report.csv")
+	if err := writeReportFile(htmlPath, r.WriteHTML); err != nil {
+		return err
+	}
+	if err := writeReportFile(csvPath, r.WriteCSV); err != nil {
+		return err
+	}
+
+	fmt.Printf("%d sessions, estimated cost %s\n", r.Total.Sessions, session.FormatCost(r.Total.Cost))
+	fmt.Printf("Created: %s\n", htmlPath)
+	fmt.Printf("Created: %s\n", csvPath)
+
+	if !*noOpen {
+		if err := openInBrowser(htmlPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not open report: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// convertedEntries converts another agent's session file for the report,
+// skipping files that can't be read. Projects are named for the directory
+// the agent ran in, when it records one, and the agent.
+func convertedEntries(agent, path string, pricing session.Pricing) []report.Entry {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	convs, err := convert.Convert(agent, data, filepath.Base(path))
+	if err != nil {
+		return nil
+	}
+	var entries []report.Entry
+	for _, conv := range convs {
+		sess, err := session.Parse(conv.Data)
+		if err != nil {
+			continue
+		}
+		stats := session.SessionStats(sess, pricing)
+		start := stats.Start
+		if start.IsZero() {
+			start = conv.Updated
+		}
+		project := agent
+		if conv.Cwd != "" {
+			project = filepath.Base(conv.Cwd) + " (" + agent + ")"
+		}
+		entries = append(entries, report.Entry{
+			Project: project,
+			Time:    start.Local(),
+			Stats:   stats,
+		})
+	}
+	return entries
+}
+
+func writeReportFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", path, err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return f.Close()
+}
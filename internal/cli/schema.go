@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/robzolkos/claude-session-export/internal/normalize"
+)
+
+// runSchema prints the JSON Schema of the --format json export
+func runSchema(args []string) error {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	output := fs.String("output", "", "File to write the schema to (default: stdout)")
+	fs.StringVar(output, "o", "", "File to write the schema to (default: stdout)")
+
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+
+	write := func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(normalize.Schema())
+	}
+
+	if *output == "" {
+		return write(os.Stdout)
+	}
+	if err := writeReportFile(*output, write); err != nil {
+		return err
+	}
+	fmt.Printf("Created: %s\n", *output)
+	return nil
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/robzolkos/claude-session-export/internal/config"
+	"github.com/robzolkos/claude-session-export/internal/serve"
+	"github.com/robzolkos/claude-session-export/internal/session"
+	"github.com/robzolkos/claude-session-export/render"
+)
+
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:8080", "Address to listen on")
+	accessLog := fs.String("access-log", "", "Access log file (default: access.jsonl in the config directory)")
+	noAccessLog := fs.Bool("no-access-log", false, "Don't record session views and downloads")
+	userHeader := fs.String("user-header", "", "Header identifying the viewer, set by an authenticating proxy (e.g. X-Forwarded-User)")
+	admin := fs.Bool("admin", false, "Serve the access log at /admin, to clients on this machine")
+	adminUsers := fs.String("admin-users", "", "Comma-separated users, named by --user-header, who may open /admin")
+	header := fs.String("header", "", "HTML snippet (or @file) shown at the top of every page")
+	footer := fs.String("footer", "", "HTML snippet (or @file) shown at the bottom of every page")
+	flags := fs.Bool("flags", false, "Let viewers flag conversations, saved to a .flags.json sidecar next to each session")
+
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+
+	archives, err := parseArchiveArgs(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	opts := serve.Options{UserHeader: *userHeader, Admin: *admin, Version: version, Pricing: pricingFor(cfg), Flags: *flags}
+	for _, user := range strings.Split(*adminUsers, ",") {
+		if user = strings.TrimSpace(user); user != "" {
+			opts.AdminUsers = append(opts.AdminUsers, user)
+		}
+	}
+	if len(opts.AdminUsers) > 0 && !opts.Admin {
+		return errors.New("--admin-users applies to --admin")
+	}
+	if opts.Title, err = sessionTitler(cfg); err != nil {
+		return err
+	}
+	if opts.Header, err = brandingSnippet(*header, cfg.Header); err != nil {
+		return err
+	}
+	if opts.Footer, err = brandingSnippet(*footer, cfg.Footer); err != nil {
+		return err
+	}
+	if !*noAccessLog {
+		path := *accessLog
+		if path == "" {
+			dir, err := config.Dir()
+			if err != nil {
+				return err
+			}
+			path = filepath.Join(dir, "access.jsonl")
+		}
+		if opts.AccessLog, err = serve.OpenAccessLog(path); err != nil {
+			return err
+		}
+	}
+
+	srv, err := serve.New(archives, render.Viewer, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range archives {
+		fmt.Printf("Serving %s%s%s from %s at /u/%s/\n", colorBold, a.Name, colorReset, a.Dir, a.Name)
+	}
+	if opts.AccessLog != nil {
+		fmt.Printf("Logging access to %s\n", opts.AccessLog.Path())
+	}
+	if opts.Flags {
+		fmt.Println("Reviewer flags are saved next to each session (export with the flags command)")
+	}
+	if opts.Admin {
+		who := "clients on this machine"
+		if len(opts.AdminUsers) > 0 {
+			who = strings.Join(opts.AdminUsers, ", ")
+		}
+		fmt.Printf("Access log page at %shttp://%s/admin%s (for %s)\n", colorCyan, *addr, colorReset, who)
+	}
+	fmt.Printf("Listening on %shttp://%s%s\n", colorCyan, *addr, colorReset)
+	return http.ListenAndServe(*addr, srv)
+}
+
+// parseArchiveArgs turns NAME=DIR (or DIR, named after its base name)
+// arguments into archives, defaulting to the local Claude projects directory
+func parseArchiveArgs(args []string) ([]serve.Archive, error) {
+	if len(args) == 0 {
+		dir, err := session.GetClaudeProjectsDir()
+		if err != nil {
+			return nil, err
+		}
+		return []serve.Archive{{Name: "local", Dir: dir}}, nil
+	}
+
+	var archives []serve.Archive
+	for _, arg := range args {
+		name, dir, ok := strings.Cut(arg, "=")
+		if !ok {
+			dir = arg
+			name = filepath.Base(filepath.Clean(arg))
+		}
+		if name == "" || dir == "" {
+			return nil, fmt.Errorf("invalid archive %q (expected NAME=DIR)", arg)
+		}
+		archives = append(archives, serve.Archive{Name: name, Dir: dir})
+	}
+	return archives, nil
+}
@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/robzolkos/claude-session-export/internal/config"
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+func runStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	limit := fs.Int("limit", 0, "Only include the N most recent sessions (default: all)")
+	top := fs.Int("top", 5, "Number of most expensive sessions to list")
+	asJSON := fs.Bool("json", false, "Print a single session's stats as JSON")
+
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return runSessionStats(fs.Arg(0), pricingFor(cfg), *asJSON)
+	}
+
+	sessions, err := session.FindLocalSessions(*limit)
+	if err != nil {
+		return fmt.Errorf("finding sessions: %w", err)
+	}
+	if len(sessions) == 0 {
+		fmt.Println("No sessions found.")
+		return nil
+	}
+	title, err := sessionTitler(cfg)
+	if err != nil {
+		return err
+	}
+	session.LoadSessionSummaries(sessions, title)
+
+	printCostStats(sessions, pricingFor(cfg), *top)
+	return nil
+}
+
+// runSessionStats prints the stats for one session, given as a file path,
+// picker number or session ID
+func runSessionStats(ref string, pricing session.Pricing, asJSON bool) error {
+	path := ref
+	if _, err := os.Stat(ref); err != nil {
+		info, err := resolveSession(ref, 100)
+		if err != nil {
+			return err
+		}
+		path = info.Path
+	}
+
+	sess, err := session.ParseFile(path)
+	if err != nil {
+		return fmt.Errorf("parsing session: %w", err)
+	}
+	stats := session.SessionStats(sess, pricing)
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Path string `json:"path"`
+			session.Stats
+			DurationSeconds int `json:"duration_seconds"`
+			ActiveSeconds   int `json:"active_seconds"`
+		}{path, stats, int(stats.Duration.Seconds()), int(stats.ActiveTime.Seconds())})
+	}
+
+	printSessionStats(path, stats)
+	return nil
+}
+
+func printSessionStats(path string, stats session.Stats) {
+	fmt.Printf("%s%s%s\n", colorCyan+colorBold, path, colorReset)
+	if !stats.Start.IsZero() {
+		fmt.Printf("  Duration:  %s (active %s)\n", formatDuration(stats.Duration), formatDuration(stats.ActiveTime))
+	}
+	cost := session.FormatCost(stats.Cost)
+	if len(stats.Unpriced) > 0 {
+		cost += fmt.Sprintf(" %s(no price for %s)%s", colorDim, strings.Join(stats.Unpriced, ", "), colorReset)
+	}
+	fmt.Printf("  Est. cost: %s\n", cost)
+	fmt.Printf("  Commits:   %d\n", stats.Commits)
+
+	fmt.Printf("\n%sTokens by model%s\n", colorBold, colorReset)
+	if len(stats.Models) == 0 {
+		fmt.Printf("  %sNo usage recorded%s\n", colorDim, colorReset)
+	}
+	for _, model := range sortedKeys(stats.Models) {
+		m := stats.Models[model]
+		fmt.Printf("  %-30s %8s  %s%s in, %s out, %s cache read, %s cache write%s\n",
+			model, session.FormatCost(m.Cost), colorDim,
+			formatTokenCount(m.InputTokens), formatTokenCount(m.OutputTokens),
+			formatTokenCount(m.CacheReadTokens), formatTokenCount(m.CacheWriteTokens),
+			colorReset)
+	}
+
+	fmt.Printf("\n%sTool calls%s\n", colorBold, colorReset)
+	tools := sortedKeys(stats.Tools)
+	sort.SliceStable(tools, func(i, j int) bool { return stats.Tools[tools[i]] > stats.Tools[tools[j]] })
+	if len(tools) == 0 {
+		fmt.Printf("  %sNone%s\n", colorDim, colorReset)
+	}
+	for _, tool := range tools {
+		fmt.Printf("  %-30s %d\n", tool, stats.Tools[tool])
+	}
+
+	if len(stats.MCPServers) > 0 {
+		fmt.Printf("\n%sMCP calls by server%s\n", colorBold, colorReset)
+		for _, server := range sortedKeys(stats.MCPServers) {
+			fmt.Printf("  %-30s %d\n", server, stats.MCPServers[server])
+		}
+	}
+
+	fmt.Printf("\n%sFiles touched (%d)%s\n", colorBold, len(stats.FilesTouched), colorReset)
+	for _, file := range stats.FilesTouched {
+		fmt.Printf("  %s\n", file)
+	}
+}
+
+// sortedKeys returns a map's keys in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// pricingFor returns the built-in model prices with the config's overrides
+func pricingFor(cfg *config.Config) session.Pricing {
+	return session.DefaultPricing.Merge(cfg.Pricing)
+}
+
+func printCostStats(sessions []session.SessionInfo, pricing session.Pricing, top int) {
+	total := make(map[string]session.TokenUsage)
+	costs := make([]float64, len(sessions))
+	for i, s := range sessions {
+		for model, u := range s.Usage {
+			t := total[model]
+			t.InputTokens += u.InputTokens
+			t.OutputTokens += u.OutputTokens
+			t.CacheReadTokens += u.CacheReadTokens
+			t.CacheWriteTokens += u.CacheWriteTokens
+			total[model] = t
+		}
+		costs[i] = pricing.Estimate(s.Usage).Total
+	}
+	estimate := pricing.Estimate(total)
+
+	fmt.Printf("%d sessions, estimated cost %s%s%s\n",
+		len(sessions), colorBold, session.FormatCost(estimate.Total), colorReset)
+
+	models := make([]string, 0, len(total))
+	for model := range total {
+		models = append(models, model)
+	}
+	sort.Slice(models, func(i, j int) bool {
+		if estimate.ByModel[models[i]] != estimate.ByModel[models[j]] {
+			return estimate.ByModel[models[i]] > estimate.ByModel[models[j]]
+		}
+		return models[i] < models[j]
+	})
+
+	fmt.Printf("\n%sBy model%s\n", colorBold, colorReset)
+	for _, model := range models {
+		u := total[model]
+		cost := "unknown price"
+		if c, ok := estimate.ByModel[model]; ok {
+			cost = session.FormatCost(c)
+		}
+		fmt.Printf("  %-30s %8s  %s%s in, %s out, %s cache read, %s cache write%s\n",
+			model, cost, colorDim,
+			formatTokenCount(u.InputTokens), formatTokenCount(u.OutputTokens),
+			formatTokenCount(u.CacheReadTokens), formatTokenCount(u.CacheWriteTokens),
+			colorReset)
+	}
+	if len(estimate.Unpriced) > 0 {
+		fmt.Printf("  %sAdd prices for unknown models under \"pricing\" in the config file.%s\n", colorDim, colorReset)
+	}
+
+	// Most expensive sessions
+	order := make([]int, len(sessions))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return costs[order[a]] > costs[order[b]] })
+
+	fmt.Printf("\n%sMost expensive sessions%s\n", colorBold, colorReset)
+	for _, i := range order[:min(top, len(order))] {
+		if costs[i] == 0 {
+			break
+		}
+		s := sessions[i]
+		summary := s.Summary
+		if len(summary) > 50 {
+			summary = summary[:47] + "..."
+		}
+		fmt.Printf("  %8s  %s%-14s%s %s  %s(%s)%s\n",
+			session.FormatCost(costs[i]),
+			colorDim, sessionDisplayTime(s).Format("Jan 02 3:04pm"), colorReset,
+			summary, colorDim, s.SessionID, colorReset)
+	}
+}
@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+
+	"github.com/robzolkos/claude-session-export/internal/confluence"
+	"github.com/robzolkos/claude-session-export/internal/encrypt"
+	"github.com/robzolkos/claude-session-export/internal/gitlab"
+	"github.com/robzolkos/claude-session-export/internal/history"
+	"github.com/robzolkos/claude-session-export/internal/webhook"
+)
+
+// exportSummary describes where an export went and what can be done with
+// it next. It is printed after every export, or as JSON with --json.
+type exportSummary struct {
+	Format      string `json:"format"`
+	Destination string `json:"destination"`
+	Path        string `json:"path,omitempty"` // Local file written
+	URL         string `json:"url,omitempty"`  // Where an upload can be viewed
+	Size        int64  `json:"size"`           // Bytes written or uploaded
+	SessionSize int    `json:"session_size"`   // Bytes of session data, after filtering and redaction
+	Opened      bool   `json:"opened"`         // Whether a browser was launched
+	Encrypted   bool   `json:"encrypted,omitempty"`
+	Open        string `json:"open,omitempty"`
+	Update      string `json:"update,omitempty"`
+	Delete      string `json:"delete,omitempty"`
+}
+
+// localSummary describes an export written to path, sizing it from the file
+func localSummary(format, path string, data []byte) exportSummary {
+	s := exportSummary{
+		Format:      format,
+		Destination: history.DestinationLocal,
+		Path:        path,
+		Size:        int64(len(data)),
+		SessionSize: len(data),
+		Update:      "Run the same export again to overwrite it",
+		Delete:      removeCommand(path),
+	}
+	if info, err := os.Stat(path); err == nil {
+		s.Size = info.Size()
+	}
+	switch format {
+	case "html":
+		s.Open = openCommand(path)
+	case "zip":
+		s.Open = "Extract the zip and open viewer.html in a browser"
+	case "tar.gz":
+		s.Open = "tar -xzf " + shellQuote(path) + " and open viewer.html in a browser"
+	case "jsonl":
+		s.Open = "claude-session-export json " + shellQuote(path)
+	case "mbox":
+		s.Open = "Import the mbox into a mail client or archive"
+	case "eml":
+		s.Open = "Open the .eml files in a mail client, or import the directory into an archive"
+	}
+	return s
+}
+
+// gistSummary describes a session uploaded to a gist
+func gistSummary(gistURL, sessionPath string, data []byte) exportSummary {
+	id := path.Base(strings.TrimSuffix(gistURL, "/"))
+	return exportSummary{
+		Format:      "jsonl",
+		Destination: history.DestinationGist,
+		URL:         gistURL,
+		Size:        int64(len(data)),
+		SessionSize: len(data),
+		Open:        "claude-session-export open " + gistURL,
+		Update:      "claude-session-export json " + shellQuote(sessionPath) + " --gist-id " + id,
+		Delete:      "claude-session-export gists delete " + id,
+	}
+}
+
+// snippetSummary describes a session uploaded to a GitLab snippet
+func snippetSummary(snippet *gitlab.Snippet, instance string, data []byte) exportSummary {
+	return exportSummary{
+		Format:      "jsonl",
+		Destination: history.DestinationGitLab,
+		URL:         snippet.WebURL,
+		Size:        int64(len(data)),
+		SessionSize: len(data),
+		Open:        openCommand(snippet.WebURL),
+		Update:      "Export again with --upload gitlab to create a new snippet",
+		Delete:      gitlab.DeleteCommand(instance, snippet.ID),
+	}
+}
+
+// webhookSummary describes an export sent to a webhook. The URL is where
+// the service said it put the export, or else the webhook itself.
+func webhookSummary(format, url string, result *webhook.Result, size int64, data []byte) exportSummary {
+	s := exportSummary{
+		Format:      format,
+		Destination: history.DestinationWebhook,
+		URL:         url,
+		Size:        size,
+		SessionSize: len(data),
+		Update:      "Export again with --upload webhook to send it again",
+	}
+	if result.Location != "" {
+		s.URL = result.Location
+		s.Open = openCommand(result.Location)
+	}
+	return s
+}
+
+// confluenceSummary describes a session published as a Confluence page
+func confluenceSummary(page *confluence.Result, data []byte) exportSummary {
+	return exportSummary{
+		Format:      "confluence",
+		Destination: history.DestinationConfluence,
+		URL:         page.URL,
+		Size:        int64(len(data)),
+		SessionSize: len(data),
+		Open:        openCommand(page.URL),
+		Update:      "Export again with --upload confluence to update the page",
+		Delete:      "Delete the page in Confluence (page " + page.ID + ")",
+	}
+}
+
+// decryptCommand is the age command that decrypts file next to itself.
+// Recipients decrypt with their identity (private key) file.
+func decryptCommand(file string, spec *encrypt.Spec) string {
+	identity := "-i IDENTITY_FILE "
+	if spec.Passphrase {
+		identity = ""
+	}
+	return "age --decrypt " + identity + "-o " + shellQuote(strings.TrimSuffix(file, encrypt.Ext)) + " " + shellQuote(file)
+}
+
+// printSummary writes the summary for people, or as JSON
+func printSummary(w io.Writer, s exportSummary, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(s)
+	}
+
+	var b strings.Builder
+	b.WriteString("\nExport complete\n")
+	row := func(label, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "  %-9s %s\n", label+":", value)
+		}
+	}
+	if s.Encrypted {
+		row("Format", s.Format+", encrypted with age")
+	} else {
+		row("Format", s.Format)
+	}
+	row("File", s.Path)
+	row("URL", s.URL)
+	size := formatBytes(int(s.Size))
+	if s.Size != int64(s.SessionSize) {
+		size += fmt.Sprintf(" (session data %s)", formatBytes(s.SessionSize))
+	}
+	row("Size", size)
+	if s.Opened {
+		row("Open", "Opened in your browser; to reopen: "+s.Open)
+	} else {
+		row("Open", s.Open)
+	}
+	row("Update", s.Update)
+	row("Delete", s.Delete)
+	if s.Destination == history.DestinationLocal && s.Format == "html" {
+		b.WriteString("\nUse --gist to upload to GitHub Gist for sharing.\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// openCommand is the shell command that opens path in the default app
+func openCommand(path string) string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "open " + shellQuote(path)
+	case "windows":
+		return `start "" "` + path + `"`
+	default:
+		return "xdg-open " + shellQuote(path)
+	}
+}
+
+// removeCommand is the shell command that deletes path
+func removeCommand(path string) string {
+	if runtime.GOOS == "windows" {
+		return `del "` + path + `"`
+	}
+	return "rm " + shellQuote(path)
+}
+
+// removeDirCommand is the shell command that deletes the directory path
+func removeDirCommand(path string) string {
+	if runtime.GOOS == "windows" {
+		return `rmdir /s /q "` + path + `"`
+	}
+	return "rm -r " + shellQuote(path)
+}
+
+// shellQuote quotes s for a POSIX shell when it needs it
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`!*?[]{}()<>|&;#~") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
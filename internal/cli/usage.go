@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/config"
+	"github.com/robzolkos/claude-session-export/internal/history"
+)
+
+func runUsage(args []string) error {
+	fs := flag.NewFlagSet("usage", flag.ExitOnError)
+	weeks := fs.Int("weeks", 8, "Number of weeks to show")
+	noEmoji := fs.Bool("no-emoji", false, "Draw bars with plain ASCII")
+
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	entries, err := history.Load()
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No exports recorded yet.")
+		return nil
+	}
+
+	bar := "▇"
+	if *noEmoji || cfg.NoEmoji {
+		bar = "#"
+	}
+	printUsageReport(entries, *weeks, bar, time.Now())
+	return nil
+}
+
+// weekStart returns midnight on the Monday of t's week
+func weekStart(t time.Time) time.Time {
+	t = t.Local()
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}
+
+func printUsageReport(entries []history.Entry, weeks int, bar string, now time.Time) {
+	first := entries[0].Time.Local()
+	fmt.Printf("%d exports since %s\n", len(entries), first.Format("Jan 02 2006"))
+
+	// Exports per week, most recent first
+	fmt.Printf("\n%sExports per week%s\n", colorBold, colorReset)
+	perWeek := make(map[time.Time]int)
+	for _, e := range entries {
+		perWeek[weekStart(e.Time)]++
+	}
+	current := weekStart(now)
+	for i := 0; i < weeks; i++ {
+		week := current.AddDate(0, 0, -7*i)
+		count := perWeek[week]
+		fmt.Printf("  %s  %3d %s\n", week.Format("Jan 02"), count, strings.Repeat(bar, count))
+	}
+
+	printUsageCounts("Formats", entries, func(e history.Entry) string { return e.Format })
+	printUsageCounts("Destinations", entries, func(e history.Entry) string { return e.Destination })
+
+	// Everything that left the machine
+	var uploads []history.Entry
+	for _, e := range entries {
+		if e.Destination != history.DestinationLocal {
+			uploads = append(uploads, e)
+		}
+	}
+	fmt.Printf("\n%sUploads%s\n", colorBold, colorReset)
+	if len(uploads) == 0 {
+		fmt.Println("  None - every export stayed on this machine.")
+		return
+	}
+	for i := len(uploads) - 1; i >= 0; i-- {
+		e := uploads[i]
+		fmt.Printf("  %s%s%s  %-6s %s  %s(%s)%s\n",
+			colorDim, e.Time.Local().Format("Jan 02 2006 3:04pm"), colorReset,
+			e.Destination, e.Location,
+			colorDim, e.SessionID, colorReset)
+	}
+}
+
+func printUsageCounts(title string, entries []history.Entry, key func(history.Entry) string) {
+	counts := make(map[string]int)
+	for _, e := range entries {
+		counts[key(e)]++
+	}
+
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	fmt.Printf("\n%s%s%s\n", colorBold, title, colorReset)
+	for _, k := range keys {
+		fmt.Printf("  %-8s %d\n", k, counts[k])
+	}
+}
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+// Destinations for exports made without -o, --zip or --gist
+const (
+	DestinationLocal      = "local"
+	DestinationGist       = "gist"
+	DestinationGitLab     = "gitlab"
+	DestinationWebhook    = "webhook"
+	DestinationConfluence = "confluence"
+	DestinationZip        = "zip" // Profiles only
+)
+
+// Config holds user settings loaded from the config file
+type Config struct {
+	// Redact lists custom redaction rules applied to every export
+	Redact []RedactRule `json:"redact,omitempty"`
+
+	// DefaultDestination is "local" (write an HTML viewer, the default),
+	// "gist" (upload, as older versions did), "gitlab", "webhook" or
+	// "confluence"
+	DefaultDestination string `json:"default_destination,omitempty"`
+
+	// HTMLDir is where local HTML viewers are written (default: user cache dir)
+	HTMLDir string `json:"html_dir,omitempty"`
+
+	// Theme is the viewer color palette used when --theme isn't given
+	Theme string `json:"theme,omitempty"`
+
+	// Pricing adds to or replaces the built-in model prices used for cost
+	// estimates, in US dollars per million tokens
+	Pricing session.Pricing `json:"pricing,omitempty"`
+
+	// NoEmoji uses plain text instead of emoji in output and viewers, as
+	// if --no-emoji were always given
+	NoEmoji bool `json:"no_emoji,omitempty"`
+
+	// Header and Footer are HTML snippets added to every generated page and
+	// archive index, or @path to read one from a file
+	Header string `json:"header,omitempty"`
+	Footer string `json:"footer,omitempty"`
+
+	// CommitURLTemplate is where the viewer links commits, as with
+	// --commit-url-template
+	CommitURLTemplate string `json:"commit_url_template,omitempty"`
+
+	// EditorLinks makes file paths in viewers open in an editor, as with
+	// --editor-links
+	EditorLinks string `json:"editor_links,omitempty"`
+
+	// Profiles are named bundles of export options, chosen with --profile
+	Profiles map[string]Profile `json:"profiles,omitempty"`
+
+	// Summaries picks how sessions are titled in the picker and archives
+	Summaries Summaries `json:"summaries,omitempty"`
+
+	// Publish is where the publish command pushes the archive
+	Publish Publish `json:"publish,omitempty"`
+
+	// GitLab configures uploads with --upload gitlab
+	GitLab GitLab `json:"gitlab,omitempty"`
+
+	// Webhook configures uploads with --upload webhook
+	Webhook Webhook `json:"webhook,omitempty"`
+
+	// Confluence configures uploads with --upload confluence
+	Confluence Confluence `json:"confluence,omitempty"`
+}
+
+// Confluence says where --upload confluence creates pages. The token is
+// read from CONFLUENCE_TOKEN.
+type Confluence struct {
+	URL      string `json:"url,omitempty"`       // e.g. https://acme.atlassian.net/wiki
+	Space    string `json:"space,omitempty"`     // Space key
+	ParentID string `json:"parent_id,omitempty"` // Page to create pages under
+	User     string `json:"user,omitempty"`      // Account email, for Cloud API tokens
+}
+
+// Webhook configures where --upload webhook sends exports
+type Webhook struct {
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"` // $VARS in values are read from the environment
+	Format  string            `json:"format,omitempty"`  // jsonl (default) or zip
+}
+
+// GitLab configures snippet uploads
+type GitLab struct {
+	URL        string `json:"url,omitempty"`        // Instance; GITLAB_URL takes precedence. Default: https://gitlab.com
+	Visibility string `json:"visibility,omitempty"` // private (default), internal or public
+}
+
+// Publish configures the publish command. Flags given on the command line
+// take precedence.
+type Publish struct {
+	Repo    string `json:"repo,omitempty"`     // owner/name on GitHub
+	Branch  string `json:"branch,omitempty"`   // Default: gh-pages
+	Dir     string `json:"dir,omitempty"`      // Archive directory to publish
+	SiteURL string `json:"site_url,omitempty"` // Where the pages are served, for the sitemap. Default: the GitHub Pages URL
+	NoIndex bool   `json:"noindex,omitempty"`  // Keep the pages out of search engines
+}
+
+// Summaries configures the summary providers, tried in order until one
+// gives a title
+type Summaries struct {
+	Providers      []string `json:"providers,omitempty"` // entries, first-message, ollama, anthropic
+	OllamaURL      string   `json:"ollama_url,omitempty"`
+	OllamaModel    string   `json:"ollama_model,omitempty"`
+	AnthropicModel string   `json:"anthropic_model,omitempty"`
+}
+
+// Profile fixes the options for a common export workflow. Flags given on
+// the command line take precedence.
+type Profile struct {
+	Format       string       `json:"format,omitempty"`
+	Theme        string       `json:"theme,omitempty"`
+	Redact       []RedactRule `json:"redact,omitempty"` // Added to the global rules
+	Anonymize    bool         `json:"anonymize,omitempty"`
+	NoToolOutput bool         `json:"no_tool_output,omitempty"`
+	Destination  string       `json:"destination,omitempty"` // "local", "gist", "gitlab", "webhook", "confluence" or "zip"
+	OutputDir    string       `json:"output_dir,omitempty"`
+}
+
+// RedactRule is a user-defined redaction pattern
+type RedactRule struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// Dir returns the directory holding the tool's config and state
+func Dir() (string, error) {
+	if dir := os.Getenv("CLAUDE_SESSION_EXPORT_HOME"); dir != "" {
+		return dir, nil
+	}
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("getting config directory: %w", err)
+	}
+	return filepath.Join(base, "claude-session-export"), nil
+}
+
+// Path returns the path to the config file
+func Path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+// Load reads the config file, returning an empty config if it doesn't exist
+func Load() (*Config, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+
+	switch cfg.DefaultDestination {
+	case "", DestinationLocal, DestinationGist, DestinationGitLab, DestinationWebhook, DestinationConfluence:
+	default:
+		return nil, fmt.Errorf("config %s: default_destination must be %q, %q, %q, %q or %q", path, DestinationLocal, DestinationGist, DestinationGitLab, DestinationWebhook, DestinationConfluence)
+	}
+	for name, p := range cfg.Profiles {
+		switch p.Destination {
+		case "", DestinationLocal, DestinationGist, DestinationGitLab, DestinationWebhook, DestinationConfluence, DestinationZip:
+		default:
+			return nil, fmt.Errorf("config %s: profile %q: destination must be %q, %q, %q, %q, %q or %q", path, name, DestinationLocal, DestinationGist, DestinationGitLab, DestinationWebhook, DestinationConfluence, DestinationZip)
+		}
+	}
+	switch cfg.GitLab.Visibility {
+	case "", "private", "internal", "public":
+	default:
+		return nil, fmt.Errorf("config %s: gitlab.visibility must be private, internal or public", path)
+	}
+	switch cfg.Webhook.Format {
+	case "", "jsonl", "zip":
+	default:
+		return nil, fmt.Errorf("config %s: webhook.format must be jsonl or zip", path)
+	}
+	return &cfg, nil
+}
+
+// ProfileNames returns the configured profile names, sorted
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
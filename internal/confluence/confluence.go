@@ -0,0 +1,310 @@
+package confluence
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/normalize"
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+// ErrNoToken is returned by Publish when CONFLUENCE_TOKEN isn't set
+var ErrNoToken = errors.New("no Confluence credentials: set CONFLUENCE_TOKEN to an API token (Cloud) or personal access token (Data Center)")
+
+var (
+	ansiCodes  = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+	codeFence  = regexp.MustCompile("^\\s*(```+|~~~+)\\s*([\\w+-]*)")
+	inlineCode = regexp.MustCompile("`([^`\n]+)`")
+)
+
+var httpClient = &http.Client{Timeout: 2 * time.Minute}
+
+// HasToken reports whether CONFLUENCE_TOKEN is set
+func HasToken() bool {
+	return os.Getenv("CONFLUENCE_TOKEN") != ""
+}
+
+// Render converts a parsed session to Confluence storage format XHTML: a
+// heading per turn, prose as paragraphs, and code, tool input and tool
+// output in code macros
+func Render(sess *session.Session, id string) string {
+	doc := normalize.Build(sess, id)
+
+	var b strings.Builder
+	b.WriteString("<p>")
+	var facts []string
+	if doc.Session.Start != nil {
+		facts = append(facts, doc.Session.Start.Format("2006-01-02 15:04 MST"))
+	}
+	if doc.Session.Cwd != "" {
+		facts = append(facts, "<code>"+html.EscapeString(doc.Session.Cwd)+"</code>")
+	}
+	if doc.Session.GitBranch != "" {
+		facts = append(facts, "branch <code>"+html.EscapeString(doc.Session.GitBranch)+"</code>")
+	}
+	if len(doc.Session.Models) > 0 {
+		facts = append(facts, html.EscapeString(strings.Join(doc.Session.Models, ", ")))
+	}
+	facts = append(facts, "session "+html.EscapeString(id))
+	b.WriteString(strings.Join(facts, " · "))
+	b.WriteString("</p>\n")
+
+	for _, msg := range doc.Messages {
+		if msg.IsMeta {
+			continue
+		}
+		heading := "User"
+		if msg.Role == "assistant" {
+			heading = "Claude"
+		}
+		if msg.Timestamp != nil {
+			heading += " · " + msg.Timestamp.Format("15:04")
+		}
+		fmt.Fprintf(&b, "<h2>%s</h2>\n", html.EscapeString(heading))
+
+		for _, block := range msg.Content {
+			switch block.Type {
+			case "text":
+				writeText(&b, block.Text)
+			case "tool_use":
+				writeToolCall(&b, block.Tool)
+			case "tool_result":
+				writeResult(&b, block.Result)
+			case "image":
+				b.WriteString("<p><em>[image]</em></p>\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// writeText writes Markdown prose: fenced code becomes code macros, blank
+// lines separate paragraphs, and inline code is kept
+func writeText(b *strings.Builder, text string) {
+	var para, code []string
+	var fence, language string
+	flush := func() {
+		if len(para) == 0 {
+			return
+		}
+		var lines []string
+		for _, line := range para {
+			lines = append(lines, inlineCode.ReplaceAllString(html.EscapeString(line), "<code>$1</code>"))
+		}
+		b.WriteString("<p>" + strings.Join(lines, "<br/>") + "</p>\n")
+		para = nil
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		if fence != "" {
+			if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				writeCode(b, strings.Join(code, "\n"), language)
+				fence, code = "", nil
+				continue
+			}
+			code = append(code, line)
+			continue
+		}
+		if m := codeFence.FindStringSubmatch(line); m != nil {
+			flush()
+			fence, language = m[1], m[2]
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		para = append(para, line)
+	}
+	flush()
+	if fence != "" {
+		writeCode(b, strings.Join(code, "\n"), language)
+	}
+}
+
+func writeToolCall(b *strings.Builder, call *normalize.ToolCall) {
+	summary := session.InputSummary(call.Input)
+	fmt.Fprintf(b, "<p><strong>%s</strong>", html.EscapeString(call.Name))
+	if summary != "" && !strings.Contains(summary, "\n") {
+		fmt.Fprintf(b, " <code>%s</code>", html.EscapeString(summary))
+	}
+	b.WriteString("</p>\n")
+	if strings.Contains(summary, "\n") {
+		writeCode(b, summary, "")
+	}
+	if call.Result != nil {
+		writeResult(b, call.Result)
+	}
+}
+
+func writeResult(b *strings.Builder, result *normalize.Result) {
+	if result.IsError {
+		b.WriteString("<p><em>Failed:</em></p>\n")
+	}
+	if text := strings.TrimRight(result.Text, "\n"); text != "" {
+		writeCode(b, text, "")
+	}
+}
+
+// writeCode writes a code macro. The body is CDATA, so only "]]>" needs
+// splitting; terminal color codes are dropped.
+func writeCode(b *strings.Builder, code, language string) {
+	code = ansiCodes.ReplaceAllString(code, "")
+	b.WriteString(`<ac:structured-macro ac:name="code">`)
+	if language != "" {
+		fmt.Fprintf(b, `<ac:parameter ac:name="language">%s</ac:parameter>`, html.EscapeString(language))
+	}
+	b.WriteString("<ac:plain-text-body><![CDATA[")
+	b.WriteString(strings.ReplaceAll(code, "]]>", "]]]]><![CDATA[>"))
+	b.WriteString("]]></ac:plain-text-body></ac:structured-macro>\n")
+}
+
+// Options says where to publish
+type Options struct {
+	URL      string // Site, e.g. https://acme.atlassian.net/wiki
+	Space    string // Space key
+	ParentID string // Page to publish under; the space's top level if empty
+	User     string // Email for Cloud API tokens; empty for Data Center personal access tokens
+}
+
+// Result describes a published page
+type Result struct {
+	ID      string
+	URL     string
+	Version int
+	Created bool // False when an existing page was updated
+}
+
+type content struct {
+	ID      string `json:"id"`
+	Version struct {
+		Number int `json:"number"`
+	} `json:"version"`
+	Links struct {
+		Base  string `json:"base"`
+		WebUI string `json:"webui"`
+	} `json:"_links"`
+}
+
+// Publish creates a page with the title and storage format body in the
+// space, or updates the page already there with that title
+func Publish(title, body string, opts Options) (*Result, error) {
+	if !HasToken() {
+		return nil, ErrNoToken
+	}
+	if opts.URL == "" || opts.Space == "" {
+		return nil, errors.New("no Confluence site or space: set confluence.url and confluence.space in the config file")
+	}
+	base := strings.TrimRight(opts.URL, "/")
+
+	query := url.Values{"spaceKey": {opts.Space}, "title": {title}, "expand": {"version"}}
+	var found struct {
+		Results []content `json:"results"`
+	}
+	if err := call(opts, http.MethodGet, base+"/rest/api/content?"+query.Encode(), nil, &found); err != nil {
+		return nil, fmt.Errorf("looking for an existing page: %w", err)
+	}
+
+	page := map[string]interface{}{
+		"type":  "page",
+		"title": title,
+		"space": map[string]string{"key": opts.Space},
+		"body":  map[string]interface{}{"storage": map[string]string{"value": body, "representation": "storage"}},
+	}
+	if opts.ParentID != "" {
+		page["ancestors"] = []map[string]string{{"id": opts.ParentID}}
+	}
+
+	var saved content
+	result := &Result{Created: len(found.Results) == 0}
+	if result.Created {
+		if err := call(opts, http.MethodPost, base+"/rest/api/content", page, &saved); err != nil {
+			return nil, fmt.Errorf("creating page: %w", err)
+		}
+	} else {
+		existing := found.Results[0]
+		page["version"] = map[string]interface{}{"number": existing.Version.Number + 1, "message": "Updated by claude-session-export"}
+		if err := call(opts, http.MethodPut, base+"/rest/api/content/"+existing.ID, page, &saved); err != nil {
+			return nil, fmt.Errorf("updating page %s: %w", existing.ID, err)
+		}
+	}
+
+	result.ID = saved.ID
+	result.Version = saved.Version.Number
+	linkBase := saved.Links.Base
+	if linkBase == "" {
+		linkBase = base
+	}
+	result.URL = linkBase + saved.Links.WebUI
+	return result, nil
+}
+
+// call makes an API request, decoding the JSON reply into out
+func call(opts Options, method, endpoint string, body, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, endpoint, reqBody)
+	if err != nil {
+		return err
+	}
+	token := os.Getenv("CONFLUENCE_TOKEN")
+	if opts.User != "" {
+		req.SetBasicAuth(opts.User, token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(respBody, &apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(respBody))
+		}
+		err := fmt.Errorf("API request failed: %s: %s", resp.Status, apiErr.Message)
+		switch resp.StatusCode {
+		case http.StatusUnauthorized:
+			return fmt.Errorf("%w (check CONFLUENCE_TOKEN, and confluence.user for Cloud)", err)
+		case http.StatusForbidden, http.StatusNotFound:
+			return fmt.Errorf("%w (check the space key, parent page and that you can add pages there)", err)
+		}
+		return err
+	}
+	if out != nil {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("parsing response: %w", err)
+		}
+	}
+	return nil
+}
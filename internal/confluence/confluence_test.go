@@ -0,0 +1,115 @@
+package confluence
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+const sample = `{"type":"user","uuid":"u1","cwd":"/home/alice/app","gitBranch":"main","message":{"role":"user","content":"Why is <b> & ` + "`x`" + ` broken?"},"timestamp":"2025-01-15T10:00:00Z"}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"role":"assistant","model":"claude-sonnet-4","content":[{"type":"text","text":"Try this:\n\n` + "```go" + `\nif a < b {}\n` + "```" + `"},{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"go test"}}]},"timestamp":"2025-01-15T10:01:00Z"}
+{"type":"user","uuid":"u2","parentUuid":"a1","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"ok ]]> done","is_error":true}]},"timestamp":"2025-01-15T10:01:30Z"}
+`
+
+func TestRender(t *testing.T) {
+	sess, err := session.Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := Render(sess, "abc")
+
+	for _, want := range []string{
+		"<code>/home/alice/app</code> · branch <code>main</code>",
+		"<h2>User · 10:00</h2>\n<p>Why is &lt;b&gt; &amp; <code>x</code> broken?</p>",
+		"<h2>Claude · 10:01</h2>\n<p>Try this:</p>",
+		`<ac:parameter ac:name="language">go</ac:parameter><ac:plain-text-body><![CDATA[if a < b {}]]>`,
+		"<p><strong>Bash</strong> <code>go test</code></p>",
+		"<p><em>Failed:</em></p>",
+		"<![CDATA[ok ]]]]><![CDATA[> done]]>",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected body to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestPublish(t *testing.T) {
+	var pages []map[string]interface{}
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/wiki/rest/api/content":
+			if r.URL.Query().Get("spaceKey") != "ENG" || r.URL.Query().Get("title") != "Fix login" {
+				t.Errorf("Unexpected lookup %s", r.URL.RawQuery)
+			}
+			if len(pages) == 0 {
+				w.Write([]byte(`{"results":[]}`))
+			} else {
+				w.Write([]byte(`{"results":[{"id":"42","version":{"number":3}}]}`))
+			}
+		case r.Method == http.MethodPost && r.URL.Path == "/wiki/rest/api/content",
+			r.Method == http.MethodPut && r.URL.Path == "/wiki/rest/api/content/42":
+			var page map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&page)
+			pages = append(pages, page)
+			w.Write([]byte(`{"id":"42","version":{"number":4},"_links":{"base":"https://acme.atlassian.net/wiki","webui":"/spaces/ENG/pages/42"}}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("CONFLUENCE_TOKEN", "secret")
+	opts := Options{URL: server.URL + "/wiki/", Space: "ENG", ParentID: "7", User: "alice@acme.dev"}
+
+	result, err := Publish("Fix login", "<p>hi</p>", opts)
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if !result.Created || result.URL != "https://acme.atlassian.net/wiki/spaces/ENG/pages/42" {
+		t.Errorf("Expected a new page, got %+v", result)
+	}
+	if !strings.HasPrefix(auth, "Basic ") {
+		t.Errorf("Expected basic auth with a user, got %q", auth)
+	}
+	ancestors, _ := json.Marshal(pages[0]["ancestors"])
+	if string(ancestors) != `[{"id":"7"}]` || pages[0]["version"] != nil {
+		t.Errorf("Expected the page under the parent, got %v", pages[0])
+	}
+
+	opts.User = ""
+	result, err = Publish("Fix login", "<p>hi again</p>", opts)
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	version, _ := json.Marshal(pages[1]["version"])
+	if result.Created || !strings.Contains(string(version), `"number":4`) {
+		t.Errorf("Expected the page updated to version 4, got %+v with %s", result, version)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Expected a bearer token without a user, got %q", auth)
+	}
+}
+
+func TestPublish_Errors(t *testing.T) {
+	t.Setenv("CONFLUENCE_TOKEN", "")
+	if _, err := Publish("t", "", Options{URL: "https://x", Space: "ENG"}); err != ErrNoToken {
+		t.Errorf("Expected ErrNoToken, got %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message":"Unauthorized"}`))
+	}))
+	defer server.Close()
+	t.Setenv("CONFLUENCE_TOKEN", "wrong")
+	_, err := Publish("t", "", Options{URL: server.URL, Space: "ENG"})
+	if err == nil || !strings.Contains(err.Error(), "check CONFLUENCE_TOKEN") {
+		t.Errorf("Expected a hint about the token, got %v", err)
+	}
+}
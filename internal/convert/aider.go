@@ -0,0 +1,103 @@
+package convert
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// aiderStart opens each chat in .aider.chat.history.md; aider appends new
+// chats to the same file
+var aiderStart = regexp.MustCompile(`(?m)^# aider chat started at (\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})\s*$`)
+
+// IsAider reports whether data looks like an aider chat history
+func IsAider(data []byte) bool {
+	return aiderStart.Match(data)
+}
+
+// Aider converts each chat in an .aider.chat.history.md. Prompts are the
+// lines starting with "####", aider's own output (edits applied, commits,
+// command output) the lines starting with ">", and the rest is the model's
+// reply. Aider's output becomes a call to an "Aider" tool.
+func Aider(data []byte, name string) ([]Conversation, error) {
+	history := strings.ReplaceAll(string(data), "\r\n", "\n")
+	starts := aiderStart.FindAllStringSubmatchIndex(history, -1)
+	if len(starts) == 0 {
+		return nil, fmt.Errorf("%s has no aider chats", name)
+	}
+
+	var result []Conversation
+	for i, loc := range starts {
+		end := len(history)
+		if i+1 < len(starts) {
+			end = starts[i+1][0]
+		}
+		started, _ := time.ParseInLocation("2006-01-02 15:04:05", history[loc[2]:loc[3]], time.Local)
+		id := "aider-" + started.Format("20060102-150405")
+
+		w := newEntryWriter(id)
+		var title string
+		kind, block := "", []string{}
+		calls := 0
+		flush := func() {
+			body := strings.TrimSpace(strings.Join(block, "\n"))
+			block = block[:0]
+			if body == "" {
+				return
+			}
+			switch kind {
+			case "user":
+				if title == "" {
+					title = firstLine(body)
+				}
+				w.add("user", body, started, "")
+			case "aider":
+				calls++
+				callID := fmt.Sprintf("%s-aider-%d", id, calls)
+				w.add("assistant", []interface{}{toolUse(callID, "Aider", map[string]string{"description": firstLine(body)})}, started, "")
+				w.add("user", []interface{}{toolResult(callID, body)}, started, "")
+			default:
+				w.add("assistant", []interface{}{text(body)}, started, "")
+			}
+		}
+
+		for _, line := range strings.Split(history[loc[1]:end], "\n") {
+			lineKind, content := "assistant", line
+			switch {
+			case strings.HasPrefix(line, "#### "), line == "####":
+				lineKind, content = "user", strings.TrimPrefix(strings.TrimPrefix(line, "####"), " ")
+			case strings.HasPrefix(line, "> "), line == ">":
+				lineKind, content = "aider", strings.TrimPrefix(strings.TrimPrefix(line, ">"), " ")
+			case strings.TrimSpace(line) == "":
+				// Blank lines belong to whatever block they're in
+				lineKind = kind
+			}
+			if lineKind != kind {
+				flush()
+				kind = lineKind
+			}
+			block = append(block, content)
+		}
+		flush()
+		w.summary(title)
+
+		result = append(result, Conversation{
+			ID:       id,
+			Title:    title,
+			Updated:  started,
+			Messages: w.messages,
+			Data:     w.buf.Bytes(),
+		})
+	}
+	return result, nil
+}
+
+// firstLine returns the first line of s, shortened for a title
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	if r := []rune(line); len(r) > 80 {
+		line = string(r[:77]) + "..."
+	}
+	return line
+}
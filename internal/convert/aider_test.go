@@ -0,0 +1,64 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+const aiderHistory = `
+# aider chat started at 2024-05-01 10:00:00
+
+> /usr/local/bin/aider --model gpt-4o
+> Aider v0.50.0
+
+#### Add a --verbose flag
+#### to the CLI
+
+I'll add the flag to main.py:
+
+main.py
+` + "```python" + `
+parser.add_argument("--verbose")
+` + "```" + `
+
+> Applied edit to main.py
+> Commit 1a2b3c4 feat: Add --verbose flag
+
+# aider chat started at 2024-05-02 09:30:00
+
+#### /ask what does this do?
+
+It parses arguments.
+`
+
+func TestAider(t *testing.T) {
+	if !IsAider([]byte(aiderHistory)) || IsAider([]byte(`{"type":"user"}`)) {
+		t.Fatal("Expected only the aider history to be recognized")
+	}
+	convs, err := Aider([]byte(aiderHistory), ".aider.chat.history.md")
+	if err != nil {
+		t.Fatalf("Aider failed: %v", err)
+	}
+	if len(convs) != 2 || convs[0].ID != "aider-20240501-100000" || convs[0].Title != "Add a --verbose flag" {
+		t.Fatalf("Unexpected chats %+v", convs)
+	}
+
+	sess, err := session.Parse(convs[0].Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Startup output, prompt, reply, applied edit: two tool calls with results
+	if len(sess.Messages) != 6 {
+		t.Fatalf("Expected 6 messages, got %d", len(sess.Messages))
+	}
+	if got := session.ExtractText(&sess.Messages[2]); got != "Add a --verbose flag\nto the CLI" {
+		t.Errorf("Unexpected prompt %q", got)
+	}
+	if call := sess.Messages[4].Content[0]; call.Type != "tool_use" || call.Name != "Aider" {
+		t.Errorf("Expected aider's output as a tool call, got %+v", call)
+	}
+	if got := session.ToolResultText(sess.Messages[5].Content[0].Content); got != "Applied edit to main.py\nCommit 1a2b3c4 feat: Add --verbose flag" {
+		t.Errorf("Unexpected tool output %q", got)
+	}
+}
@@ -0,0 +1,181 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// chatGPTConversation is one conversation in the conversations.json of a
+// ChatGPT data export. Its messages form a tree, since edits and
+// regenerations branch; current_node is the end of the branch shown.
+type chatGPTConversation struct {
+	ID             string                 `json:"id"`
+	ConversationID string                 `json:"conversation_id"`
+	Title          string                 `json:"title"`
+	CreateTime     float64                `json:"create_time"`
+	UpdateTime     float64                `json:"update_time"`
+	CurrentNode    string                 `json:"current_node"`
+	Mapping        map[string]chatGPTNode `json:"mapping"`
+}
+
+type chatGPTNode struct {
+	ID      string          `json:"id"`
+	Parent  string          `json:"parent"`
+	Message *chatGPTMessage `json:"message"`
+}
+
+type chatGPTMessage struct {
+	ID     string `json:"id"`
+	Author struct {
+		Role string `json:"role"` // user, assistant, system or tool
+		Name string `json:"name"` // For tools, e.g. python or browser
+	} `json:"author"`
+	CreateTime float64 `json:"create_time"`
+	Content    struct {
+		ContentType string            `json:"content_type"`
+		Parts       []json.RawMessage `json:"parts"`
+		Text        string            `json:"text"` // code and execution_output
+		Thoughts    []struct {
+			Summary string `json:"summary"`
+			Content string `json:"content"`
+		} `json:"thoughts"`
+	} `json:"content"`
+	Recipient string `json:"recipient"`
+	Metadata  struct {
+		ModelSlug string `json:"model_slug"`
+		Hidden    bool   `json:"is_visually_hidden_from_conversation"`
+	} `json:"metadata"`
+}
+
+// IsChatGPT reports whether data looks like a ChatGPT conversations.json
+func IsChatGPT(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '[' {
+		return false
+	}
+	var probe []struct {
+		Mapping json.RawMessage `json:"mapping"`
+	}
+	return json.Unmarshal(data, &probe) == nil && len(probe) > 0 && len(probe[0].Mapping) > 0
+}
+
+// ChatGPT converts the conversations in a ChatGPT conversations.json,
+// following the branch each one was left on
+func ChatGPT(data []byte) ([]Conversation, error) {
+	var convs []chatGPTConversation
+	if err := json.Unmarshal(data, &convs); err != nil {
+		return nil, fmt.Errorf("parsing ChatGPT export: %w", err)
+	}
+
+	var result []Conversation
+	for _, c := range convs {
+		id := c.ConversationID
+		if id == "" {
+			id = c.ID
+		}
+		w := newEntryWriter(id)
+		pendingTool := "" // The call a tool's output answers
+
+		for _, node := range c.branch() {
+			msg := node.Message
+			if msg == nil || msg.Metadata.Hidden {
+				continue
+			}
+			ts := unixTime(msg.CreateTime)
+			switch msg.Author.Role {
+			case "user":
+				if s := msg.text(); s != "" {
+					w.add("user", s, ts, "")
+				}
+			case "assistant":
+				switch msg.Content.ContentType {
+				case "code":
+					// A call to a tool such as the Python sandbox
+					name := msg.Recipient
+					if name == "" || name == "all" {
+						name = "python"
+					}
+					pendingTool = msg.ID
+					w.add("assistant", []interface{}{toolUse(msg.ID, name, map[string]string{"code": msg.Content.Text})}, ts, msg.Metadata.ModelSlug)
+				case "thoughts":
+					var parts []string
+					for _, t := range msg.Content.Thoughts {
+						parts = append(parts, strings.TrimSpace(t.Summary+"\n\n"+t.Content))
+					}
+					if len(parts) > 0 {
+						w.add("assistant", []interface{}{thinking(strings.Join(parts, "\n\n"))}, ts, msg.Metadata.ModelSlug)
+					}
+				default:
+					if s := msg.text(); s != "" {
+						w.add("assistant", []interface{}{text(s)}, ts, msg.Metadata.ModelSlug)
+					}
+				}
+			case "tool":
+				output := msg.Content.Text
+				if output == "" {
+					output = msg.text()
+				}
+				if pendingTool != "" {
+					w.add("user", []interface{}{toolResult(pendingTool, output)}, ts, "")
+					pendingTool = ""
+				} else if output != "" {
+					// Browsing and plugin results have no matching call
+					w.add("user", []interface{}{toolResult(msg.ID, output)}, ts, "")
+				}
+			}
+		}
+		w.summary(c.Title)
+
+		result = append(result, Conversation{
+			ID:       id,
+			Title:    c.Title,
+			Updated:  unixTime(c.UpdateTime),
+			Messages: w.messages,
+			Data:     w.buf.Bytes(),
+		})
+	}
+	return result, nil
+}
+
+// branch returns the messages from the root to the current node
+func (c chatGPTConversation) branch() []chatGPTNode {
+	var nodes []chatGPTNode
+	seen := make(map[string]bool)
+	for id := c.CurrentNode; id != "" && !seen[id]; {
+		seen[id] = true
+		node, ok := c.Mapping[id]
+		if !ok {
+			break
+		}
+		nodes = append(nodes, node)
+		id = node.Parent
+	}
+	for i, j := 0, len(nodes)-1; i < j; i, j = i+1, j-1 {
+		nodes[i], nodes[j] = nodes[j], nodes[i]
+	}
+	return nodes
+}
+
+// text joins a message's text parts. Images and other attachments become
+// placeholders.
+func (m *chatGPTMessage) text() string {
+	var parts []string
+	for _, raw := range m.Content.Parts {
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			if s = strings.TrimSpace(s); s != "" {
+				parts = append(parts, s)
+			}
+			continue
+		}
+		var obj struct {
+			ContentType string `json:"content_type"`
+		}
+		if json.Unmarshal(raw, &obj) == nil && strings.HasPrefix(obj.ContentType, "image") {
+			parts = append(parts, "[image]")
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
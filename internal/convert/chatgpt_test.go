@@ -0,0 +1,66 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+const chatGPTExport = `[{
+  "title": "Plot sales",
+  "conversation_id": "c1",
+  "create_time": 1700000000.5,
+  "update_time": 1700000100,
+  "current_node": "n5",
+  "mapping": {
+    "root": {"id": "root", "parent": null, "message": null},
+    "n0": {"id": "n0", "parent": "root", "message": {"id": "n0", "author": {"role": "system"}, "content": {"content_type": "text", "parts": [""]}, "metadata": {"is_visually_hidden_from_conversation": true}}},
+    "n1": {"id": "n1", "parent": "n0", "message": {"id": "n1", "author": {"role": "user"}, "create_time": 1700000001, "content": {"content_type": "multimodal_text", "parts": [{"content_type": "image_asset_pointer"}, "Plot this"]}}},
+    "old": {"id": "old", "parent": "n1", "message": {"id": "old", "author": {"role": "assistant"}, "content": {"content_type": "text", "parts": ["Regenerated away"]}}},
+    "n2": {"id": "n2", "parent": "n1", "message": {"id": "n2", "author": {"role": "assistant"}, "recipient": "python", "content": {"content_type": "code", "text": "plot()"}, "metadata": {"model_slug": "gpt-4o"}}},
+    "n3": {"id": "n3", "parent": "n2", "message": {"id": "n3", "author": {"role": "tool", "name": "python"}, "content": {"content_type": "execution_output", "text": "<Figure>"}}},
+    "n5": {"id": "n5", "parent": "n3", "message": {"id": "n5", "author": {"role": "assistant"}, "create_time": 1700000050, "content": {"content_type": "text", "parts": ["Here is the plot."]}, "metadata": {"model_slug": "gpt-4o"}}}
+  }
+}]`
+
+func TestChatGPT(t *testing.T) {
+	if !IsChatGPT([]byte(chatGPTExport)) {
+		t.Fatal("Expected the export to be recognized")
+	}
+	if IsChatGPT([]byte(`[{"type":"user"}]`)) || IsChatGPT([]byte(`{"type":"user"}`)) {
+		t.Error("Expected other JSON not to be taken for a ChatGPT export")
+	}
+
+	convs, err := ChatGPT([]byte(chatGPTExport))
+	if err != nil {
+		t.Fatalf("ChatGPT failed: %v", err)
+	}
+	if len(convs) != 1 || convs[0].ID != "c1" || convs[0].Title != "Plot sales" || convs[0].Messages != 4 {
+		t.Fatalf("Unexpected conversations %+v", convs)
+	}
+	if strings.Contains(string(convs[0].Data), "Regenerated away") {
+		t.Error("Expected only the current branch")
+	}
+
+	sess, err := session.Parse(convs[0].Data)
+	if err != nil {
+		t.Fatalf("Parsing the converted session failed: %v", err)
+	}
+	if len(sess.Messages) != 4 {
+		t.Fatalf("Expected 4 messages, got %d", len(sess.Messages))
+	}
+	if got := session.ExtractText(&sess.Messages[0]); got != "[image]\n\nPlot this" {
+		t.Errorf("Unexpected prompt %q", got)
+	}
+	call := sess.Messages[1].Content[0]
+	if call.Type != "tool_use" || call.Name != "python" || string(call.Input) != `{"code":"plot()"}` || sess.Messages[1].Model != "gpt-4o" {
+		t.Errorf("Unexpected tool call %+v", sess.Messages[1])
+	}
+	if result := sess.Messages[2].Content[0]; result.Type != "tool_result" || result.ToolUseID != "n2" {
+		t.Errorf("Expected the output to answer the call, got %+v", result)
+	}
+	if sess.Metadata == nil || sess.Metadata.Title != "Plot sales" {
+		t.Errorf("Expected the title as the session's summary, got %+v", sess.Metadata)
+	}
+}
@@ -0,0 +1,294 @@
+package convert
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// codexLine is one line of a Codex CLI rollout file,
+// ~/.codex/sessions/YYYY/MM/DD/rollout-*.jsonl. Older rollouts start with
+// a bare header and have no envelope: each line is the payload itself.
+type codexLine struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+
+	ID string `json:"id"` // The old header's
+}
+
+type codexPayload struct {
+	Type string `json:"type"`
+
+	// session_meta and turn_context
+	ID    string `json:"id"`
+	Cwd   string `json:"cwd"`
+	Model string `json:"model"`
+
+	// message
+	Role    string `json:"role"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+
+	// reasoning
+	Summary []struct {
+		Text string `json:"text"`
+	} `json:"summary"`
+
+	// function_call, custom_tool_call and their outputs
+	Name      string          `json:"name"`
+	Arguments string          `json:"arguments"`
+	Input     string          `json:"input"`
+	CallID    string          `json:"call_id"`
+	Output    json.RawMessage `json:"output"`
+
+	// local_shell_call
+	Action *struct {
+		Command []string `json:"command"`
+	} `json:"action"`
+
+	// token_count events
+	Info *struct {
+		LastTokenUsage struct {
+			InputTokens       int `json:"input_tokens"`
+			CachedInputTokens int `json:"cached_input_tokens"`
+			OutputTokens      int `json:"output_tokens"`
+		} `json:"last_token_usage"`
+	} `json:"info"`
+}
+
+// IsCodex reports whether data looks like a Codex CLI rollout. The start
+// of the file is enough.
+func IsCodex(data []byte) bool {
+	// The first line can be long, so it may be cut off
+	first, _, _ := bytes.Cut(bytes.TrimSpace(data), []byte("\n"))
+	if bytes.Contains(first, []byte(`"type":"session_meta"`)) {
+		return true
+	}
+	return bytes.HasPrefix(first, []byte(`{"id":`)) && bytes.Contains(first, []byte(`"instructions":`))
+}
+
+// Codex converts a Codex CLI rollout. Shell commands become Bash calls, so
+// they render as Claude Code's do.
+func Codex(data []byte, name string) ([]Conversation, error) {
+	id := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	var cwd, model, title string
+	var updated time.Time
+
+	var lines []codexLine
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		var line codexLine
+		if err := json.Unmarshal(raw, &line); err != nil {
+			return nil, fmt.Errorf("parsing Codex CLI rollout line %d: %w", n, err)
+		}
+		if line.Payload == nil {
+			// An old rollout: the header, then bare items
+			if n == 1 && line.ID != "" {
+				id = line.ID
+				continue
+			}
+			line.Payload = append(json.RawMessage(nil), raw...)
+			line.Type = "response_item"
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading Codex CLI rollout: %w", err)
+	}
+
+	// The session's ID and directory come first, so every entry has them
+	for _, line := range lines {
+		if line.Type == "session_meta" {
+			var meta codexPayload
+			if json.Unmarshal(line.Payload, &meta) == nil {
+				if meta.ID != "" {
+					id = meta.ID
+				}
+				cwd = meta.Cwd
+			}
+			break
+		}
+	}
+	w := newEntryWriter(id)
+	w.cwd = cwd
+
+	// Items from one model response are gathered into one message, carrying
+	// the response's token usage when Codex reports it. Usage reported
+	// between responses goes on the next.
+	var blocks []interface{}
+	var blocksAt time.Time
+	var usage map[string]int
+	flush := func() {
+		if len(blocks) > 0 {
+			w.addWithUsage("assistant", blocks, blocksAt, model, usage)
+			blocks, usage = nil, nil
+		}
+	}
+	assistant := func(block map[string]interface{}, ts time.Time) {
+		if len(blocks) == 0 {
+			blocksAt = ts
+		}
+		blocks = append(blocks, block)
+	}
+
+	for _, line := range lines {
+		if !line.Timestamp.IsZero() {
+			updated = line.Timestamp
+		}
+		var p codexPayload
+		if json.Unmarshal(line.Payload, &p) != nil {
+			continue
+		}
+		switch line.Type {
+		case "turn_context":
+			if p.Model != "" {
+				model = p.Model
+			}
+			continue
+		case "event_msg":
+			if p.Type == "token_count" && p.Info != nil {
+				u := p.Info.LastTokenUsage
+				if usage == nil {
+					usage = make(map[string]int)
+				}
+				// OpenAI counts cached tokens within the input
+				usage["input_tokens"] += u.InputTokens - u.CachedInputTokens
+				usage["output_tokens"] += u.OutputTokens
+				usage["cache_read_input_tokens"] += u.CachedInputTokens
+				flush()
+			}
+			continue
+		case "response_item":
+		default:
+			continue
+		}
+
+		switch p.Type {
+		case "message":
+			var parts []string
+			for _, c := range p.Content {
+				if strings.TrimSpace(c.Text) != "" {
+					parts = append(parts, c.Text)
+				}
+			}
+			content := strings.TrimSpace(strings.Join(parts, "\n\n"))
+			if content == "" {
+				continue
+			}
+			if p.Role == "assistant" {
+				assistant(text(content), line.Timestamp)
+				continue
+			}
+			if p.Role != "user" || codexContext(content) {
+				continue
+			}
+			flush()
+			if title == "" {
+				title = firstLine(content)
+			}
+			w.add("user", content, line.Timestamp, "")
+		case "reasoning":
+			var parts []string
+			for _, s := range p.Summary {
+				parts = append(parts, s.Text)
+			}
+			if summary := strings.TrimSpace(strings.Join(parts, "\n\n")); summary != "" {
+				assistant(thinking(summary), line.Timestamp)
+			}
+		case "function_call":
+			var args map[string]interface{}
+			json.Unmarshal([]byte(p.Arguments), &args)
+			name, input := p.Name, interface{}(args)
+			if name == "shell" {
+				var shell struct {
+					Command []string `json:"command"`
+				}
+				json.Unmarshal([]byte(p.Arguments), &shell)
+				name, input = "Bash", map[string]string{"command": shellCommand(shell.Command)}
+			}
+			if input == nil {
+				input = map[string]string{"arguments": p.Arguments}
+			}
+			assistant(toolUse(p.CallID, name, input), line.Timestamp)
+		case "local_shell_call":
+			var command []string
+			if p.Action != nil {
+				command = p.Action.Command
+			}
+			assistant(toolUse(p.CallID, "Bash", map[string]string{"command": shellCommand(command)}), line.Timestamp)
+		case "custom_tool_call":
+			assistant(toolUse(p.CallID, p.Name, map[string]string{"input": p.Input}), line.Timestamp)
+		case "function_call_output", "custom_tool_call_output", "local_shell_call_output":
+			flush()
+			output, failed := codexOutput(p.Output)
+			result := toolResult(p.CallID, output)
+			if failed {
+				result["is_error"] = true
+			}
+			w.add("user", []interface{}{result}, line.Timestamp, "")
+		}
+	}
+	flush()
+	w.summary(title)
+
+	return []Conversation{{
+		ID:       id,
+		Title:    title,
+		Cwd:      cwd,
+		Updated:  updated,
+		Messages: w.messages,
+		Data:     w.buf.Bytes(),
+	}}, nil
+}
+
+// codexContext reports whether a user message is context Codex adds to
+// the conversation rather than something typed
+func codexContext(content string) bool {
+	for _, tag := range []string{"<environment_context>", "<user_instructions>", "# AGENTS.md instructions"} {
+		if strings.HasPrefix(content, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// shellCommand turns Codex's argv into a command line, unwrapping
+// bash -lc "..."
+func shellCommand(argv []string) string {
+	if len(argv) == 3 && (argv[0] == "bash" || argv[0] == "sh" || argv[0] == "zsh") && (argv[1] == "-lc" || argv[1] == "-c") {
+		return argv[2]
+	}
+	return strings.Join(argv, " ")
+}
+
+// codexOutput unwraps a tool's output, which older versions record as JSON
+// with its exit code, and reports whether the command failed
+func codexOutput(raw json.RawMessage) (string, bool) {
+	var s string
+	if json.Unmarshal(raw, &s) != nil {
+		// A structured output: keep it as it is
+		return string(raw), false
+	}
+	var wrapped struct {
+		Output   *string `json:"output"`
+		Metadata struct {
+			ExitCode int `json:"exit_code"`
+		} `json:"metadata"`
+	}
+	if json.Unmarshal([]byte(s), &wrapped) == nil && wrapped.Output != nil {
+		return *wrapped.Output, wrapped.Metadata.ExitCode != 0
+	}
+	return s, false
+}
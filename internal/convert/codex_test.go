@@ -0,0 +1,95 @@
+package convert
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+const codexRollout = `{"timestamp":"2025-09-01T10:00:00Z","type":"session_meta","payload":{"id":"0199-abc","timestamp":"2025-09-01T10:00:00Z","cwd":"/home/alice/app","originator":"codex_cli_rs"}}
+{"timestamp":"2025-09-01T10:00:00Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"<environment_context>\n  <cwd>/home/alice/app</cwd>\n</environment_context>"}]}}
+{"timestamp":"2025-09-01T10:00:01Z","type":"turn_context","payload":{"cwd":"/home/alice/app","model":"gpt-5"}}
+{"timestamp":"2025-09-01T10:00:01Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"List the files"}]}}
+{"timestamp":"2025-09-01T10:00:02Z","type":"event_msg","payload":{"type":"user_message","message":"List the files"}}
+{"timestamp":"2025-09-01T10:00:03Z","type":"response_item","payload":{"type":"reasoning","summary":[{"type":"summary_text","text":"**Listing**"}],"encrypted_content":"x"}}
+{"timestamp":"2025-09-01T10:00:03Z","type":"response_item","payload":{"type":"function_call","name":"shell","arguments":"{\"command\":[\"bash\",\"-lc\",\"ls\"]}","call_id":"call_1"}}
+{"timestamp":"2025-09-01T10:00:04Z","type":"event_msg","payload":{"type":"token_count","info":{"last_token_usage":{"input_tokens":1000,"cached_input_tokens":400,"output_tokens":50}}}}
+{"timestamp":"2025-09-01T10:00:04Z","type":"response_item","payload":{"type":"function_call_output","call_id":"call_1","output":"{\"output\":\"ls: denied\",\"metadata\":{\"exit_code\":2}}"}}
+{"timestamp":"2025-09-01T10:00:05Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"I can't list them."}]}}
+{"timestamp":"2025-09-01T10:00:05Z","type":"event_msg","payload":{"type":"token_count","info":{"last_token_usage":{"input_tokens":1100,"cached_input_tokens":1000,"output_tokens":10}}}}
+`
+
+func TestCodex(t *testing.T) {
+	if !IsCodex([]byte(codexRollout)) || !IsCodex([]byte(`{"id":"x","timestamp":"2025-05-01T10:00:00Z","instructions":null}`)) {
+		t.Fatal("Expected Codex rollouts to be recognized")
+	}
+	if IsCodex([]byte(`{"type":"user","uuid":"u1","message":{"role":"user","content":"hi"}}`)) {
+		t.Error("Expected a Claude Code session not to be taken for Codex")
+	}
+	if Detect([]byte(codexRollout)) != FormatCodex {
+		t.Error("Expected Detect to find the Codex rollout")
+	}
+
+	convs, err := Convert(FormatCodex, []byte(codexRollout), "rollout-2025-09-01T10-00-00-0199-abc.jsonl")
+	if err != nil {
+		t.Fatalf("Codex failed: %v", err)
+	}
+	c := convs[0]
+	if c.ID != "0199-abc" || c.Title != "List the files" || c.Cwd != "/home/alice/app" || c.Updated.Second() != 5 {
+		t.Errorf("Unexpected conversation %+v", c)
+	}
+
+	sess, err := session.Parse(c.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sess.Messages) != 4 {
+		t.Fatalf("Expected the prompt, call, result and reply, got %d messages", len(sess.Messages))
+	}
+	call := sess.Messages[1]
+	if call.Content[0].Type != "thinking" || call.Content[1].Name != "Bash" || string(call.Content[1].Input) != `{"command":"ls"}` {
+		t.Errorf("Unexpected call %+v", call.Content)
+	}
+	if result := sess.Messages[2].Content[0]; result.Content != "ls: denied" || !result.IsError {
+		t.Errorf("Expected the failed output, got %+v", result)
+	}
+	if sess.Metadata.Cwd != "/home/alice/app" {
+		t.Errorf("Expected the working directory kept, got %q", sess.Metadata.Cwd)
+	}
+
+	usage := session.UsageByModel(sess)["gpt-5"]
+	if usage.InputTokens != 700 || usage.CacheReadTokens != 1400 || usage.OutputTokens != 60 {
+		t.Errorf("Unexpected usage %+v", usage)
+	}
+}
+
+func TestSessionFiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CODEX_HOME", "")
+	for _, path := range []string{
+		".codex/sessions/2025/09/01/rollout-a.jsonl",
+		".codex/sessions/2025/09/01/notes.jsonl",
+		".gemini/tmp/hash/chats/session-a.json",
+		".gemini/tmp/hash/checkpoint-a.json",
+	} {
+		path = filepath.Join(home, path)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, nil, 0644)
+	}
+
+	for agent, want := range map[string]string{
+		FormatCodex:  "rollout-a.jsonl",
+		FormatGemini: "session-a.json",
+	} {
+		paths, err := SessionFiles(agent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(paths) != 1 || filepath.Base(paths[0]) != want {
+			t.Errorf("Expected %s for %s, got %v", want, agent, paths)
+		}
+	}
+}
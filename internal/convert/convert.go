@@ -0,0 +1,168 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Input formats. Claude is Claude Code's own JSONL, which needs no
+// converting.
+const (
+	FormatClaude  = "claude"
+	FormatChatGPT = "chatgpt"
+	FormatAider   = "aider"
+	FormatCursor  = "cursor"
+	FormatGemini  = "gemini"
+	FormatCodex   = "codex"
+)
+
+// Formats lists the input formats, for --input-format
+var Formats = []string{FormatClaude, FormatChatGPT, FormatAider, FormatCursor, FormatGemini, FormatCodex}
+
+// Detect guesses the format of a transcript, falling back to Claude
+func Detect(data []byte) string {
+	switch {
+	case IsChatGPT(data):
+		return FormatChatGPT
+	case IsAider(data):
+		return FormatAider
+	case IsCursor(data):
+		return FormatCursor
+	case IsGemini(data):
+		return FormatGemini
+	case IsCodex(data):
+		return FormatCodex
+	}
+	return FormatClaude
+}
+
+// Convert converts a transcript in the given format. name is the file it
+// came from, naming conversations that have no ID of their own.
+func Convert(format string, data []byte, name string) ([]Conversation, error) {
+	var convs []Conversation
+	var err error
+	switch format {
+	case FormatChatGPT:
+		convs, err = ChatGPT(data)
+	case FormatAider:
+		convs, err = Aider(data, name)
+	case FormatCursor:
+		convs, err = Cursor(data, name)
+	case FormatGemini:
+		convs, err = Gemini(data, name)
+	case FormatCodex:
+		convs, err = Codex(data, name)
+	default:
+		return nil, fmt.Errorf("can't convert %q transcripts (available: %s)", format, strings.Join(Formats[1:], ", "))
+	}
+	for i := range convs {
+		convs[i].Source = format
+	}
+	return convs, err
+}
+
+// Conversation is a transcript from another tool, rewritten as Claude Code
+// JSONL so every export format can render it
+type Conversation struct {
+	Source   string // The format it was converted from
+	ID       string
+	Title    string
+	Cwd      string // The directory it ran in, if known
+	Updated  time.Time
+	Messages int
+	Data     []byte // Claude Code JSONL
+}
+
+// entryWriter builds Claude Code JSONL, threading each entry onto the one
+// before it
+type entryWriter struct {
+	buf       bytes.Buffer
+	sessionID string
+	cwd       string // Written on each entry, when known
+	last      string
+	n         int
+	messages  int
+}
+
+func newEntryWriter(sessionID string) *entryWriter {
+	return &entryWriter{sessionID: sessionID}
+}
+
+// add writes a user or assistant message. content is a string or a list
+// of content blocks.
+func (w *entryWriter) add(role string, content interface{}, ts time.Time, model string) {
+	w.addWithUsage(role, content, ts, model, nil)
+}
+
+// addWithUsage writes a message with its token usage, keyed as Claude's
+// API reports it, so stats and reports count it
+func (w *entryWriter) addWithUsage(role string, content interface{}, ts time.Time, model string, usage map[string]int) {
+	w.n++
+	uuid := fmt.Sprintf("%s-%d", w.sessionID, w.n)
+	message := map[string]interface{}{"role": role, "content": content}
+	if model != "" {
+		message["model"] = model
+	}
+	if usage != nil {
+		message["usage"] = usage
+	}
+	entry := map[string]interface{}{
+		"type":      role,
+		"uuid":      uuid,
+		"sessionId": w.sessionID,
+		"message":   message,
+	}
+	if w.last != "" {
+		entry["parentUuid"] = w.last
+	}
+	if w.cwd != "" {
+		entry["cwd"] = w.cwd
+	}
+	if !ts.IsZero() {
+		entry["timestamp"] = ts.UTC().Format(time.RFC3339Nano)
+	}
+	w.write(entry)
+	w.last = uuid
+	w.messages++
+}
+
+// summary names the conversation, as Claude Code's summary entries do
+func (w *entryWriter) summary(title string) {
+	if title != "" {
+		w.write(map[string]interface{}{"type": "summary", "summary": title, "leafUuid": w.last})
+	}
+}
+
+func (w *entryWriter) write(entry map[string]interface{}) {
+	line, _ := json.Marshal(entry) // Only strings, numbers and maps
+	w.buf.Write(line)
+	w.buf.WriteByte('\n')
+}
+
+// text and toolUse build content blocks
+func text(s string) map[string]interface{} {
+	return map[string]interface{}{"type": "text", "text": s}
+}
+
+func toolUse(id, name string, input interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "tool_use", "id": id, "name": name, "input": input}
+}
+
+func toolResult(id, output string) map[string]interface{} {
+	return map[string]interface{}{"type": "tool_result", "tool_use_id": id, "content": output}
+}
+
+func thinking(s string) map[string]interface{} {
+	return map[string]interface{}{"type": "thinking", "thinking": s}
+}
+
+// unixTime converts the fractional Unix seconds some exports use
+func unixTime(secs float64) time.Time {
+	if secs <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(secs*float64(time.Second)))
+}
@@ -0,0 +1,100 @@
+package convert
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// cursorExported is the line under the title of a chat exported from
+// Cursor, e.g. "_Exported on 6/3/2025 at 14:02:11 GMT+2 from Cursor (1.0.0)_"
+var cursorExported = regexp.MustCompile(`(?m)^_Exported on (\d{1,2}/\d{1,2}/\d{4}) at (\d{1,2}:\d{2}:\d{2}).* from Cursor.*_\s*$`)
+
+// IsCursor reports whether data looks like a chat exported from Cursor
+func IsCursor(data []byte) bool {
+	if len(data) > 4096 {
+		data = data[:4096]
+	}
+	return cursorExported.Match(data)
+}
+
+// Cursor converts a chat exported from Cursor as Markdown: a title, then
+// turns separated by "---", each headed **User** or **Cursor**
+func Cursor(data []byte, name string) ([]Conversation, error) {
+	md := strings.ReplaceAll(string(data), "\r\n", "\n")
+	id := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+
+	var exported time.Time
+	if m := cursorExported.FindStringSubmatch(md); m != nil {
+		exported, _ = time.ParseInLocation("1/2/2006 15:04:05", m[1]+" "+m[2], time.Local)
+	}
+
+	w := newEntryWriter(id)
+	var title, role string
+	var body []string
+	flush := func() {
+		content := strings.TrimSpace(strings.Join(body, "\n\n---\n\n"))
+		body = nil
+		switch {
+		case content == "":
+		case role == "user":
+			w.add("user", content, exported, "")
+		case role == "assistant":
+			w.add("assistant", []interface{}{text(content)}, exported, "")
+		}
+	}
+
+	for _, section := range splitSections(md) {
+		section = strings.TrimSpace(section)
+		switch {
+		case strings.HasPrefix(section, "**User**"):
+			flush()
+			role = "user"
+			body = append(body, strings.TrimPrefix(section, "**User**"))
+		case strings.HasPrefix(section, "**Cursor**"):
+			flush()
+			role = "assistant"
+			body = append(body, strings.TrimPrefix(section, "**Cursor**"))
+		case role == "":
+			// The header: title and export line
+			if t, ok := strings.CutPrefix(section, "# "); ok {
+				title, _, _ = strings.Cut(t, "\n")
+				title = strings.TrimSpace(title)
+			}
+		default:
+			// A horizontal rule inside a message
+			body = append(body, section)
+		}
+	}
+	flush()
+	w.summary(title)
+
+	return []Conversation{{
+		ID:       id,
+		Title:    title,
+		Updated:  exported,
+		Messages: w.messages,
+		Data:     w.buf.Bytes(),
+	}}, nil
+}
+
+// splitSections splits Markdown at lines that are just "---", outside
+// code fences
+func splitSections(md string) []string {
+	var sections []string
+	var current []string
+	fenced := false
+	for _, line := range strings.Split(md, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenced = !fenced
+		}
+		if !fenced && strings.TrimSpace(line) == "---" {
+			sections = append(sections, strings.Join(current, "\n"))
+			current = nil
+			continue
+		}
+		current = append(current, line)
+	}
+	return append(sections, strings.Join(current, "\n"))
+}
@@ -0,0 +1,67 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+const cursorChat = `# Fix the login bug
+_Exported on 6/3/2025 at 14:02:11 GMT+2 from Cursor (1.0.0)_
+
+---
+
+**User**
+
+Why does login fail?
+
+---
+
+**Cursor**
+
+The token check is inverted:
+
+` + "```go" + `
+---
+` + "```" + `
+
+---
+
+Fixed it.
+
+---
+
+**User**
+
+Thanks
+`
+
+func TestCursor(t *testing.T) {
+	if !IsCursor([]byte(cursorChat)) || IsCursor([]byte("# Notes\n\n---\n")) {
+		t.Fatal("Expected only the Cursor export to be recognized")
+	}
+	if Detect([]byte(cursorChat)) != FormatCursor {
+		t.Error("Expected Detect to find the Cursor export")
+	}
+	convs, err := Convert(FormatCursor, []byte(cursorChat), "cursor_fix_login.md")
+	if err != nil {
+		t.Fatalf("Cursor failed: %v", err)
+	}
+	c := convs[0]
+	if c.ID != "cursor_fix_login" || c.Title != "Fix the login bug" || c.Source != FormatCursor || c.Updated.Day() != 3 {
+		t.Errorf("Unexpected conversation %+v", c)
+	}
+
+	sess, err := session.Parse(c.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sess.Messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(sess.Messages))
+	}
+	reply := session.ExtractText(&sess.Messages[1])
+	if !strings.Contains(reply, "```go\n---\n```") || !strings.HasSuffix(reply, "---\n\nFixed it.") {
+		t.Errorf("Expected rules in the reply kept, got %q", reply)
+	}
+}
@@ -0,0 +1,57 @@
+package convert
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Agents lists the tools whose own session directories can be read, for
+// report --include
+var Agents = []string{FormatCodex, FormatGemini}
+
+// SessionFiles finds an agent's session files where it keeps them: Codex
+// CLI's rollouts under $CODEX_HOME/sessions (default ~/.codex), and Gemini
+// CLI's chats under ~/.gemini/tmp/*/chats. Gemini's /chat save checkpoints
+// repeat its chats, so they're left out. A missing directory finds nothing.
+func SessionFiles(agent string) ([]string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("getting home directory: %w", err)
+	}
+
+	var root string
+	var match func(path string) bool
+	switch agent {
+	case FormatCodex:
+		codexHome := os.Getenv("CODEX_HOME")
+		if codexHome == "" {
+			codexHome = filepath.Join(home, ".codex")
+		}
+		root = filepath.Join(codexHome, "sessions")
+		match = func(path string) bool {
+			return strings.HasPrefix(filepath.Base(path), "rollout-") && strings.HasSuffix(path, ".jsonl")
+		}
+	case FormatGemini:
+		root = filepath.Join(home, ".gemini", "tmp")
+		match = func(path string) bool {
+			return filepath.Base(filepath.Dir(path)) == "chats" && strings.HasSuffix(path, ".json")
+		}
+	default:
+		return nil, fmt.Errorf("can't find %q sessions (available: %s)", agent, strings.Join(Agents, ", "))
+	}
+
+	var paths []string
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip errors
+		}
+		if !d.IsDir() && match(path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
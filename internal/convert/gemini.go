@@ -0,0 +1,262 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// geminiSession is a chat Gemini CLI records under
+// ~/.gemini/tmp/PROJECT_HASH/chats
+type geminiSession struct {
+	SessionID   string          `json:"sessionId"`
+	ProjectHash string          `json:"projectHash"`
+	StartTime   time.Time       `json:"startTime"`
+	LastUpdated time.Time       `json:"lastUpdated"`
+	Messages    []geminiMessage `json:"messages"`
+}
+
+type geminiMessage struct {
+	ID        string          `json:"id"`
+	Timestamp time.Time       `json:"timestamp"`
+	Type      string          `json:"type"` // user, gemini, info or error
+	Content   json.RawMessage `json:"content"`
+	Model     string          `json:"model"`
+	Thoughts  []struct {
+		Subject     string `json:"subject"`
+		Description string `json:"description"`
+	} `json:"thoughts"`
+	ToolCalls []struct {
+		ID     string          `json:"id"`
+		Name   string          `json:"name"`
+		Args   json.RawMessage `json:"args"`
+		Result json.RawMessage `json:"result"`
+		Status string          `json:"status"`
+	} `json:"toolCalls"`
+	Tokens *struct {
+		Input  int `json:"input"`
+		Output int `json:"output"`
+		Cached int `json:"cached"`
+	} `json:"tokens"`
+}
+
+// geminiContent is one turn of a checkpoint saved with /chat save: the
+// Gemini API's own history format
+type geminiContent struct {
+	Role  string       `json:"role"` // user or model
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text         string `json:"text"`
+	Thought      bool   `json:"thought"`
+	FunctionCall *struct {
+		ID   string          `json:"id"`
+		Name string          `json:"name"`
+		Args json.RawMessage `json:"args"`
+	} `json:"functionCall"`
+	FunctionResponse *struct {
+		ID       string          `json:"id"`
+		Name     string          `json:"name"`
+		Response json.RawMessage `json:"response"`
+	} `json:"functionResponse"`
+}
+
+// IsGemini reports whether data looks like a Gemini CLI chat or checkpoint.
+// The start of the file is enough.
+func IsGemini(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return false
+	}
+	if data[0] == '{' {
+		return bytes.Contains(data[:min(len(data), 512)], []byte(`"projectHash"`))
+	}
+	var probe []struct {
+		Role  string          `json:"role"`
+		Parts json.RawMessage `json:"parts"`
+	}
+	return data[0] == '[' && json.Unmarshal(data, &probe) == nil && len(probe) > 0 && probe[0].Role != "" && len(probe[0].Parts) > 0
+}
+
+// Gemini converts a Gemini CLI chat, or a checkpoint saved with /chat save
+func Gemini(data []byte, name string) ([]Conversation, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		return geminiCheckpoint(data, name)
+	}
+
+	var s geminiSession
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parsing Gemini CLI chat: %w", err)
+	}
+	id := s.SessionID
+	if id == "" {
+		id = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	w := newEntryWriter(id)
+	var title string
+	for _, m := range s.Messages {
+		var content string
+		json.Unmarshal(m.Content, &content)
+		content = strings.TrimSpace(content)
+
+		switch m.Type {
+		case "user":
+			if content == "" {
+				continue
+			}
+			if title == "" {
+				title = firstLine(content)
+			}
+			w.add("user", content, m.Timestamp, "")
+		case "gemini":
+			var blocks []interface{}
+			for _, t := range m.Thoughts {
+				blocks = append(blocks, thinking(strings.TrimSpace(t.Subject+"\n\n"+t.Description)))
+			}
+			if content != "" {
+				blocks = append(blocks, text(content))
+			}
+			var results []interface{}
+			for _, call := range m.ToolCalls {
+				blocks = append(blocks, toolUse(call.ID, call.Name, rawOrEmpty(call.Args)))
+				results = append(results, geminiResult(call.ID, call.Result, call.Status == "error"))
+			}
+			if len(blocks) == 0 {
+				continue
+			}
+			w.addWithUsage("assistant", blocks, m.Timestamp, m.Model, geminiUsage(m))
+			if len(results) > 0 {
+				w.add("user", results, m.Timestamp, "")
+			}
+		}
+	}
+	w.summary(title)
+
+	updated := s.LastUpdated
+	if updated.IsZero() {
+		updated = s.StartTime
+	}
+	return []Conversation{{ID: id, Title: title, Updated: updated, Messages: w.messages, Data: w.buf.Bytes()}}, nil
+}
+
+// geminiCheckpoint converts a /chat save checkpoint, which has no times
+func geminiCheckpoint(data []byte, name string) ([]Conversation, error) {
+	var turns []geminiContent
+	if err := json.Unmarshal(data, &turns); err != nil {
+		return nil, fmt.Errorf("parsing Gemini CLI checkpoint: %w", err)
+	}
+	id := strings.TrimSuffix(name, filepath.Ext(name))
+	w := newEntryWriter(id)
+	var title string
+	calls := 0
+	for _, turn := range turns {
+		var blocks []interface{}
+		for _, p := range turn.Parts {
+			switch {
+			case p.FunctionCall != nil:
+				calls++
+				callID := p.FunctionCall.ID
+				if callID == "" {
+					callID = fmt.Sprintf("%s-call-%d", id, calls)
+				}
+				blocks = append(blocks, toolUse(callID, p.FunctionCall.Name, rawOrEmpty(p.FunctionCall.Args)))
+			case p.FunctionResponse != nil:
+				callID := p.FunctionResponse.ID
+				if callID == "" {
+					callID = fmt.Sprintf("%s-call-%d", id, calls)
+				}
+				blocks = append(blocks, geminiResult(callID, p.FunctionResponse.Response, false))
+			case p.Thought && p.Text != "":
+				blocks = append(blocks, thinking(p.Text))
+			case strings.TrimSpace(p.Text) != "":
+				blocks = append(blocks, text(p.Text))
+			}
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+		role := "assistant"
+		if turn.Role == "user" {
+			role = "user"
+			if title == "" {
+				if b, ok := blocks[0].(map[string]interface{}); ok && b["type"] == "text" {
+					title = firstLine(b["text"].(string))
+				}
+			}
+		}
+		w.add(role, blocks, time.Time{}, "")
+	}
+	w.summary(title)
+	return []Conversation{{ID: id, Title: title, Messages: w.messages, Data: w.buf.Bytes()}}, nil
+}
+
+// geminiResult turns a tool's result, which Gemini CLI records as API
+// parts or {"output": ...}, into a tool_result block
+func geminiResult(id string, raw json.RawMessage, isError bool) map[string]interface{} {
+	output := geminiOutput(raw)
+	block := toolResult(id, output)
+	if isError {
+		block["is_error"] = true
+	}
+	return block
+}
+
+func geminiOutput(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var obj struct {
+		Output           *string `json:"output"`
+		Error            *string `json:"error"`
+		FunctionResponse *struct {
+			Response json.RawMessage `json:"response"`
+		} `json:"functionResponse"`
+	}
+	if json.Unmarshal(raw, &obj) == nil {
+		switch {
+		case obj.Output != nil:
+			return *obj.Output
+		case obj.Error != nil:
+			return *obj.Error
+		case obj.FunctionResponse != nil:
+			return geminiOutput(obj.FunctionResponse.Response)
+		}
+	}
+	var list []json.RawMessage
+	if json.Unmarshal(raw, &list) == nil {
+		var parts []string
+		for _, item := range list {
+			if out := geminiOutput(item); out != "" {
+				parts = append(parts, out)
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	return string(raw)
+}
+
+func geminiUsage(m geminiMessage) map[string]int {
+	if m.Tokens == nil {
+		return nil
+	}
+	// Gemini counts cached tokens within the input
+	return map[string]int{
+		"input_tokens":            m.Tokens.Input - m.Tokens.Cached,
+		"output_tokens":           m.Tokens.Output,
+		"cache_read_input_tokens": m.Tokens.Cached,
+	}
+}
+
+// rawOrEmpty keeps a tool's JSON arguments, or an empty object
+func rawOrEmpty(raw json.RawMessage) json.RawMessage {
+	if len(bytes.TrimSpace(raw)) == 0 || string(raw) == "null" {
+		return json.RawMessage("{}")
+	}
+	return raw
+}
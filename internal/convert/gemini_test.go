@@ -0,0 +1,89 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+const geminiChat = `{
+  "sessionId": "5e1f-chat",
+  "projectHash": "abc123",
+  "startTime": "2025-09-02T09:00:00Z",
+  "lastUpdated": "2025-09-02T09:05:00Z",
+  "messages": [
+    {"id": "m1", "timestamp": "2025-09-02T09:00:00Z", "type": "user", "content": "Read the README"},
+    {"id": "m2", "timestamp": "2025-09-02T09:00:05Z", "type": "gemini", "content": "", "model": "gemini-2.5-pro",
+     "thoughts": [{"subject": "Reading", "description": "Opening the file"}],
+     "toolCalls": [{"id": "read-1", "name": "read_file", "args": {"absolute_path": "/app/README.md"}, "status": "success",
+       "result": [{"functionResponse": {"id": "read-1", "name": "read_file", "response": {"output": "# App"}}}]}],
+     "tokens": {"input": 500, "output": 20, "cached": 100, "thoughts": 5, "tool": 0, "total": 525}},
+    {"id": "m3", "timestamp": "2025-09-02T09:00:09Z", "type": "gemini", "content": "It's titled App.", "model": "gemini-2.5-pro"},
+    {"id": "m4", "timestamp": "2025-09-02T09:01:00Z", "type": "info", "content": "Request cancelled."}
+  ]
+}`
+
+const geminiSaved = `[
+  {"role": "user", "parts": [{"text": "List files"}]},
+  {"role": "model", "parts": [{"text": "Listing."}, {"functionCall": {"name": "list_directory", "args": {"path": "."}}}]},
+  {"role": "user", "parts": [{"functionResponse": {"name": "list_directory", "response": {"output": "a.go"}}}]},
+  {"role": "model", "parts": [{"text": "Just a.go."}]}
+]`
+
+func TestGemini(t *testing.T) {
+	if !IsGemini([]byte(geminiChat)) || !IsGemini([]byte(geminiSaved)) {
+		t.Fatal("Expected Gemini CLI chats and checkpoints to be recognized")
+	}
+	if IsGemini([]byte(chatGPTExport)) || IsGemini([]byte(`{"type":"user"}`)) {
+		t.Error("Expected other JSON not to be taken for Gemini CLI")
+	}
+	if Detect([]byte(geminiChat)) != FormatGemini {
+		t.Error("Expected Detect to find the Gemini CLI chat")
+	}
+
+	convs, err := Convert(FormatGemini, []byte(geminiChat), "session-2025-09-02T09-00-5e1f.json")
+	if err != nil {
+		t.Fatalf("Gemini failed: %v", err)
+	}
+	c := convs[0]
+	if c.ID != "5e1f-chat" || c.Title != "Read the README" || c.Updated.Minute() != 5 || c.Messages != 4 {
+		t.Errorf("Unexpected conversation %+v", c)
+	}
+	sess, err := session.Parse(c.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := sess.Messages[1].Content
+	if call[0].Thinking != "Reading\n\nOpening the file" || call[1].Name != "read_file" || call[1].ID != "read-1" {
+		t.Errorf("Unexpected call %+v", call)
+	}
+	if result := sess.Messages[2].Content[0]; result.ToolUseID != "read-1" || result.Content != "# App" {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if usage := session.UsageByModel(sess)["gemini-2.5-pro"]; usage.InputTokens != 400 || usage.CacheReadTokens != 100 || usage.OutputTokens != 20 {
+		t.Errorf("Unexpected usage %+v", usage)
+	}
+}
+
+func TestGeminiCheckpoint(t *testing.T) {
+	convs, err := Gemini([]byte(geminiSaved), "checkpoint-fix.json")
+	if err != nil {
+		t.Fatalf("Gemini failed: %v", err)
+	}
+	c := convs[0]
+	if c.ID != "checkpoint-fix" || c.Title != "List files" {
+		t.Errorf("Unexpected conversation %+v", c)
+	}
+	sess, err := session.Parse(c.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sess.Messages) != 4 {
+		t.Fatalf("Expected 4 messages, got %d", len(sess.Messages))
+	}
+	call := sess.Messages[1].Content[1]
+	result := sess.Messages[2].Content[0]
+	if call.Name != "list_directory" || result.ToolUseID != call.ID || result.Content != "a.go" {
+		t.Errorf("Expected the result paired with its call, got %+v and %+v", call, result)
+	}
+}
@@ -0,0 +1,190 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/normalize"
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+// domain is used for addresses and message IDs. It is reserved, so nothing
+// generated here can reach a real mailbox.
+const domain = "claude-session-export.invalid"
+
+// maxReferences caps the References header of long threads: the first
+// message and the most recent ones, as RFC 5322 suggests
+const maxReferences = 10
+
+var (
+	ansiCodes = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+	fromLine  = regexp.MustCompile(`(?m)^(>*From )`)
+)
+
+var (
+	userAddress   = "User <user@" + domain + ">"
+	claudeAddress = "Claude <claude@" + domain + ">"
+)
+
+// Message is one turn of the conversation as an RFC 5322 message
+type Message struct {
+	Role string // "user" or "assistant"
+	Date time.Time
+	Data []byte
+}
+
+// turn is a run of messages from the same side
+type turn struct {
+	role     string
+	date     *time.Time
+	model    string
+	messages []normalize.Message
+}
+
+// Build renders a parsed session as a thread of messages, one per turn:
+// each prompt, then everything Claude did in reply. Replies carry
+// In-Reply-To and References, so mail clients show them as a thread.
+func Build(sess *session.Session, id string) []Message {
+	doc := normalize.Build(sess, id)
+
+	subject := session.DefaultTitle(sess)
+	if subject == "" {
+		subject = "Claude Code session " + id
+	}
+	fallback := time.Now()
+	if doc.Session.Start != nil {
+		fallback = *doc.Session.Start
+	}
+
+	var turns []*turn
+	for _, msg := range doc.Messages {
+		if msg.IsMeta {
+			continue
+		}
+		if len(turns) == 0 || turns[len(turns)-1].role != msg.Role {
+			turns = append(turns, &turn{role: msg.Role, date: msg.Timestamp})
+		}
+		t := turns[len(turns)-1]
+		t.messages = append(t.messages, msg)
+		if msg.Model != "" {
+			t.model = msg.Model
+		}
+	}
+
+	var result []Message
+	var ids []string
+	for i, t := range turns {
+		date := fallback
+		if t.date != nil {
+			date = *t.date
+		}
+		messageID := fmt.Sprintf("<%s.%d@%s>", id, i+1, domain)
+
+		var h bytes.Buffer
+		header := func(name, value string) {
+			fmt.Fprintf(&h, "%s: %s\r\n", name, value)
+		}
+		from, to := userAddress, claudeAddress
+		if t.role == "assistant" {
+			from, to = claudeAddress, userAddress
+		}
+		header("From", from)
+		header("To", to)
+		s := subject
+		if i > 0 {
+			s = "Re: " + subject
+		}
+		header("Subject", mime.QEncoding.Encode("utf-8", s))
+		header("Date", date.Format(time.RFC1123Z))
+		header("Message-ID", messageID)
+		if len(ids) > 0 {
+			header("In-Reply-To", ids[len(ids)-1])
+			refs := ids
+			if len(refs) > maxReferences {
+				refs = append([]string{ids[0]}, ids[len(ids)-maxReferences+1:]...)
+			}
+			header("References", strings.Join(refs, " "))
+		}
+		header("X-Claude-Session-Id", id)
+		if t.model != "" {
+			header("X-Claude-Model", t.model)
+		}
+		header("MIME-Version", "1.0")
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		h.WriteString("\r\n")
+
+		w := quotedprintable.NewWriter(&h)
+		w.Write([]byte(body(t.messages))) // Line breaks become CRLF
+		w.Close()
+		h.WriteString("\r\n")
+
+		result = append(result, Message{Role: t.role, Date: date, Data: h.Bytes()})
+		ids = append(ids, messageID)
+	}
+	return result
+}
+
+// body is the plain text of a turn: prose as it is, tool calls as
+// "[Name] summary" with their output indented below
+func body(messages []normalize.Message) string {
+	var b strings.Builder
+	para := func(text string) {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(text + "\n")
+	}
+	result := func(r *normalize.Result) {
+		text := strings.TrimRight(ansiCodes.ReplaceAllString(r.Text, ""), "\n")
+		if r.IsError {
+			text = "(failed) " + text
+		}
+		if text != "" {
+			b.WriteString("\n    " + strings.ReplaceAll(text, "\n", "\n    ") + "\n")
+		}
+	}
+
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			switch block.Type {
+			case "text":
+				if text := strings.TrimSpace(block.Text); text != "" {
+					para(text)
+				}
+			case "tool_use":
+				call := "[" + block.Tool.Name + "]"
+				if summary := session.InputSummary(block.Tool.Input); summary != "" {
+					call += " " + summary
+				}
+				para(call)
+				if block.Tool.Result != nil {
+					result(block.Tool.Result)
+				}
+			case "tool_result":
+				result(block.Result)
+			case "image":
+				para("[image]")
+			}
+		}
+	}
+	return b.String()
+}
+
+// Mbox joins messages into an mbox file. Lines starting with "From " are
+// quoted with ">" (mboxrd), so readers don't take them for a new message.
+func Mbox(messages []Message) []byte {
+	var b bytes.Buffer
+	for _, msg := range messages {
+		fmt.Fprintf(&b, "From claude-session-export %s\n", msg.Date.UTC().Format(time.ANSIC))
+		data := strings.ReplaceAll(string(msg.Data), "\r\n", "\n")
+		b.WriteString(fromLine.ReplaceAllString(data, ">$1"))
+		b.WriteString("\n")
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,101 @@
+package email
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+const sample = `{"type":"summary","summary":"Fix the login café"}
+{"type":"user","uuid":"u1","message":{"role":"user","content":"Why does login fail?\nFrom what I see it's the cookie."},"timestamp":"2025-01-15T10:00:00Z"}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"role":"assistant","model":"claude-sonnet-4","content":[{"type":"text","text":"Let me check."},{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"go test ./auth"}}]},"timestamp":"2025-01-15T10:01:00Z"}
+{"type":"user","uuid":"u2","parentUuid":"a1","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"FAIL\nexit 1","is_error":true}]},"timestamp":"2025-01-15T10:01:30Z"}
+{"type":"assistant","uuid":"a2","parentUuid":"u2","message":{"role":"assistant","model":"claude-sonnet-4","content":[{"type":"text","text":"The cookie is missing SameSite."}]},"timestamp":"2025-01-15T10:02:00Z"}
+{"type":"user","uuid":"u3","parentUuid":"a2","message":{"role":"user","content":"Thanks"},"timestamp":"2025-01-15T10:03:00Z"}
+`
+
+func TestBuild(t *testing.T) {
+	sess, err := session.Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := Build(sess, "abc123")
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 turns, got %d", len(messages))
+	}
+
+	var parsed []*mail.Message
+	var bodies []string
+	for _, m := range messages {
+		msg, err := mail.ReadMessage(bytes.NewReader(m.Data))
+		if err != nil {
+			t.Fatalf("Invalid message: %v\n%s", err, m.Data)
+		}
+		data, _ := io.ReadAll(msg.Body)
+		parsed = append(parsed, msg)
+		bodies = append(bodies, string(data))
+	}
+
+	if subject := decodeHeader(parsed[0].Header.Get("Subject")); subject != "Fix the login café" {
+		t.Errorf("Unexpected subject %q", subject)
+	}
+	if subject := decodeHeader(parsed[1].Header.Get("Subject")); subject != "Re: Fix the login café" {
+		t.Errorf("Unexpected reply subject %q", subject)
+	}
+	if from := parsed[1].Header.Get("From"); !strings.HasPrefix(from, "Claude <") {
+		t.Errorf("Expected Claude's turn from Claude, got %q", from)
+	}
+	if parsed[1].Header.Get("In-Reply-To") != parsed[0].Header.Get("Message-ID") {
+		t.Error("Expected the reply to point at the prompt")
+	}
+	if refs := parsed[2].Header.Get("References"); refs != parsed[0].Header.Get("Message-ID")+" "+parsed[1].Header.Get("Message-ID") {
+		t.Errorf("Unexpected References %q", refs)
+	}
+	if date, _ := parsed[1].Header.Date(); date.Format("15:04") != "10:01" {
+		t.Errorf("Expected the turn's time, got %v", date)
+	}
+	if parsed[1].Header.Get("X-Claude-Model") != "claude-sonnet-4" {
+		t.Errorf("Expected the model header, got %q", parsed[1].Header.Get("X-Claude-Model"))
+	}
+
+	// Both assistant entries and the tool result belong to one turn
+	for _, want := range []string{"Let me check.", "[Bash] go test ./auth", "    (failed) FAIL", "The cookie is missing SameSite."} {
+		if !strings.Contains(qpDecode(bodies[1]), want) {
+			t.Errorf("Expected Claude's turn to contain %q, got:\n%s", want, qpDecode(bodies[1]))
+		}
+	}
+}
+
+func TestMbox(t *testing.T) {
+	sess, _ := session.Parse([]byte(sample))
+	mbox := string(Mbox(Build(sess, "abc123")))
+
+	if n := strings.Count(mbox, "\nFrom claude-session-export ") + 1; !strings.HasPrefix(mbox, "From claude-session-export Wed Jan 15 10:00:00 2025\n") || n != 3 {
+		t.Errorf("Expected 3 separators, got %d:\n%s", n, mbox)
+	}
+	if !strings.Contains(mbox, "\n>From what I see") {
+		t.Errorf("Expected body lines starting with From to be quoted:\n%s", mbox)
+	}
+	if strings.Contains(mbox, "\r") {
+		t.Error("Expected mbox lines to end in LF")
+	}
+}
+
+func decodeHeader(s string) string {
+	out, err := new(mime.WordDecoder).DecodeHeader(s)
+	if err != nil {
+		return s
+	}
+	return out
+}
+
+func qpDecode(s string) string {
+	data, _ := io.ReadAll(quotedprintable.NewReader(strings.NewReader(s)))
+	return strings.ReplaceAll(string(data), "\r\n", "\n")
+}
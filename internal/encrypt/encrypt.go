@@ -0,0 +1,122 @@
+package encrypt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Ext is added to the names of encrypted files
+const Ext = ".age"
+
+// ErrNoAge is returned when neither age nor rage is installed
+var ErrNoAge = errors.New("encrypting needs age: install it from https://age-encryption.org (or rage)")
+
+// Spec says who can decrypt: age recipients, recipients files, or anyone
+// with a passphrase asked for on the terminal
+type Spec struct {
+	Recipients     []string // age1..., ssh-ed25519 ... or ssh-rsa ... keys
+	RecipientFiles []string
+	Passphrase     bool
+}
+
+// Parse reads an --encrypt value: "passphrase", or "age:" followed by
+// comma-separated recipients, each a public key or a recipients file
+func Parse(s string) (Spec, error) {
+	var spec Spec
+	if s == "passphrase" {
+		spec.Passphrase = true
+		return spec, nil
+	}
+	list, ok := strings.CutPrefix(s, "age:")
+	if !ok || strings.TrimSpace(list) == "" {
+		return spec, fmt.Errorf("--encrypt %q should be age:RECIPIENT or passphrase", s)
+	}
+	for _, r := range strings.Split(list, ",") {
+		r = strings.TrimSpace(r)
+		switch {
+		case r == "":
+		case strings.HasPrefix(r, "age1"), strings.HasPrefix(r, "ssh-"):
+			spec.Recipients = append(spec.Recipients, r)
+		default:
+			if _, err := os.Stat(r); err != nil {
+				return spec, fmt.Errorf("recipient %q is neither an age or SSH public key nor a readable recipients file", r)
+			}
+			spec.RecipientFiles = append(spec.RecipientFiles, r)
+		}
+	}
+	return spec, nil
+}
+
+// binary finds the age CLI, or its Rust port rage, which takes the same
+// flags
+func binary() (string, error) {
+	for _, name := range []string{"age", "rage"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", ErrNoAge
+}
+
+// Available reports whether age or rage is installed
+func Available() bool {
+	_, err := binary()
+	return err == nil
+}
+
+// args builds the age command line. Armor writes PEM-style text, for
+// destinations that only take text.
+func (s Spec) args(armor bool) []string {
+	var args []string
+	if armor {
+		args = append(args, "--armor")
+	}
+	if s.Passphrase {
+		return append(args, "--passphrase")
+	}
+	for _, r := range s.Recipients {
+		args = append(args, "--recipient", r)
+	}
+	for _, f := range s.RecipientFiles {
+		args = append(args, "--recipients-file", f)
+	}
+	return args
+}
+
+// File encrypts src to dst
+func File(src, dst string, spec Spec, armor bool) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	return run(spec.args(armor), in, dst)
+}
+
+// Bytes encrypts data to dst
+func Bytes(data []byte, dst string, spec Spec, armor bool) error {
+	return run(spec.args(armor), bytes.NewReader(data), dst)
+}
+
+// run pipes the plaintext through age into dst. Passphrases are read from
+// the terminal by age itself, never through this process.
+func run(args []string, plaintext io.Reader, dst string) error {
+	bin, err := binary()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(bin, append(args, "--output", dst)...)
+	cmd.Stdin = plaintext
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("age failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
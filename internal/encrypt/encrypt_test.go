@@ -0,0 +1,71 @@
+package encrypt
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	recipients := filepath.Join(t.TempDir(), "team.txt")
+	os.WriteFile(recipients, []byte("age1abc\n"), 0644)
+
+	spec, err := Parse("age:age1xyz, ssh-ed25519 AAAA alice," + recipients)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	args := strings.Join(spec.args(false), " ")
+	if want := "--recipient age1xyz --recipient ssh-ed25519 AAAA alice --recipients-file " + recipients; args != want {
+		t.Errorf("Expected %q, got %q", want, args)
+	}
+
+	spec, err = Parse("passphrase")
+	if err != nil || strings.Join(spec.args(true), " ") != "--armor --passphrase" {
+		t.Errorf("Expected an armored passphrase, got %v %v", spec.args(true), err)
+	}
+
+	for _, bad := range []string{"", "age:", "gpg:alice", "age:/no/such/file"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func TestFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script in place of age")
+	}
+	// A stand-in for age that records its arguments and copies stdin to
+	// the --output file
+	bin := t.TempDir()
+	log := filepath.Join(bin, "args")
+	script := "#!/bin/sh\necho \"$@\" > " + log + "\nfor a; do out=$a; done\ncat > \"$out\"\n"
+	os.WriteFile(filepath.Join(bin, "age"), []byte(script), 0755)
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "export.zip")
+	os.WriteFile(src, []byte("PK"), 0644)
+	spec, _ := Parse("age:age1xyz")
+	if err := File(src, src+Ext, spec, false); err != nil {
+		t.Fatalf("File failed: %v", err)
+	}
+	if data, _ := os.ReadFile(src + Ext); string(data) != "PK" {
+		t.Errorf("Expected the plaintext piped to age, got %q", data)
+	}
+	if args, _ := os.ReadFile(log); strings.TrimSpace(string(args)) != "--recipient age1xyz --output "+src+Ext {
+		t.Errorf("Unexpected age arguments %q", args)
+	}
+}
+
+func TestNoAge(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	if Available() {
+		t.Fatal("Expected age to be unavailable")
+	}
+	if err := Bytes([]byte("x"), filepath.Join(t.TempDir(), "x.age"), Spec{Passphrase: true}, false); err != ErrNoAge {
+		t.Errorf("Expected ErrNoAge, got %v", err)
+	}
+}
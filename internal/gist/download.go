@@ -0,0 +1,61 @@
+package gist
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// gistFiles is a gist with its files, as GET /gists/{id} returns it. The
+// API includes each file's content up to a megabyte; larger files are
+// truncated and must be fetched from raw_url.
+type gistFiles struct {
+	Files map[string]struct {
+		Content   string `json:"content"`
+		RawURL    string `json:"raw_url"`
+		Truncated bool   `json:"truncated"`
+	} `json:"files"`
+}
+
+// Download fetches a gist's files, keyed by name. Secret gists can be read
+// by anyone with the ID, so no credentials are needed; a token is used
+// when set, for its higher rate limit. It returns ErrGistNotFound if the
+// gist doesn't exist.
+func Download(id string) (map[string][]byte, error) {
+	body, err := request(http.MethodGet, "/gists/"+id, http.StatusOK, nil, token())
+	if err != nil {
+		return nil, err
+	}
+	var g gistFiles
+	if err := json.Unmarshal(body, &g); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+
+	files := make(map[string][]byte, len(g.Files))
+	for name, f := range g.Files {
+		if !f.Truncated {
+			files[name] = []byte(f.Content)
+			continue
+		}
+		data, err := downloadRaw(f.RawURL)
+		if err != nil {
+			return nil, fmt.Errorf("downloading %s: %w", name, err)
+		}
+		files[name] = data
+	}
+	return files, nil
+}
+
+// downloadRaw fetches a file's raw content
+func downloadRaw(url string) ([]byte, error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -1,16 +1,63 @@
 package gist
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/robzolkos/claude-session-export/internal/httpretry"
 )
 
+// ErrNoAuth is returned by Upload when there's no way to reach GitHub:
+// neither a token in the environment nor the gh CLI
+var ErrNoAuth = errors.New("no GitHub credentials: set GITHUB_TOKEN, or install gh from https://cli.github.com/ and run gh auth login")
+
+// DescriptionTag starts the description of every gist the CLI creates, so
+// they can be found again
+const DescriptionTag = "Claude Code Transcript"
+
+// ErrGistNotFound is returned by Update when the gist doesn't exist or
+// belongs to someone else
+var ErrGistNotFound = errors.New("gist not found")
+
+// apiURL is the GitHub API root, replaced in tests
+var apiURL = "https://api.github.com"
+
+var httpClient = httpretry.NewClient()
+
+// HasGH reports whether the gh CLI is installed
+func HasGH() bool {
+	_, err := exec.LookPath("gh")
+	return err == nil
+}
+
+// HasToken reports whether a token for UploadViaAPI is set
+func HasToken() bool {
+	return token() != ""
+}
+
+// token returns the GitHub token from the environment, checking the same
+// variables as gh
+func token() string {
+	if t := os.Getenv("GITHUB_TOKEN"); t != "" {
+		return t
+	}
+	return os.Getenv("GH_TOKEN")
+}
+
 // GistFile represents a file in a gist
 type GistFile struct {
 	Content string `json:"content"`
@@ -23,45 +70,97 @@ type GistRequest struct {
 	Files       map[string]GistFile `json:"files"`
 }
 
-// GistResponse represents a response from the GitHub API
+// GistResponse represents a gist as the GitHub API returns it
 type GistResponse struct {
-	ID      string `json:"id"`
-	HTMLURL string `json:"html_url"`
+	ID          string    `json:"id"`
+	HTMLURL     string    `json:"html_url"`
+	Description string    `json:"description"`
+	Public      bool      `json:"public"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
-// Upload uploads all files in a directory to GitHub Gist using gh CLI
-func Upload(dir string, public bool) (string, error) {
-	// Check if gh CLI is available
-	if _, err := exec.LookPath("gh"); err != nil {
-		return "", errors.New("gh CLI not found. Install from https://cli.github.com/")
+// Options describes the gist to create or update
+type Options struct {
+	Description string // Default: DescriptionTag
+	Public      bool   // Only for new gists; GitHub can't change it later
+}
+
+func (o Options) description() string {
+	if o.Description == "" {
+		return DescriptionTag
 	}
+	return o.Description
+}
 
-	// Collect all files
+// RateLimitError is returned when the API rate limit is used up for longer
+// than is worth waiting
+type RateLimitError struct {
+	Reset time.Time // Zero if GitHub didn't say
+}
+
+func (e *RateLimitError) Error() string {
+	if e.Reset.IsZero() {
+		return "GitHub API rate limit exceeded; try again later"
+	}
+	return fmt.Sprintf("GitHub API rate limit exceeded; it resets at %s", e.Reset.Local().Format("15:04"))
+}
+
+// Upload uploads all files in a directory to a GitHub Gist. It talks to the
+// API directly when GITHUB_TOKEN or GH_TOKEN is set, and otherwise falls
+// back to the gh CLI.
+func Upload(dir string, opts Options) (string, error) {
+	if HasToken() {
+		return UploadViaAPI(dir, opts)
+	}
+	if HasGH() {
+		return UploadViaGH(dir, opts)
+	}
+	return "", ErrNoAuth
+}
+
+// listFiles returns the files under dir, relative and slash-separated
+func listFiles(dir string) ([]string, error) {
 	var files []string
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 		if !info.IsDir() {
-			files = append(files, path)
+			rel, err := filepath.Rel(dir, p)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
 		}
 		return nil
 	})
 	if err != nil {
-		return "", fmt.Errorf("walking directory: %w", err)
+		return nil, fmt.Errorf("walking directory: %w", err)
 	}
-
 	if len(files) == 0 {
-		return "", errors.New("no files to upload")
+		return nil, errors.New("no files to upload")
+	}
+	return files, nil
+}
+
+// UploadViaGH uploads all files in a directory using the gh CLI
+func UploadViaGH(dir string, opts Options) (string, error) {
+	if !HasGH() {
+		return "", ErrNoAuth
+	}
+	files, err := listFiles(dir)
+	if err != nil {
+		return "", err
 	}
 
 	// Build gh gist create command (private by default)
-	args := []string{"gist", "create"}
-	if public {
+	args := []string{"gist", "create", "--desc", opts.description()}
+	if opts.Public {
 		args = append(args, "--public")
 	}
 	for _, f := range files {
-		args = append(args, f)
+		args = append(args, filepath.Join(dir, filepath.FromSlash(f)))
 	}
 
 	cmd := exec.Command("gh", args...)
@@ -89,66 +188,293 @@ func Upload(dir string, public bool) (string, error) {
 	return output, nil
 }
 
-// UploadViaAPI uploads files to GitHub Gist using the API directly
-// Requires GITHUB_TOKEN environment variable
-func UploadViaAPI(dir string, public bool) (string, error) {
-	token := os.Getenv("GITHUB_TOKEN")
-	if token == "" {
-		return "", errors.New("GITHUB_TOKEN environment variable not set")
-	}
-
-	// Collect files
-	files := make(map[string]GistFile)
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if info.IsDir() {
-			return nil
-		}
+// UploadViaAPI uploads files to GitHub Gist over HTTP, without gh, using
+// GITHUB_TOKEN or GH_TOKEN. The request body is streamed from disk, so
+// sessions of any size GitHub accepts can be uploaded.
+func UploadViaAPI(dir string, opts Options) (string, error) {
+	meta := map[string]interface{}{"description": opts.description(), "public": opts.Public}
+	return callAPI(http.MethodPost, "/gists", http.StatusCreated, dir, meta)
+}
 
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return err
-		}
+// UpdateViaAPI replaces the files and description of an existing gist over
+// HTTP, keeping its URL. Files the gist has that dir doesn't are left alone.
+func UpdateViaAPI(id, dir string, opts Options) (string, error) {
+	meta := map[string]interface{}{"description": opts.description()}
+	return callAPI(http.MethodPatch, "/gists/"+id, http.StatusOK, dir, meta)
+}
 
-		// Use relative path as filename
-		relPath, _ := filepath.Rel(dir, path)
-		files[relPath] = GistFile{Content: string(content)}
-		return nil
-	})
-	if err != nil {
-		return "", fmt.Errorf("reading files: %w", err)
+// Update replaces the files and description of an existing gist, using the
+// API when a token is set and gh otherwise. opts.Public is ignored. It
+// returns ErrGistNotFound if the gist is gone.
+func Update(id, dir string, opts Options) (string, error) {
+	if HasToken() {
+		return UpdateViaAPI(id, dir, opts)
 	}
-
-	// Create request (private by default)
-	req := GistRequest{
-		Description: "Claude Code Transcript",
-		Public:      public,
-		Files:       files,
+	if HasGH() {
+		return UpdateViaGH(id, dir, opts)
 	}
+	return "", ErrNoAuth
+}
 
-	body, err := json.Marshal(req)
+// UpdateViaGH replaces the files and description of an existing gist
+// through gh's API access, so gh's login is used
+func UpdateViaGH(id, dir string, opts Options) (string, error) {
+	if !HasGH() {
+		return "", ErrNoAuth
+	}
+	files, err := listFiles(dir)
 	if err != nil {
 		return "", err
 	}
 
-	// Use gh API command
-	cmd := exec.Command("gh", "api", "gists", "-X", "POST", "--input", "-")
-	cmd.Stdin = bytes.NewReader(body)
+	body, err := os.CreateTemp("", "claude-gist-*.json")
+	if err != nil {
+		return "", fmt.Errorf("creating request file: %w", err)
+	}
+	defer os.Remove(body.Name())
+	err = writeRequest(body, dir, files, map[string]interface{}{"description": opts.description()})
+	if closeErr := body.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("writing request file: %w", err)
+	}
 
+	cmd := exec.Command("gh", "api", "--method", "PATCH", "gists/"+id, "--input", body.Name())
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
-
 	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("API request failed: %s", stderr.String())
+		if strings.Contains(stderr.String(), "HTTP 404") {
+			return "", fmt.Errorf("%w: %s", ErrGistNotFound, id)
+		}
+		return "", fmt.Errorf("gh api failed: %s", strings.TrimSpace(stderr.String()))
 	}
 
 	var resp GistResponse
 	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
 		return "", fmt.Errorf("parsing response: %w", err)
 	}
+	return resp.HTMLURL, nil
+}
+
+// IDFromURL returns the gist ID from a gist URL, or the argument itself if
+// it is already an ID
+func IDFromURL(s string) string {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "#")
+	s, _, _ = strings.Cut(s, "?")
+	return path.Base(strings.TrimSuffix(s, "/"))
+}
 
+// callAPI sends a create or update request and returns the gist's URL
+func callAPI(method, endpoint string, want int, dir string, meta map[string]interface{}) (string, error) {
+	files, err := listFiles(dir)
+	if err != nil {
+		return "", err
+	}
+	body, err := apiCall(method, endpoint, want, func(w io.Writer) error {
+		return writeRequest(w, dir, files, meta)
+	})
+	if err != nil {
+		return "", err
+	}
+	var resp GistResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("parsing response: %w", err)
+	}
 	return resp.HTMLURL, nil
 }
+
+// apiCall makes an API request with the token from the environment,
+// retrying rate limits and server errors. body streams the request body,
+// or is nil for none.
+func apiCall(method, endpoint string, want int, body func(io.Writer) error) ([]byte, error) {
+	tok := token()
+	if tok == "" {
+		return nil, errors.New("GITHUB_TOKEN environment variable not set")
+	}
+	return request(method, endpoint, want, body, tok)
+}
+
+// request makes an API request, retrying rate limits and server errors.
+// An empty tok makes an anonymous request.
+func request(method, endpoint string, want int, body func(io.Writer) error, tok string) ([]byte, error) {
+	return httpretry.Do(func() ([]byte, error) {
+		return send(method, endpoint, want, body, tok)
+	})
+}
+
+// send makes one request, returning the response body on success
+func send(method, endpoint string, want int, body func(io.Writer) error, tok string) ([]byte, error) {
+	var reqBody io.Reader
+	if body != nil {
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(body(pw))
+		}()
+		defer pr.Close()
+		reqBody = pr
+	}
+
+	req, err := http.NewRequest(method, apiURL+endpoint, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if tok != "" {
+		req.Header.Set("Authorization", "Bearer "+tok)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode == want {
+		return respBody, nil
+	}
+	// Requests for a particular gist get 404 when it's gone
+	if resp.StatusCode == http.StatusNotFound && strings.HasPrefix(endpoint, "/gists/") {
+		return nil, fmt.Errorf("%w: %s", ErrGistNotFound, path.Base(endpoint))
+	}
+	return nil, apiError(resp, respBody)
+}
+
+// apiError turns a failed response into an error that says what to do,
+// marked for retrying when waiting may help. GitHub asks clients to wait
+// out secondary rate limits.
+func apiError(resp *http.Response, body []byte) error {
+	var apiErr struct {
+		Message string `json:"message"`
+		Errors  []struct {
+			Message string `json:"message"`
+			Code    string `json:"code"`
+			Field   string `json:"field"`
+		} `json:"errors"`
+	}
+	json.Unmarshal(body, &apiErr)
+	message := apiErr.Message
+	if message == "" {
+		message = strings.TrimSpace(string(body))
+	}
+	for _, e := range apiErr.Errors {
+		switch {
+		case e.Message != "":
+			message += "; " + e.Message
+		case e.Field != "":
+			message += fmt.Sprintf("; %s %s", e.Field, e.Code)
+		}
+	}
+	err := fmt.Errorf("API request failed: %s: %s", resp.Status, message)
+
+	// Secondary rate limits say how long to wait
+	if wait, ok := httpretry.RetryAfter(resp); ok {
+		return httpretry.After(wait, err)
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		var reset time.Time
+		if secs, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			reset = time.Unix(secs, 0)
+		}
+		rateErr := &RateLimitError{Reset: reset}
+		if reset.IsZero() {
+			return rateErr
+		}
+		return httpretry.After(time.Until(reset), rateErr)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusForbidden && strings.Contains(strings.ToLower(message), "rate limit"):
+		// A secondary rate limit without Retry-After; GitHub asks for a minute
+		return httpretry.After(time.Minute, err)
+	case resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("%w (check that the token is valid)", err)
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound:
+		// GitHub answers 404 to tokens without the gist scope
+		return fmt.Errorf("%w (check that the token has the gist scope)", err)
+	}
+	return httpretry.Status(resp, err)
+}
+
+// writeRequest writes a GistRequest as JSON, with meta's fields before the
+// files, copying each file's content straight from disk rather than holding
+// it in memory
+func writeRequest(w io.Writer, dir string, files []string, meta map[string]interface{}) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteByte('{')
+	keys := make([]string, 0, len(meta))
+	for key := range meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		name, _ := json.Marshal(key)
+		value, err := json.Marshal(meta[key])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(bw, "%s:%s,", name, value)
+	}
+	bw.WriteString(`"files":{`)
+	for i, name := range files {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		bw.Write(key)
+		bw.WriteString(`:{"content":"`)
+
+		f, err := os.Open(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return err
+		}
+		err = writeEscaped(bw, bufio.NewReader(f))
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", name, err)
+		}
+		bw.WriteString(`"}`)
+	}
+	bw.WriteString("}}")
+	return bw.Flush()
+}
+
+// writeEscaped copies r to w as the inside of a JSON string. Invalid UTF-8
+// becomes U+FFFD, as encoding/json does.
+func writeEscaped(w *bufio.Writer, r *bufio.Reader) error {
+	for {
+		c, size, err := r.ReadRune()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch {
+		case c == '"' || c == '\\':
+			w.WriteByte('\\')
+			w.WriteByte(byte(c))
+		case c == '\n':
+			w.WriteString(`\n`)
+		case c == '\r':
+			w.WriteString(`\r`)
+		case c == '\t':
+			w.WriteString(`\t`)
+		case c < 0x20:
+			fmt.Fprintf(w, `\u%04x`, c)
+		case c == utf8.RuneError && size == 1:
+			w.WriteString("\ufffd")
+		default:
+			w.WriteRune(c)
+		}
+	}
+}
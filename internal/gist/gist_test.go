@@ -0,0 +1,371 @@
+package gist
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/httpretry"
+)
+
+func TestUploadViaAPI(t *testing.T) {
+	var got GistRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/gists" || r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Unexpected request %s %s (auth %q)", r.Method, r.URL.Path, r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"abc","html_url":"https://gist.github.com/alice/abc"}`))
+	}))
+	defer server.Close()
+	apiURL = server.URL
+	t.Setenv("GITHUB_TOKEN", "secret")
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "session.jsonl"), []byte(`{"type":"user"}`), 0644)
+
+	url, err := UploadViaAPI(dir, Options{})
+	if err != nil {
+		t.Fatalf("UploadViaAPI failed: %v", err)
+	}
+	if url != "https://gist.github.com/alice/abc" {
+		t.Errorf("Expected the gist URL, got %q", url)
+	}
+	if got.Public || got.Files["session.jsonl"].Content != `{"type":"user"}` {
+		t.Errorf("Expected a secret gist with the session file, got %+v", got)
+	}
+}
+
+func TestUploadViaAPI_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message":"Bad credentials"}`))
+	}))
+	defer server.Close()
+	apiURL = server.URL
+	t.Setenv("GITHUB_TOKEN", "wrong")
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "session.jsonl"), []byte("{}"), 0644)
+
+	_, err := UploadViaAPI(dir, Options{})
+	if err == nil || !strings.Contains(err.Error(), "Bad credentials") {
+		t.Errorf("Expected GitHub's error message, got %v", err)
+	}
+}
+
+func TestUploadViaAPI_StreamsContent(t *testing.T) {
+	var got GistRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("Request body isn't valid JSON: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"abc","html_url":"https://gist.github.com/alice/abc"}`))
+	}))
+	defer server.Close()
+	apiURL = server.URL
+	t.Setenv("GITHUB_TOKEN", "secret")
+
+	content := "line \"one\"\\\n\ttab\x01 héllo </script>  " + strings.Repeat("x", 1<<20)
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "session.jsonl"), []byte(content+"\xff"), 0644)
+	os.WriteFile(filepath.Join(dir, "viewer.html"), []byte("<html>"), 0644)
+
+	if _, err := UploadViaAPI(dir, Options{Public: true}); err != nil {
+		t.Fatalf("UploadViaAPI failed: %v", err)
+	}
+	if got.Files["session.jsonl"].Content != content+"\ufffd" {
+		t.Error("Expected session content to survive encoding")
+	}
+	if got.Files["viewer.html"].Content != "<html>" || !got.Public || got.Description != DescriptionTag {
+		t.Errorf("Unexpected request %+v", got.Files["viewer.html"])
+	}
+}
+
+func TestUploadViaAPI_Retries(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		attempts++
+		switch attempts {
+		case 1:
+			w.Header().Set("Retry-After", "5")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"You have exceeded a secondary rate limit."}`))
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"abc","html_url":"https://gist.github.com/alice/abc"}`))
+		}
+	}))
+	defer server.Close()
+	apiURL = server.URL
+	t.Setenv("GITHUB_TOKEN", "secret")
+
+	var waits []time.Duration
+	httpretry.Sleep = func(d time.Duration) { waits = append(waits, d) }
+	defer func() { httpretry.Sleep = time.Sleep }()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "session.jsonl"), []byte("{}"), 0644)
+
+	url, err := UploadViaAPI(dir, Options{})
+	if err != nil {
+		t.Fatalf("UploadViaAPI failed: %v", err)
+	}
+	if url != "https://gist.github.com/alice/abc" || attempts != 3 {
+		t.Errorf("Expected success on the third attempt, got %q after %d", url, attempts)
+	}
+	if len(waits) != 2 || waits[0] != 5*time.Second {
+		t.Errorf("Expected to wait as Retry-After says, got %v", waits)
+	}
+}
+
+func TestUploadViaAPI_NoRetryAfterServerError(t *testing.T) {
+	// GitHub may have created the gist before failing, so retrying could
+	// publish a second copy
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	apiURL = server.URL
+	t.Setenv("GITHUB_TOKEN", "secret")
+
+	httpretry.Sleep = func(time.Duration) {}
+	defer func() { httpretry.Sleep = time.Sleep }()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "session.jsonl"), []byte("{}"), 0644)
+
+	if _, err := UploadViaAPI(dir, Options{}); err == nil || attempts != 1 {
+		t.Errorf("Expected the error after one attempt, got %v after %d", err, attempts)
+	}
+}
+
+func TestUploadViaAPI_RateLimited(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Unix()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"API rate limit exceeded"}`))
+	}))
+	defer server.Close()
+	apiURL = server.URL
+	t.Setenv("GITHUB_TOKEN", "secret")
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "session.jsonl"), []byte("{}"), 0644)
+
+	_, err := UploadViaAPI(dir, Options{})
+	var rateErr *RateLimitError
+	if !errors.As(err, &rateErr) || rateErr.Reset.Unix() != reset {
+		t.Errorf("Expected a RateLimitError with the reset time, got %v", err)
+	}
+}
+
+func TestUpload_PrefersToken(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = r.Header.Get("Authorization") == "Bearer from-gh-token"
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"abc","html_url":"https://gist.github.com/alice/abc"}`))
+	}))
+	defer server.Close()
+	apiURL = server.URL
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "from-gh-token")
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "session.jsonl"), []byte("{}"), 0644)
+
+	if _, err := Upload(dir, Options{}); err != nil || !called {
+		t.Errorf("Expected Upload to use the API with GH_TOKEN, got %v", err)
+	}
+
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("PATH", t.TempDir())
+	if _, err := Upload(dir, Options{}); !errors.Is(err, ErrNoAuth) {
+		t.Errorf("Expected ErrNoAuth without a token or gh, got %v", err)
+	}
+}
+
+func TestUpdateViaAPI(t *testing.T) {
+	var method, path string
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		json.NewDecoder(r.Body).Decode(&got)
+		if r.URL.Path == "/gists/gone" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Not Found"}`))
+			return
+		}
+		w.Write([]byte(`{"id":"abc","html_url":"https://gist.github.com/alice/abc"}`))
+	}))
+	defer server.Close()
+	apiURL = server.URL
+	t.Setenv("GITHUB_TOKEN", "secret")
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "session.jsonl"), []byte("{}"), 0644)
+
+	url, err := Update("abc", dir, Options{Description: "Claude Code Transcript: Fix login"})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if method != http.MethodPatch || path != "/gists/abc" || url != "https://gist.github.com/alice/abc" {
+		t.Errorf("Expected a PATCH of the gist, got %s %s -> %q", method, path, url)
+	}
+	if _, ok := got["public"]; ok || got["description"] != "Claude Code Transcript: Fix login" {
+		t.Errorf("Expected an update to send the description only, got %v", got)
+	}
+
+	if _, err := Update("gone", dir, Options{}); !errors.Is(err, ErrGistNotFound) {
+		t.Errorf("Expected ErrGistNotFound, got %v", err)
+	}
+}
+
+func TestIDFromURL(t *testing.T) {
+	for _, in := range []string{"abc123", "https://gist.github.com/alice/abc123", "https://gist.github.com/alice/abc123/", "https://gist.github.com/alice/abc123#file-session-jsonl"} {
+		if got := IDFromURL(in); got != "abc123" {
+			t.Errorf("IDFromURL(%q) = %q", in, got)
+		}
+	}
+}
+
+func TestDownload(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gists/abc":
+			if auth := r.Header.Get("Authorization"); auth != "" {
+				t.Errorf("Expected an anonymous request, got %q", auth)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"files": map[string]interface{}{
+				"session.jsonl": map[string]interface{}{"content": "", "truncated": true, "raw_url": server.URL + "/raw/session.jsonl"},
+				"notes.md":      map[string]interface{}{"content": "# Notes"},
+			}})
+		case "/raw/session.jsonl":
+			w.Write([]byte(`{"type":"user"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	apiURL = server.URL
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+
+	files, err := Download("abc")
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	if string(files["session.jsonl"]) != `{"type":"user"}` || string(files["notes.md"]) != "# Notes" {
+		t.Errorf("Expected both files, truncated ones from raw_url, got %q", files)
+	}
+
+	if _, err := Download("gone"); !errors.Is(err, ErrGistNotFound) {
+		t.Errorf("Expected ErrGistNotFound, got %v", err)
+	}
+}
+
+func TestState(t *testing.T) {
+	t.Setenv("CLAUDE_SESSION_EXPORT_HOME", t.TempDir())
+
+	state, err := LoadState()
+	if err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+	if _, ok := state.Lookup("s1"); ok {
+		t.Fatal("Expected an empty state")
+	}
+	state.Put(Record{SessionID: "s1", GistID: "abc", URL: "https://gist.github.com/alice/abc"})
+	created := state.Gists["s1"].Created
+	if err := state.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	state, err = LoadState()
+	if err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+	rec, ok := state.Lookup("s1")
+	if !ok || rec.GistID != "abc" {
+		t.Fatalf("Expected the record to be saved, got %+v", rec)
+	}
+
+	state.Put(Record{SessionID: "s1", GistID: "abc", URL: rec.URL})
+	if rec := state.Gists["s1"]; !rec.Created.Equal(created) || rec.Updated.Before(created) {
+		t.Errorf("Expected an update to keep the creation time, got %+v", rec)
+	}
+
+	state.Put(Record{SessionID: "s2", GistID: "def"})
+	if records := state.Records(); len(records) != 2 || records[0].GistID != "def" {
+		t.Errorf("Expected the latest upload first, got %+v", records)
+	}
+	state.Remove("abc")
+	if _, ok := state.Lookup("s1"); ok || len(state.Records()) != 1 {
+		t.Error("Expected Remove to forget the gist")
+	}
+}
+
+func TestListAndDelete(t *testing.T) {
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/gists":
+			if r.URL.Query().Get("page") != "1" {
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.Write([]byte(`[
+				{"id":"old","html_url":"https://gist.github.com/alice/old","description":"Claude Code Transcript","updated_at":"2025-01-01T00:00:00Z"},
+				{"id":"other","html_url":"https://gist.github.com/alice/other","description":"dotfiles","updated_at":"2025-03-01T00:00:00Z"},
+				{"id":"new","html_url":"https://gist.github.com/alice/new","description":"Claude Code Transcript: Fix login","updated_at":"2025-02-01T00:00:00Z"}
+			]`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/gists/gone":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	defer server.Close()
+	apiURL = server.URL
+	t.Setenv("GITHUB_TOKEN", "secret")
+
+	gists, err := List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(gists) != 2 || gists[0].ID != "new" || gists[1].ID != "old" {
+		t.Errorf("Expected the CLI's gists, newest first, got %+v", gists)
+	}
+
+	if err := Delete("new"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0] != "/gists/new" {
+		t.Errorf("Expected a DELETE of the gist, got %v", deleted)
+	}
+	if err := Delete("gone"); !errors.Is(err, ErrGistNotFound) {
+		t.Errorf("Expected ErrGistNotFound, got %v", err)
+	}
+}
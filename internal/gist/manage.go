@@ -0,0 +1,97 @@
+package gist
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// maxListPages caps how many pages of gists List reads
+const maxListPages = 30
+
+// List returns the account's gists created by the CLI, found by their
+// DescriptionTag, newest first. It uses the API when a token is set and gh
+// otherwise.
+func List() ([]GistResponse, error) {
+	var all []GistResponse
+	switch {
+	case HasToken():
+		for page := 1; page <= maxListPages; page++ {
+			body, err := apiCall(http.MethodGet, fmt.Sprintf("/gists?per_page=100&page=%d", page), http.StatusOK, nil)
+			if err != nil {
+				return nil, err
+			}
+			var gists []GistResponse
+			if err := json.Unmarshal(body, &gists); err != nil {
+				return nil, fmt.Errorf("parsing response: %w", err)
+			}
+			all = append(all, gists...)
+			if len(gists) < 100 {
+				break
+			}
+		}
+
+	case HasGH():
+		cmd := exec.Command("gh", "api", "gists?per_page=100", "--paginate")
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("gh api failed: %s", strings.TrimSpace(stderr.String()))
+		}
+		// --paginate prints one JSON array per page
+		dec := json.NewDecoder(&stdout)
+		for {
+			var gists []GistResponse
+			err := dec.Decode(&gists)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("parsing response: %w", err)
+			}
+			all = append(all, gists...)
+		}
+
+	default:
+		return nil, ErrNoAuth
+	}
+
+	var tagged []GistResponse
+	for _, g := range all {
+		if strings.HasPrefix(g.Description, DescriptionTag) {
+			tagged = append(tagged, g)
+		}
+	}
+	sort.Slice(tagged, func(i, j int) bool { return tagged[i].UpdatedAt.After(tagged[j].UpdatedAt) })
+	return tagged, nil
+}
+
+// Delete deletes a gist, using the API when a token is set and gh
+// otherwise. It returns ErrGistNotFound if the gist is already gone.
+func Delete(id string) error {
+	switch {
+	case HasToken():
+		_, err := apiCall(http.MethodDelete, "/gists/"+id, http.StatusNoContent, nil)
+		return err
+
+	case HasGH():
+		cmd := exec.Command("gh", "api", "--method", "DELETE", "gists/"+id)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			if strings.Contains(stderr.String(), "HTTP 404") {
+				return fmt.Errorf("%w: %s", ErrGistNotFound, id)
+			}
+			return fmt.Errorf("gh api failed: %s", strings.TrimSpace(stderr.String()))
+		}
+		return nil
+	}
+	return ErrNoAuth
+}
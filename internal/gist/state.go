@@ -0,0 +1,118 @@
+package gist
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/config"
+)
+
+// Record links a session to the gist it was uploaded to
+type Record struct {
+	SessionID string    `json:"session_id"`
+	GistID    string    `json:"gist_id"`
+	URL       string    `json:"url"`
+	Source    string    `json:"source,omitempty"`
+	Public    bool      `json:"public,omitempty"`
+	Created   time.Time `json:"created"`
+	Updated   time.Time `json:"updated"`
+}
+
+// State is the local record of gists the CLI has created, keyed by session
+// ID, so re-exporting a session updates its gist instead of adding another
+type State struct {
+	path  string
+	Gists map[string]Record
+}
+
+// StatePath returns the path to the state file
+func StatePath() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gists.json"), nil
+}
+
+// LoadState reads the state file, returning an empty state if it doesn't
+// exist
+func LoadState() (*State, error) {
+	path, err := StatePath()
+	if err != nil {
+		return nil, err
+	}
+	state := &State{path: path, Gists: make(map[string]Record)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading gist state: %w", err)
+	}
+	if err := json.Unmarshal(data, &state.Gists); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// Lookup returns the gist a session was uploaded to
+func (s *State) Lookup(sessionID string) (Record, bool) {
+	rec, ok := s.Gists[sessionID]
+	return rec, ok
+}
+
+// Put records an upload, keeping the creation time of an existing record
+// for the same gist
+func (s *State) Put(rec Record) {
+	now := time.Now()
+	if old, ok := s.Gists[rec.SessionID]; ok && old.GistID == rec.GistID {
+		rec.Created = old.Created
+	}
+	if rec.Created.IsZero() {
+		rec.Created = now
+	}
+	rec.Updated = now
+	s.Gists[rec.SessionID] = rec
+}
+
+// Remove forgets the records of a gist
+func (s *State) Remove(gistID string) {
+	for id, rec := range s.Gists {
+		if rec.GistID == gistID {
+			delete(s.Gists, id)
+		}
+	}
+}
+
+// Records returns the records, most recently updated first
+func (s *State) Records() []Record {
+	records := make([]Record, 0, len(s.Gists))
+	for _, rec := range s.Gists {
+		records = append(records, rec)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Updated.After(records[j].Updated) })
+	return records
+}
+
+// Save writes the state file
+func (s *State) Save() error {
+	data, err := json.MarshalIndent(s.Gists, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("writing gist state: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,205 @@
+package gitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/robzolkos/claude-session-export/internal/httpretry"
+)
+
+// DefaultURL is the instance used when none is configured
+const DefaultURL = "https://gitlab.com"
+
+// Snippet visibilities. Private snippets are visible only to their author,
+// internal ones to everyone signed in to the instance.
+const (
+	VisibilityPrivate  = "private"
+	VisibilityInternal = "internal"
+	VisibilityPublic   = "public"
+)
+
+// Visibilities lists the snippet visibilities GitLab accepts
+var Visibilities = []string{VisibilityPrivate, VisibilityInternal, VisibilityPublic}
+
+// ErrNoToken is returned by Upload when GITLAB_TOKEN isn't set
+var ErrNoToken = errors.New("no GitLab credentials: set GITLAB_TOKEN to a personal access token with the api scope")
+
+var httpClient = httpretry.NewClient()
+
+// HasToken reports whether GITLAB_TOKEN is set
+func HasToken() bool {
+	return os.Getenv("GITLAB_TOKEN") != ""
+}
+
+// InstanceURL picks the GitLab instance: GITLAB_URL, then the configured
+// URL, then gitlab.com
+func InstanceURL(configured string) string {
+	url := os.Getenv("GITLAB_URL")
+	if url == "" {
+		url = configured
+	}
+	if url == "" {
+		url = DefaultURL
+	}
+	return strings.TrimRight(url, "/")
+}
+
+// Options describes the snippet to create
+type Options struct {
+	URL         string // Instance; see InstanceURL
+	Title       string
+	Description string
+	Visibility  string // Default: VisibilityPrivate
+}
+
+// Snippet is a snippet as the GitLab API returns it
+type Snippet struct {
+	ID     int    `json:"id"`
+	Title  string `json:"title"`
+	WebURL string `json:"web_url"`
+	RawURL string `json:"raw_url"`
+}
+
+type snippetFile struct {
+	FilePath string `json:"file_path"`
+	Content  string `json:"content"`
+}
+
+type snippetRequest struct {
+	Title       string        `json:"title"`
+	Description string        `json:"description,omitempty"`
+	Visibility  string        `json:"visibility"`
+	Files       []snippetFile `json:"files"`
+}
+
+// Upload creates a personal snippet holding the files in dir
+func Upload(dir string, opts Options) (*Snippet, error) {
+	if !HasToken() {
+		return nil, ErrNoToken
+	}
+	if opts.URL == "" {
+		opts.URL = DefaultURL
+	}
+	if opts.Visibility == "" {
+		opts.Visibility = VisibilityPrivate
+	}
+
+	req := snippetRequest{Title: opts.Title, Description: opts.Description, Visibility: opts.Visibility}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading directory: %w", err)
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		req.Files = append(req.Files, snippetFile{FilePath: e.Name(), Content: string(data)})
+	}
+	if len(req.Files) == 0 {
+		return nil, errors.New("no files to upload")
+	}
+	sort.Slice(req.Files, func(i, j int) bool { return req.Files[i].FilePath < req.Files[j].FilePath })
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := apiCall(opts.URL, http.MethodPost, "/snippets", http.StatusCreated, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var snippet Snippet
+	if err := json.Unmarshal(respBody, &snippet); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+	if snippet.WebURL == "" {
+		return nil, errors.New("GitLab didn't return the snippet's URL")
+	}
+	return &snippet, nil
+}
+
+// DeleteCommand is a shell command that deletes a snippet, for the export
+// summary
+func DeleteCommand(instance string, id int) string {
+	return fmt.Sprintf(`curl -X DELETE -H "PRIVATE-TOKEN: $GITLAB_TOKEN" %s/api/v4/snippets/%d`, instance, id)
+}
+
+// apiCall makes a request to the instance's API, retrying rate limits and
+// server errors
+func apiCall(instance, method, endpoint string, want int, body []byte) ([]byte, error) {
+	return httpretry.Do(func() ([]byte, error) {
+		return send(instance, method, endpoint, want, body)
+	})
+}
+
+// send makes one request, returning the response body on success
+func send(instance, method, endpoint string, want int, body []byte) ([]byte, error) {
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, instance+"/api/v4"+endpoint, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("PRIVATE-TOKEN", os.Getenv("GITLAB_TOKEN"))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode == want {
+		return respBody, nil
+	}
+
+	// GitLab reports errors as {"message": ...} or {"error": ...}, where
+	// message can be a string or a map of field errors
+	var apiErr struct {
+		Message json.RawMessage `json:"message"`
+		Error   string          `json:"error"`
+	}
+	json.Unmarshal(respBody, &apiErr)
+	message := apiErr.Error
+	if len(apiErr.Message) > 0 {
+		var s string
+		if json.Unmarshal(apiErr.Message, &s) == nil {
+			message = s
+		} else {
+			message = string(apiErr.Message)
+		}
+	}
+	if message == "" {
+		message = strings.TrimSpace(string(respBody))
+	}
+	err = fmt.Errorf("API request failed: %s: %s", resp.Status, message)
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return nil, fmt.Errorf("%w (check that GITLAB_TOKEN is valid for %s)", err, instance)
+	case http.StatusForbidden:
+		return nil, fmt.Errorf("%w (check that the token has the api scope and snippets are enabled)", err)
+	}
+	return nil, httpretry.Status(resp, err)
+}
@@ -0,0 +1,121 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/httpretry"
+)
+
+func sessionDir(t *testing.T) string {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "session.jsonl"), []byte(`{"type":"user"}`), 0644)
+	return dir
+}
+
+func TestUpload(t *testing.T) {
+	var got snippetRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v4/snippets" || r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			t.Errorf("Unexpected request %s %s (token %q)", r.Method, r.URL.Path, r.Header.Get("PRIVATE-TOKEN"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":42,"web_url":"https://git.acme.dev/-/snippets/42"}`))
+	}))
+	defer server.Close()
+	t.Setenv("GITLAB_TOKEN", "secret")
+
+	snippet, err := Upload(sessionDir(t), Options{URL: server.URL, Title: "Claude Code Transcript: Fix login"})
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if snippet.ID != 42 || snippet.WebURL != "https://git.acme.dev/-/snippets/42" {
+		t.Errorf("Expected the snippet, got %+v", snippet)
+	}
+	if got.Visibility != VisibilityPrivate || got.Title != "Claude Code Transcript: Fix login" {
+		t.Errorf("Expected a private snippet with the title, got %+v", got)
+	}
+	if len(got.Files) != 1 || got.Files[0].FilePath != "session.jsonl" || got.Files[0].Content != `{"type":"user"}` {
+		t.Errorf("Expected the session file, got %+v", got.Files)
+	}
+}
+
+func TestUpload_NoToken(t *testing.T) {
+	t.Setenv("GITLAB_TOKEN", "")
+	if _, err := Upload(sessionDir(t), Options{}); !errors.Is(err, ErrNoToken) {
+		t.Errorf("Expected ErrNoToken, got %v", err)
+	}
+}
+
+func TestUpload_Errors(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   string
+	}{
+		{http.StatusUnauthorized, `{"message":"401 Unauthorized"}`, "check that GITLAB_TOKEN is valid"},
+		{http.StatusBadRequest, `{"message":{"title":["can't be blank"]}}`, `can't be blank`},
+		{http.StatusForbidden, `{"error":"insufficient_scope"}`, "insufficient_scope"},
+	}
+	t.Setenv("GITLAB_TOKEN", "secret")
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		_, err := Upload(sessionDir(t), Options{URL: server.URL})
+		server.Close()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Status %d: expected an error containing %q, got %v", tt.status, tt.want, err)
+		}
+	}
+}
+
+func TestUpload_RetriesRateLimit(t *testing.T) {
+	var waited []time.Duration
+	httpretry.Sleep = func(d time.Duration) { waited = append(waited, d) }
+	defer func() { httpretry.Sleep = time.Sleep }()
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1,"web_url":"https://gitlab.com/-/snippets/1"}`))
+	}))
+	defer server.Close()
+	t.Setenv("GITLAB_TOKEN", "secret")
+
+	if _, err := Upload(sessionDir(t), Options{URL: server.URL}); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if calls != 2 || len(waited) != 1 || waited[0] != 3*time.Second {
+		t.Errorf("Expected one retry after 3s, got %d calls and waits %v", calls, waited)
+	}
+}
+
+func TestInstanceURL(t *testing.T) {
+	t.Setenv("GITLAB_URL", "")
+	if got := InstanceURL(""); got != DefaultURL {
+		t.Errorf("Expected %s, got %s", DefaultURL, got)
+	}
+	if got := InstanceURL("https://git.acme.dev/"); got != "https://git.acme.dev" {
+		t.Errorf("Expected the configured URL, got %s", got)
+	}
+	t.Setenv("GITLAB_URL", "https://gitlab.internal")
+	if got := InstanceURL("https://git.acme.dev"); got != "https://gitlab.internal" {
+		t.Errorf("Expected GITLAB_URL to win, got %s", got)
+	}
+}
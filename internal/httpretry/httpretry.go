@@ -0,0 +1,126 @@
+package httpretry
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Retries for rate limits and server errors. Backing off starts at
+// firstWait and doubles; waits longer than MaxWait are reported instead.
+const (
+	MaxRetries = 3
+	MaxWait    = time.Minute
+	firstWait  = time.Second
+)
+
+// Sleep waits between retries, replaced in tests
+var Sleep = time.Sleep
+
+// NewClient returns a client for uploads. It has no overall timeout so
+// large uploads can finish; a stalled server is caught waiting for the
+// response headers.
+func NewClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = 2 * time.Minute
+	return &http.Client{Transport: transport}
+}
+
+// retryError is a failure worth retrying after wait, or with backoff,
+// after backing off
+type retryError struct {
+	wait    time.Duration
+	backoff bool
+	err     error
+}
+
+func (e *retryError) Error() string { return e.err.Error() }
+
+func (e *retryError) Unwrap() error { return e.err }
+
+// After marks err as worth retrying once wait has passed
+func After(wait time.Duration, err error) error {
+	return &retryError{wait: wait, err: err}
+}
+
+// Backoff marks err as worth retrying after backing off
+func Backoff(err error) error {
+	return &retryError{backoff: true, err: err}
+}
+
+// Do calls send until it succeeds or fails with an error not marked by
+// After or Backoff, at most MaxRetries times more. The last error is
+// returned unmarked.
+func Do[T any](send func() (T, error)) (T, error) {
+	for attempt := 0; ; attempt++ {
+		v, err := send()
+		if err == nil {
+			return v, nil
+		}
+		var retry *retryError
+		if !errors.As(err, &retry) {
+			return v, err
+		}
+		wait := retry.wait
+		if retry.backoff {
+			wait = firstWait << attempt
+		}
+		if attempt == MaxRetries || wait > MaxWait {
+			return v, retry.err
+		}
+		Sleep(wait)
+	}
+}
+
+// Status marks err, from a failed response, as worth retrying when the
+// status says waiting may help: after Retry-After for 429 Too Many
+// Requests, and after backing off for server errors. A POST or PATCH that
+// failed with a server error may have been carried out anyway, so it's
+// only retried when a gateway or an unavailable server turned it away.
+func Status(resp *http.Response, err error) error {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		if wait, ok := RetryAfter(resp); ok {
+			return After(wait, err)
+		}
+		return Backoff(err)
+	case resp.StatusCode >= 500 && idempotent(resp.Request):
+		return Backoff(err)
+	case resp.StatusCode == http.StatusBadGateway || resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusGatewayTimeout:
+		return Backoff(err)
+	}
+	return err
+}
+
+// idempotent reports whether sending req twice has the same effect as
+// sending it once
+func idempotent(req *http.Request) bool {
+	if req == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// RetryAfter reads a response's Retry-After header, given in seconds or
+// as a date
+func RetryAfter(resp *http.Response) (time.Duration, bool) {
+	return retryAfter(resp.Header.Get("Retry-After"), time.Now())
+}
+
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if wait := t.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return time.Second, true
+	}
+	return 0, false
+}
@@ -0,0 +1,104 @@
+package httpretry
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDo(t *testing.T) {
+	var waited []time.Duration
+	Sleep = func(d time.Duration) { waited = append(waited, d) }
+	defer func() { Sleep = time.Sleep }()
+
+	failed := errors.New("failed")
+	errs := []error{Backoff(failed), Backoff(failed), After(7*time.Second, failed), nil}
+	calls := 0
+	got, err := Do(func() (int, error) {
+		calls++
+		return calls, errs[calls-1]
+	})
+	if err != nil || got != 4 {
+		t.Fatalf("Expected success on the fourth call, got %d, %v", got, err)
+	}
+	if want := []time.Duration{time.Second, 2 * time.Second, 7 * time.Second}; fmt.Sprint(waited) != fmt.Sprint(want) {
+		t.Errorf("Waited %v, want %v", waited, want)
+	}
+
+	// Retries run out, and the error comes back unmarked
+	calls, waited = 0, nil
+	_, err = Do(func() (int, error) {
+		calls++
+		return 0, Backoff(failed)
+	})
+	if err != failed || calls != MaxRetries+1 {
+		t.Errorf("Expected the error after %d calls, got %v after %d", MaxRetries+1, err, calls)
+	}
+
+	// Errors not worth retrying, and waits too long, are returned at once
+	calls = 0
+	for _, e := range []error{failed, After(time.Hour, failed)} {
+		if _, err := Do(func() (int, error) { calls++; return 0, e }); err != failed {
+			t.Errorf("Expected %v, got %v", failed, err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Expected no retries, got %d calls", calls)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	failed := errors.New("failed")
+	tests := []struct {
+		method     string
+		status     int
+		retryAfter string
+		wait       time.Duration
+		backoff    bool
+	}{
+		{http.MethodPost, http.StatusTooManyRequests, "3", 3 * time.Second, false},
+		{http.MethodPost, http.StatusTooManyRequests, "", 0, true},
+		{http.MethodGet, http.StatusBadGateway, "", 0, true},
+		{http.MethodGet, http.StatusInternalServerError, "", 0, true},
+		{http.MethodGet, http.StatusNotFound, "", 0, false},
+		// A POST may have gone through, unless a gateway turned it away
+		{http.MethodPost, http.StatusInternalServerError, "", 0, false},
+		{http.MethodPatch, http.StatusInternalServerError, "", 0, false},
+		{http.MethodPost, http.StatusBadGateway, "", 0, true},
+		{http.MethodPost, http.StatusServiceUnavailable, "", 0, true},
+		{http.MethodPost, http.StatusGatewayTimeout, "", 0, true},
+	}
+	for _, tt := range tests {
+		req := &http.Request{Method: tt.method}
+		resp := &http.Response{StatusCode: tt.status, Header: http.Header{}, Request: req}
+		if tt.retryAfter != "" {
+			resp.Header.Set("Retry-After", tt.retryAfter)
+		}
+		err := Status(resp, failed)
+		var retry *retryError
+		if !errors.As(err, &retry) {
+			if tt.wait != 0 || tt.backoff {
+				t.Errorf("%s status %d: expected a retry, got %v", tt.method, tt.status, err)
+			}
+			continue
+		}
+		if retry.wait != tt.wait || retry.backoff != tt.backoff {
+			t.Errorf("%s status %d: got wait %v backoff %v, want %v %v", tt.method, tt.status, retry.wait, retry.backoff, tt.wait, tt.backoff)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	if wait, ok := retryAfter("30", now); !ok || wait != 30*time.Second {
+		t.Errorf("Got %v, %v for seconds", wait, ok)
+	}
+	if wait, ok := retryAfter("Wed, 01 Jan 2025 12:00:45 GMT", now); !ok || wait != 45*time.Second {
+		t.Errorf("Got %v, %v for a date", wait, ok)
+	}
+	if _, ok := retryAfter("", now); ok {
+		t.Error("Expected no wait without the header")
+	}
+}
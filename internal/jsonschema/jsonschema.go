@@ -0,0 +1,136 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// For returns the JSON schema for t as encoding/json would encode it,
+// registering named structs in defs under Name and referring to them by
+// refPrefix plus that name, e.g. "#/$defs/"
+func For(t reflect.Type, defs map[string]interface{}, refPrefix string) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return map[string]interface{}{} // Any JSON value
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return For(t.Elem(), defs, refPrefix)
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": For(t.Elem(), defs, refPrefix)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": For(t.Elem(), defs, refPrefix)}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Struct:
+		// Anonymous structs have no name to refer to
+		if t.Name() == "" {
+			return object(t, defs, refPrefix)
+		}
+		name := Name(t)
+		ref := map[string]interface{}{"$ref": refPrefix + name}
+		if _, ok := defs[name]; ok {
+			return ref
+		}
+		// Reserve the name first so recursive types terminate
+		defs[name] = nil
+		defs[name] = object(t, defs, refPrefix)
+		return ref
+	}
+	return map[string]interface{}{}
+}
+
+// Name is the name t is registered under in defs: its package path and
+// name, with dots for slashes so it can follow a JSON pointer, e.g.
+// "example.com.app.session.Message"
+func Name(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.PkgPath() == "" {
+		return t.Name()
+	}
+	return strings.ReplaceAll(t.PkgPath(), "/", ".") + "." + t.Name()
+}
+
+// object returns the schema for a struct's fields
+func object(t reflect.Type, defs map[string]interface{}, refPrefix string) map[string]interface{} {
+	props := make(map[string]interface{})
+	var required []string
+	addFields(t, defs, refPrefix, props, &required)
+
+	schema := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// addFields adds t's fields to props. The fields of untagged embedded
+// structs are promoted, as encoding/json does, unless t has a field of the
+// same name itself.
+func addFields(t reflect.Type, defs map[string]interface{}, refPrefix string, props map[string]interface{}, required *[]string) {
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = For(f.Type, defs, refPrefix)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+
+	for _, et := range embedded {
+		inner := make(map[string]interface{})
+		var innerRequired []string
+		addFields(et, defs, refPrefix, inner, &innerRequired)
+		for name, schema := range inner {
+			if _, ok := props[name]; !ok {
+				props[name] = schema
+			}
+		}
+		for _, name := range innerRequired {
+			if !slices.Contains(*required, name) {
+				*required = append(*required, name)
+			}
+		}
+	}
+}
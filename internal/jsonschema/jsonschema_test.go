@@ -0,0 +1,126 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type Base struct {
+	ID      string `json:"id"`
+	Comment string `json:"comment,omitempty"`
+	Name    string `json:"base_name"`
+}
+
+type Node struct {
+	Base
+	Name     string          `json:"name"` // Wins over Base's field of the same JSON name
+	Children []*Node         `json:"children,omitempty"`
+	Labels   map[string]int  `json:"labels"`
+	Created  time.Time       `json:"created"`
+	Extra    json.RawMessage `json:"extra,omitempty"`
+	Dash     string          `json:"-,"`
+	Skipped  string          `json:"-"`
+	Inline   struct {
+		Ratio float64 `json:"ratio"`
+	} `json:"inline"`
+	Tagged Base `json:"tagged,omitempty"`
+	hidden string
+}
+
+// URL has the same name as url.URL, in another package
+type URL struct {
+	Link  string  `json:"link"`
+	Other url.URL `json:"other"`
+}
+
+func TestFor(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"string", "", `{"type":"string"}`},
+		{"bool", false, `{"type":"boolean"}`},
+		{"int", int64(0), `{"type":"integer"}`},
+		{"float", 0.5, `{"type":"number"}`},
+		{"time", time.Time{}, `{"format":"date-time","type":"string"}`},
+		{"raw", json.RawMessage{}, `{}`},
+		{"slice", []string{}, `{"items":{"type":"string"},"type":"array"}`},
+		{"map", map[string]bool{}, `{"additionalProperties":{"type":"boolean"},"type":"object"}`},
+		{"pointer", new(int), `{"type":"integer"}`},
+		{"anonymous struct", struct {
+			A string `json:"a,omitempty"`
+		}{}, `{"properties":{"a":{"type":"string"}},"type":"object"}`},
+		{"named struct", Base{}, `{"$ref":"#/$defs/` + Name(reflect.TypeOf(Base{})) + `"}`},
+	}
+	for _, tt := range tests {
+		got, _ := json.Marshal(For(reflect.TypeOf(tt.v), map[string]interface{}{}, "#/$defs/"))
+		if string(got) != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestForStruct(t *testing.T) {
+	defs := make(map[string]interface{})
+	For(reflect.TypeOf(Node{}), defs, "#/$defs/")
+
+	node, _ := defs[Name(reflect.TypeOf(Node{}))].(map[string]interface{})
+	if node == nil {
+		t.Fatalf("Expected Node under its package path, got %v", defs)
+	}
+	props := node["properties"].(map[string]interface{})
+	tests := []struct {
+		field string
+		want  string // Empty for no property
+	}{
+		// Embedded fields are promoted
+		{"id", `{"type":"string"}`},
+		{"comment", `{"type":"string"}`},
+		{"base_name", `{"type":"string"}`},
+		{"name", `{"type":"string"}`},
+		// Recursive types refer back to their definition
+		{"children", `{"items":{"$ref":"#/$defs/` + Name(reflect.TypeOf(Node{})) + `"},"type":"array"}`},
+		{"labels", `{"additionalProperties":{"type":"integer"},"type":"object"}`},
+		{"created", `{"format":"date-time","type":"string"}`},
+		{"extra", `{}`},
+		{"-", `{"type":"string"}`},
+		{"inline", `{"properties":{"ratio":{"type":"number"}},"required":["ratio"],"type":"object"}`},
+		{"tagged", `{"$ref":"#/$defs/` + Name(reflect.TypeOf(Base{})) + `"}`},
+		{"Base", ""},
+		{"Skipped", ""},
+		{"hidden", ""},
+	}
+	for _, tt := range tests {
+		prop, ok := props[tt.field]
+		if tt.want == "" {
+			if ok {
+				t.Errorf("Expected no %s property, got %v", tt.field, prop)
+			}
+			continue
+		}
+		got, _ := json.Marshal(prop)
+		if string(got) != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.field, got, tt.want)
+		}
+	}
+
+	want := []string{"name", "labels", "created", "-", "inline", "id", "base_name"}
+	if got := node["required"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected required %v, got %v", want, got)
+	}
+}
+
+func TestForKeysByPackage(t *testing.T) {
+	defs := make(map[string]interface{})
+	For(reflect.TypeOf(URL{}), defs, "#/$defs/")
+
+	for _, name := range []string{"github.com.robzolkos.claude-session-export.internal.jsonschema.URL", "net.url.URL"} {
+		if defs[name] == nil {
+			t.Errorf("Expected a definition for %s, got %v", name, defs)
+		}
+	}
+}
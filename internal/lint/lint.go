@@ -0,0 +1,326 @@
+package lint
+
+import (
+	"archive/zip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+// Severities. Errors fail the lint; warnings are only reported.
+const (
+	Error   = "error"
+	Warning = "warning"
+)
+
+// Check names
+const (
+	CheckOversized     = "oversized"
+	CheckBrokenLink    = "broken-link"
+	CheckMissingAnchor = "missing-anchor"
+	CheckUnescaped     = "unescaped-content"
+	CheckEmbeddedData  = "embedded-data"
+	CheckDeadCommitURL = "dead-commit-url"
+)
+
+// DefaultMaxPageSize is the largest page that passes without --max-size
+const DefaultMaxPageSize = 10 << 20
+
+// Issue is one problem found in an export
+type Issue struct {
+	File     string `json:"file"`
+	Check    string `json:"check"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// Options controls which checks run
+type Options struct {
+	MaxPageSize int64 // Default: DefaultMaxPageSize
+
+	// CheckURLs requests each commit URL, which needs network access and
+	// fails for private repositories viewed without credentials
+	CheckURLs bool
+	Client    *http.Client
+}
+
+var (
+	scriptPattern    = regexp.MustCompile(`(?is)<script\b[^>]*>.*?</script\s*>`)
+	scriptOpen       = regexp.MustCompile(`(?i)<script\b`)
+	scriptClose      = regexp.MustCompile(`(?i)</script\s*>`)
+	attrPattern      = regexp.MustCompile(`(?i)\s(href|src)\s*=\s*"([^"]*)"`)
+	idPattern        = regexp.MustCompile(`(?i)\s(?:id|name)\s*=\s*"([^"]*)"`)
+	titlePattern     = regexp.MustCompile(`(?is)<title>(.*?)</title>`)
+	embeddedPattern  = regexp.MustCompile(`atob\("([A-Za-z0-9+/=]*)"\)`)
+	commitURLPattern = regexp.MustCompile(`https://github\.com/[\w.-]+/[\w.-]+/commit/[0-9a-f]{7,40}`)
+)
+
+// Check lints an export: a viewer HTML file, a zip, or a directory of
+// pages
+func Check(target string, opts Options) ([]Issue, error) {
+	if opts.MaxPageSize <= 0 {
+		opts.MaxPageSize = DefaultMaxPageSize
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 15 * time.Second}
+	}
+
+	files, err := readExport(target)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []Issue
+	commitURLs := make(map[string]string) // URL -> first file using it
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		data := files[name]
+		if !isPage(name) {
+			continue
+		}
+		add := func(check, severity, format string, args ...interface{}) {
+			issues = append(issues, Issue{File: name, Check: check, Severity: severity, Message: fmt.Sprintf(format, args...)})
+		}
+
+		if int64(len(data)) > opts.MaxPageSize {
+			add(CheckOversized, Error, "page is %s, over the %s limit", formatSize(int64(len(data))), formatSize(opts.MaxPageSize))
+		}
+
+		page := string(data)
+		checkEscaping(page, add)
+		checkLinks(name, page, files, add)
+
+		for _, url := range commitURLPattern.FindAllString(page, -1) {
+			if _, ok := commitURLs[url]; !ok {
+				commitURLs[url] = name
+			}
+		}
+		if m := embeddedPattern.FindStringSubmatch(page); m != nil {
+			decoded, err := base64.StdEncoding.DecodeString(m[1])
+			if err != nil {
+				add(CheckEmbeddedData, Error, "embedded session data is not valid base64: %v", err)
+				continue
+			}
+			for _, url := range sessionCommitURLs(decoded) {
+				if _, ok := commitURLs[url]; !ok {
+					commitURLs[url] = name
+				}
+			}
+		}
+	}
+
+	if opts.CheckURLs {
+		issues = append(issues, checkCommitURLs(commitURLs, opts.Client)...)
+	}
+	return issues, nil
+}
+
+// readExport loads the files of an export keyed by slash-separated path
+func readExport(target string) (map[string][]byte, error) {
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte)
+
+	switch {
+	case info.IsDir():
+		err := filepath.WalkDir(target, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(target, p)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			files[filepath.ToSlash(rel)] = data
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", target, err)
+		}
+
+	case strings.EqualFold(filepath.Ext(target), ".zip"):
+		r, err := zip.OpenReader(target)
+		if err != nil {
+			return nil, fmt.Errorf("opening zip: %w", err)
+		}
+		defer r.Close()
+		for _, f := range r.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("reading %s from zip: %w", f.Name, err)
+			}
+			data, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return nil, fmt.Errorf("reading %s from zip: %w", f.Name, err)
+			}
+			files[f.Name] = data
+		}
+
+	default:
+		data, err := os.ReadFile(target)
+		if err != nil {
+			return nil, err
+		}
+		files[filepath.Base(target)] = data
+	}
+	return files, nil
+}
+
+func isPage(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	return ext == ".html" || ext == ".htm"
+}
+
+// checkEscaping looks for session or branding text that broke out of the
+// markup it was injected into
+func checkEscaping(page string, add func(check, severity, format string, args ...interface{})) {
+	opens := len(scriptOpen.FindAllStringIndex(page, -1))
+	closes := len(scriptClose.FindAllStringIndex(page, -1))
+	if opens != closes {
+		add(CheckUnescaped, Error, "%d <script> tags but %d </script> tags; injected text may have closed a script early", opens, closes)
+	}
+	if m := titlePattern.FindStringSubmatch(page); m != nil && strings.ContainsAny(m[1], "<>") {
+		add(CheckUnescaped, Error, "page title contains unescaped markup: %q", m[1])
+	}
+}
+
+// checkLinks verifies relative links point at files in the export, and
+// fragments at ids on the target page. Links built by scripts aren't seen.
+func checkLinks(name, page string, files map[string][]byte, add func(check, severity, format string, args ...interface{})) {
+	markup := scriptPattern.ReplaceAllString(page, "")
+	ids := anchors(markup)
+
+	for _, m := range attrPattern.FindAllStringSubmatch(markup, -1) {
+		link := m[2]
+		if link == "" || link == "#" || isExternal(link) {
+			continue
+		}
+
+		target, fragment, _ := strings.Cut(link, "#")
+		target, _, _ = strings.Cut(target, "?")
+		if target == "" {
+			if !ids[fragment] {
+				add(CheckMissingAnchor, Error, "link to #%s, but the page has no such id", fragment)
+			}
+			continue
+		}
+
+		resolved := path.Clean(path.Join(path.Dir(name), target))
+		if strings.HasSuffix(target, "/") {
+			resolved = path.Join(resolved, "index.html")
+		}
+		data, ok := files[resolved]
+		if !ok {
+			add(CheckBrokenLink, Error, "link to %s, which isn't in the export", link)
+			continue
+		}
+		if fragment != "" && isPage(resolved) && !anchors(scriptPattern.ReplaceAllString(string(data), ""))[fragment] {
+			add(CheckMissingAnchor, Error, "link to %s, but %s has no such id", link, resolved)
+		}
+	}
+}
+
+func anchors(markup string) map[string]bool {
+	ids := make(map[string]bool)
+	for _, m := range idPattern.FindAllStringSubmatch(markup, -1) {
+		ids[m[1]] = true
+	}
+	return ids
+}
+
+func isExternal(link string) bool {
+	if strings.HasPrefix(link, "//") {
+		return true
+	}
+	scheme, _, ok := strings.Cut(link, ":")
+	return ok && !strings.ContainsAny(scheme, "/?#")
+}
+
+// sessionCommitURLs builds the URLs of commits made in an embedded
+// session, on the GitHub, GitLab or Bitbucket repository it pushed to
+func sessionCommitURLs(data []byte) []string {
+	sess, err := session.Parse(data)
+	if err != nil {
+		return nil
+	}
+	urls := commitURLPattern.FindAllString(string(data), -1)
+	repo := session.DetectRepo(sess)
+	if repo == nil {
+		return urls
+	}
+	for _, c := range session.ExtractCommits(sess) {
+		urls = append(urls, strings.ReplaceAll(repo.CommitURLTemplate(), "{hash}", c.CommitHash))
+	}
+	return urls
+}
+
+// checkCommitURLs requests each commit URL. Not found is an error; a
+// request that couldn't be made is a warning.
+func checkCommitURLs(urls map[string]string, client *http.Client) []Issue {
+	sorted := make([]string, 0, len(urls))
+	for url := range urls {
+		sorted = append(sorted, url)
+	}
+	sort.Strings(sorted)
+
+	var issues []Issue
+	for _, url := range sorted {
+		resp, err := client.Head(url)
+		if err != nil {
+			issues = append(issues, Issue{File: urls[url], Check: CheckDeadCommitURL, Severity: Warning, Message: fmt.Sprintf("couldn't check %s: %v", url, err)})
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			issues = append(issues, Issue{File: urls[url], Check: CheckDeadCommitURL, Severity: Error, Message: fmt.Sprintf("%s returned %s", url, resp.Status)})
+		}
+	}
+	return issues
+}
+
+// Errors counts the issues that fail the lint
+func Errors(issues []Issue) int {
+	n := 0
+	for _, issue := range issues {
+		if issue.Severity == Error {
+			n++
+		}
+	}
+	return n
+}
+
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,138 @@
+package lint
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robzolkos/claude-session-export/render"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func hasIssue(issues []Issue, check string) bool {
+	for _, issue := range issues {
+		if issue.Check == check {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCheckCleanExport(t *testing.T) {
+	data := `{"type":"user","message":{"role":"user","content":"</script><b>hi</b>"}}` + "\n"
+	var buf bytes.Buffer
+	if err := render.RenderTo(&buf, strings.NewReader(data), render.Options{Title: "Fix <login>"}); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "viewer.html")
+	writeFile(t, path, buf.String())
+
+	issues, err := Check(path, Options{})
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("Expected a rendered export to be clean, got %+v", issues)
+	}
+}
+
+func TestCheckLinks(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "index.html"), `<html><body>
+<a href="sessions/one.html">one</a>
+<a href="sessions/two.html">two</a>
+<a href="sessions/one.html#turn-3">turn</a>
+<a href="#top">top</a>
+<a href="https://example.com/missing.html">external</a>
+</body></html>`)
+	writeFile(t, filepath.Join(dir, "sessions", "one.html"), `<html><body><h1 id="turn-1">One</h1><a href="../index.html">back</a></body></html>`)
+
+	issues, err := Check(dir, Options{})
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if len(issues) != 3 {
+		t.Fatalf("Expected 3 issues, got %+v", issues)
+	}
+	if issues[0].Check != CheckBrokenLink || !strings.Contains(issues[0].Message, "sessions/two.html") {
+		t.Errorf("Expected broken link to two.html, got %+v", issues[0])
+	}
+	if issues[1].Check != CheckMissingAnchor || !strings.Contains(issues[1].Message, "turn-3") {
+		t.Errorf("Expected missing anchor turn-3, got %+v", issues[1])
+	}
+	if issues[2].Check != CheckMissingAnchor || !strings.Contains(issues[2].Message, "#top") {
+		t.Errorf("Expected missing anchor top, got %+v", issues[2])
+	}
+	if Errors(issues) != 3 {
+		t.Errorf("Expected all issues to be errors")
+	}
+}
+
+func TestCheckEscapingAndSize(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "broken.html"), `<html><head><title>Fix <b>login</b></title></head>
+<body><script>var s = "</script>";</script></body></html>`)
+
+	issues, err := Check(dir, Options{MaxPageSize: 64})
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	for _, check := range []string{CheckOversized, CheckUnescaped} {
+		if !hasIssue(issues, check) {
+			t.Errorf("Expected a %s issue, got %+v", check, issues)
+		}
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestCheckCommitURLs(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "index.html"), `<html><body>
+<a href="https://github.com/acme/app/commit/abc1234">ok</a>
+<a href="https://github.com/acme/app/commit/def5678">gone</a>
+</body></html>`)
+
+	var requested []string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requested = append(requested, r.Method+" "+r.URL.Path)
+		status := http.StatusOK
+		if strings.HasSuffix(r.URL.Path, "def5678") {
+			status = http.StatusNotFound
+		}
+		return &http.Response{StatusCode: status, Status: http.StatusText(status), Body: http.NoBody}, nil
+	})}
+
+	issues, err := Check(dir, Options{Client: client})
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if len(issues) != 0 || len(requested) != 0 {
+		t.Fatalf("Expected no URL checks without CheckURLs, got %+v", issues)
+	}
+
+	issues, err = Check(dir, Options{CheckURLs: true, Client: client})
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if len(requested) != 2 || requested[0] != "HEAD /acme/app/commit/abc1234" {
+		t.Errorf("Expected HEAD requests for both commits, got %v", requested)
+	}
+	if len(issues) != 1 || issues[0].Check != CheckDeadCommitURL || !strings.Contains(issues[0].Message, "def5678") {
+		t.Errorf("Expected one dead commit URL, got %+v", issues)
+	}
+}
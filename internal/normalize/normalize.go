@@ -0,0 +1,214 @@
+package normalize
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/jsonschema"
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+// SchemaVersion is bumped whenever the document changes incompatibly
+const SchemaVersion = 1
+
+// Document is a parsed session in a stable shape: nested messages resolved,
+// timestamps parsed, and tool results attached to the calls they answer
+type Document struct {
+	SchemaVersion int        `json:"schema_version"`
+	Session       Metadata   `json:"session"`
+	Messages      []Message  `json:"messages"`
+	Subagents     []Subagent `json:"subagents,omitempty"`
+}
+
+// Metadata describes the session as a whole
+type Metadata struct {
+	ID            string                        `json:"id"`
+	Title         string                        `json:"title,omitempty"`
+	Cwd           string                        `json:"cwd,omitempty"`
+	GitBranch     string                        `json:"git_branch,omitempty"`
+	Version       string                        `json:"claude_code_version,omitempty"`
+	Models        []string                      `json:"models"`
+	Start         *time.Time                    `json:"start,omitempty"`
+	End           *time.Time                    `json:"end,omitempty"`
+	ActiveSeconds int                           `json:"active_seconds"`
+	Usage         map[string]session.TokenUsage `json:"usage"` // By model, subagents included
+}
+
+// Message is one user or assistant turn
+type Message struct {
+	UUID       string              `json:"uuid,omitempty"`
+	ParentUUID string              `json:"parent_uuid,omitempty"`
+	Role       string              `json:"role"` // "user" or "assistant"
+	Timestamp  *time.Time          `json:"timestamp,omitempty"`
+	Model      string              `json:"model,omitempty"`
+	Usage      *session.TokenUsage `json:"usage,omitempty"`
+	IsMeta     bool                `json:"is_meta,omitempty"`   // Injected context rather than typed
+	Branch     bool                `json:"branch,omitempty"`    // An alternative to an earlier reply
+	Continued  bool                `json:"continued,omitempty"` // Follows a compaction or earlier session
+	Content    []Block             `json:"content"`
+}
+
+// Block is one piece of a message's content
+type Block struct {
+	Type   string    `json:"type"` // "text", "thinking", "tool_use", "tool_result" or "image"
+	Text   string    `json:"text,omitempty"`
+	Tool   *ToolCall `json:"tool,omitempty"`
+	Result *Result   `json:"result,omitempty"` // Only for results whose call isn't in the session
+	Image  *Image    `json:"image,omitempty"`
+}
+
+// ToolCall is a tool use together with its result
+type ToolCall struct {
+	ID     string          `json:"id"`
+	Name   string          `json:"name"`
+	Input  json.RawMessage `json:"input"`
+	Result *Result         `json:"result,omitempty"`
+}
+
+// Result is the output of a tool call
+type Result struct {
+	ToolUseID string     `json:"tool_use_id"`
+	Text      string     `json:"text"`
+	IsError   bool       `json:"is_error,omitempty"`
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+}
+
+// Image is an inline image
+type Image struct {
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"` // Base64
+}
+
+// Subagent is the transcript of a subagent started by the Task tool
+type Subagent struct {
+	AgentID  string    `json:"agent_id,omitempty"`
+	Messages []Message `json:"messages"`
+}
+
+// Build normalizes a parsed session
+func Build(sess *session.Session, id string) *Document {
+	doc := &Document{
+		SchemaVersion: SchemaVersion,
+		Session:       Metadata{ID: id, Models: []string{}, Usage: session.UsageByModel(sess)},
+	}
+
+	if meta := sess.Metadata; meta != nil {
+		doc.Session.Title = meta.Title
+		doc.Session.Cwd = meta.Cwd
+		doc.Session.GitBranch = meta.GitBranch
+		doc.Session.Version = meta.Version
+		doc.Session.Start = timePtr(meta.StartTime)
+		doc.Session.End = timePtr(meta.EndTime)
+		doc.Session.ActiveSeconds = int(meta.ActiveTime.Seconds())
+	}
+	for model := range doc.Session.Usage {
+		doc.Session.Models = append(doc.Session.Models, model)
+	}
+	sort.Strings(doc.Session.Models)
+
+	doc.Messages = messages(sess.Messages)
+
+	byAgent := make(map[string][]session.Message)
+	var order []string
+	for _, msg := range sess.Sidechain {
+		if _, ok := byAgent[msg.AgentID]; !ok {
+			order = append(order, msg.AgentID)
+		}
+		byAgent[msg.AgentID] = append(byAgent[msg.AgentID], msg)
+	}
+	for _, agent := range order {
+		doc.Subagents = append(doc.Subagents, Subagent{AgentID: agent, Messages: messages(byAgent[agent])})
+	}
+	return doc
+}
+
+// messages converts a thread, attaching tool results to their calls and
+// dropping the user entries that only carried those results
+func messages(list []session.Message) []Message {
+	calls := make(map[string]*ToolCall)
+	out := []Message{}
+
+	for i := range list {
+		msg := &list[i]
+		m := Message{
+			UUID:       msg.UUID,
+			ParentUUID: msg.ParentUUID,
+			Role:       msg.Role,
+			Timestamp:  timePtr(msg.Timestamp),
+			Model:      msg.Model,
+			Usage:      msg.Usage,
+			IsMeta:     msg.IsMeta,
+			Branch:     msg.Branch,
+			Continued:  msg.Continued,
+			Content:    []Block{},
+		}
+
+		for _, block := range msg.Content {
+			switch block.Type {
+			case "tool_use":
+				call := &ToolCall{ID: block.ID, Name: block.Name, Input: block.Input}
+				if call.Input == nil {
+					call.Input = json.RawMessage("{}")
+				}
+				calls[block.ID] = call
+				m.Content = append(m.Content, Block{Type: "tool_use", Tool: call})
+			case "tool_result":
+				result := &Result{
+					ToolUseID: block.ToolUseID,
+					Text:      session.ToolResultText(block.Content),
+					IsError:   block.IsError,
+					Timestamp: timePtr(msg.Timestamp),
+				}
+				if call, ok := calls[block.ToolUseID]; ok {
+					call.Result = result
+				} else {
+					m.Content = append(m.Content, Block{Type: "tool_result", Result: result})
+				}
+			case "image":
+				if block.Source != nil {
+					m.Content = append(m.Content, Block{Type: "image", Image: &Image{
+						MediaType: block.Source.MediaType,
+						Data:      block.Source.Data,
+					}})
+				}
+			case "thinking":
+				m.Content = append(m.Content, Block{Type: "thinking", Text: block.Thinking})
+			default:
+				if block.Text != "" {
+					m.Content = append(m.Content, Block{Type: "text", Text: block.Text})
+				}
+			}
+		}
+
+		// Entries left empty only carried results now attached to calls
+		if len(m.Content) == 0 {
+			continue
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// Schema returns the JSON Schema for Document, generated from the Go types
+// so it can't drift from what Build produces
+func Schema() map[string]interface{} {
+	defs := make(map[string]interface{})
+	root := jsonschema.For(reflect.TypeOf(Document{}), defs, "#/$defs/")
+	return map[string]interface{}{
+		"$schema":  "https://json-schema.org/draft/2020-12/schema",
+		"title":    "claude-session-export normalized session",
+		"$comment": fmt.Sprintf("schema_version %d", SchemaVersion),
+		"$ref":     root["$ref"],
+		"$defs":    defs,
+	}
+}
@@ -0,0 +1,69 @@
+package normalize
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/robzolkos/claude-session-export/internal/jsonschema"
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+const normalizeSession = `{"type":"user","uuid":"u1","sessionId":"s1","cwd":"/home/alice/app","timestamp":"2024-06-01T10:00:00Z","message":{"role":"user","content":"List the files"}}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","timestamp":"2024-06-01T10:00:05Z","message":{"role":"assistant","model":"claude-sonnet-4-20250514","usage":{"input_tokens":10,"output_tokens":5},"content":[{"type":"thinking","thinking":"Use ls."},{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"ls"}}]}}
+{"type":"user","uuid":"u2","parentUuid":"a1","timestamp":"2024-06-01T10:00:06Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"main.go","is_error":false}]}}
+{"type":"assistant","uuid":"a2","parentUuid":"u2","timestamp":"2024-06-01T10:00:08Z","message":{"role":"assistant","model":"claude-sonnet-4-20250514","content":[{"type":"text","text":"Just main.go."}]}}`
+
+func TestBuild(t *testing.T) {
+	sess, err := session.Parse([]byte(normalizeSession))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	doc := Build(sess, "s1")
+
+	if doc.SchemaVersion != SchemaVersion || doc.Session.ID != "s1" || doc.Session.Cwd != "/home/alice/app" {
+		t.Errorf("Unexpected metadata: %+v", doc.Session)
+	}
+	if len(doc.Session.Models) != 1 || doc.Session.Usage["claude-sonnet-4-20250514"].InputTokens != 10 {
+		t.Errorf("Expected usage by model, got %v %v", doc.Session.Models, doc.Session.Usage)
+	}
+	if doc.Session.Start == nil || doc.Session.End == nil || !doc.Session.End.After(*doc.Session.Start) {
+		t.Errorf("Expected parsed start and end times, got %v %v", doc.Session.Start, doc.Session.End)
+	}
+
+	// The user entry carrying only the tool result is folded into the call
+	if len(doc.Messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d: %+v", len(doc.Messages), doc.Messages)
+	}
+	reply := doc.Messages[1]
+	if len(reply.Content) != 2 || reply.Content[0].Type != "thinking" || reply.Content[0].Text != "Use ls." {
+		t.Fatalf("Expected thinking then a tool call, got %+v", reply.Content)
+	}
+	call := reply.Content[1].Tool
+	if call == nil || call.Name != "Bash" || call.Result == nil || call.Result.Text != "main.go" {
+		t.Errorf("Expected the Bash call paired with its result, got %+v", call)
+	}
+	if call != nil && call.Result != nil && call.Result.Timestamp == nil {
+		t.Error("Expected the result to keep its timestamp")
+	}
+}
+
+func TestSchema(t *testing.T) {
+	defs, ok := Schema()["$defs"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected $defs in the schema")
+	}
+	for _, v := range []interface{}{Document{}, Metadata{}, Message{}, Block{}, ToolCall{}, Result{}, session.TokenUsage{}} {
+		if name := jsonschema.Name(reflect.TypeOf(v)); defs[name] == nil {
+			t.Errorf("Expected a definition for %s", name)
+		}
+	}
+
+	doc := defs[jsonschema.Name(reflect.TypeOf(Document{}))].(map[string]interface{})
+	if required := doc["required"].([]string); len(required) == 0 || required[0] != "schema_version" {
+		t.Errorf("Expected schema_version to be required, got %v", required)
+	}
+	input := defs[jsonschema.Name(reflect.TypeOf(ToolCall{}))].(map[string]interface{})["properties"].(map[string]interface{})["input"]
+	if len(input.(map[string]interface{})) != 0 {
+		t.Errorf("Expected tool input to accept any JSON, got %v", input)
+	}
+}
@@ -0,0 +1,300 @@
+package patch
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+// author signs the patches. The domain is reserved, so the address can't
+// reach anyone.
+const author = "Claude <claude@claude-session-export.invalid>"
+
+// context is the number of unchanged lines around each hunk
+const context = 3
+
+// maxCells caps the table used to diff the changed middle of a file;
+// beyond it the middle is replaced wholesale
+const maxCells = 4 << 20
+
+// Patch is the change one prompt led to, as a git format-patch message
+type Patch struct {
+	Subject string
+	Date    time.Time
+	Data    []byte
+}
+
+// Build replays a session's file writes and edits and returns a patch for
+// each prompt that changed files, with paths relative to the session's
+// working directory. skipped lists the files edited without their contents
+// being known, which no patch can include.
+func Build(sess *session.Session) (patches []Patch, skipped []string) {
+	prompts := session.GetUserPrompts(sess)
+	cwd := ""
+	if sess.Metadata != nil {
+		cwd = sess.Metadata.Cwd
+	}
+
+	// Each prompt's changes run until the next prompt
+	var cuts []time.Time
+	for _, p := range prompts[min(1, len(prompts)):] {
+		if !p.Timestamp.IsZero() {
+			cuts = append(cuts, p.Timestamp.Add(-time.Nanosecond))
+		}
+	}
+	cuts = append(cuts, time.Time{})
+
+	var bodies []string
+	before := make(map[string]string)
+	for i, cut := range cuts {
+		files, missing := session.ReconstructFiles(sess, cut)
+		if i == len(cuts)-1 {
+			skipped = missing
+		}
+		var diffs bytes.Buffer
+		var updated time.Time
+		for _, file := range files {
+			old, seen := before[file.Path]
+			if !seen {
+				old = file.Original
+			}
+			if old == file.Content && (seen || !file.Created) {
+				continue
+			}
+			path := filepath.ToSlash(session.LocalPath(file.Path, cwd))
+			writeFileDiff(&diffs, path, old, file.Content, file.Created && !seen)
+			before[file.Path] = file.Content
+			if file.Updated.After(updated) {
+				updated = file.Updated
+			}
+		}
+		if diffs.Len() == 0 {
+			continue
+		}
+		p := Patch{Subject: "Changes from the session", Date: updated, Data: diffs.Bytes()}
+		body := ""
+		if i < len(prompts) {
+			p.Subject, body = message(session.ExtractText(&prompts[i]))
+		}
+		patches = append(patches, p)
+		bodies = append(bodies, body)
+	}
+
+	// Numbered once the count is known
+	for i := range patches {
+		patches[i].Data = formatPatch(patches[i], fmt.Sprintf("[PATCH %d/%d] ", i+1, len(patches)), bodies[i])
+	}
+	return patches, skipped
+}
+
+// Series joins patches into one mbox, which git am applies in order
+func Series(patches []Patch) []byte {
+	var buf bytes.Buffer
+	for _, p := range patches {
+		buf.Write(p.Data)
+	}
+	return buf.Bytes()
+}
+
+// message makes a commit message from a prompt: its first line as the
+// subject, shortened if long, and the rest as the body
+func message(prompt string) (subject, body string) {
+	prompt = strings.TrimSpace(prompt)
+	subject, body, _ = strings.Cut(prompt, "\n")
+	subject = strings.Join(strings.Fields(subject), " ")
+	if len(subject) > 72 {
+		cut := strings.LastIndex(subject[:69], " ")
+		if cut < 40 {
+			cut = 69
+		}
+		subject = strings.TrimSpace(subject[:cut]) + "..."
+	}
+	if subject == "" {
+		subject = "Changes from the session"
+	}
+	return subject, strings.TrimSpace(body)
+}
+
+// formatPatch wraps a patch's diffs in the mail git format-patch writes
+func formatPatch(p Patch, number, body string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("From 0000000000000000000000000000000000000000 Mon Sep 17 00:00:00 2001\n")
+	buf.WriteString("From: " + author + "\n")
+	if !p.Date.IsZero() {
+		buf.WriteString("Date: " + p.Date.Format(time.RFC1123Z) + "\n")
+	}
+	buf.WriteString("Subject: " + number + p.Subject + "\n\n")
+	if body != "" {
+		for _, line := range strings.Split(body, "\n") {
+			// Lines git am would take for the end of the message
+			if line == "---" || strings.HasPrefix(line, "diff -") || strings.HasPrefix(line, "Index: ") || strings.HasPrefix(line, "From ") {
+				line = " " + line
+			}
+			buf.WriteString(line + "\n")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("---\n")
+	buf.Write(p.Data)
+	buf.WriteString("-- \nclaude-session-export\n\n")
+	return buf.Bytes()
+}
+
+// writeFileDiff writes a git diff of one file
+func writeFileDiff(buf *bytes.Buffer, path, old, new string, created bool) {
+	fmt.Fprintf(buf, "diff --git a/%s b/%s\n", path, path)
+	if created {
+		buf.WriteString("new file mode 100644\n--- /dev/null\n")
+	} else {
+		fmt.Fprintf(buf, "--- a/%s\n", path)
+	}
+	fmt.Fprintf(buf, "+++ b/%s\n", path)
+	buf.Write(unified(splitLines(old), splitLines(new)))
+}
+
+// splitLines splits text into lines, each keeping its newline; the last
+// has none if the text doesn't end with one
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// edit is one line of a diff: kept (' '), removed ('-') or added ('+'),
+// with the number of old and new lines before it
+type edit struct {
+	kind   byte
+	line   string
+	oldPos int
+	newPos int
+}
+
+// unified returns the hunks of a unified diff turning a into b, lines
+// as splitLines returns them
+func unified(a, b []string) []byte {
+	edits := diffLines(a, b)
+	var buf bytes.Buffer
+	for i := 0; i < len(edits); {
+		if edits[i].kind == ' ' {
+			i++
+			continue
+		}
+		// Take in later changes until a long enough unchanged run
+		end := i + 1
+		for j := i; j < len(edits); j++ {
+			if edits[j].kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*context {
+				break
+			}
+		}
+		start, stop := max(i-context, 0), min(end+context, len(edits))
+
+		var oldLen, newLen int
+		for _, e := range edits[start:stop] {
+			if e.kind != '+' {
+				oldLen++
+			}
+			if e.kind != '-' {
+				newLen++
+			}
+		}
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(edits[start].oldPos, oldLen), hunkRange(edits[start].newPos, newLen))
+		for _, e := range edits[start:stop] {
+			buf.WriteByte(e.kind)
+			buf.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				buf.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = stop
+	}
+	return buf.Bytes()
+}
+
+// hunkRange formats one side of a hunk header: the first line and the
+// count, or for an empty side the line before it
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
+
+// diffLines lines up a and b: lines they share at the start and end are
+// kept, and the middle is matched by longest common subsequence
+func diffLines(a, b []string) []edit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	var edits []edit
+	oldPos, newPos := 0, 0
+	add := func(kind byte, line string) {
+		edits = append(edits, edit{kind, line, oldPos, newPos})
+		if kind != '+' {
+			oldPos++
+		}
+		if kind != '-' {
+			newPos++
+		}
+	}
+	for _, line := range a[:prefix] {
+		add(' ', line)
+	}
+	if len(midA)*len(midB) > maxCells {
+		for _, line := range midA {
+			add('-', line)
+		}
+		for _, line := range midB {
+			add('+', line)
+		}
+	} else {
+		// lcs[i][j] is the longest common subsequence of midA[i:] and midB[j:]
+		width := len(midB) + 1
+		lcs := make([]int32, (len(midA)+1)*width)
+		for i := len(midA) - 1; i >= 0; i-- {
+			for j := len(midB) - 1; j >= 0; j-- {
+				if midA[i] == midB[j] {
+					lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+				} else {
+					lcs[i*width+j] = max(lcs[(i+1)*width+j], lcs[i*width+j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(midA) || j < len(midB) {
+			switch {
+			case i < len(midA) && j < len(midB) && midA[i] == midB[j]:
+				add(' ', midA[i])
+				i++
+				j++
+			case j == len(midB) || (i < len(midA) && lcs[(i+1)*width+j] >= lcs[i*width+j+1]):
+				add('-', midA[i])
+				i++
+			default:
+				add('+', midB[j])
+				j++
+			}
+		}
+	}
+	for _, line := range a[len(a)-suffix:] {
+		add(' ', line)
+	}
+	return edits
+}
@@ -0,0 +1,58 @@
+package patch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+const editSession = `{"type":"user","cwd":"/app","timestamp":"2025-06-01T10:00:00Z","message":{"role":"user","content":"Add a main package"}}
+{"type":"assistant","timestamp":"2025-06-01T10:00:05Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"w1","name":"Write","input":{"file_path":"/app/main.go","content":"package main\n\nfunc main() {\n}\n"}}]}}
+{"type":"user","timestamp":"2025-06-01T10:00:06Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"w1","content":"File created"}]}}
+{"type":"user","timestamp":"2025-06-01T10:01:00Z","message":{"role":"user","content":"Make it print hello\n\nUse fmt."}}
+{"type":"assistant","timestamp":"2025-06-01T10:01:05Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"e1","name":"Edit","input":{"file_path":"/app/main.go","old_string":"func main() {\n}","new_string":"func main() {\n\tfmt.Println(\"hello\")\n}"}}]}}
+{"type":"user","timestamp":"2025-06-01T10:01:06Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"e1","content":"Updated"}]}}
+{"type":"user","timestamp":"2025-06-01T10:02:00Z","message":{"role":"user","content":"Thanks"}}
+`
+
+func TestBuild(t *testing.T) {
+	sess, err := session.Parse([]byte(editSession))
+	if err != nil {
+		t.Fatal(err)
+	}
+	patches, skipped := Build(sess)
+	if len(patches) != 2 || len(skipped) != 0 {
+		t.Fatalf("Expected a patch for each prompt that changed files, got %d (skipped %v)", len(patches), skipped)
+	}
+
+	first := string(patches[0].Data)
+	for _, want := range []string{
+		"Subject: [PATCH 1/2] Add a main package\n",
+		"new file mode 100644\n--- /dev/null\n+++ b/main.go\n@@ -0,0 +1,4 @@\n+package main\n",
+	} {
+		if !strings.Contains(first, want) {
+			t.Errorf("Expected %q in the first patch:\n%s", want, first)
+		}
+	}
+
+	second := string(patches[1].Data)
+	for _, want := range []string{
+		"Subject: [PATCH 2/2] Make it print hello\n\nUse fmt.\n\n---\n",
+		"--- a/main.go\n+++ b/main.go\n@@ -1,4 +1,5 @@\n package main\n \n func main() {\n+\tfmt.Println(\"hello\")\n }\n",
+	} {
+		if !strings.Contains(second, want) {
+			t.Errorf("Expected %q in the second patch:\n%s", want, second)
+		}
+	}
+}
+
+func TestUnified(t *testing.T) {
+	old := splitLines("a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm")
+	new := splitLines("a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n")
+	want := "@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n" +
+		"@@ -10,4 +10,4 @@\n j\n k\n l\n-m\n\\ No newline at end of file\n+m\n"
+	if got := string(unified(old, new)); got != want {
+		t.Errorf("Unexpected diff:\n%s", got)
+	}
+}
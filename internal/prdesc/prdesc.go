@@ -0,0 +1,201 @@
+package prdesc
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+// testCommandPattern matches Bash commands that run a test suite
+var testCommandPattern = regexp.MustCompile(`\b(go test|(npm|yarn|pnpm|bun)( run)? test|pytest|cargo (test|nextest)|rspec|rails test|jest|vitest|mix test|make (test|check)|gradle(w)? test|mvn test|phpunit|dotnet test|swift test)\b`)
+
+// maxApproachLen caps each approach note, taken from Claude's replies
+const maxApproachLen = 600
+
+// TestRun is the latest result of a test command run during the session
+type TestRun struct {
+	Command string
+	Passed  bool
+	Summary string // Last line of output, e.g. "ok  ./... 0.3s"
+}
+
+// Description is a pull request body assembled from a session
+type Description struct {
+	Goal     string
+	Approach []string
+	Commits  []session.IndexItem
+	Files    []string
+	Tests    []TestRun
+}
+
+// Build assembles a description from what happened in a session
+func Build(sess *session.Session) Description {
+	d := Description{Goal: strings.TrimSpace(session.GetFirstUserMessage(sess))}
+
+	cwd := ""
+	if sess.Metadata != nil {
+		cwd = sess.Metadata.Cwd
+	}
+
+	for _, conv := range session.GroupConversations(sess) {
+		if note := lastReply(conv); note != "" {
+			d.Approach = append(d.Approach, note)
+		}
+	}
+
+	d.Commits = session.ExtractCommits(sess)
+
+	for _, file := range session.SessionStats(sess, nil).FilesTouched {
+		if cwd != "" {
+			if rel, err := filepath.Rel(cwd, file); err == nil && !strings.HasPrefix(rel, "..") {
+				file = rel
+			}
+		}
+		d.Files = append(d.Files, file)
+	}
+
+	d.Tests = testRuns(sess)
+	return d
+}
+
+// lastReply returns the opening paragraph of Claude's final text in a
+// conversation, which is usually its summary of what it did
+func lastReply(conv session.Conversation) string {
+	for i := len(conv.Messages) - 1; i >= 0; i-- {
+		msg := conv.Messages[i]
+		if msg.Role != "assistant" {
+			continue
+		}
+		for j := len(msg.Content) - 1; j >= 0; j-- {
+			block := msg.Content[j]
+			if block.Type != "text" || strings.TrimSpace(block.Text) == "" {
+				continue
+			}
+			text := strings.TrimSpace(block.Text)
+			if p := strings.Index(text, "\n\n"); p > 0 {
+				text = text[:p]
+			}
+			if r := []rune(text); len(r) > maxApproachLen {
+				text = string(r[:maxApproachLen-3]) + "..."
+			}
+			return text
+		}
+	}
+	return ""
+}
+
+// testRuns finds test commands run through Bash and the outcome of the
+// last run of each
+func testRuns(sess *session.Session) []TestRun {
+	results := make(map[string]session.ContentBlock)
+	for _, msg := range sess.Messages {
+		for _, block := range msg.Content {
+			if block.Type == "tool_result" {
+				results[block.ToolUseID] = block
+			}
+		}
+	}
+
+	var runs []TestRun
+	index := make(map[string]int)
+	for _, msg := range sess.Messages {
+		for _, block := range msg.Content {
+			if block.Type != "tool_use" || block.Name != "Bash" {
+				continue
+			}
+			input, err := session.ParseToolInput(block.Input)
+			if err != nil || !testCommandPattern.MatchString(input.Command) {
+				continue
+			}
+			result, ok := results[block.ID]
+			if !ok {
+				continue
+			}
+			run := TestRun{
+				Command: input.Command,
+				Passed:  !result.IsError,
+				Summary: lastLine(session.ToolResultText(result.Content)),
+			}
+			if i, seen := index[run.Command]; seen {
+				runs[i] = run
+				continue
+			}
+			index[run.Command] = len(runs)
+			runs = append(runs, run)
+		}
+	}
+	return runs
+}
+
+// ansiCodes matches terminal color codes, left out of test summaries
+var ansiCodes = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+func lastLine(text string) string {
+	lines := strings.Split(strings.TrimSpace(ansiCodes.ReplaceAllString(text, "")), "\n")
+	line := strings.TrimSpace(lines[len(lines)-1])
+	if r := []rune(line); len(r) > 120 {
+		line = string(r[:117]) + "..."
+	}
+	return line
+}
+
+// WriteMarkdown writes the description as a PR body
+func (d Description) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString("## Goal\n\n")
+	if d.Goal != "" {
+		b.WriteString(quote(d.Goal) + "\n")
+	} else {
+		b.WriteString("_No prompt found._\n")
+	}
+
+	if len(d.Approach) > 0 {
+		b.WriteString("\n## Approach\n\n")
+		for _, note := range d.Approach {
+			b.WriteString("- " + strings.ReplaceAll(note, "\n", "\n  ") + "\n")
+		}
+	}
+
+	if len(d.Commits) > 0 {
+		b.WriteString("\n## Commits\n\n")
+		for _, c := range d.Commits {
+			fmt.Fprintf(&b, "- `%s` %s\n", c.CommitHash, c.CommitMessage)
+		}
+	}
+
+	if len(d.Files) > 0 {
+		b.WriteString("\n## Files changed\n\n")
+		for _, f := range d.Files {
+			fmt.Fprintf(&b, "- `%s`\n", f)
+		}
+	}
+
+	b.WriteString("\n## Tests\n\n")
+	if len(d.Tests) == 0 {
+		b.WriteString("_No test runs found in the session._\n")
+	}
+	for _, t := range d.Tests {
+		status := "passed"
+		if !t.Passed {
+			status = "**failed**"
+		}
+		fmt.Fprintf(&b, "- `%s` %s", t.Command, status)
+		if t.Summary != "" {
+			fmt.Fprintf(&b, " (%s)", t.Summary)
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// quote formats text as a Markdown block quote
+func quote(text string) string {
+	return "> " + strings.ReplaceAll(strings.TrimSpace(text), "\n", "\n> ")
+}
@@ -0,0 +1,53 @@
+package prdesc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+const prSession = `{"type":"user","cwd":"/home/alice/app","timestamp":"2024-06-01T10:00:00Z","message":{"role":"user","content":"Fix the login redirect loop"}}
+{"type":"assistant","timestamp":"2024-06-01T10:00:05Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Edit","input":{"file_path":"/home/alice/app/auth/login.go","old_string":"a","new_string":"b"}}]}}
+{"type":"user","timestamp":"2024-06-01T10:00:06Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"ok"}]}}
+{"type":"assistant","timestamp":"2024-06-01T10:00:10Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"t2","name":"Bash","input":{"command":"go test ./..."}}]}}
+{"type":"user","timestamp":"2024-06-01T10:00:20Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t2","content":"--- FAIL: TestLogin","is_error":true}]}}
+{"type":"assistant","timestamp":"2024-06-01T10:00:30Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"t3","name":"Bash","input":{"command":"go test ./..."}}]}}
+{"type":"user","timestamp":"2024-06-01T10:00:40Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t3","content":"\u001b[32mok\u001b[0m  \tapp/auth\t0.2s"}]}}
+{"type":"assistant","timestamp":"2024-06-01T10:00:45Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"t4","name":"Bash","input":{"command":"git commit -m 'Fix redirect loop'"}}]}}
+{"type":"user","timestamp":"2024-06-01T10:00:46Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t4","content":"[main abc1234] Fix redirect loop"}]}}
+{"type":"assistant","timestamp":"2024-06-01T10:00:50Z","message":{"role":"assistant","content":[{"type":"text","text":"The session cookie was cleared before the redirect, so login looped.\n\nDetails follow."}]}}`
+
+func TestBuild(t *testing.T) {
+	sess, err := session.Parse([]byte(prSession))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	d := Build(sess)
+
+	if d.Goal != "Fix the login redirect loop" {
+		t.Errorf("Expected the first prompt as the goal, got %q", d.Goal)
+	}
+	if len(d.Approach) != 1 || !strings.HasSuffix(d.Approach[0], "so login looped.") {
+		t.Errorf("Expected the opening paragraph of the final reply, got %q", d.Approach)
+	}
+	if len(d.Files) != 1 || d.Files[0] != "auth/login.go" {
+		t.Errorf("Expected files relative to the working directory, got %v", d.Files)
+	}
+	if len(d.Tests) != 1 || !d.Tests[0].Passed {
+		t.Errorf("Expected the last run of go test to count, got %+v", d.Tests)
+	} else if d.Tests[0].Summary != "ok  \tapp/auth\t0.2s" {
+		t.Errorf("Expected the summary without color codes, got %q", d.Tests[0].Summary)
+	}
+	if len(d.Commits) != 1 {
+		t.Errorf("Expected one commit, got %+v", d.Commits)
+	}
+
+	var b strings.Builder
+	d.WriteMarkdown(&b)
+	for _, want := range []string{"## Goal\n\n> Fix the login redirect loop", "- `abc1234` Fix redirect loop", "- `go test ./...` passed"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package publish
+
+import (
+	"cmp"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/report"
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+// maxBars caps the rows of the project and tool charts
+const maxBars = 10
+
+// barRow is one bar of a chart: its label, length and tooltip
+type barRow struct {
+	Label string
+	Value float64
+	Title string
+}
+
+// dashboard sums up the archived sessions for the index page: totals, and
+// inline SVG charts of sessions per week, tokens per project and the most
+// used tools, with cost estimated at pricing
+func dashboard(sessions []archivedSession, pricing session.Pricing) string {
+	if len(sessions) == 0 {
+		return ""
+	}
+	var entries, dated []report.Entry
+	tools := make(map[string]int)
+	unpriced := false
+	for _, s := range sessions {
+		stats := session.SessionStats(s.Session, pricing)
+		entry := report.Entry{Project: cmp.Or(s.Project, "(no project)"), Time: stats.Start.UTC(), Stats: stats}
+		entries = append(entries, entry)
+		if !stats.Start.IsZero() {
+			dated = append(dated, entry)
+		}
+		for name, n := range stats.Tools {
+			tools[name] += n
+		}
+		unpriced = unpriced || len(stats.Unpriced) > 0
+	}
+	r := report.Build(entries, "week", time.Now())
+
+	var b strings.Builder
+	b.WriteString("<section class=\"dashboard\" aria-label=\"Archive statistics\">\n<div class=\"cards\">\n")
+	cost := session.FormatCost(r.Total.Cost)
+	if unpriced {
+		cost += "*"
+	}
+	for _, card := range [][2]string{
+		{"Sessions", fmt.Sprint(r.Total.Sessions)},
+		{"Tokens", report.FormatTokens(r.Total.Tokens())},
+		{"Tool calls", fmt.Sprint(r.Total.ToolCalls)},
+		{"Est. cost", cost},
+	} {
+		fmt.Fprintf(&b, "<div class=\"card\"><div class=\"label\">%s</div><div class=\"value\">%s</div></div>\n", card[0], html.EscapeString(card[1]))
+	}
+	b.WriteString("</div>\n")
+	if unpriced {
+		b.WriteString("<p class=\"note\">* Leaves out models without a known price.</p>\n")
+	}
+
+	if len(dated) > 0 {
+		b.WriteString("<h2>Sessions per week</h2>\n")
+		b.WriteString(weekChart(report.Build(dated, "week", time.Now()).Rows))
+	}
+
+	var projects []barRow
+	for _, p := range r.Projects {
+		projects = append(projects, barRow{
+			Label: p.Project,
+			Value: float64(p.Tokens()),
+			Title: fmt.Sprintf("%s: %s tokens, %s, %s", p.Project, report.FormatTokens(p.Tokens()), count(p.Sessions, "session"), session.FormatCost(p.Cost)),
+		})
+	}
+	sort.SliceStable(projects, func(i, j int) bool { return projects[i].Value > projects[j].Value })
+	b.WriteString("<h2>Tokens per project</h2>\n")
+	b.WriteString(barChart("Tokens per project", projects, func(v float64) string { return report.FormatTokens(int(v)) }))
+
+	var used []barRow
+	for name, n := range tools {
+		used = append(used, barRow{Label: name, Value: float64(n), Title: name + ": " + count(n, "call")})
+	}
+	sort.Slice(used, func(i, j int) bool {
+		if used[i].Value != used[j].Value {
+			return used[i].Value > used[j].Value
+		}
+		return used[i].Label < used[j].Label
+	})
+	if len(used) > 0 {
+		b.WriteString("<h2>Most used tools</h2>\n")
+		b.WriteString(barChart("Most used tools", used, func(v float64) string { return fmt.Sprint(int(v)) }))
+	}
+	b.WriteString("</section>\n")
+	return b.String()
+}
+
+// weekChart draws a column per week from the first to the last, weeks
+// without sessions included. rows must not be empty.
+func weekChart(rows []report.Row) string {
+	var weeks []report.Row
+	for i, row := range rows {
+		if i > 0 {
+			for start := weeks[len(weeks)-1].Start.AddDate(0, 0, 7); start.Before(row.Start); start = start.AddDate(0, 0, 7) {
+				weeks = append(weeks, report.Row{Start: start, Label: "Week of " + start.Format("2006-01-02")})
+			}
+		}
+		weeks = append(weeks, row)
+	}
+	most := 0
+	for _, w := range weeks {
+		most = max(most, w.Sessions)
+	}
+
+	const width, height, axis = 800.0, 160.0, 20.0
+	var b strings.Builder
+	fmt.Fprintf(&b, "<svg viewBox=\"0 0 %g %g\" role=\"img\" aria-label=\"Sessions per week\">\n", width, height+axis)
+	step := width / float64(len(weeks))
+	for i, w := range weeks {
+		h := float64(w.Sessions) / float64(max(most, 1)) * height
+		fmt.Fprintf(&b, "<rect x=\"%.1f\" y=\"%.1f\" width=\"%.1f\" height=\"%.1f\"><title>%s: %s</title></rect>\n",
+			float64(i)*step+step*0.1, height-h, step*0.8, h, html.EscapeString(w.Label), count(w.Sessions, "session"))
+	}
+	first, last := weeks[0].Start.Format("Jan 2 2006"), weeks[len(weeks)-1].Start.Format("Jan 2 2006")
+	fmt.Fprintf(&b, "<text x=\"0\" y=\"%g\">%s</text>\n", height+axis-4, first)
+	if len(weeks) > 1 {
+		fmt.Fprintf(&b, "<text x=\"%g\" y=\"%g\" text-anchor=\"end\">%s</text>\n", width, height+axis-4, last)
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// barChart draws a labelled horizontal bar for each of the first maxBars
+// rows, scaled to the largest
+func barChart(label string, rows []barRow, format func(float64) string) string {
+	if len(rows) > maxBars {
+		rows = rows[:maxBars]
+	}
+	most := 0.0
+	for _, row := range rows {
+		most = max(most, row.Value)
+	}
+
+	const width, rowHeight, labelWidth, valueWidth = 800.0, 24.0, 200.0, 70.0
+	var b strings.Builder
+	fmt.Fprintf(&b, "<svg viewBox=\"0 0 %g %g\" role=\"img\" aria-label=\"%s\">\n", width, rowHeight*float64(len(rows)), html.EscapeString(label))
+	for i, row := range rows {
+		y := float64(i) * rowHeight
+		w := 0.0
+		if most > 0 {
+			w = row.Value / most * (width - labelWidth - valueWidth)
+		}
+		name := row.Label
+		if len([]rune(name)) > 28 {
+			name = string([]rune(name)[:27]) + "…"
+		}
+		fmt.Fprintf(&b, "<text x=\"%g\" y=\"%g\" text-anchor=\"end\">%s</text>\n", labelWidth-8, y+16, html.EscapeString(name))
+		fmt.Fprintf(&b, "<rect x=\"%g\" y=\"%g\" width=\"%.1f\" height=\"%g\"><title>%s</title></rect>\n", labelWidth, y+4, w, rowHeight-8, html.EscapeString(row.Title))
+		fmt.Fprintf(&b, "<text x=\"%.1f\" y=\"%g\">%s</text>\n", labelWidth+w+6, y+16, html.EscapeString(format(row.Value)))
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// count formats n of a thing, e.g. "1 session" or "3 sessions"
+func count(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,691 @@
+package publish
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+// Defaults for Options
+const (
+	DefaultBranch  = "gh-pages"
+	DefaultMessage = "Publish session archive"
+)
+
+// apiURL is the GitHub API root, replaced in tests
+var apiURL = "https://api.github.com"
+
+var httpClient = &http.Client{Timeout: 5 * time.Minute}
+
+var (
+	repoPattern   = regexp.MustCompile(`^[\w.-]+/[\w.-]+$`)
+	titlePattern  = regexp.MustCompile(`(?is)<title>(.*?)</title>`)
+	headPattern   = regexp.MustCompile(`(?i)<head(\s[^>]*)?>`)
+	robotsPattern = regexp.MustCompile(`(?i)<meta\s+name="robots"`)
+)
+
+// robotsMeta keeps a page out of search engines
+const robotsMeta = `<meta name="robots" content="noindex, nofollow">`
+
+// Options says where to publish
+type Options struct {
+	Repo    string // owner/name
+	Branch  string // Default: DefaultBranch
+	Message string // Commit message. Default: DefaultMessage
+
+	// UseGit pushes with the git CLI and its credentials instead of the API,
+	// which is used when GITHUB_TOKEN or GH_TOKEN is set
+	UseGit bool
+
+	// Remote overrides the repository URL git pushes to
+	Remote string
+}
+
+// Result describes a publish
+type Result struct {
+	Files   int    `json:"files"`
+	Size    int64  `json:"size"`
+	Commit  string `json:"commit,omitempty"` // Empty if nothing changed
+	URL     string `json:"url"`              // Where GitHub Pages serves the branch
+	Changed bool   `json:"changed"`
+
+	// PagesEnabled is false when the API reports Pages isn't set up for the
+	// repository; nil when it wasn't checked
+	PagesEnabled *bool `json:"pages_enabled,omitempty"`
+}
+
+// File is one file to publish: read from Source on disk, or Data when
+// generated
+type File struct {
+	Path   string // Slash-separated, relative to the branch root
+	Source string
+	Data   []byte
+}
+
+func (f File) read() ([]byte, error) {
+	if f.Source == "" {
+		return f.Data, nil
+	}
+	return os.ReadFile(f.Source)
+}
+
+// ValidRepo reports whether repo looks like owner/name
+func ValidRepo(repo string) bool {
+	return repoPattern.MatchString(repo)
+}
+
+// PagesURL is where GitHub Pages serves a repository
+func PagesURL(repo string) string {
+	owner, name, _ := strings.Cut(repo, "/")
+	owner = strings.ToLower(owner)
+	if strings.EqualFold(name, owner+".github.io") {
+		return "https://" + owner + ".github.io/"
+	}
+	return "https://" + owner + ".github.io/" + name + "/"
+}
+
+// HasToken reports whether a token is set for publishing over the API
+func HasToken() bool {
+	return token() != ""
+}
+
+func token() string {
+	if t := os.Getenv("GITHUB_TOKEN"); t != "" {
+		return t
+	}
+	return os.Getenv("GH_TOKEN")
+}
+
+// Files lists what publishing dir puts on the branch: every file in it
+// except dotfiles, plus an index.html listing the pages when dir has no
+// index, with statistics and a search page over the sessions the pages
+// carry, and a .nojekyll so GitHub Pages serves the files as they are
+// unless dir is a Jekyll site. Costs are estimated at pricing.
+func Files(dir string, pricing session.Pricing) ([]File, error) {
+	var files []File
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, File{Path: filepath.ToSlash(rel), Source: p})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", dir, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s has no files to publish", dir)
+	}
+
+	has := func(name string) bool {
+		for _, f := range files {
+			if f.Path == name {
+				return true
+			}
+		}
+		return false
+	}
+	if !has("index.html") && !has("index.md") {
+		sessions, err := archivedSessions(files)
+		if err != nil {
+			return nil, err
+		}
+		var search []File
+		if !has(SearchPage) && !has(SearchIndex) {
+			if search, err = searchFiles(sessions); err != nil {
+				return nil, err
+			}
+		}
+		index, err := indexPage(files, len(search) > 0, dashboard(sessions, pricing))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, File{Path: "index.html", Data: index})
+		files = append(files, search...)
+	}
+	if !has("_config.yml") {
+		files = append(files, File{Path: ".nojekyll", Data: []byte{}})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// Sitemap adds a sitemap.xml listing the HTML pages at their URLs under
+// siteURL, and a robots.txt pointing crawlers to it, unless the files
+// already have their own
+func Sitemap(files []File, siteURL string) ([]File, error) {
+	siteURL = strings.TrimSuffix(siteURL, "/") + "/"
+	type entry struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod,omitempty"`
+	}
+	var set struct {
+		XMLName xml.Name `xml:"urlset"`
+		Xmlns   string   `xml:"xmlns,attr"`
+		URLs    []entry  `xml:"url"`
+	}
+	set.Xmlns = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	for _, f := range files {
+		if !isPage(f.Path) {
+			continue
+		}
+		segments := strings.Split(f.Path, "/")
+		for i, segment := range segments {
+			segments[i] = url.PathEscape(segment)
+		}
+		loc := strings.Join(segments, "/")
+		if path.Base(f.Path) == "index.html" {
+			loc = strings.TrimSuffix(loc, "index.html")
+		}
+		e := entry{Loc: siteURL + loc}
+		if f.Source != "" {
+			if info, err := os.Stat(f.Source); err == nil {
+				e.LastMod = info.ModTime().UTC().Format("2006-01-02")
+			}
+		}
+		set.URLs = append(set.URLs, e)
+	}
+
+	if !hasFile(files, "sitemap.xml") {
+		data, err := xml.MarshalIndent(set, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("writing sitemap: %w", err)
+		}
+		files = append(files, File{Path: "sitemap.xml", Data: append([]byte(xml.Header), append(data, '\n')...)})
+	}
+	if !hasFile(files, "robots.txt") {
+		files = append(files, File{Path: "robots.txt", Data: []byte("User-agent: *\nAllow: /\n\nSitemap: " + siteURL + "sitemap.xml\n")})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// NoIndex keeps the files out of search engines: every HTML page gets a
+// robots meta tag saying not to index it or follow its links. Crawlers
+// aren't turned away in robots.txt, since they'd then never see the tag
+// and could still index pages linked from elsewhere by their URL.
+func NoIndex(files []File) ([]File, error) {
+	out := make([]File, 0, len(files))
+	for _, f := range files {
+		if !isPage(f.Path) {
+			out = append(out, f)
+			continue
+		}
+		data, err := f.read()
+		if err != nil {
+			return nil, err
+		}
+		if !robotsPattern.Match(data) {
+			data = addRobotsMeta(data)
+		}
+		out = append(out, File{Path: f.Path, Data: data})
+	}
+	return out, nil
+}
+
+// addRobotsMeta puts the robots meta tag at the top of a page's head, or
+// of the page if it has none
+func addRobotsMeta(page []byte) []byte {
+	loc := headPattern.FindIndex(page)
+	if loc == nil {
+		return append([]byte(robotsMeta+"\n"), page...)
+	}
+	out := append([]byte{}, page[:loc[1]]...)
+	out = append(out, "\n"+robotsMeta...)
+	return append(out, page[loc[1]:]...)
+}
+
+func isPage(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), ".html")
+}
+
+func hasFile(files []File, name string) bool {
+	for _, f := range files {
+		if f.Path == name {
+			return true
+		}
+	}
+	return false
+}
+
+// indexPage lists the HTML pages, titled from their <title>, under a form
+// for the search page if there is one and the archive's statistics
+func indexPage(files []File, search bool, stats string) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Claude Code sessions</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; max-width: 860px; margin: 2rem auto; padding: 0 1rem; color: #222; }
+h2 { font-size: 1rem; color: #666; margin-top: 2rem; }
+li { margin: 0.3rem 0; }
+form { display: flex; gap: 0.5rem; }
+form input { flex: 1; font: inherit; padding: 0.4rem; }
+.cards { display: flex; gap: 0.75rem; margin: 1.5rem 0 0.5rem; flex-wrap: wrap; }
+.card { flex: 1; min-width: 120px; padding: 0.6rem 0.8rem; border: 1px solid #ddd; border-radius: 6px; }
+.card .label { color: #666; font-size: 0.75rem; text-transform: uppercase; letter-spacing: 0.05em; }
+.card .value { font-size: 1.3rem; margin-top: 0.2rem; font-variant-numeric: tabular-nums; }
+.note { color: #666; font-size: 0.85rem; }
+svg { width: 100%; height: auto; }
+svg rect { fill: #3b82f6; }
+svg text { font-size: 12px; fill: #444; }
+</style>
+</head>
+<body>
+<h1>Claude Code sessions</h1>
+`)
+	if search {
+		b.WriteString(`<form action="` + SearchPage + `" role="search">
+<input type="search" name="q" placeholder="Search prompts and replies" aria-label="Search sessions">
+<button type="submit">Search</button>
+</form>
+`)
+	}
+	b.WriteString(stats)
+	dir := "\x00"
+	for _, f := range files {
+		if !strings.HasSuffix(strings.ToLower(f.Path), ".html") {
+			continue
+		}
+		if d := path.Dir(f.Path); d != dir {
+			if dir != "\x00" {
+				b.WriteString("</ul>\n")
+			}
+			if d != "." {
+				fmt.Fprintf(&b, "<h2>%s</h2>\n", html.EscapeString(d))
+			}
+			b.WriteString("<ul>\n")
+			dir = d
+		}
+		title, err := pageTitle(f)
+		if err != nil {
+			return nil, err
+		}
+		if title == "" {
+			title = path.Base(f.Path)
+		}
+		fmt.Fprintf(&b, "<li><a href=\"%s\">%s</a></li>\n", html.EscapeString(f.Path), html.EscapeString(title))
+	}
+	if dir != "\x00" {
+		b.WriteString("</ul>\n")
+	} else {
+		b.WriteString("<p>No pages yet.</p>\n")
+	}
+	b.WriteString("</body>\n</html>\n")
+	return []byte(b.String()), nil
+}
+
+// pageTitle reads the <title> from the start of a page
+func pageTitle(f File) (string, error) {
+	r, err := os.Open(f.Source)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	head := make([]byte, 8192)
+	n, _ := io.ReadFull(r, head)
+	m := titlePattern.FindSubmatch(head[:n])
+	if m == nil {
+		return "", nil
+	}
+	return html.UnescapeString(strings.TrimSpace(string(m[1]))), nil
+}
+
+// Publish replaces the contents of the branch with files in one commit,
+// creating the branch if needed. History is kept, so earlier versions stay
+// in the repository.
+func Publish(files []File, opts Options) (*Result, error) {
+	if !ValidRepo(opts.Repo) {
+		return nil, fmt.Errorf("repository %q should look like owner/name", opts.Repo)
+	}
+	if opts.Branch == "" {
+		opts.Branch = DefaultBranch
+	}
+	if opts.Message == "" {
+		opts.Message = DefaultMessage
+	}
+
+	result := &Result{Files: len(files), URL: PagesURL(opts.Repo)}
+	for _, f := range files {
+		if f.Source == "" {
+			result.Size += int64(len(f.Data))
+		} else if info, err := os.Stat(f.Source); err == nil {
+			result.Size += info.Size()
+		}
+	}
+
+	var err error
+	if !opts.UseGit && HasToken() {
+		err = viaAPI(files, opts, result)
+	} else {
+		err = viaGit(files, opts, result)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// blobSHA is the ID git gives a file's content
+func blobSHA(data []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(data))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// viaAPI publishes with the Git Data API, uploading only the files whose
+// content isn't already on the branch
+func viaAPI(files []File, opts Options, result *Result) error {
+	repo := "/repos/" + opts.Repo
+
+	var parent, parentTree string
+	existing := make(map[string]string) // Path -> blob SHA
+	var ref struct {
+		Object struct {
+			SHA string `json:"sha"`
+		} `json:"object"`
+	}
+	status, err := call(http.MethodGet, repo+"/git/ref/heads/"+opts.Branch, nil, &ref)
+	switch {
+	case status == http.StatusNotFound:
+		// New branch
+	case err != nil:
+		return fmt.Errorf("reading branch %s: %w", opts.Branch, err)
+	default:
+		parent = ref.Object.SHA
+		var commit struct {
+			Tree struct {
+				SHA string `json:"sha"`
+			} `json:"tree"`
+		}
+		if _, err := call(http.MethodGet, repo+"/git/commits/"+parent, nil, &commit); err != nil {
+			return fmt.Errorf("reading branch %s: %w", opts.Branch, err)
+		}
+		parentTree = commit.Tree.SHA
+
+		var tree struct {
+			Tree []struct {
+				Path string `json:"path"`
+				Type string `json:"type"`
+				SHA  string `json:"sha"`
+			} `json:"tree"`
+		}
+		if _, err := call(http.MethodGet, repo+"/git/trees/"+parentTree+"?recursive=1", nil, &tree); err != nil {
+			return fmt.Errorf("reading branch %s: %w", opts.Branch, err)
+		}
+		for _, entry := range tree.Tree {
+			if entry.Type == "blob" {
+				existing[entry.Path] = entry.SHA
+			}
+		}
+	}
+
+	type treeEntry struct {
+		Path string `json:"path"`
+		Mode string `json:"mode"`
+		Type string `json:"type"`
+		SHA  string `json:"sha"`
+	}
+	var entries []treeEntry
+	for _, f := range files {
+		data, err := f.read()
+		if err != nil {
+			return err
+		}
+		sha := blobSHA(data)
+		if existing[f.Path] != sha {
+			var blob struct {
+				SHA string `json:"sha"`
+			}
+			body := map[string]string{"content": base64.StdEncoding.EncodeToString(data), "encoding": "base64"}
+			if _, err := call(http.MethodPost, repo+"/git/blobs", body, &blob); err != nil {
+				return fmt.Errorf("uploading %s: %w", f.Path, err)
+			}
+			sha = blob.SHA
+		}
+		entries = append(entries, treeEntry{Path: f.Path, Mode: "100644", Type: "blob", SHA: sha})
+	}
+
+	var tree struct {
+		SHA string `json:"sha"`
+	}
+	if _, err := call(http.MethodPost, repo+"/git/trees", map[string]interface{}{"tree": entries}, &tree); err != nil {
+		return fmt.Errorf("creating tree: %w", err)
+	}
+	if tree.SHA != parentTree {
+		parents := []string{}
+		if parent != "" {
+			parents = append(parents, parent)
+		}
+		var commit struct {
+			SHA string `json:"sha"`
+		}
+		body := map[string]interface{}{"message": opts.Message, "tree": tree.SHA, "parents": parents}
+		if _, err := call(http.MethodPost, repo+"/git/commits", body, &commit); err != nil {
+			return fmt.Errorf("creating commit: %w", err)
+		}
+
+		if parent == "" {
+			_, err = call(http.MethodPost, repo+"/git/refs", map[string]string{"ref": "refs/heads/" + opts.Branch, "sha": commit.SHA}, nil)
+		} else {
+			_, err = call(http.MethodPatch, repo+"/git/refs/heads/"+opts.Branch, map[string]interface{}{"sha": commit.SHA}, nil)
+		}
+		if err != nil {
+			return fmt.Errorf("updating branch %s: %w", opts.Branch, err)
+		}
+		result.Commit = commit.SHA
+		result.Changed = true
+	}
+
+	var pages struct {
+		HTMLURL string `json:"html_url"`
+	}
+	status, err = call(http.MethodGet, repo+"/pages", nil, &pages)
+	switch {
+	case status == http.StatusNotFound:
+		enabled := false
+		result.PagesEnabled = &enabled
+	case err == nil:
+		enabled := true
+		result.PagesEnabled = &enabled
+		if pages.HTMLURL != "" {
+			result.URL = pages.HTMLURL
+		}
+	}
+	return nil
+}
+
+// call makes an API request, decoding the JSON reply into out. It returns
+// the status code along with any error.
+func call(method, endpoint string, body, out interface{}) (int, error) {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, apiURL+endpoint, reqBody)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token())
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(respBody, &apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(respBody))
+		}
+		return resp.StatusCode, fmt.Errorf("%s: %s", resp.Status, apiErr.Message)
+	}
+	if out != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return resp.StatusCode, fmt.Errorf("parsing response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// viaGit publishes by cloning the branch, replacing its files and pushing,
+// using whatever credentials git has for the remote
+func viaGit(files []File, opts Options, result *Result) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return errors.New("publishing needs GITHUB_TOKEN or git")
+	}
+	remote := opts.Remote
+	if remote == "" {
+		remote = "https://github.com/" + opts.Repo + ".git"
+	}
+
+	work, err := os.MkdirTemp("", "claude-publish-*")
+	if err != nil {
+		return fmt.Errorf("creating temp directory: %w", err)
+	}
+	defer os.RemoveAll(work)
+
+	heads, err := git("", "ls-remote", "--heads", remote, opts.Branch)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(heads) != "" {
+		if _, err := git("", "clone", "--quiet", "--depth", "1", "--single-branch", "--branch", opts.Branch, remote, work); err != nil {
+			return err
+		}
+	} else {
+		if _, err := git(work, "init", "--quiet"); err != nil {
+			return err
+		}
+		if _, err := git(work, "checkout", "--quiet", "--orphan", opts.Branch); err != nil {
+			return err
+		}
+		if _, err := git(work, "remote", "add", "origin", remote); err != nil {
+			return err
+		}
+	}
+
+	// Replace everything but the repository itself
+	entries, err := os.ReadDir(work)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Name() != ".git" {
+			if err := os.RemoveAll(filepath.Join(work, e.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	for _, f := range files {
+		data, err := f.read()
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(work, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dest, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	if _, err := git(work, "add", "--all"); err != nil {
+		return err
+	}
+	status, err := git(work, "status", "--porcelain")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(status) == "" {
+		return nil
+	}
+
+	args := []string{"commit", "--quiet", "-m", opts.Message}
+	if name, _ := git(work, "config", "user.email"); strings.TrimSpace(name) == "" {
+		args = append([]string{"-c", "user.name=claude-session-export", "-c", "user.email=claude-session-export@users.noreply.github.com"}, args...)
+	}
+	if _, err := git(work, args...); err != nil {
+		return err
+	}
+	if _, err := git(work, "push", "--quiet", "origin", opts.Branch); err != nil {
+		return err
+	}
+	sha, err := git(work, "rev-parse", "HEAD")
+	if err != nil {
+		return err
+	}
+	result.Commit = strings.TrimSpace(sha)
+	result.Changed = true
+	return nil
+}
+
+// git runs a git command in dir, returning its output
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	// Fail instead of prompting for credentials
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,414 @@
+package publish
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+func writeArchive(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "myproj"), 0755)
+	os.MkdirAll(filepath.Join(dir, ".cache"), 0755)
+	os.WriteFile(filepath.Join(dir, "myproj", "fix-login.html"), []byte("<html><head><title>Fix &amp; login</title></head></html>"), 0644)
+	os.WriteFile(filepath.Join(dir, "myproj", "session.jsonl"), []byte("{}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ".DS_Store"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(dir, ".cache", "page"), []byte("x"), 0644)
+	return dir
+}
+
+func paths(files []File) []string {
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Path)
+	}
+	return names
+}
+
+func TestFiles(t *testing.T) {
+	files, err := Files(writeArchive(t), nil)
+	if err != nil {
+		t.Fatalf("Files failed: %v", err)
+	}
+	want := []string{".nojekyll", "index.html", "myproj/fix-login.html", "myproj/session.jsonl"}
+	if got := paths(files); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	index := string(files[1].Data)
+	if !strings.Contains(index, `<a href="myproj/fix-login.html">Fix &amp; login</a>`) || !strings.Contains(index, "<h2>myproj</h2>") {
+		t.Errorf("Expected the index to link the page by its title, got:\n%s", index)
+	}
+}
+
+func TestSitemap(t *testing.T) {
+	files, _ := Files(writeArchive(t), nil)
+	files, err := Sitemap(files, "https://acme.github.io/transcripts")
+	if err != nil {
+		t.Fatalf("Sitemap failed: %v", err)
+	}
+	want := []string{".nojekyll", "index.html", "myproj/fix-login.html", "myproj/session.jsonl", "robots.txt", "sitemap.xml"}
+	if got := paths(files); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	sitemap := string(files[5].Data)
+	for _, loc := range []string{"<loc>https://acme.github.io/transcripts/</loc>", "<loc>https://acme.github.io/transcripts/myproj/fix-login.html</loc>"} {
+		if !strings.Contains(sitemap, loc) {
+			t.Errorf("Expected %s in the sitemap, got:\n%s", loc, sitemap)
+		}
+	}
+	if strings.Contains(sitemap, "session.jsonl") {
+		t.Errorf("Expected only pages in the sitemap, got:\n%s", sitemap)
+	}
+	if robots := string(files[4].Data); !strings.Contains(robots, "Sitemap: https://acme.github.io/transcripts/sitemap.xml") {
+		t.Errorf("Expected robots.txt to point to the sitemap, got %q", robots)
+	}
+}
+
+func TestNoIndex(t *testing.T) {
+	dir := writeArchive(t)
+	os.WriteFile(filepath.Join(dir, "kept.html"), []byte(`<head><meta name="robots" content="noindex"></head>`), 0644)
+	files, _ := Files(dir, nil)
+	files, err := NoIndex(files)
+	if err != nil {
+		t.Fatalf("NoIndex failed: %v", err)
+	}
+	byPath := make(map[string]string)
+	for _, f := range files {
+		data, _ := f.read()
+		byPath[f.Path] = string(data)
+	}
+
+	if page := byPath["myproj/fix-login.html"]; page != "<html><head>\n"+robotsMeta+"<title>Fix &amp; login</title></head></html>" {
+		t.Errorf("Expected the robots tag at the top of the head, got %q", page)
+	}
+	if !strings.Contains(byPath["index.html"], robotsMeta) {
+		t.Error("Expected the generated index to carry the robots tag")
+	}
+	if strings.Count(byPath["kept.html"], "robots") != 1 {
+		t.Errorf("Expected a page's own robots tag kept as is, got %q", byPath["kept.html"])
+	}
+	if byPath["myproj/session.jsonl"] != "{}\n" {
+		t.Errorf("Expected other files left alone, got %q", byPath["myproj/session.jsonl"])
+	}
+	// Crawlers have to reach the pages to see the tag
+	if robots, ok := byPath["robots.txt"]; ok {
+		t.Errorf("Expected no robots.txt, got %q", robots)
+	}
+}
+
+func TestFiles_Search(t *testing.T) {
+	dir := writeArchive(t)
+	jsonl := `{"type":"user","cwd":"/src/webapp","timestamp":"2025-06-01T10:00:00Z","message":{"role":"user","content":"Why does the   login redirect loop?"}}
+{"type":"assistant","timestamp":"2025-06-01T10:00:05Z","message":{"role":"assistant","content":[{"type":"text","text":"The session cookie is dropped."},{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"grep secret"}}]}}
+`
+	page := `<html><head><title>Session Viewer</title></head><script>atob("` + base64.StdEncoding.EncodeToString([]byte(jsonl)) + `")</script></html>`
+	os.WriteFile(filepath.Join(dir, "myproj", "loop.html"), []byte(page), 0644)
+
+	files, err := Files(dir, nil)
+	if err != nil {
+		t.Fatalf("Files failed: %v", err)
+	}
+	byPath := make(map[string][]byte)
+	for _, f := range files {
+		byPath[f.Path], _ = f.read()
+	}
+	if _, ok := byPath[SearchPage]; !ok {
+		t.Fatalf("Expected a search page, got %v", paths(files))
+	}
+	if index := string(byPath["index.html"]); !strings.Contains(index, `<form action="search.html"`) || strings.Contains(index, `href="search.html"`) {
+		t.Errorf("Expected the index to have a search form and not list the search page, got:\n%s", index)
+	}
+
+	var entries []searchEntry
+	if err := json.Unmarshal(byPath[SearchIndex], &entries); err != nil {
+		t.Fatalf("Expected a JSON search index: %v", err)
+	}
+	want := searchEntry{
+		URL:     "myproj/loop.html",
+		Title:   "Why does the login redirect loop?",
+		Project: "webapp",
+		Date:    "2025-06-01",
+		Text:    "Why does the login redirect loop? The session cookie is dropped.",
+	}
+	if len(entries) != 1 || entries[0] != want {
+		t.Errorf("Expected only the viewer page indexed as %+v, got %+v", want, entries)
+	}
+}
+
+func TestFiles_KeepsOwnIndex(t *testing.T) {
+	dir := writeArchive(t)
+	os.WriteFile(filepath.Join(dir, "index.html"), []byte("mine"), 0644)
+	os.WriteFile(filepath.Join(dir, "_config.yml"), []byte("title: x"), 0644)
+
+	files, err := Files(dir, nil)
+	if err != nil {
+		t.Fatalf("Files failed: %v", err)
+	}
+	for _, f := range files {
+		if f.Source == "" {
+			t.Errorf("Expected no generated files, got %s", f.Path)
+		}
+	}
+}
+
+func TestFiles_Empty(t *testing.T) {
+	if _, err := Files(t.TempDir(), nil); err == nil {
+		t.Error("Expected an error for an empty directory")
+	}
+}
+
+func TestPagesURL(t *testing.T) {
+	tests := map[string]string{
+		"Acme/transcripts":    "https://acme.github.io/transcripts/",
+		"acme/acme.github.io": "https://acme.github.io/",
+	}
+	for repo, want := range tests {
+		if got := PagesURL(repo); got != want {
+			t.Errorf("PagesURL(%q) = %q, expected %q", repo, got, want)
+		}
+	}
+}
+
+func TestValidRepo(t *testing.T) {
+	for repo, want := range map[string]bool{"acme/site": true, "acme/my.site-2": true, "acme": false, "a/b/c": false, "": false} {
+		if got := ValidRepo(repo); got != want {
+			t.Errorf("ValidRepo(%q) = %v, expected %v", repo, got, want)
+		}
+	}
+}
+
+// fakeGitHub keeps just enough of a repository to serve the Git Data API
+type fakeGitHub struct {
+	mu      sync.Mutex
+	blobs   map[string][]byte
+	trees   map[string]map[string]string // Tree SHA -> path -> blob SHA
+	commits map[string]string            // Commit SHA -> tree SHA
+	ref     string
+	uploads int
+}
+
+func (g *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	reply := func(status int, v interface{}) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+	}
+	const repo = "/repos/acme/site"
+	p := r.Method + " " + strings.TrimPrefix(r.URL.Path, repo)
+
+	switch {
+	case p == "GET /git/ref/heads/gh-pages":
+		if g.ref == "" {
+			reply(404, map[string]string{"message": "Not Found"})
+			return
+		}
+		reply(200, map[string]interface{}{"object": map[string]string{"sha": g.ref}})
+	case strings.HasPrefix(p, "GET /git/commits/"):
+		reply(200, map[string]interface{}{"tree": map[string]string{"sha": g.commits[strings.TrimPrefix(p, "GET /git/commits/")]}})
+	case strings.HasPrefix(p, "GET /git/trees/"):
+		var entries []map[string]string
+		for path, sha := range g.trees[strings.TrimPrefix(p, "GET /git/trees/")] {
+			entries = append(entries, map[string]string{"path": path, "type": "blob", "sha": sha})
+		}
+		reply(200, map[string]interface{}{"tree": entries})
+	case p == "POST /git/blobs":
+		var body struct{ Content string }
+		json.NewDecoder(r.Body).Decode(&body)
+		data, _ := base64.StdEncoding.DecodeString(body.Content)
+		sha := blobSHA(data)
+		g.blobs[sha] = data
+		g.uploads++
+		reply(201, map[string]string{"sha": sha})
+	case p == "POST /git/trees":
+		var body struct {
+			Tree []struct{ Path, SHA string }
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		tree := make(map[string]string)
+		var lines []string
+		for _, e := range body.Tree {
+			if g.blobs[e.SHA] == nil {
+				reply(422, map[string]string{"message": "unknown blob " + e.SHA})
+				return
+			}
+			tree[e.Path] = e.SHA
+			lines = append(lines, e.Path+" "+e.SHA)
+		}
+		sort.Strings(lines)
+		sum := sha1.Sum([]byte(strings.Join(lines, "\n")))
+		sha := hex.EncodeToString(sum[:])
+		g.trees[sha] = tree
+		reply(201, map[string]string{"sha": sha})
+	case p == "POST /git/commits":
+		var body struct {
+			Tree    string
+			Parents []string
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if len(body.Parents) > 0 && body.Parents[0] != g.ref {
+			reply(422, map[string]string{"message": "wrong parent"})
+			return
+		}
+		sha := fmt.Sprintf("c%d", len(g.commits)+1)
+		g.commits[sha] = body.Tree
+		reply(201, map[string]string{"sha": sha})
+	case p == "POST /git/refs", p == "PATCH /git/refs/heads/gh-pages":
+		var body struct{ SHA string }
+		json.NewDecoder(r.Body).Decode(&body)
+		g.ref = body.SHA
+		reply(200, map[string]string{"ref": "refs/heads/gh-pages"})
+	case p == "GET /pages":
+		reply(404, map[string]string{"message": "Not Found"})
+	default:
+		reply(500, map[string]string{"message": "unexpected " + p})
+	}
+}
+
+func TestPublishViaAPI(t *testing.T) {
+	fake := &fakeGitHub{blobs: map[string][]byte{}, trees: map[string]map[string]string{}, commits: map[string]string{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	apiURL = server.URL
+	t.Setenv("GITHUB_TOKEN", "secret")
+
+	dir := writeArchive(t)
+	files, _ := Files(dir, nil)
+	result, err := Publish(files, Options{Repo: "acme/site"})
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if !result.Changed || result.Commit != "c1" || fake.ref != "c1" || fake.uploads != 4 {
+		t.Errorf("Expected a first commit with 4 files, got %+v (%d uploads)", result, fake.uploads)
+	}
+	if result.PagesEnabled == nil || *result.PagesEnabled || result.URL != "https://acme.github.io/site/" {
+		t.Errorf("Expected Pages reported as not enabled, got %+v", result)
+	}
+
+	// Publishing the same files again changes nothing
+	result, err = Publish(files, Options{Repo: "acme/site"})
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if result.Changed || fake.uploads != 4 {
+		t.Errorf("Expected nothing to publish, got %+v (%d uploads)", result, fake.uploads)
+	}
+
+	// Only the changed file is uploaded
+	os.WriteFile(filepath.Join(dir, "myproj", "session.jsonl"), []byte("{}\n{}\n"), 0644)
+	files, _ = Files(dir, nil)
+	result, err = Publish(files, Options{Repo: "acme/site"})
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if result.Commit != "c2" || fake.ref != "c2" || fake.uploads != 5 {
+		t.Errorf("Expected a second commit uploading one file, got %+v (%d uploads)", result, fake.uploads)
+	}
+}
+
+func TestPublishViaGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	remote := filepath.Join(t.TempDir(), "site.git")
+	if out, err := exec.Command("git", "init", "--quiet", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v: %s", err, out)
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	dir := writeArchive(t)
+	files, _ := Files(dir, nil)
+	opts := Options{Repo: "acme/site", UseGit: true, Remote: remote}
+	result, err := Publish(files, opts)
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if !result.Changed || result.Commit == "" {
+		t.Errorf("Expected a commit, got %+v", result)
+	}
+
+	out, err := exec.Command("git", "--git-dir", remote, "ls-tree", "-r", "--name-only", "gh-pages").Output()
+	if err != nil {
+		t.Fatalf("git ls-tree failed: %v", err)
+	}
+	if got := strings.Fields(string(out)); strings.Join(got, " ") != strings.Join(paths(files), " ") {
+		t.Errorf("Expected the branch to hold %v, got %v", paths(files), got)
+	}
+
+	// Removed files are removed from the branch too
+	os.Remove(filepath.Join(dir, "myproj", "session.jsonl"))
+	files, _ = Files(dir, nil)
+	if _, err := Publish(files, opts); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	out, _ = exec.Command("git", "--git-dir", remote, "ls-tree", "-r", "--name-only", "gh-pages").Output()
+	if strings.Contains(string(out), "session.jsonl") {
+		t.Errorf("Expected session.jsonl removed, got %s", out)
+	}
+
+	result, err = Publish(files, opts)
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if result.Changed {
+		t.Errorf("Expected nothing to publish, got %+v", result)
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	parse := func(jsonl string) *session.Session {
+		sess, err := session.Parse([]byte(jsonl))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sess
+	}
+	sessions := []archivedSession{
+		{URL: "a.html", Project: "webapp", Session: parse(`{"type":"user","timestamp":"2025-06-02T10:00:00Z","message":{"role":"user","content":"Fix it"}}
+{"type":"assistant","timestamp":"2025-06-02T10:00:05Z","message":{"id":"m1","role":"assistant","model":"claude-sonnet-4-20250514","content":[{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"make"}},{"type":"tool_use","id":"t2","name":"Bash","input":{"command":"make test"}}],"usage":{"input_tokens":1000000,"output_tokens":0}}}
+`)},
+		{URL: "b.html", Project: "api", Session: parse(`{"type":"user","timestamp":"2025-06-23T10:00:00Z","message":{"role":"user","content":"Add a route"}}
+{"type":"assistant","timestamp":"2025-06-23T10:00:05Z","message":{"id":"m2","role":"assistant","model":"mystery-model","content":[{"type":"tool_use","id":"t3","name":"Read","input":{"file_path":"/api/main.go"}}],"usage":{"input_tokens":500,"output_tokens":0}}}
+`)},
+	}
+
+	page := dashboard(sessions, session.DefaultPricing)
+	for _, want := range []string{
+		`<div class="label">Sessions</div><div class="value">2</div>`,
+		`<div class="label">Tokens</div><div class="value">1.0M</div>`,
+		`<div class="label">Est. cost</div><div class="value">$3.00*</div>`,
+		"<title>Week of 2025-06-09: 0 sessions</title>", // Weeks without sessions are drawn
+		`aria-label="Tokens per project"`,
+		`text-anchor="end">webapp</text>`,
+		"<title>Bash: 2 calls</title>",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected %s in the dashboard, got:\n%s", want, page)
+		}
+	}
+	if strings.Index(page, ">webapp<") > strings.Index(page, ">api<") {
+		t.Error("Expected projects ordered by tokens")
+	}
+	if dashboard(nil, session.DefaultPricing) != "" {
+		t.Error("Expected no dashboard without sessions")
+	}
+}
@@ -0,0 +1,291 @@
+package publish
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+// Names of the generated search page and the index it loads
+const (
+	SearchPage  = "search.html"
+	SearchIndex = "search-index.json"
+)
+
+// maxSearchText caps the text indexed per session, so the index stays
+// small enough to load in a browser
+const maxSearchText = 32 << 10
+
+// embeddedPattern matches the session a viewer page carries
+var embeddedPattern = regexp.MustCompile(`atob\("([A-Za-z0-9+/=]*)"\)`)
+
+// searchEntry is one session in the search index
+type searchEntry struct {
+	URL     string `json:"url"`
+	Title   string `json:"title"`
+	Project string `json:"project,omitempty"`
+	Date    string `json:"date,omitempty"` // YYYY-MM-DD
+	Text    string `json:"text"`
+}
+
+// archivedSession is a session carried by one of the viewer pages
+type archivedSession struct {
+	URL     string
+	Title   string
+	Project string
+	Session *session.Session
+}
+
+// archivedSessions reads the session embedded in each viewer page among
+// files. Pages without one, or whose data doesn't parse, are left out.
+func archivedSessions(files []File) ([]archivedSession, error) {
+	var sessions []archivedSession
+	for _, f := range files {
+		if !isPage(f.Path) {
+			continue
+		}
+		data, err := f.read()
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := pageSession(f.Path, data); ok {
+			sessions = append(sessions, s)
+		}
+	}
+	return sessions, nil
+}
+
+// Page is a viewer page that carries a session
+type Page struct {
+	Path string // As in File
+	Size int
+}
+
+// SessionPages lists the viewer pages among files that carry a session
+func SessionPages(files []File) ([]Page, error) {
+	var pages []Page
+	for _, f := range files {
+		if !isPage(f.Path) {
+			continue
+		}
+		data, err := f.read()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := pageSession(f.Path, data); ok {
+			pages = append(pages, Page{Path: f.Path, Size: len(data)})
+		}
+	}
+	return pages, nil
+}
+
+// pageSession decodes the session a viewer page embeds, titled from the
+// page, or if it has the viewer's own title, from the session
+func pageSession(name string, page []byte) (archivedSession, bool) {
+	m := embeddedPattern.FindSubmatch(page)
+	if m == nil {
+		return archivedSession{}, false
+	}
+	data, err := base64.StdEncoding.DecodeString(string(m[1]))
+	if err != nil {
+		return archivedSession{}, false
+	}
+	sess, err := session.Parse(data)
+	if err != nil || len(sess.Messages) == 0 {
+		return archivedSession{}, false
+	}
+
+	s := archivedSession{URL: name, Session: sess}
+	if d := path.Dir(name); d != "." {
+		s.Project = d
+	}
+	if t := titlePattern.FindSubmatch(page); t != nil {
+		s.Title = html.UnescapeString(strings.TrimSpace(string(t[1])))
+	}
+	if s.Title == "Session Viewer" {
+		s.Title = ""
+	}
+	if meta := sess.Metadata; meta != nil {
+		if s.Title == "" {
+			s.Title = meta.Title
+		}
+		if meta.Cwd != "" {
+			s.Project = filepath.Base(meta.Cwd)
+		}
+	}
+	if s.Title == "" {
+		if prompts := session.GetUserPrompts(sess); len(prompts) > 0 {
+			s.Title = shorten(session.ExtractText(&prompts[0]), 80)
+		}
+	}
+	if s.Title == "" {
+		s.Title = path.Base(name)
+	}
+	return s, true
+}
+
+// searchFiles builds the search index from the archived sessions, and the
+// page that searches it. It returns nothing when there are no sessions.
+func searchFiles(sessions []archivedSession) ([]File, error) {
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+	entries := make([]searchEntry, 0, len(sessions))
+	for _, s := range sessions {
+		entries = append(entries, searchEntryFor(s))
+	}
+	// Newest first, as results are listed
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Date > entries[j].Date })
+
+	index, err := json.Marshal(entries)
+	if err != nil {
+		return nil, fmt.Errorf("writing search index: %w", err)
+	}
+	return []File{
+		{Path: SearchIndex, Data: index},
+		{Path: SearchPage, Data: []byte(searchPage)},
+	}, nil
+}
+
+// searchEntryFor indexes a session's prompts and replies, without tool
+// calls
+func searchEntryFor(s archivedSession) searchEntry {
+	entry := searchEntry{URL: s.URL, Title: s.Title, Project: s.Project}
+	if meta := s.Session.Metadata; meta != nil && !meta.StartTime.IsZero() {
+		entry.Date = meta.StartTime.UTC().Format("2006-01-02")
+	}
+	var text strings.Builder
+	for _, e := range session.SearchEntries(s.Session) {
+		if e.Text == "" {
+			continue
+		}
+		if text.Len() > 0 {
+			text.WriteByte(' ')
+		}
+		text.WriteString(strings.Join(strings.Fields(e.Text), " "))
+		if text.Len() >= maxSearchText {
+			break
+		}
+	}
+	entry.Text = shorten(text.String(), maxSearchText)
+	return entry
+}
+
+// shorten cuts text to at most n bytes on one line, without splitting a
+// character
+func shorten(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) <= n {
+		return text
+	}
+	text = text[:n]
+	for !utf8.ValidString(text) {
+		text = text[:len(text)-1]
+	}
+	return text
+}
+
+// searchPage loads the search index and lists the sessions matching every
+// word of the query, linking each to its page with the query highlighted
+const searchPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Search Claude Code sessions</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; max-width: 860px; margin: 2rem auto; padding: 0 1rem; color: #222; }
+input { font: inherit; width: 100%; box-sizing: border-box; padding: 0.5rem; }
+#status { color: #666; }
+li { margin: 1rem 0; list-style: none; }
+ul { padding: 0; }
+.meta { color: #666; font-size: 0.9rem; }
+.snippet { margin: 0.2rem 0 0; }
+mark { background: #fde68a; }
+</style>
+</head>
+<body>
+<p><a href="index.html">All sessions</a></p>
+<h1>Search sessions</h1>
+<input type="search" id="q" placeholder="Search prompts and replies" aria-label="Search sessions" autofocus>
+<p id="status" role="status" aria-live="polite">Loading the index...</p>
+<ul id="results"></ul>
+<script>
+const input = document.getElementById('q');
+const statusLine = document.getElementById('status');
+const results = document.getElementById('results');
+let sessions = null;
+
+function escapeHtml(s) {
+	return s.replace(/[&<>"']/g, c => ({ '&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;', "'": '&#39;' })[c]);
+}
+
+function mark(text, terms) {
+	const pattern = new RegExp(terms.map(t => t.replace(/[.*+?^${}()|[\]\\]/g, '\\$&')).join('|'), 'gi');
+	let out = '', last = 0;
+	for (const m of text.matchAll(pattern)) {
+		out += escapeHtml(text.slice(last, m.index)) + '<mark>' + escapeHtml(m[0]) + '</mark>';
+		last = m.index + m[0].length;
+	}
+	return out + escapeHtml(text.slice(last));
+}
+
+function snippet(s, terms) {
+	const at = Math.max(0, s.lower.indexOf(terms[0]));
+	const start = Math.max(0, at - 80);
+	const text = s.text.slice(start, at + 160);
+	return (start > 0 ? '...' : '') + text + (at + 160 < s.text.length ? '...' : '');
+}
+
+function search() {
+	const query = input.value.trim();
+	history.replaceState(null, '', query ? '?q=' + encodeURIComponent(query) : location.pathname);
+	results.innerHTML = '';
+	if (!sessions) return;
+	const terms = query.toLowerCase().split(/\s+/).filter(Boolean);
+	if (!terms.length) {
+		statusLine.textContent = sessions.length + ' sessions indexed';
+		return;
+	}
+	const hits = sessions.filter(s => terms.every(t => s.all.includes(t)));
+	statusLine.textContent = hits.length === 1 ? '1 session' : hits.length + ' sessions';
+	if (hits.length > 100) statusLine.textContent += ', showing the newest 100';
+	for (const s of hits.slice(0, 100)) {
+		// The viewer highlights ?q=, so pass the phrase if it's there
+		const q = s.lower.includes(query.toLowerCase()) ? query : terms[0];
+		const li = document.createElement('li');
+		li.innerHTML = '<a href="' + escapeHtml(s.url + '?q=' + encodeURIComponent(q)) + '">' + mark(s.title, terms) + '</a>' +
+			'<div class="meta">' + escapeHtml([s.project, s.date].filter(Boolean).join(' · ')) + '</div>' +
+			'<p class="snippet">' + mark(snippet(s, terms), terms) + '</p>';
+		results.appendChild(li);
+	}
+}
+
+fetch('` + SearchIndex + `')
+	.then(r => {
+		if (!r.ok) throw new Error(r.status + ' ' + r.statusText);
+		return r.json();
+	})
+	.then(index => {
+		sessions = index.map(s => Object.assign(s, {
+			lower: s.text.toLowerCase(),
+			all: (s.title + ' ' + (s.project || '') + ' ' + s.text).toLowerCase()
+		}));
+		input.value = new URLSearchParams(location.search).get('q') || '';
+		search();
+	})
+	.catch(err => { statusLine.textContent = 'Could not load the search index: ' + err.message; });
+input.addEventListener('input', search);
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,139 @@
+package redact
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	homeDirPattern = regexp.MustCompile(`(?:/home/|/Users/|[A-Za-z]:\\+Users\\+)([A-Za-z0-9._\-]+)`)
+	emailPattern   = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`)
+	remotePattern  = regexp.MustCompile(`(?:github\.com|gitlab\.com|bitbucket\.org)[:/]([A-Za-z0-9_.\-]+/[A-Za-z0-9_\-]+(?:\.[A-Za-z0-9_\-]+)*)`)
+	cwdPattern     = regexp.MustCompile(`"cwd"\s*:\s*"([^"]+)"`)
+)
+
+// genericUsernames aren't identifying and are too common to replace safely
+var genericUsernames = map[string]bool{
+	"root":  true,
+	"admin": true,
+	"user":  true,
+}
+
+// placeholders hands out stable numbered placeholders per kind, so the same
+// value always maps to the same placeholder within an export
+type placeholders struct {
+	assigned map[string]string
+	counts   map[string]int
+}
+
+func newPlaceholders() *placeholders {
+	return &placeholders{
+		assigned: make(map[string]string),
+		counts:   make(map[string]int),
+	}
+}
+
+func (p *placeholders) get(kind, value string) string {
+	key := kind + "\x00" + value
+	if name, ok := p.assigned[key]; ok {
+		return name
+	}
+	p.counts[kind]++
+	name := kind
+	if n := p.counts[kind]; n > 1 {
+		name = fmt.Sprintf("%s%d", kind, n)
+	}
+	p.assigned[key] = name
+	return name
+}
+
+// AnonymizeRules builds rules that rewrite home directories, usernames,
+// hostnames, email addresses and repository names found in the session data
+// to stable placeholders. Applied with Redactor.Apply, they rewrite object
+// keys as well as values.
+func AnonymizeRules(data []byte) []Rule {
+	p := newPlaceholders()
+	text := string(data)
+	var rules []Rule
+
+	// Email addresses (git@host remotes are left alone)
+	rules = append(rules, Rule{
+		Pattern: emailPattern,
+		Func: func(match string) string {
+			if strings.HasPrefix(match, "git@") {
+				return match
+			}
+			return p.get("email", strings.ToLower(match)) + "@example.com"
+		},
+	})
+
+	// Repository slugs from git remotes
+	for _, slug := range uniqueMatches(remotePattern, text) {
+		slug = strings.TrimSuffix(slug, ".git")
+		rules = append(rules, literalRule(slug, "org/"+p.get("repo", slug), false))
+	}
+
+	// Project directory names from the session's working directories
+	for _, cwd := range uniqueMatches(cwdPattern, text) {
+		cwd = strings.ReplaceAll(cwd, `\\`, `\`)
+		name := cwd[strings.LastIndexAny(cwd, `/\`)+1:]
+		if len(name) >= 3 {
+			rules = append(rules, literalRule(name, p.get("project", name), true))
+		}
+	}
+
+	// Hostname
+	if host, err := os.Hostname(); err == nil && len(host) >= 3 {
+		short := strings.SplitN(host, ".", 2)[0]
+		rules = append(rules, literalRule(host, "host", true))
+		if short != host && len(short) >= 3 {
+			rules = append(rules, literalRule(short, "host", true))
+		}
+	}
+
+	// Usernames from home directories and the current user
+	names := uniqueMatches(homeDirPattern, text)
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		names = append(names, u.Username[strings.LastIndex(u.Username, `\`)+1:])
+	}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if len(name) < 3 || seen[name] || genericUsernames[name] {
+			continue
+		}
+		seen[name] = true
+		rules = append(rules, literalRule(name, p.get("user", name), true))
+	}
+
+	return rules
+}
+
+// uniqueMatches returns the distinct first submatches of re in text,
+// longest first so that overlapping names are replaced correctly
+func uniqueMatches(re *regexp.Regexp, text string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, m := range re.FindAllStringSubmatch(text, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			out = append(out, m[1])
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return len(out[i]) > len(out[j])
+	})
+	return out
+}
+
+// literalRule replaces a literal string, optionally only at word boundaries
+func literalRule(literal, replacement string, wholeWord bool) Rule {
+	pattern := regexp.QuoteMeta(literal)
+	if wholeWord {
+		pattern = `\b` + pattern + `\b`
+	}
+	return Rule{Pattern: regexp.MustCompile(pattern), Replacement: replacement}
+}
@@ -0,0 +1,167 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// DefaultReplacement is used when a rule doesn't specify its own replacement
+const DefaultReplacement = "[REDACTED]"
+
+// Rule replaces every match of Pattern with Replacement, or with the result
+// of Func when it is set
+type Rule struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+	Func        func(match string) string
+}
+
+// builtinPatterns match common secret formats
+var builtinPatterns = []string{
+	`sk-ant-[A-Za-z0-9_\-]{20,}`,    // Anthropic API keys
+	`sk-[A-Za-z0-9]{32,}`,           // OpenAI-style API keys
+	`gh[pousr]_[A-Za-z0-9]{36,}`,    // GitHub tokens
+	`github_pat_[A-Za-z0-9_]{40,}`,  // GitHub fine-grained tokens
+	`AKIA[0-9A-Z]{16}`,              // AWS access key IDs
+	`xox[baprs]-[A-Za-z0-9\-]{10,}`, // Slack tokens
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`, // Private keys
+}
+
+// Redactor applies redaction rules to session data
+type Redactor struct {
+	rules []Rule
+}
+
+// New creates a redactor with the built-in secret rules followed by custom rules
+func New(custom []Rule) *Redactor {
+	var rules []Rule
+	for _, p := range builtinPatterns {
+		rules = append(rules, Rule{Pattern: regexp.MustCompile(p), Replacement: DefaultReplacement})
+	}
+	rules = append(rules, custom...)
+	return &Redactor{rules: rules}
+}
+
+// ParseRule compiles a user-supplied pattern into a rule
+func ParseRule(pattern, replacement string) (Rule, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+	}
+	if replacement == "" {
+		replacement = DefaultReplacement
+	}
+	return Rule{Pattern: re, Replacement: replacement}, nil
+}
+
+// String redacts a single string, returning the result and number of replacements
+func (r *Redactor) String(s string) (string, int) {
+	count := 0
+	for _, rule := range r.rules {
+		s = rule.Pattern.ReplaceAllStringFunc(s, func(match string) string {
+			count++
+			if rule.Func != nil {
+				return rule.Func(match)
+			}
+			return rule.Replacement
+		})
+	}
+	return s, count
+}
+
+// Apply redacts JSONL session data line by line. Strings, keys included,
+// are redacted as decoded, so escaped secrets are caught too, and only the
+// strings that change are re-encoded: keys keep their order and numbers
+// their digits. Lines without matches are passed through untouched.
+func (r *Redactor) Apply(data []byte) ([]byte, int) {
+	lines := bytes.Split(data, []byte("\n"))
+	total := 0
+
+	for i, line := range lines {
+		out, n, err := r.line(line)
+		if err != nil {
+			// Not JSON - redact the raw text
+			redacted, m := r.String(string(line))
+			out, n = []byte(redacted), m
+		}
+		if n > 0 {
+			lines[i] = out
+			total += n
+		}
+	}
+
+	return bytes.Join(lines, []byte("\n")), total
+}
+
+// line redacts the strings in one line of JSON, object keys as well as
+// values, copying everything else from the input as it is
+func (r *Redactor) line(line []byte) ([]byte, int, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	var out bytes.Buffer
+	total := 0
+	prev := int64(0)
+	depth := 0 // Objects and arrays still open
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		// The input since the last token: separators, spacing and the token
+		end := dec.InputOffset()
+		raw := line[prev:end]
+		prev = end
+
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+
+		s, ok := tok.(string)
+		if !ok {
+			out.Write(raw)
+			continue
+		}
+		redacted, n := r.String(s)
+		if n == 0 {
+			out.Write(raw)
+			continue
+		}
+		encoded, err := marshal(redacted)
+		if err != nil {
+			return nil, 0, err
+		}
+		// Separators and spacing never contain a quote, so the string
+		// starts at the first one
+		quote := bytes.IndexByte(raw, '"')
+		out.Write(raw[:quote])
+		out.Write(encoded)
+		total += n
+	}
+	if depth > 0 {
+		return nil, 0, errors.New("incomplete JSON")
+	}
+	out.Write(line[prev:])
+	return out.Bytes(), total, nil
+}
+
+// marshal encodes v without HTML escaping, matching Claude Code's own output
+func marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
@@ -0,0 +1,288 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+// Periods lists the rollup granularities
+var Periods = []string{"day", "week", "month"}
+
+// Entry is one session's contribution to a report
+type Entry struct {
+	Project string
+	Time    time.Time // When the session started
+	Stats   session.Stats
+}
+
+// Totals are the usage figures summed over a group of sessions
+type Totals struct {
+	Sessions         int
+	InputTokens      int
+	OutputTokens     int
+	CacheReadTokens  int
+	CacheWriteTokens int
+	ToolCalls        int
+	Cost             float64
+}
+
+func (t *Totals) add(e Entry) {
+	t.Sessions++
+	for _, m := range e.Stats.Models {
+		t.InputTokens += m.InputTokens
+		t.OutputTokens += m.OutputTokens
+		t.CacheReadTokens += m.CacheReadTokens
+		t.CacheWriteTokens += m.CacheWriteTokens
+	}
+	for _, n := range e.Stats.Tools {
+		t.ToolCalls += n
+	}
+	t.Cost += e.Stats.Cost
+}
+
+// Tokens is the total of all token kinds
+func (t Totals) Tokens() int {
+	return t.InputTokens + t.OutputTokens + t.CacheReadTokens + t.CacheWriteTokens
+}
+
+// Row is the rollup for one period
+type Row struct {
+	Start time.Time
+	Label string
+	Totals
+}
+
+// ProjectRow is the rollup for one project
+type ProjectRow struct {
+	Project string
+	Totals
+}
+
+// Report is a usage rollup across sessions
+type Report struct {
+	Period    string
+	Generated time.Time
+	Rows      []Row        // Oldest period first
+	Projects  []ProjectRow // Most expensive first
+	Total     Totals
+}
+
+// ValidPeriod reports whether name is one of Periods
+func ValidPeriod(name string) bool {
+	for _, p := range Periods {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// periodStart returns the start of the day, week (Monday) or month
+// containing t, in t's location
+func periodStart(t time.Time, period string) time.Time {
+	switch period {
+	case "week":
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+}
+
+func periodLabel(start time.Time, period string) string {
+	switch period {
+	case "week":
+		return "Week of " + start.Format("2006-01-02")
+	case "month":
+		return start.Format("2006-01")
+	default:
+		return start.Format("2006-01-02")
+	}
+}
+
+// Build rolls entries up by period and project
+func Build(entries []Entry, period string, now time.Time) *Report {
+	r := &Report{Period: period, Generated: now}
+
+	rows := make(map[time.Time]*Row)
+	projects := make(map[string]*ProjectRow)
+	for _, e := range entries {
+		start := periodStart(e.Time, period)
+		row, ok := rows[start]
+		if !ok {
+			row = &Row{Start: start, Label: periodLabel(start, period)}
+			rows[start] = row
+		}
+		row.add(e)
+
+		project, ok := projects[e.Project]
+		if !ok {
+			project = &ProjectRow{Project: e.Project}
+			projects[e.Project] = project
+		}
+		project.add(e)
+
+		r.Total.add(e)
+	}
+
+	for _, row := range rows {
+		r.Rows = append(r.Rows, *row)
+	}
+	sort.Slice(r.Rows, func(i, j int) bool { return r.Rows[i].Start.Before(r.Rows[j].Start) })
+
+	for _, p := range projects {
+		r.Projects = append(r.Projects, *p)
+	}
+	sort.Slice(r.Projects, func(i, j int) bool {
+		if r.Projects[i].Cost != r.Projects[j].Cost {
+			return r.Projects[i].Cost > r.Projects[j].Cost
+		}
+		return r.Projects[i].Project < r.Projects[j].Project
+	})
+	return r
+}
+
+// WriteCSV writes one line per period
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{r.Period, "sessions", "input_tokens", "output_tokens",
+		"cache_read_tokens", "cache_write_tokens", "tool_calls", "estimated_cost_usd"})
+	for _, row := range r.Rows {
+		cw.Write([]string{
+			row.Start.Format("2006-01-02"),
+			strconv.Itoa(row.Sessions),
+			strconv.Itoa(row.InputTokens),
+			strconv.Itoa(row.OutputTokens),
+			strconv.Itoa(row.CacheReadTokens),
+			strconv.Itoa(row.CacheWriteTokens),
+			strconv.Itoa(row.ToolCalls),
+			strconv.FormatFloat(row.Cost, 'f', 4, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// bar is one column of the dashboard's cost chart
+type bar struct {
+	X, Y, Width, Height float64
+	Title               string
+}
+
+// chartBars lays out a bar per period, scaled to the most expensive one
+func (r *Report) chartBars(width, height float64) []bar {
+	if len(r.Rows) == 0 {
+		return nil
+	}
+	max := 0.0
+	for _, row := range r.Rows {
+		if row.Cost > max {
+			max = row.Cost
+		}
+	}
+	step := width / float64(len(r.Rows))
+	bars := make([]bar, len(r.Rows))
+	for i, row := range r.Rows {
+		h := 0.0
+		if max > 0 {
+			h = row.Cost / max * height
+		}
+		bars[i] = bar{
+			X:      float64(i)*step + step*0.1,
+			Y:      height - h,
+			Width:  step * 0.8,
+			Height: h,
+			Title:  fmt.Sprintf("%s: %s, %d sessions", row.Label, session.FormatCost(row.Cost), row.Sessions),
+		}
+	}
+	return bars
+}
+
+// WriteHTML writes a self-contained dashboard page
+func (r *Report) WriteHTML(w io.Writer) error {
+	return dashboardTemplate.Execute(w, struct {
+		*Report
+		Bars []bar
+	}{r, r.chartBars(800, 160)})
+}
+
+var dashboardTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"cost":   session.FormatCost,
+	"tokens": FormatTokens,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>Claude Code usage report</title>
+<style>
+	body { margin: 0; padding: 32px; font-family: -apple-system, BlinkMacSystemFont, sans-serif; background: #0a0a0b; color: #fafafa; }
+	main { max-width: 960px; margin: 0 auto; }
+	h1 { font-size: 1.4rem; margin-bottom: 4px; }
+	h2 { font-size: 1.05rem; margin-top: 32px; }
+	.meta { color: #71717a; font-size: 0.8rem; }
+	.cards { display: flex; gap: 12px; margin: 24px 0; flex-wrap: wrap; }
+	.card { flex: 1; min-width: 140px; padding: 14px 16px; background: #18181b; border: 1px solid #27272a; border-radius: 8px; }
+	.card .label { color: #a1a1aa; font-size: 0.75rem; text-transform: uppercase; letter-spacing: 0.05em; }
+	.card .value { font-size: 1.4rem; margin-top: 4px; font-variant-numeric: tabular-nums; }
+	svg { width: 100%; height: auto; background: #18181b; border: 1px solid #27272a; border-radius: 8px; }
+	svg rect { fill: #3b82f6; }
+	table { width: 100%; border-collapse: collapse; font-size: 0.85rem; }
+	th, td { text-align: right; padding: 6px 8px; border-bottom: 1px solid #27272a; font-variant-numeric: tabular-nums; }
+	th:first-child, td:first-child { text-align: left; }
+	th { color: #a1a1aa; font-weight: 600; }
+</style>
+</head>
+<body>
+<main>
+	<h1>Claude Code usage report</h1>
+	<div class="meta">By {{.Period}} · generated {{.Generated.Format "Jan 02 2006 15:04"}}</div>
+
+	<div class="cards">
+		<div class="card"><div class="label">Sessions</div><div class="value">{{.Total.Sessions}}</div></div>
+		<div class="card"><div class="label">Tokens</div><div class="value">{{tokens .Total.Tokens}}</div></div>
+		<div class="card"><div class="label">Tool calls</div><div class="value">{{.Total.ToolCalls}}</div></div>
+		<div class="card"><div class="label">Est. cost</div><div class="value">{{cost .Total.Cost}}</div></div>
+	</div>
+
+	{{if .Bars}}<h2>Estimated cost by {{.Period}}</h2>
+	<svg viewBox="0 0 800 160" role="img" aria-label="Estimated cost by {{.Period}}">{{range .Bars}}
+		<rect x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{.Height}}"><title>{{.Title}}</title></rect>{{end}}
+	</svg>{{end}}
+
+	<h2>By {{.Period}}</h2>
+	<table>
+		<tr><th>{{.Period}}</th><th>Sessions</th><th>Input</th><th>Output</th><th>Cache read</th><th>Cache write</th><th>Tool calls</th><th>Est. cost</th></tr>{{range .Rows}}
+		<tr><td>{{.Label}}</td><td>{{.Sessions}}</td><td>{{tokens .InputTokens}}</td><td>{{tokens .OutputTokens}}</td><td>{{tokens .CacheReadTokens}}</td><td>{{tokens .CacheWriteTokens}}</td><td>{{.ToolCalls}}</td><td>{{cost .Cost}}</td></tr>{{end}}
+	</table>
+
+	<h2>By project</h2>
+	<table>
+		<tr><th>Project</th><th>Sessions</th><th>Tokens</th><th>Tool calls</th><th>Est. cost</th></tr>{{range .Projects}}
+		<tr><td>{{.Project}}</td><td>{{.Sessions}}</td><td>{{tokens .Tokens}}</td><td>{{.ToolCalls}}</td><td>{{cost .Cost}}</td></tr>{{end}}
+	</table>
+</main>
+</body>
+</html>
+`))
+
+// FormatTokens formats a token count as e.g. "1.2M", "3.4K" or "512"
+func FormatTokens(count int) string {
+	switch {
+	case count >= 1000000:
+		return fmt.Sprintf("%.1fM", float64(count)/1000000)
+	case count >= 1000:
+		return fmt.Sprintf("%.1fK", float64(count)/1000)
+	default:
+		return strconv.Itoa(count)
+	}
+}
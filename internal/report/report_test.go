@@ -0,0 +1,66 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+func entry(project string, t time.Time, input int, cost float64) Entry {
+	return Entry{
+		Project: project,
+		Time:    t,
+		Stats: session.Stats{
+			Models: map[string]session.ModelStats{"claude-sonnet-4": {TokenUsage: session.TokenUsage{InputTokens: input}}},
+			Tools:  map[string]int{"Bash": 2, "Read": 1},
+			Cost:   cost,
+		},
+	}
+}
+
+func TestBuildRollsUpByPeriodAndProject(t *testing.T) {
+	// Wednesday and Friday of one week, then the following Monday
+	entries := []Entry{
+		entry("app", time.Date(2024, 6, 5, 10, 0, 0, 0, time.UTC), 100, 1),
+		entry("api", time.Date(2024, 6, 7, 10, 0, 0, 0, time.UTC), 200, 3),
+		entry("app", time.Date(2024, 6, 10, 9, 0, 0, 0, time.UTC), 50, 0.5),
+	}
+
+	r := Build(entries, "week", time.Now())
+	if len(r.Rows) != 2 {
+		t.Fatalf("Expected 2 weeks, got %d", len(r.Rows))
+	}
+	if r.Rows[0].Label != "Week of 2024-06-03" || r.Rows[0].Sessions != 2 || r.Rows[0].InputTokens != 300 || r.Rows[0].ToolCalls != 6 {
+		t.Errorf("Unexpected first week: %+v", r.Rows[0])
+	}
+	if r.Total.Sessions != 3 || r.Total.Cost != 4.5 {
+		t.Errorf("Unexpected totals: %+v", r.Total)
+	}
+	if r.Projects[0].Project != "api" || r.Projects[1].Sessions != 2 {
+		t.Errorf("Expected projects by cost, got %+v", r.Projects)
+	}
+
+	if months := Build(entries, "month", time.Now()); len(months.Rows) != 1 || months.Rows[0].Label != "2024-06" {
+		t.Errorf("Expected a single month, got %+v", months.Rows)
+	}
+
+	var csvOut bytes.Buffer
+	if err := r.WriteCSV(&csvOut); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(csvOut.String()), "\n")
+	if len(lines) != 3 || lines[1] != "2024-06-03,2,300,0,0,0,6,4.0000" {
+		t.Errorf("Unexpected CSV: %q", csvOut.String())
+	}
+
+	var htmlOut bytes.Buffer
+	if err := r.WriteHTML(&htmlOut); err != nil {
+		t.Fatalf("WriteHTML failed: %v", err)
+	}
+	if strings.Count(htmlOut.String(), "<rect") != 2 || !strings.Contains(htmlOut.String(), "$4.50") {
+		t.Errorf("Expected a bar per week and the total cost in the dashboard")
+	}
+}
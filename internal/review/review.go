@@ -0,0 +1,208 @@
+package review
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Reactions a reviewer can flag a conversation with
+const (
+	ReactionUp       = "up"
+	ReactionDown     = "down"
+	ReactionFollowUp = "follow-up"
+)
+
+// Reactions lists the valid reactions, most actionable first
+var Reactions = []string{ReactionFollowUp, ReactionDown, ReactionUp}
+
+var reactionLabels = map[string]string{
+	ReactionFollowUp: "Needs follow-up",
+	ReactionDown:     "Thumbs down",
+	ReactionUp:       "Thumbs up",
+}
+
+// ValidReaction reports whether name is one of Reactions
+func ValidReaction(name string) bool {
+	_, ok := reactionLabels[name]
+	return ok
+}
+
+// Flag is a reviewer's reaction to one conversation, i.e. a user prompt and
+// everything Claude did in response to it
+type Flag struct {
+	Message  string    `json:"message"` // UUID of the prompt's entry
+	Prompt   string    `json:"prompt"`
+	Reaction string    `json:"reaction"`
+	Note     string    `json:"note,omitempty"`
+	User     string    `json:"user,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// Sidecar holds the flags for a session. It is stored next to the session
+// as <session>.flags.json.
+type Sidecar struct {
+	Session string `json:"session"`
+	Flags   []Flag `json:"flags"`
+}
+
+// SidecarPath returns where the flags for the session at path are kept
+func SidecarPath(sessionPath string) string {
+	return strings.TrimSuffix(sessionPath, ".jsonl") + ".flags.json"
+}
+
+// Validate checks that every flag names a message and a known reaction
+func (s *Sidecar) Validate() error {
+	for _, f := range s.Flags {
+		if f.Message == "" {
+			return fmt.Errorf("flag without a message")
+		}
+		if !ValidReaction(f.Reaction) {
+			return fmt.Errorf("unknown reaction %q (available: %s)", f.Reaction, strings.Join(Reactions, ", "))
+		}
+	}
+	return nil
+}
+
+// Load reads a sidecar. A missing file is an empty sidecar.
+func Load(path string) (*Sidecar, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Sidecar{Flags: []Flag{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading flags: %w", err)
+	}
+	var s Sidecar
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if s.Flags == nil {
+		s.Flags = []Flag{}
+	}
+	return &s, nil
+}
+
+// Save writes a sidecar, removing it once nothing is flagged
+func Save(path string, s *Sidecar) error {
+	if len(s.Flags) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("removing flags: %w", err)
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing flags: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("writing flags: %w", err)
+	}
+	return nil
+}
+
+// Item is a flagged conversation in an export
+type Item struct {
+	Flag
+	Session     string `json:"session"`
+	SessionPath string `json:"session_path,omitempty"`
+	Project     string `json:"project,omitempty"`
+}
+
+// FindSidecars returns every flags sidecar under dir
+func FindSidecars(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".flags.json") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// Collect loads the flags in the given sidecars as export items, newest
+// first. project names the project a sidecar belongs to.
+func Collect(paths []string, project func(sidecarPath string) string) ([]Item, error) {
+	var items []Item
+	for _, path := range paths {
+		s, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		sessionPath := strings.TrimSuffix(path, ".flags.json") + ".jsonl"
+		id := s.Session
+		if id == "" {
+			id = strings.TrimSuffix(filepath.Base(path), ".flags.json")
+		}
+		for _, f := range s.Flags {
+			items = append(items, Item{
+				Flag:        f,
+				Session:     id,
+				SessionPath: sessionPath,
+				Project:     project(path),
+			})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Time.After(items[j].Time) })
+	return items, nil
+}
+
+// WriteMarkdown writes flagged items grouped by reaction, for pasting into
+// retro notes
+func WriteMarkdown(w io.Writer, items []Item) error {
+	fmt.Fprintf(w, "# Flagged conversations\n")
+	if len(items) == 0 {
+		_, err := fmt.Fprintf(w, "\nNothing flagged.\n")
+		return err
+	}
+	for _, reaction := range Reactions {
+		var group []Item
+		for _, item := range items {
+			if item.Reaction == reaction {
+				group = append(group, item)
+			}
+		}
+		if len(group) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n## %s (%d)\n\n", reactionLabels[reaction], len(group))
+		for _, item := range group {
+			prompt := strings.Join(strings.Fields(item.Prompt), " ")
+			if r := []rune(prompt); len(r) > 200 {
+				prompt = string(r[:197]) + "..."
+			}
+			fmt.Fprintf(w, "- **%s**", prompt)
+			var context []string
+			if item.Project != "" {
+				context = append(context, item.Project)
+			}
+			context = append(context, "session `"+item.Session+"`")
+			if !item.Time.IsZero() {
+				context = append(context, item.Time.Local().Format("Jan 02 2006"))
+			}
+			if item.User != "" {
+				context = append(context, "flagged by "+item.User)
+			}
+			fmt.Fprintf(w, "  \n  %s\n", strings.Join(context, " · "))
+			if item.Note != "" {
+				fmt.Fprintf(w, "  > %s\n", strings.Join(strings.Fields(item.Note), " "))
+			}
+		}
+	}
+	return nil
+}
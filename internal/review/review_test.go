@@ -0,0 +1,66 @@
+package review
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollectAndExport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "-home-alice-app")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	if err := Save(SidecarPath(filepath.Join(dir, "s1.jsonl")), &Sidecar{
+		Session: "s1",
+		Flags: []Flag{
+			{Message: "u1", Prompt: "fix the\nburrito endpoint", Reaction: ReactionFollowUp, Note: "missing tests", Time: day},
+			{Message: "u2", Prompt: "add caching", Reaction: ReactionUp, User: "carol", Time: day.Add(time.Hour)},
+		},
+	}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	paths, err := FindSidecars(filepath.Dir(dir))
+	if err != nil || len(paths) != 1 {
+		t.Fatalf("Expected one sidecar, got %v (%v)", paths, err)
+	}
+	items, err := Collect(paths, func(string) string { return "app" })
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if len(items) != 2 || items[0].Message != "u2" || items[0].Session != "s1" || items[0].Project != "app" {
+		t.Fatalf("Expected newest flag first with session and project, got %+v", items)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, items); err != nil {
+		t.Fatalf("WriteMarkdown failed: %v", err)
+	}
+	out := buf.String()
+	followUp := strings.Index(out, "## Needs follow-up (1)")
+	up := strings.Index(out, "## Thumbs up (1)")
+	if followUp < 0 || up < followUp {
+		t.Errorf("Expected follow-ups listed before thumbs up:\n%s", out)
+	}
+	for _, want := range []string{"- **fix the burrito endpoint**", "> missing tests", "flagged by carol"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in export:\n%s", want, out)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s := &Sidecar{Flags: []Flag{{Message: "u1", Reaction: "meh"}}}
+	if err := s.Validate(); err == nil {
+		t.Error("Expected unknown reaction rejected")
+	}
+	s.Flags[0].Reaction = ReactionDown
+	if err := s.Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
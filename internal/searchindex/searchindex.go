@@ -0,0 +1,206 @@
+package searchindex
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/config"
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+// FileName is the index's name in the config directory
+const FileName = "search-index.gob"
+
+// version changes whenever the stored entries do, so old indexes are
+// rebuilt rather than misread
+const version = 1
+
+// Index holds the searchable text of every session under a projects
+// directory, so searching needn't parse them again
+type Index struct {
+	Version  int
+	Root     string
+	Built    time.Time
+	Sessions map[string]Session // By path
+}
+
+// Session is the indexed text of one session file, with the modification
+// time and size it had when indexed
+type Session struct {
+	ModTime time.Time
+	Size    int64
+	Entries []session.SearchEntry
+}
+
+// Status counts how far an index is behind the files on disk
+type Status struct {
+	Current int // Indexed and unchanged
+	Stale   int // Changed since indexed
+	New     int // Not indexed
+	Removed int // Indexed but gone
+}
+
+// Path returns the path to the index file
+func Path() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileName), nil
+}
+
+// Build indexes every session under projectsDir, parsing several at a time.
+// Files that fail to parse are left out.
+func Build(projectsDir string) (*Index, error) {
+	paths, err := sessionFiles(projectsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := &Index{
+		Version:  version,
+		Root:     projectsDir,
+		Built:    time.Now(),
+		Sessions: make(map[string]Session, len(paths)),
+	}
+	var mu sync.Mutex
+	work := make(chan string)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range work {
+				info, err := os.Stat(path)
+				if err != nil {
+					continue
+				}
+				sess, err := session.ParseFile(path)
+				if err != nil {
+					continue
+				}
+				entry := Session{ModTime: info.ModTime(), Size: info.Size(), Entries: session.SearchEntries(sess)}
+				mu.Lock()
+				idx.Sessions[path] = entry
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, path := range paths {
+		work <- path
+	}
+	close(work)
+	wg.Wait()
+	return idx, nil
+}
+
+// sessionFiles lists the JSONL files under projectsDir
+func sessionFiles(projectsDir string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(projectsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".jsonl") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing sessions: %w", err)
+	}
+	return paths, nil
+}
+
+// Save writes the index to path, replacing any earlier one whole
+func (idx *Index) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating index directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), FileName+".*")
+	if err != nil {
+		return fmt.Errorf("creating index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := gob.NewEncoder(tmp).Encode(idx); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing index: %w", err)
+	}
+	// The index holds transcript text, so keep it private like the history
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load reads the index at path. A missing index returns an error matching
+// fs.ErrNotExist.
+func Load(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var idx Index
+	if err := gob.NewDecoder(f).Decode(&idx); err != nil {
+		return nil, fmt.Errorf("reading index: %w", err)
+	}
+	if idx.Version != version {
+		return nil, errors.New("the search index is from another version; run 'claude-session-export index build'")
+	}
+	return &idx, nil
+}
+
+// Lookup returns the indexed entries of a file, if it hasn't changed since
+// it was indexed. It fits session.SearchOptions.Lookup.
+func (idx *Index) Lookup(path string, info fs.FileInfo) ([]session.SearchEntry, bool) {
+	s, ok := idx.Sessions[path]
+	if !ok || !s.ModTime.Equal(info.ModTime()) || s.Size != info.Size() {
+		return nil, false
+	}
+	return s.Entries, true
+}
+
+// Status compares the index with the files now under its root
+func (idx *Index) Status() (Status, error) {
+	var st Status
+	paths, err := sessionFiles(idx.Root)
+	if err != nil {
+		return st, err
+	}
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		seen[path] = true
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		_, indexed := idx.Sessions[path]
+		_, current := idx.Lookup(path, info)
+		switch {
+		case !indexed:
+			st.New++
+		case current:
+			st.Current++
+		default:
+			st.Stale++
+		}
+	}
+	for path := range idx.Sessions {
+		if !seen[path] {
+			st.Removed++
+		}
+	}
+	return st, nil
+}
@@ -0,0 +1,80 @@
+package searchindex
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+func TestIndex(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, "-home-alice-api", name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	a := write("a.jsonl", `{"type":"user","message":{"role":"user","content":"Why does deploy fail?"},"timestamp":"2025-01-10T10:00:00Z"}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"make deploy"}}]},"timestamp":"2025-01-10T10:01:00Z"}
+`)
+	write("b.jsonl", `{"type":"user","message":{"role":"user","content":"deploy the site"},"timestamp":"2025-02-01T09:00:00Z"}
+`)
+
+	idx, err := Build(dir)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := idx.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	idx, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(idx.Sessions) != 2 {
+		t.Fatalf("Expected 2 indexed sessions, got %d", len(idx.Sessions))
+	}
+
+	// Searching through the index finds the same matches, without parsing
+	results, err := session.SearchSessionsIn(dir, "deploy", session.SearchOptions{Tool: "bash", Lookup: idx.Lookup})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || len(results[0].Matches) != 1 || results[0].Matches[0].Line != 2 {
+		t.Errorf("Expected one Bash match on line 2, got %+v", results)
+	}
+
+	// A changed file is no longer answered from the index
+	info, _ := os.Stat(a)
+	if _, ok := idx.Lookup(a, info); !ok {
+		t.Error("Expected the unchanged file to be found")
+	}
+	later := info.ModTime().Add(time.Minute)
+	os.Chtimes(a, later, later)
+	info, _ = os.Stat(a)
+	if _, ok := idx.Lookup(a, info); ok {
+		t.Error("Expected the changed file to be missed")
+	}
+
+	write("c.jsonl", `{"type":"user","message":{"role":"user","content":"hi"}}
+`)
+	st, err := idx.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st != (Status{Current: 1, Stale: 1, New: 1}) {
+		t.Errorf("Unexpected status %+v", st)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), FileName)); !os.IsNotExist(err) {
+		t.Errorf("Expected a not-exist error, got %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package serve
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Actions recorded in the access log
+const (
+	ActionView     = "view"
+	ActionDownload = "download"
+)
+
+// AccessEntry records one view or download of a session
+type AccessEntry struct {
+	Time       time.Time `json:"time"`
+	User       string    `json:"user,omitempty"`
+	RemoteAddr string    `json:"remote_addr"`
+	Archive    string    `json:"archive"`
+	Session    string    `json:"session"`
+	Action     string    `json:"action"`
+}
+
+// AccessLog appends access entries to a JSONL file
+type AccessLog struct {
+	mu   sync.Mutex
+	path string
+}
+
+// OpenAccessLog prepares an access log at path, creating its directory
+func OpenAccessLog(path string) (*AccessLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("creating access log directory: %w", err)
+	}
+	return &AccessLog{path: path}, nil
+}
+
+// Path returns the file the log is written to
+func (l *AccessLog) Path() string {
+	return l.path
+}
+
+// Record appends an entry to the log
+func (l *AccessLog) Record(entry AccessEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("opening access log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("writing access log: %w", err)
+	}
+	return nil
+}
+
+// Recent returns up to n of the latest entries, newest first
+func (l *AccessLog) Recent(n int) ([]AccessEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening access log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []AccessEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e AccessEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// Skip corrupt lines
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading access log: %w", err)
+	}
+
+	if n > 0 && len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// recordAccess logs a view or download if an access log is configured
+func (s *Server) recordAccess(r *http.Request, action string) {
+	if s.opts.AccessLog == nil {
+		return
+	}
+
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	entry := AccessEntry{
+		RemoteAddr: remote,
+		Archive:    r.PathValue("archive"),
+		Session:    r.PathValue("id"),
+		Action:     action,
+	}
+	if s.opts.UserHeader != "" {
+		entry.User = r.Header.Get(s.opts.UserHeader)
+	}
+
+	if err := s.opts.AccessLog.Record(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
@@ -0,0 +1,85 @@
+package serve
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/review"
+)
+
+// maxFlagsBody caps the size of a flags upload
+const maxFlagsBody = 1 << 20
+
+// flagsPath returns the sidecar for the requested session
+func (s *Server) flagsPath(w http.ResponseWriter, r *http.Request) (string, bool) {
+	a, ok := s.archive(r.PathValue("archive"))
+	if !ok {
+		http.NotFound(w, r)
+		return "", false
+	}
+	path, ok := findSession(a, r.PathValue("id"))
+	if !ok {
+		http.NotFound(w, r)
+		return "", false
+	}
+	return review.SidecarPath(path), true
+}
+
+func (s *Server) handleGetFlags(w http.ResponseWriter, r *http.Request) {
+	path, ok := s.flagsPath(w, r)
+	if !ok {
+		return
+	}
+	s.flagsMu.Lock()
+	sidecar, err := review.Load(path)
+	s.flagsMu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sidecar.Session = r.PathValue("id")
+	writeJSON(w, sidecar)
+}
+
+// handlePutFlags replaces a session's flags. Flags without a user are
+// attributed to the viewer named by the user header.
+func (s *Server) handlePutFlags(w http.ResponseWriter, r *http.Request) {
+	path, ok := s.flagsPath(w, r)
+	if !ok {
+		return
+	}
+
+	var sidecar review.Sidecar
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxFlagsBody)).Decode(&sidecar); err != nil {
+		http.Error(w, "invalid flags: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := sidecar.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sidecar.Session = r.PathValue("id")
+	if sidecar.Flags == nil {
+		sidecar.Flags = []review.Flag{}
+	}
+	for i := range sidecar.Flags {
+		f := &sidecar.Flags[i]
+		if f.User == "" && s.opts.UserHeader != "" {
+			f.User = r.Header.Get(s.opts.UserHeader)
+		}
+		if f.Time.IsZero() {
+			f.Time = time.Now()
+		}
+	}
+
+	s.flagsMu.Lock()
+	err := review.Save(path, &sidecar)
+	s.flagsMu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, sidecar)
+}
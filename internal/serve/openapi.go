@@ -0,0 +1,187 @@
+package serve
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/robzolkos/claude-session-export/internal/jsonschema"
+	"github.com/robzolkos/claude-session-export/internal/review"
+)
+
+// apiOperation describes one JSON endpoint for the OpenAPI spec
+type apiOperation struct {
+	method   string // Default: GET
+	path     string
+	summary  string
+	params   []apiParam
+	request  interface{} // zero value of the request body type, if any
+	response interface{} // zero value of the response type
+	flags    bool        // Only served with Options.Flags
+}
+
+type apiParam struct {
+	name     string
+	in       string
+	desc     string
+	required bool
+}
+
+// apiOperations lists the JSON API. Response schemas are generated from
+// the Go types so the spec can't drift from what handlers encode.
+var apiOperations = []apiOperation{
+	{
+		path:     "/api/archives",
+		summary:  "List archives and their session counts",
+		response: []ArchiveSummary{},
+	},
+	{
+		path:    "/api/archives/{archive}/sessions",
+		summary: "List the sessions in an archive, newest first",
+		params: []apiParam{
+			{name: "archive", in: "path", desc: "Archive name", required: true},
+		},
+		response: []SessionSummary{},
+	},
+	{
+		path:    "/api/search",
+		summary: "Search every archive for a term",
+		params: []apiParam{
+			{name: "q", in: "query", desc: "Case-insensitive search term", required: true},
+		},
+		response: []SearchHit{},
+	},
+	{
+		path:    "/api/archives/{archive}/sessions/{id}/flags",
+		summary: "Get the reviewer flags on a session",
+		params: []apiParam{
+			{name: "archive", in: "path", desc: "Archive name", required: true},
+			{name: "id", in: "path", desc: "Session ID", required: true},
+		},
+		response: review.Sidecar{},
+		flags:    true,
+	},
+	{
+		method:  http.MethodPut,
+		path:    "/api/archives/{archive}/sessions/{id}/flags",
+		summary: "Replace the reviewer flags on a session. Flags without a user are attributed to the viewer named by the user header.",
+		params: []apiParam{
+			{name: "archive", in: "path", desc: "Archive name", required: true},
+			{name: "id", in: "path", desc: "Session ID", required: true},
+		},
+		request:  review.Sidecar{},
+		response: review.Sidecar{},
+		flags:    true,
+	},
+}
+
+// OpenAPISpec builds the OpenAPI 3 document for the JSON API, with the
+// reviewer flags endpoints when flags is set
+func OpenAPISpec(version string, flags bool) map[string]interface{} {
+	schemas := make(map[string]interface{})
+	paths := make(map[string]map[string]interface{})
+
+	for _, op := range apiOperations {
+		if op.flags && !flags {
+			continue
+		}
+		var params []map[string]interface{}
+		for _, p := range op.params {
+			params = append(params, map[string]interface{}{
+				"name":        p.name,
+				"in":          p.in,
+				"description": p.desc,
+				"required":    p.required,
+				"schema":      map[string]interface{}{"type": "string"},
+			})
+		}
+
+		operation := map[string]interface{}{
+			"summary": op.summary,
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "OK",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{
+							"schema": jsonschema.For(reflect.TypeOf(op.response), schemas, "#/components/schemas/"),
+						},
+					},
+				},
+			},
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+		if op.request != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": jsonschema.For(reflect.TypeOf(op.request), schemas, "#/components/schemas/"),
+					},
+				},
+			}
+		}
+		if paths[op.path] == nil {
+			paths[op.path] = make(map[string]interface{})
+		}
+		paths[op.path][strings.ToLower(cmp.Or(op.method, http.MethodGet))] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "claude-session-export serve API",
+			"version": version,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+		},
+	}
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, OpenAPISpec(s.opts.Version, s.opts.Flags))
+}
+
+// swaggerUI is the Swagger UI release /docs loads, pinned to an exact
+// version so the page only changes when this does
+const swaggerUI = "https://unpkg.com/swagger-ui-dist@5.17.14/"
+
+// docsScript starts Swagger UI on the spec
+const docsScript = `SwaggerUIBundle({ url: '/openapi.json', dom_id: '#swagger-ui' });`
+
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<title>claude-session-export API</title>
+<link rel="stylesheet" href="` + swaggerUI + `swagger-ui.css" crossorigin="anonymous">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="` + swaggerUI + `swagger-ui-bundle.js" crossorigin="anonymous"></script>
+<script>` + docsScript + `</script>
+</body>
+</html>
+`
+
+// docsPolicy lets the page run only the pinned Swagger UI bundle and its
+// own inline script, and talk only to this server
+var docsPolicy = func() string {
+	sum := sha256.Sum256([]byte(docsScript))
+	return "default-src 'none'; " +
+		"script-src " + swaggerUI + "swagger-ui-bundle.js 'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'; " +
+		"style-src " + swaggerUI + "swagger-ui.css 'unsafe-inline'; " +
+		"img-src 'self' data:; connect-src 'self'"
+}()
+
+func (s *Server) handleDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", docsPolicy)
+	w.Write([]byte(docsPage))
+}
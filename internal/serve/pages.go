@@ -0,0 +1,221 @@
+package serve
+
+import (
+	"html/template"
+	"net"
+	"net/http"
+	"slices"
+	"sort"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+var pageTemplate = template.Must(template.New("page").Funcs(template.FuncMap{
+	"date": func(t interface{ Format(string) string }) string { return t.Format("Jan 02 2006 15:04") },
+	"cost": session.FormatCost,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>{{.Title}}</title>
+<style>
+	body { margin: 0; padding: 32px; font-family: -apple-system, BlinkMacSystemFont, sans-serif; background: #0a0a0b; color: #fafafa; }
+	main { max-width: 880px; margin: 0 auto; }
+	h1 { font-size: 1.4rem; }
+	a { color: #3b82f6; text-decoration: none; }
+	a:hover { text-decoration: underline; }
+	form { margin: 16px 0 24px; display: flex; gap: 8px; }
+	input[type=search] { flex: 1; padding: 8px 12px; background: #18181b; border: 1px solid #3f3f46; border-radius: 6px; color: inherit; }
+	button { padding: 8px 14px; background: #27272a; border: 1px solid #3f3f46; border-radius: 6px; color: inherit; cursor: pointer; }
+	ul { list-style: none; padding: 0; }
+	li { padding: 12px 0; border-bottom: 1px solid #27272a; }
+	.meta { color: #71717a; font-size: 0.8rem; }
+	.snippet { color: #a1a1aa; font-size: 0.85rem; margin-top: 4px; }
+	.crumbs { font-size: 0.85rem; margin-bottom: 8px; }
+	table { width: 100%; border-collapse: collapse; font-size: 0.85rem; }
+	th, td { text-align: left; padding: 6px 8px; border-bottom: 1px solid #27272a; }
+	th { color: #a1a1aa; font-weight: 600; }
+	h2 { font-size: 1.05rem; margin-top: 28px; }
+	.branding { max-width: 880px; margin: 0 auto; }
+</style>
+</head>
+<body>
+{{if .Header}}<div class="branding branding-header">{{.Header}}</div>{{end}}
+<main>
+	<div class="crumbs"><a href="/">All archives</a>{{if .Archive}} / <a href="/u/{{.Archive}}/">{{.Archive}}</a>{{end}}</div>
+	<h1>{{.Title}}</h1>
+	<form action="/search"><input type="search" name="q" value="{{.Query}}" placeholder="Search all archives"><button>Search</button></form>
+	{{if .Archives}}<ul>{{range .Archives}}
+		<li><a href="/u/{{.Name}}/">{{.Name}}</a> <span class="meta">{{.Sessions}} sessions</span></li>{{end}}
+	</ul>{{end}}
+	{{if .Sessions}}<ul>{{range .Sessions}}
+		<li>
+			<a href="/u/{{.Archive}}/s/{{.ID}}">{{if .Summary}}{{.Summary}}{{else}}{{.ID}}{{end}}</a>
+			<div class="meta">{{.Project}} · {{date .Modified}} · {{.Messages}} messages{{if .Cost}} · ~{{cost .Cost}}{{end}} · <a href="/u/{{.Archive}}/raw/{{.ID}}">jsonl</a></div>
+		</li>{{end}}
+	</ul>{{end}}
+	{{if .Hits}}<ul>{{range .Hits}}
+		<li>
+			<a href="/u/{{.Archive}}/s/{{.ID}}">{{.ID}}</a>
+			<div class="meta">{{.Archive}} · {{.Project}} · {{date .Modified}}</div>
+			{{range .Snippets}}<div class="snippet">{{.}}</div>{{end}}
+		</li>{{end}}
+	</ul>{{else if .Query}}<p class="meta">No matches.</p>{{end}}
+	{{if .Admin}}
+	<h2>Most accessed sessions</h2>
+	<table>
+		<tr><th>Session</th><th>Views</th><th>Downloads</th><th>Viewers</th></tr>{{range .Admin.Sessions}}
+		<tr><td><a href="/u/{{.Archive}}/s/{{.Session}}">{{.Archive}}/{{.Session}}</a></td><td>{{.Views}}</td><td>{{.Downloads}}</td><td>{{.Users}}</td></tr>{{end}}
+	</table>
+	<h2>Recent access</h2>
+	<table>
+		<tr><th>Time</th><th>User</th><th>Address</th><th>Action</th><th>Session</th></tr>{{range .Admin.Recent}}
+		<tr><td>{{date .Time}}</td><td>{{if .User}}{{.User}}{{else}}<span class="meta">unknown</span>{{end}}</td><td>{{.RemoteAddr}}</td><td>{{.Action}}</td><td>{{.Archive}}/{{.Session}}</td></tr>{{end}}
+	</table>
+	<p class="meta">Log file: {{.Admin.LogPath}}</p>
+	{{end}}
+</main>
+{{if .Footer}}<div class="branding branding-footer">{{.Footer}}</div>{{end}}
+</body>
+</html>
+`))
+
+type pageData struct {
+	Title    string
+	Archive  string
+	Query    string
+	Archives []ArchiveSummary
+	Sessions []SessionSummary
+	Hits     []SearchHit
+	Admin    *adminData
+
+	// Branding from Options, trusted as-is
+	Header template.HTML
+	Footer template.HTML
+}
+
+type adminData struct {
+	LogPath  string
+	Recent   []AccessEntry
+	Sessions []sessionAccess
+}
+
+// sessionAccess totals the access log for one session
+type sessionAccess struct {
+	Archive   string
+	Session   string
+	Views     int
+	Downloads int
+	Users     int
+}
+
+func (s *Server) renderPage(w http.ResponseWriter, data pageData) {
+	data.Header = template.HTML(s.opts.Header)
+	data.Footer = template.HTML(s.opts.Footer)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pageTemplate.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	s.renderPage(w, pageData{Title: "Session archives", Archives: s.listArchives()})
+}
+
+func (s *Server) handleArchive(w http.ResponseWriter, r *http.Request) {
+	a, ok := s.archive(r.PathValue("archive"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	sessions, err := s.listSessions(a)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.renderPage(w, pageData{Title: a.Name, Archive: a.Name, Sessions: sessions})
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	data := pageData{Title: "Search", Query: query}
+	if query != "" {
+		data.Title = "Results for “" + query + "”"
+		data.Hits = s.search(query, 3)
+	}
+	s.renderPage(w, data)
+}
+
+// adminAllowed reports whether the request may see the access log: from
+// one of the admin users, or without a list of them, from this machine
+func (s *Server) adminAllowed(r *http.Request) bool {
+	if len(s.opts.AdminUsers) > 0 {
+		return slices.Contains(s.opts.AdminUsers, r.Header.Get(s.opts.UserHeader))
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (s *Server) handleAdmin(w http.ResponseWriter, r *http.Request) {
+	if !s.adminAllowed(r) {
+		http.Error(w, "the access log is only shown to admin users", http.StatusForbidden)
+		return
+	}
+	entries, err := s.opts.AccessLog.Recent(0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	totals := make(map[string]*sessionAccess)
+	users := make(map[string]map[string]bool)
+	var order []string
+	for _, e := range entries {
+		key := e.Archive + "/" + e.Session
+		t, ok := totals[key]
+		if !ok {
+			t = &sessionAccess{Archive: e.Archive, Session: e.Session}
+			totals[key] = t
+			users[key] = make(map[string]bool)
+			order = append(order, key)
+		}
+		if e.Action == ActionDownload {
+			t.Downloads++
+		} else {
+			t.Views++
+		}
+		viewer := e.User
+		if viewer == "" {
+			viewer = e.RemoteAddr
+		}
+		users[key][viewer] = true
+	}
+
+	var sessions []sessionAccess
+	for _, key := range order {
+		totals[key].Users = len(users[key])
+		sessions = append(sessions, *totals[key])
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].Views+sessions[i].Downloads > sessions[j].Views+sessions[j].Downloads
+	})
+	if len(sessions) > 20 {
+		sessions = sessions[:20]
+	}
+
+	recent := entries
+	if len(recent) > 200 {
+		recent = recent[:200]
+	}
+
+	s.renderPage(w, pageData{Title: "Access log", Admin: &adminData{
+		LogPath:  s.opts.AccessLog.Path(),
+		Recent:   recent,
+		Sessions: sessions,
+	}})
+}
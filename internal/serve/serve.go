@@ -0,0 +1,372 @@
+package serve
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+	"github.com/robzolkos/claude-session-export/render"
+)
+
+// Archive is a directory of sessions served under its own prefix
+type Archive struct {
+	Name string
+	Dir  string
+}
+
+// Options configures optional server features
+type Options struct {
+	// AccessLog records session views and downloads when set
+	AccessLog *AccessLog
+
+	// UserHeader names the request header carrying the viewer's identity,
+	// as set by an authenticating proxy (e.g. X-Forwarded-User). Empty
+	// trusts no header, since clients can set any header themselves.
+	UserHeader string
+
+	// Admin enables the /admin page showing recent access
+	Admin bool
+
+	// AdminUsers may open /admin, as named by UserHeader. When empty, only
+	// clients on this machine may.
+	AdminUsers []string
+
+	// Version is reported in the OpenAPI spec
+	Version string
+
+	// Pricing is used to estimate session costs (default: built-in prices)
+	Pricing session.Pricing
+
+	// Header and Footer are HTML snippets (e.g. a logo or confidentiality
+	// notice) shown on every page
+	Header string
+	Footer string
+
+	// Flags lets viewers flag conversations, saving to a sidecar next to
+	// each session
+	Flags bool
+
+	// Title titles sessions in listings (default: summary entries, then
+	// the first prompt)
+	Title session.TitleFunc
+}
+
+// Server hosts one or more session archives over HTTP
+type Server struct {
+	archives []Archive
+	render   render.Renderer // Streams viewer pages (render.Viewer in production)
+	opts     Options
+	mux      *http.ServeMux
+	patterns []string // Registered routes, as METHOD /path
+	flagsMu  sync.Mutex
+}
+
+var archiveNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// New creates a server for the given archives
+func New(archives []Archive, renderer render.Renderer, opts Options) (*Server, error) {
+	if len(archives) == 0 {
+		return nil, fmt.Errorf("no archives to serve")
+	}
+
+	seen := make(map[string]bool)
+	for _, a := range archives {
+		if !archiveNamePattern.MatchString(a.Name) {
+			return nil, fmt.Errorf("invalid archive name %q (use letters, digits, '.', '_' or '-')", a.Name)
+		}
+		if seen[a.Name] {
+			return nil, fmt.Errorf("archive name %q used more than once", a.Name)
+		}
+		seen[a.Name] = true
+
+		info, err := os.Stat(a.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("archive %s: %w", a.Name, err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("archive %s: %s is not a directory", a.Name, a.Dir)
+		}
+	}
+
+	if opts.Admin && opts.AccessLog == nil {
+		return nil, fmt.Errorf("the admin page needs an access log")
+	}
+	if len(opts.AdminUsers) > 0 && opts.UserHeader == "" {
+		return nil, fmt.Errorf("admin users need a user header to identify them")
+	}
+	if opts.Pricing == nil {
+		opts.Pricing = session.DefaultPricing
+	}
+
+	s := &Server{archives: archives, render: renderer, opts: opts, mux: http.NewServeMux()}
+	s.routes()
+	return s, nil
+}
+
+func (s *Server) routes() {
+	s.handle("GET /{$}", s.handleIndex)
+	s.handle("GET /search", s.handleSearch)
+	s.handle("GET /u/{archive}/{$}", s.handleArchive)
+	s.handle("GET /u/{archive}/s/{id}", s.handleSession)
+	s.handle("GET /u/{archive}/raw/{id}", s.handleRaw)
+
+	s.handle("GET /api/archives", s.handleAPIArchives)
+	s.handle("GET /api/archives/{archive}/sessions", s.handleAPISessions)
+	s.handle("GET /api/search", s.handleAPISearch)
+	s.handle("GET /openapi.json", s.handleOpenAPI)
+	s.handle("GET /docs", s.handleDocs)
+
+	if s.opts.Flags {
+		s.handle("GET /api/archives/{archive}/sessions/{id}/flags", s.handleGetFlags)
+		s.handle("PUT /api/archives/{archive}/sessions/{id}/flags", s.handlePutFlags)
+	}
+
+	if s.opts.Admin {
+		s.handle("GET /admin", s.handleAdmin)
+	}
+}
+
+// handle registers a route, keeping its pattern so the OpenAPI spec can
+// be checked against the routes
+func (s *Server) handle(pattern string, handler http.HandlerFunc) {
+	s.patterns = append(s.patterns, pattern)
+	s.mux.HandleFunc(pattern, handler)
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) archive(name string) (Archive, bool) {
+	for _, a := range s.archives {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Archive{}, false
+}
+
+// SessionSummary describes a session in archive listings
+type SessionSummary struct {
+	Archive  string    `json:"archive"`
+	ID       string    `json:"id"`
+	Project  string    `json:"project"`
+	Summary  string    `json:"summary,omitempty"`
+	Modified time.Time `json:"modified"`
+	Size     int64     `json:"size"`
+	Messages int       `json:"messages"`
+	Cost     float64   `json:"cost,omitempty"` // Estimated, in US dollars
+}
+
+// ArchiveSummary describes an archive in the index
+type ArchiveSummary struct {
+	Name     string `json:"name"`
+	Sessions int    `json:"sessions"`
+}
+
+// SearchHit is a session matching a combined search
+type SearchHit struct {
+	Archive  string    `json:"archive"`
+	ID       string    `json:"id"`
+	Project  string    `json:"project"`
+	Modified time.Time `json:"modified"`
+	Snippets []string  `json:"snippets"`
+}
+
+func (s *Server) listArchives() []ArchiveSummary {
+	var list []ArchiveSummary
+	for _, a := range s.archives {
+		sessions, _ := session.FindSessionsIn(a.Dir, 0)
+		list = append(list, ArchiveSummary{Name: a.Name, Sessions: len(sessions)})
+	}
+	return list
+}
+
+func (s *Server) listSessions(a Archive) ([]SessionSummary, error) {
+	sessions, err := session.FindSessionsIn(a.Dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	session.LoadSessionSummaries(sessions, s.opts.Title)
+
+	list := make([]SessionSummary, 0, len(sessions))
+	for _, info := range sessions {
+		list = append(list, SessionSummary{
+			Archive:  a.Name,
+			ID:       info.SessionID,
+			Project:  info.ProjectName,
+			Summary:  info.Summary,
+			Modified: info.ModTime,
+			Size:     info.Size,
+			Messages: info.MessageCount,
+			Cost:     s.opts.Pricing.Estimate(info.Usage).Total,
+		})
+	}
+	return list, nil
+}
+
+// search runs a query across every archive, newest sessions first
+func (s *Server) search(query string, maxSnippets int) []SearchHit {
+	var hits []SearchHit
+	for _, a := range s.archives {
+		results, err := session.SearchSessionsIn(a.Dir, query, session.SearchOptions{})
+		if err != nil {
+			continue
+		}
+		for _, r := range results {
+			hit := SearchHit{
+				Archive:  a.Name,
+				ID:       r.SessionInfo.SessionID,
+				Project:  r.SessionInfo.ProjectName,
+				Modified: r.SessionInfo.ModTime,
+			}
+			for i, m := range r.Matches {
+				if i >= maxSnippets {
+					break
+				}
+				hit.Snippets = append(hit.Snippets, m.Text)
+			}
+			hits = append(hits, hit)
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		return hits[i].Modified.After(hits[j].Modified)
+	})
+	return hits
+}
+
+// findSession locates a session file by ID within an archive
+func findSession(a Archive, id string) (string, bool) {
+	sessions, err := session.FindSessionsIn(a.Dir, 0)
+	if err != nil {
+		return "", false
+	}
+	for _, info := range sessions {
+		if info.SessionID == id {
+			return info.Path, true
+		}
+	}
+	return "", false
+}
+
+func (s *Server) readSession(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	a, ok := s.archive(r.PathValue("archive"))
+	if !ok {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	path, ok := findSession(a, r.PathValue("id"))
+	if !ok {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		http.Error(w, "reading session", http.StatusInternalServerError)
+		return nil, false
+	}
+	return data, true
+}
+
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	a, ok := s.archive(r.PathValue("archive"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	path, ok := findSession(a, r.PathValue("id"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		http.Error(w, "reading session", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	// Subagent transcripts are nested under their Task calls by the viewer
+	sub, _ := session.LoadSubagentTranscripts(path)
+	title, _ := session.ReadTitle(path)
+
+	view := render.Options{
+		Title:    title,
+		Pricing:  s.opts.Pricing,
+		Header:   s.opts.Header,
+		Footer:   s.opts.Footer,
+		IndexURL: "/u/" + a.Name + "/",
+	}
+	if s.opts.Flags {
+		view.FlagsURL = "/api/archives/" + a.Name + "/sessions/" + r.PathValue("id") + "/flags"
+	}
+
+	s.recordAccess(r, ActionView)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	data := io.MultiReader(f, strings.NewReader("\n"), bytes.NewReader(sub))
+	if err := s.render.Render(w, data, view); err != nil {
+		// Headers are already sent; all we can do is stop
+		return
+	}
+}
+
+func (s *Server) handleRaw(w http.ResponseWriter, r *http.Request) {
+	data, ok := s.readSession(w, r)
+	if !ok {
+		return
+	}
+	s.recordAccess(r, ActionDownload)
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", r.PathValue("id")+".jsonl"))
+	w.Write(data)
+}
+
+func (s *Server) handleAPIArchives(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.listArchives())
+}
+
+func (s *Server) handleAPISessions(w http.ResponseWriter, r *http.Request) {
+	a, ok := s.archive(r.PathValue("archive"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	list, err := s.listSessions(a)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, list)
+}
+
+func (s *Server) handleAPISearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "missing q parameter", http.StatusBadRequest)
+		return
+	}
+	hits := s.search(query, 3)
+	if hits == nil {
+		hits = []SearchHit{}
+	}
+	writeJSON(w, hits)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
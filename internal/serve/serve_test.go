@@ -0,0 +1,350 @@
+package serve
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/robzolkos/claude-session-export/internal/jsonschema"
+	"github.com/robzolkos/claude-session-export/render"
+)
+
+func writeSession(t *testing.T, dir, project, id, text string) {
+	t.Helper()
+	projectDir := filepath.Join(dir, project)
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+	line := `{"type":"user","timestamp":"2024-06-01T10:00:00Z","message":{"role":"user","content":"` + text + `"}}` + "\n"
+	if err := os.WriteFile(filepath.Join(projectDir, id+".jsonl"), []byte(line), 0644); err != nil {
+		t.Fatalf("Failed to write session: %v", err)
+	}
+}
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	alice := t.TempDir()
+	bob := t.TempDir()
+	writeSession(t, alice, "-home-alice-app", "a1", "fix the burrito endpoint")
+	writeSession(t, bob, "-home-bob-api", "b1", "burrito caching")
+	writeSession(t, bob, "-home-bob-api", "b2", "unrelated")
+
+	srv, err := New([]Archive{{Name: "alice", Dir: alice}, {Name: "bob", Dir: bob}},
+		render.RendererFunc(func(w io.Writer, session io.Reader, opts render.Options) error {
+			io.WriteString(w, "VIEWER:")
+			_, err := io.Copy(w, session)
+			return err
+		}), Options{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return srv
+}
+
+func get(t *testing.T, h http.Handler, path string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+	body, _ := io.ReadAll(rec.Result().Body)
+	return rec.Code, string(body)
+}
+
+func TestServeCombinedSearch(t *testing.T) {
+	srv := newTestServer(t)
+
+	code, body := get(t, srv, "/api/search?q=burrito")
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	var hits []SearchHit
+	if err := json.Unmarshal([]byte(body), &hits); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	archives := map[string]bool{}
+	for _, h := range hits {
+		archives[h.Archive] = true
+	}
+	if len(hits) != 2 || !archives["alice"] || !archives["bob"] {
+		t.Errorf("Expected one hit from each archive, got %+v", hits)
+	}
+}
+
+func TestServeArchivePrefixes(t *testing.T) {
+	srv := newTestServer(t)
+
+	code, body := get(t, srv, "/u/bob/s/b1")
+	if code != http.StatusOK || !strings.HasPrefix(body, "VIEWER:") {
+		t.Errorf("Expected rendered session, got %d %q", code, body)
+	}
+
+	// Sessions are only reachable under their own archive
+	if code, _ := get(t, srv, "/u/alice/s/b1"); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for session in another archive, got %d", code)
+	}
+	if code, _ := get(t, srv, "/u/carol/"); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown archive, got %d", code)
+	}
+
+	code, body = get(t, srv, "/u/bob/")
+	if code != http.StatusOK || !strings.Contains(body, "/u/bob/s/b2") {
+		t.Errorf("Expected archive listing with b2, got %d", code)
+	}
+}
+
+func TestNewRejectsDuplicateNames(t *testing.T) {
+	dir := t.TempDir()
+	_, err := New([]Archive{{Name: "a", Dir: dir}, {Name: "a", Dir: dir}}, nil, Options{})
+	if err == nil {
+		t.Error("Expected error for duplicate archive names")
+	}
+}
+
+func TestServeAccessLog(t *testing.T) {
+	dir := t.TempDir()
+	writeSession(t, dir, "-home-alice-app", "a1", "hello")
+
+	log, err := OpenAccessLog(filepath.Join(t.TempDir(), "access.jsonl"))
+	if err != nil {
+		t.Fatalf("OpenAccessLog failed: %v", err)
+	}
+	srv, err := New([]Archive{{Name: "alice", Dir: dir}},
+		render.RendererFunc(func(w io.Writer, session io.Reader, opts render.Options) error { return nil }),
+		Options{AccessLog: log, UserHeader: "X-Forwarded-User", Admin: true})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/u/alice/raw/a1", nil)
+	req.Header.Set("X-Forwarded-User", "carol")
+	srv.ServeHTTP(httptest.NewRecorder(), req)
+	get(t, srv, "/u/alice/s/a1")
+
+	entries, err := log.Recent(0)
+	if err != nil {
+		t.Fatalf("Recent failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 access entries, got %d", len(entries))
+	}
+	if entries[0].Action != ActionView || entries[1].Action != ActionDownload || entries[1].User != "carol" {
+		t.Errorf("Unexpected entries: %+v", entries)
+	}
+
+	// Only clients on this machine see the access log
+	if code, _ := get(t, srv, "/admin"); code != http.StatusForbidden {
+		t.Errorf("Expected a remote client to be refused, got %d", code)
+	}
+	req = httptest.NewRequest("GET", "/admin", nil)
+	req.RemoteAddr = "127.0.0.1:50000"
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "carol") {
+		t.Errorf("Expected admin page listing carol, got %d", rec.Code)
+	}
+
+	// Or with a list, the admin users wherever they are
+	srv, err = New([]Archive{{Name: "alice", Dir: dir}}, nil,
+		Options{AccessLog: log, UserHeader: "X-Forwarded-User", Admin: true, AdminUsers: []string{"carol"}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	for user, want := range map[string]int{"carol": http.StatusOK, "mallory": http.StatusForbidden, "": http.StatusForbidden} {
+		req := httptest.NewRequest("GET", "/admin", nil)
+		req.Header.Set("X-Forwarded-User", user)
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("User %q: expected %d, got %d", user, want, rec.Code)
+		}
+	}
+	if _, err := New([]Archive{{Name: "alice", Dir: dir}}, nil, Options{AccessLog: log, Admin: true, AdminUsers: []string{"carol"}}); err == nil {
+		t.Error("Expected admin users without a user header to be refused")
+	}
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	srv := newTestServer(t)
+
+	code, body := get(t, srv, "/openapi.json")
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+
+	var spec struct {
+		Paths      map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal([]byte(body), &spec); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+
+	for _, op := range apiOperations {
+		if _, ok := spec.Paths[op.path]; !ok && !op.flags {
+			t.Errorf("Expected path %s in spec", op.path)
+		}
+	}
+	hit, ok := spec.Components.Schemas[jsonschema.Name(reflect.TypeOf(SearchHit{}))]
+	if !ok {
+		t.Fatal("Expected SearchHit schema")
+	}
+	if _, ok := hit.Properties["snippets"]; !ok {
+		t.Errorf("Expected snippets property generated from json tag, got %v", hit.Properties)
+	}
+}
+
+func TestDocsPinsSwaggerUI(t *testing.T) {
+	srv := newTestServer(t)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	page := rec.Body.String()
+
+	if !strings.Contains(page, "swagger-ui-dist@5.17.14/swagger-ui-bundle.js") || strings.Contains(page, "swagger-ui-dist@5/") {
+		t.Errorf("Expected Swagger UI pinned to an exact version, got:\n%s", page)
+	}
+	// The policy allows the inline script by its hash
+	_, script, _ := strings.Cut(page, "<script>")
+	script, _, _ = strings.Cut(script, "</script>")
+	sum := sha256.Sum256([]byte(script))
+	policy := rec.Header().Get("Content-Security-Policy")
+	if !strings.Contains(policy, "'sha256-"+base64.StdEncoding.EncodeToString(sum[:])+"'") {
+		t.Errorf("Expected the inline script's hash in the policy, got %q", policy)
+	}
+	if !strings.Contains(policy, "script-src "+swaggerUI+"swagger-ui-bundle.js ") {
+		t.Errorf("Expected scripts limited to the pinned bundle, got %q", policy)
+	}
+}
+
+func TestOpenAPISpecCoversRoutes(t *testing.T) {
+	dir := t.TempDir()
+	writeSession(t, dir, "-home-alice-app", "a1", "hello")
+	srv, err := New([]Archive{{Name: "alice", Dir: dir}}, nil, Options{Flags: true})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	data, _ := json.Marshal(OpenAPISpec("test", true))
+	var spec struct {
+		Paths map[string]map[string]interface{} `json:"paths"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+
+	routes := 0
+	for _, pattern := range srv.patterns {
+		method, path, _ := strings.Cut(pattern, " ")
+		if !strings.HasPrefix(path, "/api/") {
+			continue
+		}
+		routes++
+		if _, ok := spec.Paths[path][strings.ToLower(method)]; !ok {
+			t.Errorf("Route %s is missing from the spec", pattern)
+		}
+	}
+	if routes != len(apiOperations) {
+		t.Errorf("Expected %d API routes, found %d", len(apiOperations), routes)
+	}
+
+	put := spec.Paths["/api/archives/{archive}/sessions/{id}/flags"]["put"]
+	if body, ok := put.(map[string]interface{})["requestBody"]; !ok || body == nil {
+		t.Errorf("Expected a request body for PUT flags, got %v", put)
+	}
+	if _, ok := OpenAPISpec("test", false)["paths"].(map[string]map[string]interface{})["/api/archives/{archive}/sessions/{id}/flags"]; ok {
+		t.Error("Expected no flags endpoints without --flags")
+	}
+}
+
+func TestServeBranding(t *testing.T) {
+	dir := t.TempDir()
+	writeSession(t, dir, "-home-alice-app", "a1", "hello")
+
+	var view render.Options
+	srv, err := New([]Archive{{Name: "alice", Dir: dir}},
+		render.RendererFunc(func(w io.Writer, session io.Reader, opts render.Options) error {
+			view = opts
+			return nil
+		}), Options{Header: "<img src=logo.png>", Footer: "Internal only"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	for _, path := range []string{"/", "/u/alice/", "/search?q=hello"} {
+		_, body := get(t, srv, path)
+		if !strings.Contains(body, "<img src=logo.png>") || !strings.Contains(body, "Internal only") {
+			t.Errorf("Expected branding on %s", path)
+		}
+	}
+
+	get(t, srv, "/u/alice/s/a1")
+	if view.Header != "<img src=logo.png>" || view.Footer != "Internal only" {
+		t.Errorf("Expected branding passed to the viewer, got %+v", view)
+	}
+}
+
+func TestServeFlags(t *testing.T) {
+	dir := t.TempDir()
+	writeSession(t, dir, "-home-alice-app", "a1", "hello")
+
+	var view render.Options
+	srv, err := New([]Archive{{Name: "alice", Dir: dir}},
+		render.RendererFunc(func(w io.Writer, session io.Reader, opts render.Options) error {
+			view = opts
+			return nil
+		}), Options{Flags: true, UserHeader: "X-Forwarded-User"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	get(t, srv, "/u/alice/s/a1")
+	if view.FlagsURL != "/api/archives/alice/sessions/a1/flags" {
+		t.Errorf("Expected flags URL passed to the viewer, got %q", view.FlagsURL)
+	}
+
+	put := func(body string) int {
+		req := httptest.NewRequest("PUT", "/api/archives/alice/sessions/a1/flags", strings.NewReader(body))
+		req.Header.Set("X-Forwarded-User", "carol")
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := put(`{"flags":[{"message":"u1","reaction":"meh"}]}`); code != http.StatusBadRequest {
+		t.Errorf("Expected unknown reaction rejected, got %d", code)
+	}
+	if code := put(`{"flags":[{"message":"u1","prompt":"hello","reaction":"follow-up"}]}`); code != http.StatusOK {
+		t.Fatalf("Expected flags saved, got %d", code)
+	}
+
+	sidecar := filepath.Join(dir, "-home-alice-app", "a1.flags.json")
+	if _, err := os.Stat(sidecar); err != nil {
+		t.Fatalf("Expected sidecar next to the session: %v", err)
+	}
+
+	_, body := get(t, srv, "/api/archives/alice/sessions/a1/flags")
+	if !strings.Contains(body, `"reaction": "follow-up"`) || !strings.Contains(body, `"user": "carol"`) {
+		t.Errorf("Expected saved flag attributed to carol, got %s", body)
+	}
+
+	if code := put(`{"flags":[]}`); code != http.StatusOK {
+		t.Fatalf("Expected flags cleared, got %d", code)
+	}
+	if _, err := os.Stat(sidecar); !os.IsNotExist(err) {
+		t.Error("Expected sidecar removed once nothing is flagged")
+	}
+
+	code, _ := get(t, newTestServer(t), "/api/archives/alice/sessions/a1/flags")
+	if code != http.StatusNotFound {
+		t.Errorf("Expected flags API off by default, got %d", code)
+	}
+}
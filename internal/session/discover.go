@@ -5,9 +5,13 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 )
 
 // SessionInfo contains metadata about a discovered session
@@ -22,6 +26,7 @@ type SessionInfo struct {
 	EndTime      time.Time
 	MessageCount int
 	UserMsgCount int
+	Usage        map[string]TokenUsage // Token usage per model
 }
 
 // ProjectInfo contains metadata about a project folder
@@ -47,10 +52,14 @@ func FindLocalSessions(limit int) ([]SessionInfo, error) {
 	if err != nil {
 		return nil, err
 	}
+	return FindSessionsIn(projectsDir, limit)
+}
 
+// FindSessionsIn finds session files under a projects-style directory
+func FindSessionsIn(projectsDir string, limit int) ([]SessionInfo, error) {
 	var sessions []SessionInfo
 
-	err = filepath.WalkDir(projectsDir, func(path string, d fs.DirEntry, err error) error {
+	err := filepath.WalkDir(projectsDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return nil // Skip errors
 		}
@@ -189,6 +198,33 @@ func FindAllSessions() ([]ProjectInfo, error) {
 	return projects, nil
 }
 
+// FindSessionByID finds a local session by its ID or a unique ID prefix
+func FindSessionByID(id string) (*SessionInfo, error) {
+	sessions, err := FindLocalSessions(0)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []SessionInfo
+	for _, s := range sessions {
+		if s.SessionID == id {
+			return &s, nil
+		}
+		if strings.HasPrefix(s.SessionID, id) {
+			matches = append(matches, s)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no session found with ID %q", id)
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, fmt.Errorf("session ID %q is ambiguous (%d matches)", id, len(matches))
+	}
+}
+
 // SessionDetails contains parsed session details
 type SessionDetails struct {
 	Summary      string
@@ -196,20 +232,38 @@ type SessionDetails struct {
 	EndTime      time.Time
 	MessageCount int
 	UserMsgCount int
+	Usage        map[string]TokenUsage
 }
 
 // GetSessionDetails loads a session and returns details for display
 func GetSessionDetails(path string) (*SessionDetails, error) {
+	details, _, err := sessionDetails(path, nil)
+	return details, err
+}
+
+// TitleFunc titles a session for listings. Returning "" falls back to
+// DefaultTitle.
+type TitleFunc func(path string, sess *Session) string
+
+// sessionDetails loads a session and summarizes it for display, titling it
+// with title when given
+func sessionDetails(path string, title TitleFunc) (*SessionDetails, *Session, error) {
 	session, err := ParseFile(path)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	details := &SessionDetails{
 		MessageCount: len(session.Messages),
+		Usage:        UsageByModel(session),
+	}
+	if title != nil {
+		details.Summary = title(path, session)
+	}
+	if details.Summary == "" {
+		details.Summary = DefaultTitle(session)
 	}
 
-	// Find first meaningful user message and count user messages
 	for _, msg := range session.Messages {
 		// Track start time from first message with timestamp
 		if details.StartTime.IsZero() && !msg.Timestamp.IsZero() {
@@ -219,33 +273,49 @@ func GetSessionDetails(path string) (*SessionDetails, error) {
 		if !msg.Timestamp.IsZero() {
 			details.EndTime = msg.Timestamp
 		}
-
 		if msg.Role == "user" {
 			details.UserMsgCount++
-
-			// Only set summary if not already set
-			if details.Summary == "" {
-				text := ExtractText(&msg)
-				// Skip warmup and caveat/compaction messages
-				if text != "" && !isBoringMessage(text) {
-					// Clean up whitespace - replace newlines/tabs with spaces
-					text = strings.ReplaceAll(text, "\n", " ")
-					text = strings.ReplaceAll(text, "\t", " ")
-					text = strings.TrimSpace(text)
-					// Collapse multiple spaces
-					for strings.Contains(text, "  ") {
-						text = strings.ReplaceAll(text, "  ", " ")
-					}
-					if len(text) > 80 {
-						text = text[:80] + "..."
-					}
-					details.Summary = text
-				}
-			}
 		}
 	}
 
-	return details, nil
+	return details, session, nil
+}
+
+// DefaultTitle is the title from Claude Code's summary entries, or else the
+// first prompt
+func DefaultTitle(session *Session) string {
+	if session.Metadata != nil && session.Metadata.Title != "" {
+		return session.Metadata.Title
+	}
+	return FirstPromptTitle(session)
+}
+
+// FirstPromptTitle is the first meaningful user message, flattened to one
+// line and cut to 80 characters
+func FirstPromptTitle(session *Session) string {
+	for _, msg := range session.Messages {
+		if msg.Role != "user" || msg.IsMeta {
+			continue
+		}
+		text := ExtractText(&msg)
+		// Skip warmup and caveat/compaction messages
+		if text == "" || isBoringMessage(text) {
+			continue
+		}
+		// Clean up whitespace - replace newlines/tabs with spaces
+		text = strings.ReplaceAll(text, "\n", " ")
+		text = strings.ReplaceAll(text, "\t", " ")
+		text = strings.TrimSpace(text)
+		// Collapse multiple spaces
+		for strings.Contains(text, "  ") {
+			text = strings.ReplaceAll(text, "  ", " ")
+		}
+		if len(text) > 80 {
+			text = text[:80] + "..."
+		}
+		return text
+	}
+	return ""
 }
 
 // isBoringMessage returns true for messages that aren't useful as summaries
@@ -262,23 +332,25 @@ func isBoringMessage(text string) bool {
 	if strings.HasPrefix(text, "This session is being continued") {
 		return true
 	}
-	// Skip command messages like /clear, /exit
-	if strings.HasPrefix(text, "<command-name>") {
+	// Skip command messages like /clear, /exit, and their output
+	if strings.HasPrefix(text, "<command-name>") || strings.HasPrefix(text, "<local-command-stdout>") {
 		return true
 	}
 	return false
 }
 
-// LoadSessionSummaries loads summaries for a list of sessions
-func LoadSessionSummaries(sessions []SessionInfo) {
+// LoadSessionSummaries loads summaries for a list of sessions, titling them
+// with title (nil for DefaultTitle)
+func LoadSessionSummaries(sessions []SessionInfo, title TitleFunc) {
 	for i := range sessions {
-		details, err := GetSessionDetails(sessions[i].Path)
+		details, _, err := sessionDetails(sessions[i].Path, title)
 		if err == nil {
 			sessions[i].Summary = details.Summary
 			sessions[i].StartTime = details.StartTime
 			sessions[i].EndTime = details.EndTime
 			sessions[i].MessageCount = details.MessageCount
 			sessions[i].UserMsgCount = details.UserMsgCount
+			sessions[i].Usage = details.Usage
 		}
 	}
 
@@ -299,8 +371,14 @@ func LoadSessionSummaries(sessions []SessionInfo) {
 
 // SearchMatch represents a single match within a session
 type SearchMatch struct {
-	Text    string // The matching text with context
-	Context string // "user" or "assistant"
+	Text      string // The matching text with context
+	Match     string // Just the text that matched
+	Context   string // "user" or "assistant"
+	Timestamp time.Time
+
+	// The entry's line in the file, and the byte offset the line starts at
+	Line   int
+	Offset int64
 }
 
 // SearchResult represents search results for a single session
@@ -309,16 +387,100 @@ type SearchResult struct {
 	Matches     []SearchMatch
 }
 
+// SearchOptions narrow a search. The zero value finds the query, ignoring
+// case, in the text of any message.
+type SearchOptions struct {
+	Regex         bool // The query is a regular expression
+	CaseSensitive bool
+	Role          string // "user" or "assistant"; both if empty
+	Tool          string // Search this tool's inputs and output instead of the text
+	Project       string // Only projects whose name contains this, ignoring case
+	Since, Until  time.Time
+
+	// Lookup returns a file's entries from an index, if they are current;
+	// other files are parsed
+	Lookup func(path string, info fs.FileInfo) ([]SearchEntry, bool)
+}
+
+// SearchEntry is the searchable part of a message: its text, and the
+// inputs and output of the tool calls it makes or answers
+type SearchEntry struct {
+	Role      string
+	Timestamp time.Time
+	Line      int
+	Offset    int64
+	Text      string
+	Tools     []ToolText
+}
+
+// ToolText is the input of a call to the named tool, or its output
+type ToolText struct {
+	Name string
+	Text string
+}
+
+// SearchEntries extracts the searchable entries of a parsed session
+func SearchEntries(session *Session) []SearchEntry {
+	var entries []SearchEntry
+	toolNames := make(map[string]string) // Tool use ID to tool name
+	for _, msg := range session.Messages {
+		entry := SearchEntry{
+			Role:      msg.Role,
+			Timestamp: msg.Timestamp,
+			Line:      msg.Line,
+			Offset:    msg.Offset,
+			Text:      ExtractText(&msg),
+		}
+		for _, block := range msg.Content {
+			switch block.Type {
+			case "tool_use":
+				toolNames[block.ID] = block.Name
+				entry.Tools = append(entry.Tools, ToolText{Name: block.Name, Text: string(block.Input)})
+			case "tool_result":
+				entry.Tools = append(entry.Tools, ToolText{Name: toolNames[block.ToolUseID], Text: ToolResultText(block.Content)})
+			}
+		}
+		if entry.Text != "" || len(entry.Tools) > 0 {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// matcher compiles the query as the options say
+func (o SearchOptions) matcher(query string) (*regexp.Regexp, error) {
+	if !o.Regex {
+		query = regexp.QuoteMeta(query)
+	}
+	if !o.CaseSensitive {
+		query = "(?i)" + query
+	}
+	re, err := regexp.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %w", err)
+	}
+	return re, nil
+}
+
 // SearchSessions searches all sessions for a query string
-func SearchSessions(query string) ([]SearchResult, error) {
+func SearchSessions(query string, opts SearchOptions) ([]SearchResult, error) {
 	projectsDir, err := GetClaudeProjectsDir()
 	if err != nil {
 		return nil, err
 	}
+	return SearchSessionsIn(projectsDir, query, opts)
+}
 
-	query = strings.ToLower(query)
-	var results []SearchResult
+// SearchSessionsIn searches the sessions under a projects-style directory,
+// several files at a time
+func SearchSessionsIn(projectsDir, query string, opts SearchOptions) ([]SearchResult, error) {
+	re, err := opts.matcher(query)
+	if err != nil {
+		return nil, err
+	}
 
+	var candidates []SearchResult
+	infos := make(map[string]fs.FileInfo)
 	err = filepath.WalkDir(projectsDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return nil
@@ -336,98 +498,161 @@ func SearchSessions(query string) ([]SearchResult, error) {
 		if err != nil {
 			return nil
 		}
-
-		// Search this session file
-		matches, err := searchSessionFile(path, query)
-		if err != nil || len(matches) == 0 {
+		// Nothing in a file last written before the window can be in it
+		if !opts.Since.IsZero() && info.ModTime().Before(opts.Since) {
 			return nil
 		}
 
 		// Get project name from path
-		rel, _ := filepath.Rel(projectsDir, path)
-		parts := strings.Split(rel, string(filepath.Separator))
-		projectName := ""
-		if len(parts) > 1 {
-			projectName = parts[0]
+		projectName := ProjectName(projectsDir, path)
+		if opts.Project != "" && !strings.Contains(strings.ToLower(projectName), strings.ToLower(opts.Project)) {
+			return nil
 		}
 
-		sessionID := strings.TrimSuffix(filepath.Base(path), ".jsonl")
-
-		results = append(results, SearchResult{
+		candidates = append(candidates, SearchResult{
 			SessionInfo: SessionInfo{
 				Path:        path,
 				ProjectName: projectName,
-				SessionID:   sessionID,
+				SessionID:   strings.TrimSuffix(filepath.Base(path), ".jsonl"),
 				ModTime:     info.ModTime(),
 				Size:        info.Size(),
 			},
-			Matches: matches,
 		})
-
+		infos[path] = info
 		return nil
 	})
-
 	if err != nil {
 		return nil, fmt.Errorf("searching sessions: %w", err)
 	}
 
+	// Search the files in parallel; each worker fills in its own results
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				path := candidates[i].SessionInfo.Path
+				entries, ok := []SearchEntry(nil), false
+				if opts.Lookup != nil {
+					entries, ok = opts.Lookup(path, infos[path])
+				}
+				if !ok {
+					session, err := ParseFile(path)
+					if err != nil {
+						continue
+					}
+					entries = SearchEntries(session)
+				}
+				candidates[i].Matches = matchEntries(entries, re, opts)
+			}
+		}()
+	}
+	for i := range candidates {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	var results []SearchResult
+	for _, c := range candidates {
+		if len(c.Matches) > 0 {
+			results = append(results, c)
+		}
+	}
+
 	// Sort by modification time (newest first)
-	sort.Slice(results, func(i, j int) bool {
+	sort.SliceStable(results, func(i, j int) bool {
 		return results[i].SessionInfo.ModTime.After(results[j].SessionInfo.ModTime)
 	})
 
 	return results, nil
 }
 
-// searchSessionFile searches a single session file for the query
-func searchSessionFile(path, query string) ([]SearchMatch, error) {
-	session, err := ParseFile(path)
-	if err != nil {
-		return nil, err
+// ProjectName is the project directory a session file is in, under
+// projectsDir; empty for files directly in it
+func ProjectName(projectsDir, path string) string {
+	rel, _ := filepath.Rel(projectsDir, path)
+	parts := strings.Split(rel, string(filepath.Separator))
+	if len(parts) > 1 {
+		return parts[0]
 	}
+	return ""
+}
 
+// matchEntries finds the first match of the query in each entry that
+// passes the filters
+func matchEntries(entries []SearchEntry, re *regexp.Regexp, opts SearchOptions) []SearchMatch {
 	var matches []SearchMatch
+	for _, entry := range entries {
+		if opts.Role != "" && entry.Role != opts.Role {
+			continue
+		}
+		if !opts.Since.IsZero() && (entry.Timestamp.IsZero() || entry.Timestamp.Before(opts.Since)) {
+			continue
+		}
+		if !opts.Until.IsZero() && (entry.Timestamp.IsZero() || !entry.Timestamp.Before(opts.Until)) {
+			continue
+		}
 
-	for _, msg := range session.Messages {
-		text := ExtractText(&msg)
-		textLower := strings.ToLower(text)
-
-		if strings.Contains(textLower, query) {
-			// Find the match and extract context
-			snippet := extractSnippet(text, query, 60)
-			if snippet != "" {
-				matches = append(matches, SearchMatch{
-					Text:    snippet,
-					Context: msg.Role,
-				})
-			}
+		text := entry.Text
+		if opts.Tool != "" {
+			text = toolText(entry, opts.Tool)
+		}
+		loc := re.FindStringIndex(text)
+		if loc == nil {
+			continue
+		}
+		// Find the match and extract context
+		if snippet := extractSnippet(text, loc[0], loc[1], 60); snippet != "" {
+			matches = append(matches, SearchMatch{
+				Text:      snippet,
+				Match:     text[loc[0]:loc[1]],
+				Context:   entry.Role,
+				Timestamp: entry.Timestamp,
+				Line:      entry.Line,
+				Offset:    entry.Offset,
+			})
 		}
 	}
-
-	return matches, nil
+	return matches
 }
 
-// extractSnippet extracts a snippet around the query match
-func extractSnippet(text, query string, contextChars int) string {
-	textLower := strings.ToLower(text)
-	queryLower := strings.ToLower(query)
+// toolText returns the inputs and output of an entry's calls to the named
+// tool
+func toolText(entry SearchEntry, tool string) string {
+	var texts []string
+	for _, t := range entry.Tools {
+		if strings.EqualFold(t.Name, tool) {
+			texts = append(texts, t.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
 
-	idx := strings.Index(textLower, queryLower)
-	if idx == -1 {
+// extractSnippet extracts a snippet around the match at text[start:end]
+func extractSnippet(text string, start, end, contextChars int) string {
+	if start == end {
 		return ""
 	}
-
-	start := idx - contextChars
-	if start < 0 {
-		start = 0
+	from := start - contextChars
+	if from < 0 {
+		from = 0
 	}
-
-	end := idx + len(query) + contextChars
-	if end > len(text) {
-		end = len(text)
+	to := end + contextChars
+	if to > len(text) {
+		to = len(text)
+	}
+	// Don't cut a character in half
+	for from > 0 && !utf8.RuneStart(text[from]) {
+		from--
+	}
+	for to < len(text) && !utf8.RuneStart(text[to]) {
+		to++
 	}
 
-	snippet := text[start:end]
+	snippet := text[from:to]
 
 	// Clean up whitespace
 	snippet = strings.ReplaceAll(snippet, "\n", " ")
@@ -438,10 +663,10 @@ func extractSnippet(text, query string, contextChars int) string {
 	snippet = strings.TrimSpace(snippet)
 
 	// Add ellipsis
-	if start > 0 {
+	if from > 0 {
 		snippet = "..." + snippet
 	}
-	if end < len(text) {
+	if to < len(text) {
 		snippet = snippet + "..."
 	}
 
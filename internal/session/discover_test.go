@@ -0,0 +1,82 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSearchSessionsIn(t *testing.T) {
+	dir := t.TempDir()
+	write := func(project, name, content string) {
+		path := filepath.Join(dir, project, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("-home-alice-api", "a.jsonl", `{"type":"user","message":{"role":"user","content":"Why does Deploy fail?"},"timestamp":"2025-01-10T10:00:00Z"}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Let me check the deploy logs"},{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"make deploy"}}]},"timestamp":"2025-01-10T10:01:00Z"}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"error: deploy key missing"}]},"timestamp":"2025-01-10T10:02:00Z"}
+`)
+	write("-home-alice-web", "b.jsonl", `{"type":"user","message":{"role":"user","content":"deploy the site"},"timestamp":"2025-02-01T09:00:00Z"}
+`)
+
+	count := func(query string, opts SearchOptions) int {
+		t.Helper()
+		results, err := SearchSessionsIn(dir, query, opts)
+		if err != nil {
+			t.Fatalf("Search %q failed: %v", query, err)
+		}
+		n := 0
+		for _, r := range results {
+			n += len(r.Matches)
+		}
+		return n
+	}
+
+	for _, tt := range []struct {
+		name  string
+		query string
+		opts  SearchOptions
+		want  int
+	}{
+		{"default ignores case", "deploy", SearchOptions{}, 3},
+		{"case sensitive", "Deploy", SearchOptions{CaseSensitive: true}, 1},
+		{"regex", `deploy (logs|the)`, SearchOptions{Regex: true}, 2},
+		{"role", "deploy", SearchOptions{Role: "assistant"}, 1},
+		{"tool input and output", "deploy", SearchOptions{Tool: "bash"}, 2},
+		{"project", "deploy", SearchOptions{Project: "WEB"}, 1},
+		{"since", "deploy", SearchOptions{Since: time.Date(2025, 1, 10, 10, 0, 30, 0, time.UTC)}, 2},
+		{"until", "deploy", SearchOptions{Until: time.Date(2025, 1, 10, 10, 0, 30, 0, time.UTC)}, 1},
+	} {
+		if got := count(tt.query, tt.opts); got != tt.want {
+			t.Errorf("%s: got %d matches, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	results, err := SearchSessionsIn(dir, "key missing", SearchOptions{Tool: "Bash"})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Expected one result, got %v, %v", results, err)
+	}
+	data, _ := os.ReadFile(results[0].SessionInfo.Path)
+	m := results[0].Matches[0]
+	if m.Line != 3 || m.Match != "key missing" || !strings.HasPrefix(string(data[m.Offset:]), `{"type":"user","message":{"role":"user","content":[{"type":"tool_result"`) {
+		t.Errorf("Expected the match to point at line 3, got %+v", m)
+	}
+
+	if _, err := SearchSessionsIn(dir, "(", SearchOptions{Regex: true}); err == nil {
+		t.Error("Expected an error for an invalid regex")
+	}
+}
+
+func TestExtractSnippet(t *testing.T) {
+	text := "ééééé needle ééééé"
+	start := len("ééééé ")
+	got := extractSnippet(text, start, start+len("needle"), 3)
+	if got != "...é needle é..." {
+		t.Errorf("Expected the snippet cut on character boundaries, got %q", got)
+	}
+}
@@ -0,0 +1,200 @@
+package session
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileState is a file as a session left it, rebuilt from its Write, Edit
+// and MultiEdit calls
+type FileState struct {
+	Path     string
+	Content  string
+	Original string // Contents before the session changed it, if read
+	Created  bool   // Written without being read first, so taken to be new
+	Changes  int    // Writes and edits applied
+	Failed   int    // Edits whose text wasn't in the file, left out
+	Updated  time.Time
+}
+
+// fileOp is a call that reads or changes a file
+type fileOp struct {
+	name      string
+	input     *ToolInput
+	raw       json.RawMessage
+	result    string
+	timestamp time.Time
+}
+
+// readLine matches a line of Read output: the line number, then → (or a
+// tab, in older versions) and the text
+var readLine = regexp.MustCompile(`^\s*\d+(?:→|\t)(.*)$`)
+
+// ReconstructFiles replays a session's file writes and edits, including
+// its subagents', to rebuild every file it changed as of at (zero for the
+// end of the session). Edits to a file the session never wrote start from
+// its contents as last read in full. Files edited without either can't be
+// rebuilt, and are returned as skipped. Calls that failed are left out.
+func ReconstructFiles(session *Session, at time.Time) (files []FileState, skipped []string) {
+	states := make(map[string]*FileState)
+	known := make(map[string]string) // Contents read in full, before any change
+	unknown := make(map[string]bool)
+	for _, op := range fileOps(session) {
+		if !at.IsZero() && op.timestamp.After(at) {
+			break
+		}
+		path := op.input.FilePath
+		file := states[path]
+		switch op.name {
+		case "Read":
+			if content, ok := readContent(op.raw, op.result); ok && file == nil {
+				known[path] = content
+			}
+			continue
+		case "Write":
+			if file == nil {
+				base, ok := known[path]
+				file = &FileState{Path: path, Original: base, Created: !ok}
+				states[path] = file
+			}
+			file.Content = op.input.Content
+			file.Changes++
+		case "Edit", "MultiEdit":
+			if file == nil {
+				base, ok := known[path]
+				if !ok {
+					unknown[path] = true
+					continue
+				}
+				file = &FileState{Path: path, Content: base, Original: base}
+				states[path] = file
+			}
+			for _, edit := range op.input.AllEdits() {
+				content, ok := applyEdit(file.Content, edit)
+				if !ok {
+					file.Failed++
+					continue
+				}
+				file.Content = content
+				file.Changes++
+			}
+		}
+		file.Updated = op.timestamp
+	}
+
+	for _, file := range states {
+		files = append(files, *file)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	for path := range unknown {
+		if states[path] == nil {
+			skipped = append(skipped, path)
+		}
+	}
+	sort.Strings(skipped)
+	return files, skipped
+}
+
+// LocalPath turns a file's path into a relative one: relative to the
+// session's working directory when it's under it, or else its full path
+// without the leading separator, so it can't point outside a directory
+func LocalPath(path, cwd string) string {
+	if cwd != "" {
+		if rel, err := filepath.Rel(cwd, path); err == nil && filepath.IsLocal(rel) {
+			return rel
+		}
+	}
+	path = strings.TrimPrefix(path, filepath.VolumeName(path))
+	parts := strings.FieldsFunc(filepath.ToSlash(path), func(r rune) bool { return r == '/' })
+	parts = slices.DeleteFunc(parts, func(part string) bool { return part == "." || part == ".." })
+	return filepath.Join(parts...)
+}
+
+// fileOps returns the successful file calls of the session and its
+// subagents in time order, with their output
+func fileOps(session *Session) []fileOp {
+	results := make(map[string]ContentBlock)
+	for _, messages := range [][]Message{session.Messages, session.Sidechain} {
+		for _, msg := range messages {
+			for _, block := range msg.Content {
+				if block.Type == "tool_result" {
+					results[block.ToolUseID] = block
+				}
+			}
+		}
+	}
+
+	var ops []fileOp
+	for _, messages := range [][]Message{session.Messages, session.Sidechain} {
+		for _, msg := range messages {
+			for _, block := range msg.Content {
+				if block.Type != "tool_use" {
+					continue
+				}
+				switch block.Name {
+				case "Read", "Write", "Edit", "MultiEdit":
+				default:
+					continue
+				}
+				result, ok := results[block.ID]
+				if !ok || result.IsError {
+					continue
+				}
+				input, err := ParseToolInput(block.Input)
+				if err != nil || input.FilePath == "" {
+					continue
+				}
+				ops = append(ops, fileOp{
+					name:      block.Name,
+					input:     input,
+					raw:       block.Input,
+					result:    ToolResultText(result.Content),
+					timestamp: msg.Timestamp,
+				})
+			}
+		}
+	}
+	sort.SliceStable(ops, func(i, j int) bool { return ops[i].timestamp.Before(ops[j].timestamp) })
+	return ops
+}
+
+// readContent recovers a file's contents from the output of a Read that
+// covered all of it
+func readContent(input json.RawMessage, output string) (string, bool) {
+	var ranged struct {
+		Offset *int `json:"offset"`
+		Limit  *int `json:"limit"`
+	}
+	if json.Unmarshal(input, &ranged) != nil || ranged.Offset != nil || ranged.Limit != nil {
+		return "", false
+	}
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		m := readLine.FindStringSubmatch(line)
+		if m == nil {
+			break // A reminder or notice after the file
+		}
+		lines = append(lines, m[1])
+	}
+	if len(lines) == 0 {
+		return "", false
+	}
+	return strings.Join(lines, "\n") + "\n", true
+}
+
+// applyEdit makes one replacement as the Edit tool does, reporting false
+// if the text to replace isn't there
+func applyEdit(content string, edit EditOp) (string, bool) {
+	if edit.OldString == "" || !strings.Contains(content, edit.OldString) {
+		return content, false
+	}
+	if edit.ReplaceAll {
+		return strings.ReplaceAll(content, edit.OldString, edit.NewString), true
+	}
+	return strings.Replace(content, edit.OldString, edit.NewString, 1), true
+}
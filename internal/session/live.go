@@ -0,0 +1,98 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LiveWindow is how recently a session must have been written to count as
+// live, i.e. Claude Code is probably still appending to it
+const LiveWindow = 2 * time.Minute
+
+// Torn line retries: Claude Code writes each entry with a single append, so
+// a final line without its newline is usually complete a moment later
+const (
+	readRetries    = 3
+	readRetryDelay = 100 * time.Millisecond
+)
+
+// idlePollInterval is how often WaitIdle checks the file
+var idlePollInterval = 250 * time.Millisecond
+
+// IsLive reports whether the session was written within LiveWindow of now
+func (s SessionInfo) IsLive(now time.Time) bool {
+	return !s.ModTime.IsZero() && now.Sub(s.ModTime) < LiveWindow
+}
+
+// IsLiveFile reports whether the file at path is a session in the Claude
+// projects directory written within LiveWindow of now. Files elsewhere,
+// such as copies, aren't being appended to however recent they are.
+func IsLiveFile(path string, now time.Time) bool {
+	info, err := os.Stat(path)
+	if err != nil || now.Sub(info.ModTime()) >= LiveWindow {
+		return false
+	}
+	projectsDir, err := GetClaudeProjectsDir()
+	if err != nil {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(projectsDir, abs)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// ReadFile reads a session file that may still be being written. A JSONL
+// file whose final line is cut off mid-entry is re-read briefly, and if the
+// line is still incomplete it is left out rather than passed on torn.
+func ReadFile(path string) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if !IsJSONL(path) || len(data) == 0 || data[len(data)-1] == '\n' {
+			return data, nil
+		}
+
+		tail := data[bytes.LastIndexByte(data, '\n')+1:]
+		if len(bytes.TrimSpace(tail)) == 0 || json.Valid(tail) {
+			return data, nil
+		}
+		if attempt == readRetries {
+			return data[:len(data)-len(tail)], nil
+		}
+		time.Sleep(readRetryDelay)
+	}
+}
+
+// WaitIdle blocks until the file at path has gone unchanged for idle, so an
+// export doesn't catch Claude Code mid-reply. It gives up after timeout.
+func WaitIdle(path string, idle, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	var lastSize int64 = -1
+
+	for {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		// The size check covers file systems with coarse modification times
+		if time.Since(info.ModTime()) >= idle && (lastSize < 0 || info.Size() == lastSize) {
+			return nil
+		}
+		lastSize = info.Size()
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("session was still being written after %s", timeout)
+		}
+		time.Sleep(idlePollInterval)
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"os"
+	"net/url"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -19,9 +19,17 @@ var CommitPattern = regexp.MustCompile(`\[[\w\-/]+\s+([a-f0-9]{7,})\]\s+(.+)`)
 // GitHubRepoPattern matches GitHub URLs in git output
 var GitHubRepoPattern = regexp.MustCompile(`github\.com[:/]([^/]+/[^/\s]+?)(?:\.git)?(?:\s|$)`)
 
+// forgeRepoPattern matches repository URLs on the hosted forges. GitLab
+// groups can be nested; a "-" segment starts a page within the repository.
+var forgeRepoPattern = regexp.MustCompile(`(github\.com|bitbucket\.org)[:/]([\w.-]+/[\w.-]+?)(?:\.git)?(?:\s|$)|(gitlab\.com)[:/]([\w.][\w.-]*(?:/[\w.][\w.-]*)+?)(?:\.git)?(?:\s|$)`)
+
+// remotePattern matches the remote in git push output ("To host:path")
+// and git remote -v, on any host
+var remotePattern = regexp.MustCompile(`(?m)(?:^To |^\w+\t)(?:https?://|ssh://)?(?:[\w.-]+@)?([\w-]+(?:\.[\w-]+)+)(?::\d+/|[:/])([\w.][\w.-]*(?:/[\w.][\w.-]*)*?)(?:\.git)?/?(?:\s|$)`)
+
 // ParseFile parses a session file (JSON or JSONL format)
 func ParseFile(path string) (*Session, error) {
-	data, err := os.ReadFile(path)
+	data, err := ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("reading file: %w", err)
 	}
@@ -128,12 +136,27 @@ func parseJSON(data []byte) (*Session, error) {
 
 func parseJSONL(data []byte) (*Session, error) {
 	var messages []Message
+	var summaries []Message
+	skipped := make(map[string]skippedEntry)
 	scanner := bufio.NewScanner(bytes.NewReader(data))
 
 	// Increase buffer size for long lines
 	buf := make([]byte, 0, 64*1024)
 	scanner.Buffer(buf, 10*1024*1024) // 10MB max line size
 
+	// Track where each line starts, for search results to point at
+	var pos, lineStart int64
+	lineNo := 0
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		if token != nil {
+			lineStart = pos
+			lineNo++
+		}
+		pos += int64(advance)
+		return advance, token, err
+	})
+
 	for scanner.Scan() {
 		line := bytes.TrimSpace(scanner.Bytes())
 		if len(line) == 0 {
@@ -145,6 +168,7 @@ func parseJSONL(data []byte) (*Session, error) {
 			// Skip invalid lines silently (matches Python behavior)
 			continue
 		}
+		msg.Line, msg.Offset = lineNo, lineStart
 
 		// Handle new Claude Code format where message is nested
 		if msg.NestedMessage != nil {
@@ -157,7 +181,20 @@ func parseJSONL(data []byte) (*Session, error) {
 
 		// Skip non-message types (file-history-snapshot, queue-operation, summary, etc.)
 		// Accept "user", "assistant", or empty type (old format)
+		if msg.Type == "summary" && msg.Summary != "" {
+			summaries = append(summaries, msg)
+			continue
+		}
+
 		if msg.Type != "" && msg.Type != "message" && msg.Type != "user" && msg.Type != "assistant" {
+			// Remember where it sat in the thread so children can link past it
+			if msg.UUID != "" {
+				skipped[msg.UUID] = skippedEntry{
+					parent:   msg.ParentUUID,
+					logical:  msg.LogicalParentUUID,
+					boundary: msg.Subtype == "compact_boundary",
+				}
+			}
 			continue
 		}
 
@@ -173,20 +210,43 @@ func parseJSONL(data []byte) (*Session, error) {
 		return nil, fmt.Errorf("scanning JSONL: %w", err)
 	}
 
-	session := &Session{Messages: messages}
+	// Subagent messages form their own threads
+	var main, sidechain []Message
+	for _, msg := range messages {
+		if msg.IsSidechain {
+			sidechain = append(sidechain, msg)
+		} else {
+			main = append(main, msg)
+		}
+	}
+
+	session := &Session{Messages: main, Sidechain: sidechain}
 	if err := parseMessages(session); err != nil {
 		return nil, err
 	}
+	session.Messages = threadMessages(session.Messages, skipped)
 
 	// Build session metadata
 	session.Metadata = buildSessionMetadata(session)
+	uuids := make(map[string]bool)
+	for _, msg := range session.Messages {
+		uuids[msg.UUID] = true
+	}
+	session.Metadata.Title = chooseTitle(summaries, func(uuid string) bool { return uuids[uuid] })
 
 	return session, nil
 }
 
 func parseMessages(session *Session) error {
-	for i := range session.Messages {
-		msg := &session.Messages[i]
+	if err := parseMessageList(session.Messages); err != nil {
+		return err
+	}
+	return parseMessageList(session.Sidechain)
+}
+
+func parseMessageList(messages []Message) error {
+	for i := range messages {
+		msg := &messages[i]
 
 		// Parse timestamp
 		if msg.RawTimestamp != "" {
@@ -237,6 +297,29 @@ func ParseToolInput(raw json.RawMessage) (*ToolInput, error) {
 	return &input, nil
 }
 
+// summaryFields are the tool input fields worth showing next to a call's
+// name, in order of preference
+var summaryFields = []string{"command", "file_path", "notebook_path", "path", "pattern", "url", "query", "description", "prompt"}
+
+// InputSummary picks the most telling field of a tool's input, falling
+// back to the whole input as indented JSON
+func InputSummary(input json.RawMessage) string {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(input, &fields); err != nil || len(fields) == 0 {
+		return ""
+	}
+	for _, key := range summaryFields {
+		if s, ok := fields[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	data, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
 // ExtractText extracts all text content from a message
 func ExtractText(msg *Message) string {
 	var texts []string
@@ -254,14 +337,16 @@ func GroupConversations(session *Session) []Conversation {
 	var current *Conversation
 
 	for _, msg := range session.Messages {
-		if msg.Role == "user" {
+		if msg.Role == "user" && !msg.IsMeta {
 			// Start a new conversation
 			if current != nil {
 				conversations = append(conversations, *current)
 			}
 			current = &Conversation{
-				UserText:  ExtractText(&msg),
-				Timestamp: msg.Timestamp,
+				UserText:       ExtractText(&msg),
+				Timestamp:      msg.Timestamp,
+				IsContinuation: msg.Continued,
+				IsBranch:       msg.Branch,
 				Messages: []MessageEntry{{
 					Role:      msg.Role,
 					Content:   msg.Content,
@@ -288,12 +373,26 @@ func GroupConversations(session *Session) []Conversation {
 	return conversations
 }
 
-// AnalyzeConversation analyzes a conversation for tool usage statistics
+// AnalyzeConversation analyzes a conversation for tool usage and timing
+// statistics
 func AnalyzeConversation(conv *Conversation) (*ToolStats, []string) {
 	stats := &ToolStats{}
 	var longTexts []string
+	var waited time.Duration
+	var prev *MessageEntry
+
+	for i := range conv.Messages {
+		msg := &conv.Messages[i]
+
+		// Latency is the wait between a prompt or tool result and the
+		// reply that follows it
+		if prev != nil && msg.Role == "assistant" && prev.Role != "assistant" &&
+			!msg.Timestamp.IsZero() && !prev.Timestamp.IsZero() && msg.Timestamp.After(prev.Timestamp) {
+			waited += msg.Timestamp.Sub(prev.Timestamp)
+			stats.Responses++
+		}
+		prev = msg
 
-	for _, msg := range conv.Messages {
 		for _, block := range msg.Content {
 			if block.Type == "tool_use" {
 				switch block.Name {
@@ -310,7 +409,11 @@ func AnalyzeConversation(conv *Conversation) (*ToolStats, []string) {
 				case "Grep":
 					stats.GrepCount++
 				default:
-					stats.OtherCount++
+					if _, _, ok := MCPTool(block.Name); ok {
+						stats.MCPCount++
+					} else {
+						stats.OtherCount++
+					}
 				}
 			}
 
@@ -321,6 +424,16 @@ func AnalyzeConversation(conv *Conversation) (*ToolStats, []string) {
 		}
 	}
 
+	if stats.Responses > 0 {
+		stats.AvgLatency = waited / time.Duration(stats.Responses)
+	}
+	if n := len(conv.Messages); n > 0 {
+		first, last := conv.Messages[0].Timestamp, conv.Messages[n-1].Timestamp
+		if !first.IsZero() && last.After(first) {
+			stats.Duration = last.Sub(first)
+		}
+	}
+
 	return stats, longTexts
 }
 
@@ -329,7 +442,7 @@ func DetectGitHubRepo(session *Session) string {
 	for _, msg := range session.Messages {
 		for _, block := range msg.Content {
 			if block.Type == "tool_result" {
-				content := extractToolResultText(block.Content)
+				content := ToolResultText(block.Content)
 				if matches := GitHubRepoPattern.FindStringSubmatch(content); len(matches) > 1 {
 					repo := strings.TrimSuffix(matches[1], ".git")
 					return "https://github.com/" + repo
@@ -340,7 +453,116 @@ func DetectGitHubRepo(session *Session) string {
 	return ""
 }
 
-func extractToolResultText(content interface{}) string {
+// Repo is a git repository a session pushed to or showed the remote of
+type Repo struct {
+	Host string // e.g. github.com or git.example.com
+	Path string // e.g. owner/name, or group/subgroup/name on GitLab
+}
+
+// DetectRepo finds the repository a session worked in from its tool
+// output: a GitHub, GitLab or Bitbucket URL, or else the remote git push
+// or git remote -v printed. It returns nil if there's none.
+func DetectRepo(session *Session) *Repo {
+	var remote *Repo
+	for _, msg := range session.Messages {
+		for _, block := range msg.Content {
+			if block.Type != "tool_result" {
+				continue
+			}
+			content := ToolResultText(block.Content)
+			if m := forgeRepoPattern.FindStringSubmatch(content); m != nil {
+				if m[1] != "" {
+					return newRepo(m[1], m[2])
+				}
+				return newRepo(m[3], m[4])
+			}
+			if m := remotePattern.FindStringSubmatch(content); m != nil && remote == nil {
+				remote = newRepo(m[1], m[2])
+			}
+		}
+	}
+	return remote
+}
+
+// IssueURLTemplate returns the URL of an issue in the repository, with {n}
+// for its number. GitHub sends the numbers of pull requests on to them.
+func (r *Repo) IssueURLTemplate() string {
+	if strings.Contains(r.Host, "gitlab") {
+		return r.URL() + "/-/issues/{n}"
+	}
+	return r.URL() + "/issues/{n}"
+}
+
+// MergeRequestURLTemplate returns the URL of a merge request, with {n} for
+// its number, on GitLab, the one forge that refers to them as !N. It's
+// empty elsewhere.
+func (r *Repo) MergeRequestURLTemplate() string {
+	if strings.Contains(r.Host, "gitlab") {
+		return r.URL() + "/-/merge_requests/{n}"
+	}
+	return ""
+}
+
+// BlobURLTemplate returns the URL of a file on a branch of the repository,
+// with {path} for the file's path in it
+func (r *Repo) BlobURLTemplate(branch string) string {
+	parts := strings.Split(branch, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	ref := strings.Join(parts, "/")
+	switch {
+	case strings.Contains(r.Host, "gitlab"):
+		return r.URL() + "/-/blob/" + ref + "/{path}"
+	case strings.Contains(r.Host, "bitbucket"):
+		return r.URL() + "/src/" + ref + "/{path}"
+	default:
+		return r.URL() + "/blob/" + ref + "/{path}"
+	}
+}
+
+// newRepo makes a Repo from a remote's host and path, which may end in .git
+func newRepo(host, path string) *Repo {
+	return &Repo{Host: host, Path: strings.TrimSuffix(path, ".git")}
+}
+
+// URL returns the repository's web page, assuming it's served over HTTPS
+// from the host git uses
+func (r *Repo) URL() string {
+	return "https://" + r.Host + "/" + r.Path
+}
+
+// CommitURLTemplate returns the URL of a commit in the repository, with
+// {hash} for the commit. GitLab and Bitbucket lay theirs out differently;
+// GitHub's layout is also Gitea's and Forgejo's.
+func (r *Repo) CommitURLTemplate() string {
+	switch {
+	case strings.Contains(r.Host, "gitlab"):
+		return r.URL() + "/-/commit/{hash}"
+	case strings.Contains(r.Host, "bitbucket"):
+		return r.URL() + "/commits/{hash}"
+	default:
+		return r.URL() + "/commit/{hash}"
+	}
+}
+
+// MCPTool splits the name Claude Code gives an MCP server's tool,
+// mcp__<server>__<tool>, into the server and tool
+func MCPTool(name string) (server, tool string, ok bool) {
+	rest, ok := strings.CutPrefix(name, "mcp__")
+	if !ok {
+		return "", "", false
+	}
+	server, tool, ok = strings.Cut(rest, "__")
+	if !ok || server == "" || tool == "" {
+		return "", "", false
+	}
+	return server, tool, true
+}
+
+// ToolResultText returns the text of a tool result's content, which is
+// either a string or a list of text blocks
+func ToolResultText(content interface{}) string {
 	switch v := content.(type) {
 	case string:
 		return v
@@ -365,7 +587,7 @@ func ExtractCommits(session *Session) []IndexItem {
 	for _, msg := range session.Messages {
 		for _, block := range msg.Content {
 			if block.Type == "tool_result" {
-				content := extractToolResultText(block.Content)
+				content := ToolResultText(block.Content)
 				for _, line := range strings.Split(content, "\n") {
 					if matches := CommitPattern.FindStringSubmatch(line); len(matches) > 2 {
 						commits = append(commits, IndexItem{
@@ -400,7 +622,7 @@ func ParseReader(r io.Reader) (*Session, error) {
 // GetFirstUserMessage returns the first user message text
 func GetFirstUserMessage(session *Session) string {
 	for _, msg := range session.Messages {
-		if msg.Role == "user" {
+		if msg.Role == "user" && !msg.IsMeta {
 			text := ExtractText(&msg)
 			if text != "" {
 				return text
@@ -410,6 +632,23 @@ func GetFirstUserMessage(session *Session) string {
 	return ""
 }
 
+// GetUserPrompts returns the user's prompts in order, skipping tool results,
+// slash commands and other boilerplate messages
+func GetUserPrompts(session *Session) []Message {
+	var prompts []Message
+	for _, msg := range session.Messages {
+		if msg.Role != "user" || msg.IsMeta {
+			continue
+		}
+		text := ExtractText(&msg)
+		if text == "" || isBoringMessage(text) {
+			continue
+		}
+		prompts = append(prompts, msg)
+	}
+	return prompts
+}
+
 // buildSessionMetadata extracts metadata from session messages
 func buildSessionMetadata(session *Session) *SessionMetadata {
 	meta := &SessionMetadata{}
@@ -2,6 +2,9 @@ package session
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -80,6 +83,38 @@ func TestParseJSONLWithSummary(t *testing.T) {
 	if len(session.Messages) != 2 {
 		t.Errorf("Expected 2 messages (excluding summary), got %d", len(session.Messages))
 	}
+
+	if session.Metadata.Title != "This is a summary" {
+		t.Errorf("Expected summary as title, got %q", session.Metadata.Title)
+	}
+}
+
+func TestSessionTitlePrefersOwnLeaf(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "s.jsonl")
+	data := []byte(`{"type":"summary","summary":"Own conversation","leafUuid":"a1"}
+{"type":"summary","summary":"Earlier session","leafUuid":"zz"}
+{"type":"user","uuid":"u1","message":{"role":"user","content":"Hello"}}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"role":"assistant","content":"Hi"}}`)
+	os.WriteFile(path, data, 0644)
+
+	session, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if session.Metadata.Title != "Own conversation" {
+		t.Errorf("Expected title of own leaf, got %q", session.Metadata.Title)
+	}
+
+	title, err := ReadTitle(path)
+	if err != nil || title != "Own conversation" {
+		t.Errorf("Expected ReadTitle to match, got %q (%v)", title, err)
+	}
+
+	details, err := GetSessionDetails(path)
+	if err != nil || details.Summary != "Own conversation" {
+		t.Errorf("Expected details summary from title, got %+v (%v)", details, err)
+	}
 }
 
 func TestParseEmptyData(t *testing.T) {
@@ -194,6 +229,8 @@ func TestAnalyzeConversation(t *testing.T) {
 					{Type: "tool_use", Name: "Read"},
 					{Type: "tool_use", Name: "Bash"},
 					{Type: "tool_use", Name: "Write"},
+					{Type: "tool_use", Name: "mcp__github__create_issue"},
+					{Type: "tool_use", Name: "mcp__"},
 				},
 			},
 		},
@@ -212,6 +249,50 @@ func TestAnalyzeConversation(t *testing.T) {
 	if stats.WriteCount != 1 {
 		t.Errorf("Expected WriteCount 1, got %d", stats.WriteCount)
 	}
+
+	if stats.MCPCount != 1 || stats.OtherCount != 1 {
+		t.Errorf("Expected MCPCount 1 and OtherCount 1, got %d and %d", stats.MCPCount, stats.OtherCount)
+	}
+}
+
+func TestMCPTool(t *testing.T) {
+	for _, tt := range []struct {
+		name, server, tool string
+		ok                 bool
+	}{
+		{"mcp__github__create_issue", "github", "create_issue", true},
+		{"mcp__claude_ai_Linear__list_issues", "claude_ai_Linear", "list_issues", true},
+		{"mcp__github", "", "", false},
+		{"Bash", "", "", false},
+	} {
+		server, tool, ok := MCPTool(tt.name)
+		if server != tt.server || tool != tt.tool || ok != tt.ok {
+			t.Errorf("MCPTool(%q) = %q, %q, %v", tt.name, server, tool, ok)
+		}
+	}
+}
+
+func TestAnalyzeConversationTiming(t *testing.T) {
+	start := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+	conv := &Conversation{
+		Messages: []MessageEntry{
+			{Role: "user", Timestamp: at(0)},
+			{Role: "assistant", Timestamp: at(4)},
+			{Role: "assistant", Timestamp: at(5)},
+			{Role: "user", Timestamp: at(6)}, // tool result
+			{Role: "assistant", Timestamp: at(14)},
+		},
+	}
+
+	stats, _ := AnalyzeConversation(conv)
+
+	if stats.Duration != 14*time.Second {
+		t.Errorf("Expected Duration 14s, got %v", stats.Duration)
+	}
+	if stats.Responses != 2 || stats.AvgLatency != 6*time.Second {
+		t.Errorf("Expected 2 responses averaging 6s, got %d averaging %v", stats.Responses, stats.AvgLatency)
+	}
 }
 
 func TestCommitPattern(t *testing.T) {
@@ -314,6 +395,24 @@ func TestParseToolInput(t *testing.T) {
 	}
 }
 
+func TestInputSummary(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`{"description": "List files", "command": "ls -la"}`, "ls -la"},
+		{`{"file_path": "/src/main.go", "content": "package main"}`, "/src/main.go"},
+		{`{"todos": []}`, "{\n  \"todos\": []\n}"},
+		{`{}`, ""},
+		{`not json`, ""},
+	}
+	for _, tt := range tests {
+		if got := InputSummary(json.RawMessage(tt.input)); got != tt.want {
+			t.Errorf("InputSummary(%s) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
 func TestGetFirstUserMessage(t *testing.T) {
 	session := &Session{
 		Messages: []Message{
@@ -347,3 +446,382 @@ func TestIsJSONL(t *testing.T) {
 		}
 	}
 }
+
+func TestGetUserPrompts(t *testing.T) {
+	session := &Session{
+		Messages: []Message{
+			{Role: "user", Content: Content{{Type: "text", Text: "Warmup"}}},
+			{Role: "user", Content: Content{{Type: "text", Text: "Fix the bug"}}},
+			{Role: "assistant", Content: Content{{Type: "text", Text: "Done"}}},
+			{Role: "user", Content: Content{{Type: "tool_result", ToolUseID: "t1"}}},
+			{Role: "user", Content: Content{{Type: "text", Text: "<command-name>/clear</command-name>"}}},
+			{Role: "user", Content: Content{{Type: "text", Text: "Add tests"}}},
+		},
+	}
+
+	prompts := GetUserPrompts(session)
+	if len(prompts) != 2 {
+		t.Fatalf("Expected 2 prompts, got %d", len(prompts))
+	}
+	if ExtractText(&prompts[1]) != "Add tests" {
+		t.Errorf("Expected 'Add tests', got '%s'", ExtractText(&prompts[1]))
+	}
+}
+
+func TestToolInputAllEdits(t *testing.T) {
+	multi, err := ParseToolInput(json.RawMessage(`{
+		"file_path": "/path/to/file",
+		"edits": [
+			{"old_string": "a", "new_string": "b"},
+			{"old_string": "c", "new_string": "d", "replace_all": true}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("ParseToolInput failed: %v", err)
+	}
+
+	edits := multi.AllEdits()
+	if len(edits) != 2 {
+		t.Fatalf("Expected 2 edits, got %d", len(edits))
+	}
+	if edits[1].OldString != "c" || edits[1].NewString != "d" || !edits[1].ReplaceAll {
+		t.Errorf("Unexpected second edit: %+v", edits[1])
+	}
+
+	single, err := ParseToolInput(json.RawMessage(`{"old_string": "x", "new_string": "y"}`))
+	if err != nil {
+		t.Fatalf("ParseToolInput failed: %v", err)
+	}
+	if edits := single.AllEdits(); len(edits) != 1 || edits[0].NewString != "y" {
+		t.Errorf("Expected single edit from old_string/new_string, got %+v", edits)
+	}
+}
+
+func TestThreadMessages(t *testing.T) {
+	// Written out of order, with a retried prompt and a compaction boundary
+	data := []byte(`{"type":"user","uuid":"u1","parentUuid":null,"message":{"role":"user","content":"first"},"timestamp":"2024-06-01T10:00:00Z"}
+{"type":"user","uuid":"u2b","parentUuid":"a1","message":{"role":"user","content":"second (retry)"},"timestamp":"2024-06-01T10:02:00Z"}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"role":"assistant","content":"reply 1"},"timestamp":"2024-06-01T10:00:05Z"}
+{"type":"user","uuid":"u2","parentUuid":"a1","message":{"role":"user","content":"second"},"timestamp":"2024-06-01T10:01:00Z"}
+{"type":"assistant","uuid":"a2","parentUuid":"u2","message":{"role":"assistant","content":"reply 2"},"timestamp":"2024-06-01T10:01:05Z"}
+{"type":"assistant","uuid":"a2b","parentUuid":"u2b","message":{"role":"assistant","content":"reply 2b"},"timestamp":"2024-06-01T10:02:05Z"}
+{"type":"system","subtype":"compact_boundary","uuid":"cb","parentUuid":null,"logicalParentUuid":"a2b","timestamp":"2024-06-01T10:03:00Z"}
+{"type":"user","uuid":"u3","parentUuid":"cb","message":{"role":"user","content":"third"},"timestamp":"2024-06-01T10:04:00Z"}
+{"type":"user","uuid":"u1","parentUuid":null,"message":{"role":"user","content":"first"},"timestamp":"2024-06-01T10:00:00Z"}`)
+
+	session, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	var order []string
+	for _, msg := range session.Messages {
+		order = append(order, ExtractText(&msg))
+	}
+	expected := []string{"first", "reply 1", "second", "reply 2", "second (retry)", "reply 2b", "third"}
+	if strings.Join(order, "|") != strings.Join(expected, "|") {
+		t.Fatalf("Expected order %v, got %v", expected, order)
+	}
+
+	convs := GroupConversations(session)
+	if len(convs) != 4 {
+		t.Fatalf("Expected 4 conversations, got %d", len(convs))
+	}
+	if convs[1].IsBranch || !convs[2].IsBranch {
+		t.Errorf("Expected only the retried prompt to be a branch")
+	}
+	if !convs[3].IsContinuation {
+		t.Errorf("Expected conversation after compaction to be a continuation")
+	}
+}
+
+func TestSidechainMessages(t *testing.T) {
+	data := []byte(`{"type":"user","uuid":"u1","message":{"role":"user","content":"research this"},"timestamp":"2024-06-01T10:00:00Z"}
+{"type":"user","uuid":"s1","isSidechain":true,"message":{"role":"user","content":"subagent prompt"},"timestamp":"2024-06-01T10:00:01Z"}
+{"type":"assistant","uuid":"s2","parentUuid":"s1","isSidechain":true,"message":{"role":"assistant","content":"subagent reply"},"timestamp":"2024-06-01T10:00:02Z"}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"role":"assistant","content":"done"},"timestamp":"2024-06-01T10:00:03Z"}`)
+
+	session, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(session.Messages) != 2 {
+		t.Errorf("Expected 2 main messages, got %d", len(session.Messages))
+	}
+	if len(session.Sidechain) != 2 || ExtractText(&session.Sidechain[1]) != "subagent reply" {
+		t.Errorf("Expected 2 parsed sidechain messages, got %+v", session.Sidechain)
+	}
+	if prompts := GetUserPrompts(session); len(prompts) != 1 {
+		t.Errorf("Expected sidechain prompts to be excluded, got %d prompts", len(prompts))
+	}
+}
+
+func TestLoadSubagentTranscripts(t *testing.T) {
+	dir := t.TempDir()
+	sessionPath := filepath.Join(dir, "abc123.jsonl")
+	os.WriteFile(sessionPath, []byte(`{"type":"user","sessionId":"abc123"}`+"\n"), 0644)
+
+	os.MkdirAll(filepath.Join(dir, "abc123", "subagents"), 0755)
+	os.WriteFile(filepath.Join(dir, "abc123", "subagents", "agent-x1.jsonl"),
+		[]byte(`{"type":"user","sessionId":"abc123","message":{"role":"user","content":"hi"}}`+"\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "agent-y2.jsonl"),
+		[]byte(`{"type":"user","sessionId":"abc123","isSidechain":true,"agentId":"y2"}`+"\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "agent-z3.jsonl"),
+		[]byte(`{"type":"user","sessionId":"other"}`+"\n"), 0644)
+
+	data, err := LoadSubagentTranscripts(sessionPath)
+	if err != nil {
+		t.Fatalf("LoadSubagentTranscripts failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 subagent lines, got %d: %s", len(lines), data)
+	}
+	if !strings.Contains(lines[0], `"agentId":"x1"`) || !strings.Contains(lines[0], `"isSidechain":true`) {
+		t.Errorf("Expected first line tagged with agent x1, got %s", lines[0])
+	}
+}
+
+func TestMetaMessagesAreNotPrompts(t *testing.T) {
+	data := []byte(`{"type":"user","uuid":"m1","isMeta":true,"message":{"role":"user","content":"Caveat: injected context"}}
+{"type":"user","uuid":"u1","parentUuid":"m1","message":{"role":"user","content":"Real prompt"}}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"role":"assistant","content":"Reply"}}
+{"type":"user","uuid":"m2","parentUuid":"a1","isMeta":true,"message":{"role":"user","content":"Hook output"}}`)
+
+	session, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if first := GetFirstUserMessage(session); first != "Real prompt" {
+		t.Errorf("Expected 'Real prompt', got %q", first)
+	}
+	if prompts := GetUserPrompts(session); len(prompts) != 1 {
+		t.Errorf("Expected 1 prompt, got %d", len(prompts))
+	}
+	if convs := GroupConversations(session); len(convs) != 1 || len(convs[0].Messages) != 3 {
+		t.Errorf("Expected meta message to stay in the conversation, got %+v", convs)
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	// The second entry repeats the first response's usage and must not be
+	// counted twice
+	data := []byte(`{"type":"user","uuid":"u1","message":{"role":"user","content":"hi"}}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"id":"msg_1","role":"assistant","model":"claude-sonnet-4-20250514","content":"one","usage":{"input_tokens":1000000,"output_tokens":100000,"cache_read_input_tokens":1000000,"cache_creation_input_tokens":0}}}
+{"type":"assistant","uuid":"a2","parentUuid":"a1","message":{"id":"msg_1","role":"assistant","model":"claude-sonnet-4-20250514","content":"two","usage":{"input_tokens":1000000,"output_tokens":100000,"cache_read_input_tokens":1000000,"cache_creation_input_tokens":0}}}
+{"type":"assistant","uuid":"a3","parentUuid":"a2","message":{"id":"msg_2","role":"assistant","model":"mystery-model","content":"three","usage":{"input_tokens":5,"output_tokens":5}}}`)
+
+	session, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	estimate := DefaultPricing.Estimate(UsageByModel(session))
+	// 1M input at $3 + 100K output at $15 + 1M cache reads at $0.30
+	if got := FormatCost(estimate.Total); got != "$4.80" {
+		t.Errorf("Expected $4.80, got %s", got)
+	}
+	if len(estimate.Unpriced) != 1 || estimate.Unpriced[0] != "mystery-model" {
+		t.Errorf("Expected mystery-model to be unpriced, got %v", estimate.Unpriced)
+	}
+
+	overridden := DefaultPricing.Merge(Pricing{"claude-sonnet-4-2025": {Input: 1}})
+	if cost, ok := overridden.MessageCost(&session.Messages[1]); !ok || cost != 1 {
+		t.Errorf("Expected the longer override to win, got %v (%v)", cost, ok)
+	}
+	if FormatCost(0.001) != "<$0.01" {
+		t.Errorf("Expected tiny costs to show as <$0.01, got %s", FormatCost(0.001))
+	}
+}
+
+func TestSessionStats(t *testing.T) {
+	data := []byte(`{"type":"user","uuid":"u1","message":{"role":"user","content":"fix it"},"timestamp":"2024-06-01T10:00:00Z"}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"id":"m1","role":"assistant","model":"claude-sonnet-4-20250514","content":[{"type":"tool_use","id":"t1","name":"Read","input":{"file_path":"/app/b.go"}},{"type":"tool_use","id":"t2","name":"Edit","input":{"file_path":"/app/a.go","old_string":"x","new_string":"y"}},{"type":"tool_use","id":"t3","name":"Bash","input":{"command":"git commit -m fix"}}],"usage":{"input_tokens":100,"output_tokens":10}},"timestamp":"2024-06-01T10:00:30Z"}
+{"type":"user","uuid":"u2","parentUuid":"a1","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t3","content":"[main abc1234] fix"}]},"timestamp":"2024-06-01T10:01:00Z"}
+{"type":"assistant","uuid":"s1","isSidechain":true,"message":{"id":"m2","role":"assistant","model":"claude-haiku-4-5","content":[{"type":"tool_use","id":"t4","name":"Read","input":{"file_path":"/app/a.go"}}],"usage":{"input_tokens":50}},"timestamp":"2024-06-01T10:00:40Z"}`)
+
+	session, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	stats := SessionStats(session, DefaultPricing)
+
+	if stats.Tools["Read"] != 2 || stats.Tools["Edit"] != 1 || stats.Tools["Bash"] != 1 {
+		t.Errorf("Unexpected tool counts: %v", stats.Tools)
+	}
+	if strings.Join(stats.FilesTouched, ",") != "/app/a.go,/app/b.go" {
+		t.Errorf("Unexpected files touched: %v", stats.FilesTouched)
+	}
+	if stats.Commits != 1 {
+		t.Errorf("Expected 1 commit, got %d", stats.Commits)
+	}
+	if len(stats.Models) != 2 || stats.Models["claude-haiku-4-5"].InputTokens != 50 {
+		t.Errorf("Expected usage for both models, got %+v", stats.Models)
+	}
+	if stats.Duration != time.Minute {
+		t.Errorf("Expected 1m duration, got %v", stats.Duration)
+	}
+}
+
+func TestReadFile_TornLine(t *testing.T) {
+	dir := t.TempDir()
+	complete := `{"type":"user","message":{"role":"user","content":"Hi"}}` + "\n"
+
+	torn := filepath.Join(dir, "torn.jsonl")
+	os.WriteFile(torn, []byte(complete+`{"type":"assistant","mess`), 0644)
+	data, err := ReadFile(torn)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(data) != complete {
+		t.Errorf("Expected the torn line to be dropped, got %q", data)
+	}
+
+	// A complete final entry without its newline is kept
+	whole := filepath.Join(dir, "whole.jsonl")
+	os.WriteFile(whole, []byte(complete+`{"type":"user"}`), 0644)
+	if data, _ := ReadFile(whole); !strings.HasSuffix(string(data), `{"type":"user"}`) {
+		t.Errorf("Expected the final entry to be kept, got %q", data)
+	}
+}
+
+func TestLiveSessions(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(home, ".claude", "projects", "-src-app", "s.jsonl")
+	os.MkdirAll(filepath.Dir(path), 0755)
+	os.WriteFile(path, []byte("{}\n"), 0644)
+
+	now := time.Now()
+	if !IsLiveFile(path, now) {
+		t.Error("Expected a session written just now to be live")
+	}
+	copied := filepath.Join(t.TempDir(), "s.jsonl")
+	os.WriteFile(copied, []byte("{}\n"), 0644)
+	if IsLiveFile(copied, now) {
+		t.Error("Expected a fresh copy outside the projects directory not to be live")
+	}
+	if (SessionInfo{ModTime: now.Add(-LiveWindow)}).IsLive(now) {
+		t.Error("Expected a session idle for the live window not to be live")
+	}
+
+	idlePollInterval = 10 * time.Millisecond
+	if err := WaitIdle(path, 50*time.Millisecond, time.Second); err != nil {
+		t.Errorf("Expected the file to go idle, got %v", err)
+	}
+	if err := WaitIdle(path, time.Hour, 30*time.Millisecond); err == nil {
+		t.Error("Expected a timeout waiting for an hour of quiet")
+	}
+}
+
+func TestReconstructFiles(t *testing.T) {
+	call := func(ts, id, name, input string) string {
+		return `{"type":"assistant","timestamp":"` + ts + `","message":{"role":"assistant","content":[{"type":"tool_use","id":"` + id + `","name":"` + name + `","input":` + input + `}]}}` + "\n"
+	}
+	result := func(ts, id, output string, isError bool) string {
+		out, _ := json.Marshal(output)
+		errFlag := ""
+		if isError {
+			errFlag = `,"is_error":true`
+		}
+		return `{"type":"user","timestamp":"` + ts + `","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"` + id + `","content":` + string(out) + errFlag + `}]}}` + "\n"
+	}
+	data := call("2025-06-01T10:00:00Z", "w1", "Write", `{"file_path":"/app/main.go","content":"package main\n\nfunc main() {}\n"}`) +
+		result("2025-06-01T10:00:01Z", "w1", "File created", false) +
+		call("2025-06-01T10:01:00Z", "e1", "Edit", `{"file_path":"/app/main.go","old_string":"func main() {}","new_string":"func main() { run() }"}`) +
+		result("2025-06-01T10:01:01Z", "e1", "Updated", false) +
+		call("2025-06-01T10:02:00Z", "e2", "Edit", `{"file_path":"/app/main.go","old_string":"main","new_string":"oops"}`) +
+		result("2025-06-01T10:02:01Z", "e2", "Found 2 matches", true) +
+		call("2025-06-01T10:03:00Z", "r1", "Read", `{"file_path":"/app/go.mod"}`) +
+		result("2025-06-01T10:03:01Z", "r1", "     1→module app\n     2→\n     3→go 1.22\n\n<system-reminder>Be careful</system-reminder>", false) +
+		call("2025-06-01T10:04:00Z", "m1", "MultiEdit", `{"file_path":"/app/go.mod","edits":[{"old_string":"1.22","new_string":"1.23"},{"old_string":"app","new_string":"example.com/app","replace_all":true}]}`) +
+		result("2025-06-01T10:04:01Z", "m1", "Applied", false) +
+		call("2025-06-01T10:05:00Z", "e3", "Edit", `{"file_path":"/app/README.md","old_string":"a","new_string":"b"}`) +
+		result("2025-06-01T10:05:01Z", "e3", "Updated", false)
+
+	sess, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, skipped := ReconstructFiles(sess, time.Time{})
+	if len(files) != 2 || len(skipped) != 1 || skipped[0] != "/app/README.md" {
+		t.Fatalf("Expected go.mod and main.go rebuilt and README.md skipped, got %+v and %v", files, skipped)
+	}
+	if files[0].Path != "/app/go.mod" || files[0].Content != "module example.com/app\n\ngo 1.23\n" {
+		t.Errorf("Unexpected go.mod %q", files[0].Content)
+	}
+	if files[1].Content != "package main\n\nfunc main() { run() }\n" || files[1].Changes != 2 {
+		t.Errorf("Unexpected main.go %+v", files[1])
+	}
+
+	files, _ = ReconstructFiles(sess, time.Date(2025, 6, 1, 10, 0, 30, 0, time.UTC))
+	if len(files) != 1 || files[0].Content != "package main\n\nfunc main() {}\n" {
+		t.Errorf("Expected main.go as first written, got %+v", files)
+	}
+}
+
+func TestLocalPath(t *testing.T) {
+	for _, tt := range []struct{ path, cwd, want string }{
+		{"/home/alice/app/cmd/main.go", "/home/alice/app", "cmd/main.go"},
+		{"/etc/hosts", "/home/alice/app", "etc/hosts"},
+		{"/home/alice/app/../../x", "", "home/alice/app/x"},
+		{"notes.md", "/home/alice/app", "notes.md"},
+	} {
+		if got := LocalPath(tt.path, tt.cwd); got != filepath.FromSlash(tt.want) {
+			t.Errorf("LocalPath(%q, %q) = %q, want %q", tt.path, tt.cwd, got, tt.want)
+		}
+	}
+}
+
+func TestDetectRepo(t *testing.T) {
+	for _, tt := range []struct {
+		output string
+		want   string // Commit URL template
+	}{
+		{"To github.com:alice/app.git\n   1a2b3c4..5d6e7f8  main -> main", "https://github.com/alice/app/commit/{hash}"},
+		{"remote: View merge request at https://gitlab.com/acme/web/app/-/merge_requests/4\nTo gitlab.com:acme/web/app.git", "https://gitlab.com/acme/web/app/-/commit/{hash}"},
+		{"origin\tgit@bitbucket.org:team/api.git (fetch)", "https://bitbucket.org/team/api/commits/{hash}"},
+		{"To ssh://git@git.example.com:2222/team/app.git\n * [new branch]      fix -> fix", "https://git.example.com/team/app/commit/{hash}"},
+		{"To https://gitlab.example.com/infra/tools\n", "https://gitlab.example.com/infra/tools/-/commit/{hash}"},
+		{"See https://github.com/alice/app/pull/12 for details", ""},
+	} {
+		data := `{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":` + jsonString(tt.output) + `}]}}`
+		sess, err := Parse([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if repo := DetectRepo(sess); repo != nil {
+			got = repo.CommitURLTemplate()
+		}
+		if got != tt.want {
+			t.Errorf("DetectRepo(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+
+	gitlab := &Repo{Host: "gitlab.com", Path: "acme/web/app"}
+	if got := gitlab.IssueURLTemplate(); got != "https://gitlab.com/acme/web/app/-/issues/{n}" {
+		t.Errorf("Unexpected GitLab issue URL %q", got)
+	}
+	if got := gitlab.MergeRequestURLTemplate(); got != "https://gitlab.com/acme/web/app/-/merge_requests/{n}" {
+		t.Errorf("Unexpected GitLab merge request URL %q", got)
+	}
+	github := &Repo{Host: "github.com", Path: "alice/app"}
+	if got := github.IssueURLTemplate(); got != "https://github.com/alice/app/issues/{n}" {
+		t.Errorf("Unexpected GitHub issue URL %q", got)
+	}
+	if got := github.MergeRequestURLTemplate(); got != "" {
+		t.Errorf("Expected no merge request URL on GitHub, got %q", got)
+	}
+	if got := github.BlobURLTemplate("fix/login#2"); got != "https://github.com/alice/app/blob/fix/login%232/{path}" {
+		t.Errorf("Unexpected GitHub blob URL %q", got)
+	}
+	if got := gitlab.BlobURLTemplate("main"); got != "https://gitlab.com/acme/web/app/-/blob/main/{path}" {
+		t.Errorf("Unexpected GitLab blob URL %q", got)
+	}
+}
+
+func jsonString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
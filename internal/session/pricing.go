@@ -0,0 +1,151 @@
+package session
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ModelPrice is what a model costs, in US dollars per million tokens
+type ModelPrice struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheRead  float64 `json:"cache_read"`
+	CacheWrite float64 `json:"cache_write"`
+}
+
+// Cost returns the cost of the given token usage at this price
+func (p ModelPrice) Cost(u TokenUsage) float64 {
+	return (float64(u.InputTokens)*p.Input +
+		float64(u.OutputTokens)*p.Output +
+		float64(u.CacheReadTokens)*p.CacheRead +
+		float64(u.CacheWriteTokens)*p.CacheWrite) / 1e6
+}
+
+// Pricing maps model names to prices. Keys match a model if they are equal
+// to it or a prefix of it, so "claude-sonnet-4" covers every dated
+// claude-sonnet-4 release; the longest matching key wins.
+type Pricing map[string]ModelPrice
+
+// DefaultPricing holds Anthropic's published API prices. Cache writes are
+// priced at the 5-minute rate.
+var DefaultPricing = Pricing{
+	"claude-opus-4":     {Input: 15, Output: 75, CacheRead: 1.5, CacheWrite: 18.75},
+	"claude-opus-4-5":   {Input: 5, Output: 25, CacheRead: 0.5, CacheWrite: 6.25},
+	"claude-sonnet-4":   {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+	"claude-haiku-4-5":  {Input: 1, Output: 5, CacheRead: 0.1, CacheWrite: 1.25},
+	"claude-3-opus":     {Input: 15, Output: 75, CacheRead: 1.5, CacheWrite: 18.75},
+	"claude-3-7-sonnet": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+	"claude-3-5-sonnet": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+	"claude-3-5-haiku":  {Input: 0.8, Output: 4, CacheRead: 0.08, CacheWrite: 1},
+	"claude-3-haiku":    {Input: 0.25, Output: 1.25, CacheRead: 0.03, CacheWrite: 0.3},
+}
+
+// Merge returns a copy of p with the prices in overrides added or replaced
+func (p Pricing) Merge(overrides Pricing) Pricing {
+	merged := make(Pricing, len(p)+len(overrides))
+	for model, price := range p {
+		merged[model] = price
+	}
+	for model, price := range overrides {
+		merged[model] = price
+	}
+	return merged
+}
+
+// Lookup finds the price for a model
+func (p Pricing) Lookup(model string) (ModelPrice, bool) {
+	best := ""
+	found := false
+	for key := range p {
+		if strings.HasPrefix(model, key) && (!found || len(key) > len(best)) {
+			best, found = key, true
+		}
+	}
+	return p[best], found
+}
+
+// MessageCost returns the cost of a single message, and whether its model
+// has a known price
+func (p Pricing) MessageCost(msg *Message) (float64, bool) {
+	if msg.Usage == nil {
+		return 0, true
+	}
+	price, ok := p.Lookup(msg.Model)
+	if !ok {
+		return 0, false
+	}
+	return price.Cost(*msg.Usage), true
+}
+
+// CostEstimate is the estimated cost of some usage, split by model
+type CostEstimate struct {
+	Total    float64
+	ByModel  map[string]float64
+	Unpriced []string // Models with usage but no known price
+}
+
+// Estimate prices usage grouped by model
+func (p Pricing) Estimate(usage map[string]TokenUsage) CostEstimate {
+	estimate := CostEstimate{ByModel: make(map[string]float64)}
+	for model, u := range usage {
+		price, ok := p.Lookup(model)
+		if !ok {
+			estimate.Unpriced = append(estimate.Unpriced, model)
+			continue
+		}
+		cost := price.Cost(u)
+		estimate.ByModel[model] = cost
+		estimate.Total += cost
+	}
+	sort.Strings(estimate.Unpriced)
+	return estimate
+}
+
+// FormatCost formats a dollar amount as e.g. "$12.34" or "<$0.01"
+func FormatCost(dollars float64) string {
+	if dollars > 0 && dollars < 0.01 {
+		return "<$0.01"
+	}
+	return fmt.Sprintf("$%.2f", dollars)
+}
+
+// UsageByModel totals a session's token usage per model, including
+// subagent activity. Claude Code writes one entry per content block of a
+// response, each repeating the response's usage, so responses are only
+// counted once.
+func UsageByModel(session *Session) map[string]TokenUsage {
+	usage := make(map[string]TokenUsage)
+	seen := make(map[string]bool)
+	add := func(messages []Message) {
+		for i := range messages {
+			msg := &messages[i]
+			if msg.Usage == nil || msg.Model == "" {
+				continue
+			}
+			if id := msg.responseID(); id != "" {
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+			}
+			u := usage[msg.Model]
+			u.InputTokens += msg.Usage.InputTokens
+			u.OutputTokens += msg.Usage.OutputTokens
+			u.CacheReadTokens += msg.Usage.CacheReadTokens
+			u.CacheWriteTokens += msg.Usage.CacheWriteTokens
+			usage[msg.Model] = u
+		}
+	}
+	add(session.Messages)
+	add(session.Sidechain)
+	return usage
+}
+
+// responseID returns the API response ID for assistant messages
+func (m *Message) responseID() string {
+	if m.NestedMessage == nil {
+		return ""
+	}
+	return m.NestedMessage.ID
+}
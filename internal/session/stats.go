@@ -0,0 +1,92 @@
+package session
+
+import (
+	"cmp"
+	"sort"
+	"time"
+)
+
+// Stats summarizes what happened in a session
+type Stats struct {
+	Models       map[string]ModelStats `json:"models"`
+	Tools        map[string]int        `json:"tools"`
+	MCPServers   map[string]int        `json:"mcp_servers,omitempty"` // Calls to each MCP server's tools
+	Start        time.Time             `json:"start"`
+	End          time.Time             `json:"end"`
+	Duration     time.Duration         `json:"-"`
+	ActiveTime   time.Duration         `json:"-"`
+	Commits      int                   `json:"commits"`
+	FilesTouched []string              `json:"files_touched"`
+	Cost         float64               `json:"estimated_cost"`
+	Unpriced     []string              `json:"unpriced_models,omitempty"`
+}
+
+// ModelStats is the token usage and estimated cost for one model
+type ModelStats struct {
+	TokenUsage
+	Cost float64 `json:"estimated_cost"`
+}
+
+// fileTools are the tools whose input names a file they read or change
+var fileTools = map[string]bool{
+	"Read": true, "Write": true, "Edit": true, "MultiEdit": true,
+	"NotebookEdit": true, "NotebookRead": true,
+}
+
+// SessionStats counts tokens, tool calls, commits and files touched in a
+// session, including its subagents, and prices its usage
+func SessionStats(session *Session, pricing Pricing) Stats {
+	stats := Stats{
+		Models:       make(map[string]ModelStats),
+		Tools:        make(map[string]int),
+		MCPServers:   make(map[string]int),
+		FilesTouched: []string{},
+	}
+
+	usage := UsageByModel(session)
+	estimate := pricing.Estimate(usage)
+	for model, u := range usage {
+		stats.Models[model] = ModelStats{TokenUsage: u, Cost: estimate.ByModel[model]}
+	}
+	stats.Cost = estimate.Total
+	stats.Unpriced = estimate.Unpriced
+
+	files := make(map[string]bool)
+	for _, messages := range [][]Message{session.Messages, session.Sidechain} {
+		for _, msg := range messages {
+			for _, block := range msg.Content {
+				if block.Type != "tool_use" {
+					continue
+				}
+				stats.Tools[block.Name]++
+				if server, _, ok := MCPTool(block.Name); ok {
+					stats.MCPServers[server]++
+				}
+				if !fileTools[block.Name] {
+					continue
+				}
+				input, err := ParseToolInput(block.Input)
+				if err != nil {
+					continue
+				}
+				if path := cmp.Or(input.FilePath, input.NotebookPath); path != "" {
+					files[path] = true
+				}
+			}
+		}
+	}
+	for file := range files {
+		stats.FilesTouched = append(stats.FilesTouched, file)
+	}
+	sort.Strings(stats.FilesTouched)
+
+	stats.Commits = len(ExtractCommits(session))
+
+	if meta := session.Metadata; meta != nil {
+		stats.Start = meta.StartTime
+		stats.End = meta.EndTime
+		stats.Duration = meta.EndTime.Sub(meta.StartTime)
+		stats.ActiveTime = meta.ActiveTime
+	}
+	return stats
+}
@@ -0,0 +1,85 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FindSubagentFiles returns the separate transcripts written by subagents
+// (Task tool) of the session at path. Newer Claude Code versions store them
+// in <session-id>/subagents/, older ones as agent-*.jsonl next to the session.
+func FindSubagentFiles(path string) []string {
+	dir := filepath.Dir(path)
+	sessionID := strings.TrimSuffix(filepath.Base(path), ".jsonl")
+
+	files, _ := filepath.Glob(filepath.Join(dir, sessionID, "subagents", "*.jsonl"))
+
+	siblings, _ := filepath.Glob(filepath.Join(dir, "agent-*.jsonl"))
+	for _, f := range siblings {
+		if firstSessionID(f) == sessionID {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// firstSessionID returns the sessionId of the first entry in a JSONL file
+func firstSessionID(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		var entry struct {
+			SessionID string `json:"sessionId"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil && entry.SessionID != "" {
+			return entry.SessionID
+		}
+	}
+	return ""
+}
+
+// LoadSubagentTranscripts reads the subagent transcripts of the session at
+// path as JSONL, with every entry marked as a sidechain and tagged with its
+// agent ID, ready to be appended to the session itself
+func LoadSubagentTranscripts(path string) ([]byte, error) {
+	var out bytes.Buffer
+	for _, file := range FindSubagentFiles(path) {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		agentID := strings.TrimPrefix(strings.TrimSuffix(filepath.Base(file), ".jsonl"), "agent-")
+
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			line = bytes.TrimSpace(line)
+			if len(line) == 0 {
+				continue
+			}
+			var entry map[string]interface{}
+			if err := json.Unmarshal(line, &entry); err != nil {
+				continue
+			}
+			entry["isSidechain"] = true
+			if _, ok := entry["agentId"]; !ok {
+				entry["agentId"] = agentID
+			}
+			encoded, err := json.Marshal(entry)
+			if err != nil {
+				continue
+			}
+			out.Write(encoded)
+			out.WriteByte('\n')
+		}
+	}
+	return out.Bytes(), nil
+}
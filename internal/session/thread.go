@@ -0,0 +1,121 @@
+package session
+
+import "sort"
+
+// skippedEntry is a non-message JSONL entry (system, summary, ...) that
+// still takes part in the uuid/parentUuid chain
+type skippedEntry struct {
+	parent   string
+	logical  string
+	boundary bool // compaction boundary
+}
+
+// threadMessages orders messages by walking the uuid/parentUuid tree
+// depth-first, so retried prompts and their replies stay together and
+// resumed or compacted history is placed before what follows it. Siblings
+// are ordered by timestamp, then by position in the file. Messages without
+// a uuid (older formats) keep their file order. Entries repeated with the
+// same uuid (as when a session is resumed) are kept once.
+func threadMessages(messages []Message, skipped map[string]skippedEntry) []Message {
+	byUUID := make(map[string]int)
+	duplicate := make([]bool, len(messages))
+	for i, msg := range messages {
+		if msg.UUID == "" {
+			continue
+		}
+		if _, ok := byUUID[msg.UUID]; ok {
+			duplicate[i] = true
+			continue
+		}
+		byUUID[msg.UUID] = i
+	}
+	if len(byUUID) == 0 {
+		return messages
+	}
+
+	// Resolve each message's parent among the kept messages
+	parent := make([]int, len(messages))
+	children := make(map[int][]int)
+	for i := range messages {
+		if duplicate[i] {
+			parent[i] = -1
+			continue
+		}
+		p, continued := resolveParent(&messages[i], byUUID, skipped)
+		parent[i] = p
+		messages[i].Continued = continued
+		if p >= 0 {
+			children[p] = append(children[p], i)
+		}
+	}
+
+	for p, kids := range children {
+		sort.SliceStable(kids, func(a, b int) bool {
+			return messages[kids[a]].Timestamp.Before(messages[kids[b]].Timestamp)
+		})
+		children[p] = kids
+	}
+
+	ordered := make([]Message, 0, len(messages))
+	visited := make([]bool, len(messages))
+	for root := range messages {
+		if parent[root] >= 0 || duplicate[root] {
+			continue
+		}
+		stack := []int{root}
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if visited[i] {
+				continue
+			}
+			visited[i] = true
+			ordered = append(ordered, messages[i])
+
+			kids := children[i]
+			for k := len(kids) - 1; k >= 0; k-- {
+				if k > 0 {
+					messages[kids[k]].Branch = true
+				}
+				stack = append(stack, kids[k])
+			}
+		}
+	}
+
+	// Anything unreachable (parent cycles) keeps its file position at the end
+	for i := range messages {
+		if !visited[i] && !duplicate[i] {
+			ordered = append(ordered, messages[i])
+		}
+	}
+	return ordered
+}
+
+// resolveParent finds the index of a message's parent, following links
+// through skipped entries. It reports whether the chain crossed a compaction
+// boundary or points at history that isn't in this file.
+func resolveParent(msg *Message, byUUID map[string]int, skipped map[string]skippedEntry) (int, bool) {
+	id := msg.ParentUUID
+	continued := false
+	seen := make(map[string]bool)
+
+	for id != "" && !seen[id] {
+		seen[id] = true
+		if i, ok := byUUID[id]; ok {
+			return i, continued
+		}
+		entry, ok := skipped[id]
+		if !ok {
+			// Parent lives in an earlier session this one resumed
+			return -1, true
+		}
+		if entry.boundary {
+			continued = true
+		}
+		id = entry.parent
+		if id == "" {
+			id = entry.logical
+		}
+	}
+	return -1, continued
+}
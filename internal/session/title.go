@@ -0,0 +1,59 @@
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+)
+
+// chooseTitle picks the session title from its summary entries: the last
+// summary of a conversation in this session, or else the last summary.
+// Files can carry summaries of other sessions they were resumed from.
+func chooseTitle(summaries []Message, inSession func(uuid string) bool) string {
+	for i := len(summaries) - 1; i >= 0; i-- {
+		if summaries[i].LeafUUID != "" && inSession(summaries[i].LeafUUID) {
+			return summaries[i].Summary
+		}
+	}
+	if len(summaries) > 0 {
+		return summaries[len(summaries)-1].Summary
+	}
+	return ""
+}
+
+// ReadTitle returns the title of a JSONL session file without keeping
+// the session in memory
+func ReadTitle(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var summaries []Message
+	uuids := make(map[string]bool)
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		var entry struct {
+			Type     string `json:"type"`
+			UUID     string `json:"uuid"`
+			Summary  string `json:"summary"`
+			LeafUUID string `json:"leafUuid"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if entry.Type == "summary" && entry.Summary != "" {
+			summaries = append(summaries, Message{Summary: entry.Summary, LeafUUID: entry.LeafUUID})
+		} else if entry.UUID != "" {
+			uuids[entry.UUID] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return chooseTitle(summaries, func(uuid string) bool { return uuids[uuid] }), nil
+}
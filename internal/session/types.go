@@ -9,6 +9,10 @@ import (
 type Session struct {
 	Messages []Message        `json:"messages"`
 	Metadata *SessionMetadata `json:"-"`
+
+	// Sidechain holds messages from subagents (Task tool), kept out of
+	// the main conversation
+	Sidechain []Message `json:"-"`
 }
 
 // Message represents a single message in the conversation
@@ -23,6 +27,29 @@ type Message struct {
 	// New Claude Code format: message is nested
 	NestedMessage *NestedMessage `json:"message,omitempty"`
 
+	// Threading: each entry points at the entry it follows
+	UUID              string `json:"uuid,omitempty"`
+	ParentUUID        string `json:"parentUuid,omitempty"`
+	LogicalParentUUID string `json:"logicalParentUuid,omitempty"`
+	Subtype           string `json:"subtype,omitempty"`
+
+	// Summary entries name the conversation ending at LeafUUID
+	Summary  string `json:"summary,omitempty"`
+	LeafUUID string `json:"leafUuid,omitempty"`
+
+	// Injected context (caveats, command output) rather than something typed
+	IsMeta bool `json:"isMeta,omitempty"`
+
+	// Subagent (Task tool) activity
+	IsSidechain bool   `json:"isSidechain,omitempty"`
+	AgentID     string `json:"agentId,omitempty"`
+
+	// Set while threading: Branch marks an alternative to an earlier reply
+	// to the same parent (e.g. a retried prompt), Continued marks a message
+	// that follows a compaction boundary or history from another session
+	Branch    bool `json:"-"`
+	Continued bool `json:"-"`
+
 	// Session metadata (from top-level fields)
 	Cwd       string `json:"cwd,omitempty"`
 	GitBranch string `json:"gitBranch,omitempty"`
@@ -31,10 +58,16 @@ type Message struct {
 	// Model and usage (extracted from nested message)
 	Model string
 	Usage *TokenUsage
+
+	// Where the entry is in a JSONL file: its 1-based line and the byte
+	// offset the line starts at
+	Line   int   `json:"-"`
+	Offset int64 `json:"-"`
 }
 
 // NestedMessage represents the nested message in new Claude Code format
 type NestedMessage struct {
+	ID         string          `json:"id,omitempty"`
 	Role       string          `json:"role"`
 	RawContent json.RawMessage `json:"content"`
 	Model      string          `json:"model,omitempty"`
@@ -48,6 +81,7 @@ type Content []ContentBlock
 type ContentBlock struct {
 	Type      string          `json:"type"`
 	Text      string          `json:"text,omitempty"`
+	Thinking  string          `json:"thinking,omitempty"`
 	Name      string          `json:"name,omitempty"`
 	ID        string          `json:"id,omitempty"`
 	ToolUseID string          `json:"tool_use_id,omitempty"`
@@ -68,15 +102,36 @@ type ImageSource struct {
 
 // ToolInput represents parsed tool input
 type ToolInput struct {
-	Command     string     `json:"command,omitempty"`
-	Description string     `json:"description,omitempty"`
-	FilePath    string     `json:"file_path,omitempty"`
-	Content     string     `json:"content,omitempty"`
-	OldString   string     `json:"old_string,omitempty"`
-	NewString   string     `json:"new_string,omitempty"`
-	Pattern     string     `json:"pattern,omitempty"`
-	Path        string     `json:"path,omitempty"`
-	Todos       []TodoItem `json:"todos,omitempty"`
+	Command      string     `json:"command,omitempty"`
+	Description  string     `json:"description,omitempty"`
+	FilePath     string     `json:"file_path,omitempty"`
+	NotebookPath string     `json:"notebook_path,omitempty"`
+	Content      string     `json:"content,omitempty"`
+	OldString    string     `json:"old_string,omitempty"`
+	NewString    string     `json:"new_string,omitempty"`
+	ReplaceAll   bool       `json:"replace_all,omitempty"`
+	Pattern      string     `json:"pattern,omitempty"`
+	Path         string     `json:"path,omitempty"`
+	Todos        []TodoItem `json:"todos,omitempty"`
+	Edits        []EditOp   `json:"edits,omitempty"`
+}
+
+// EditOp is a single string replacement made by the Edit or MultiEdit tools
+type EditOp struct {
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all,omitempty"`
+}
+
+// AllEdits returns the replacements made by an Edit or MultiEdit call
+func (t *ToolInput) AllEdits() []EditOp {
+	if len(t.Edits) > 0 {
+		return t.Edits
+	}
+	if t.OldString != "" || t.NewString != "" {
+		return []EditOp{{OldString: t.OldString, NewString: t.NewString, ReplaceAll: t.ReplaceAll}}
+	}
+	return nil
 }
 
 // TodoItem represents a todo item in the TodoWrite tool
@@ -91,6 +146,7 @@ type Conversation struct {
 	Timestamp      time.Time
 	Messages       []MessageEntry
 	IsContinuation bool
+	IsBranch       bool
 }
 
 // MessageEntry represents a message with its metadata
@@ -112,6 +168,7 @@ type TokenUsage struct {
 
 // SessionMetadata contains metadata about the session
 type SessionMetadata struct {
+	Title       string // From Claude Code's summary entries, if any
 	Cwd         string
 	GitBranch   string
 	Version     string
@@ -124,7 +181,7 @@ type SessionMetadata struct {
 	ActiveTime  time.Duration // Time excluding gaps > threshold
 }
 
-// ToolStats tracks statistics about tool usage
+// ToolStats tracks statistics about tool usage and timing in a conversation
 type ToolStats struct {
 	BashCount  int
 	ReadCount  int
@@ -132,7 +189,12 @@ type ToolStats struct {
 	EditCount  int
 	GlobCount  int
 	GrepCount  int
+	MCPCount   int // Calls to tools of MCP servers
 	OtherCount int
+
+	Duration   time.Duration // From the prompt to the last message
+	AvgLatency time.Duration // Mean wait for Claude after a prompt or tool result
+	Responses  int           // Waits that went into AvgLatency
 }
 
 // IndexItem represents an item in the index (prompt or commit)
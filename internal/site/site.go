@@ -0,0 +1,235 @@
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/normalize"
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+// maxDescriptionLen caps the front matter description, which site
+// generators use for listings and search snippets
+const maxDescriptionLen = 160
+
+var (
+	nonSlug       = regexp.MustCompile(`[^a-z0-9]+`)
+	backtickRuns  = regexp.MustCompile("`+")
+	ansiCodes     = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+	commandName   = regexp.MustCompile(`(?s)<command-name>(.*?)</command-name>`)
+	commandArgs   = regexp.MustCompile(`(?s)<command-args>(.*?)</command-args>`)
+	commandOutput = regexp.MustCompile(`(?s)<local-command-stdout>(.*?)</local-command-stdout>`)
+)
+
+// Page is a session as a Markdown page with front matter, for the content
+// directory of a static site generator
+type Page struct {
+	Path    string // Relative, slash-separated: project/YYYY-MM-DD-title-id.md
+	Content []byte
+}
+
+// Build renders a parsed session as a page. YAML front matter carries the
+// metadata Hugo and Jekyll both understand, plus the session's own fields
+// for templates that want them.
+func Build(sess *session.Session, id string) Page {
+	doc := normalize.Build(sess, id)
+
+	title := session.DefaultTitle(sess)
+	if title == "" {
+		title = "Session " + shortID(id)
+	}
+	project := "session"
+	if doc.Session.Cwd != "" {
+		project = path.Base(strings.ReplaceAll(doc.Session.Cwd, `\`, "/"))
+	}
+
+	date := time.Now()
+	if doc.Session.Start != nil {
+		date = *doc.Session.Start
+	}
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	field(&b, "title", title)
+	// Dates are left unquoted so YAML reads them as timestamps
+	fmt.Fprintf(&b, "date: %s\n", date.Format(time.RFC3339))
+	if doc.Session.End != nil {
+		fmt.Fprintf(&b, "lastmod: %s\n", doc.Session.End.Format(time.RFC3339))
+	}
+	if desc := description(sess); desc != "" {
+		field(&b, "description", desc)
+	}
+	field(&b, "project", project)
+	field(&b, "tags", []string{project})
+	field(&b, "session_id", id)
+	if doc.Session.GitBranch != "" {
+		field(&b, "git_branch", doc.Session.GitBranch)
+	}
+	field(&b, "models", doc.Session.Models)
+	// Sessions are full of {{ }} from templates and code; keep Jekyll from
+	// running them as Liquid
+	field(&b, "render_with_liquid", false)
+	b.WriteString("---\n")
+
+	for _, msg := range doc.Messages {
+		if msg.IsMeta {
+			continue
+		}
+		writeMessage(&b, msg)
+	}
+
+	return Page{
+		Path:    path.Join(slug(project, "session"), fmt.Sprintf("%s-%s-%s.md", date.Format("2006-01-02"), slug(title, "session"), shortID(id))),
+		Content: []byte(b.String()),
+	}
+}
+
+// field writes a front matter line. Values are written as JSON, which YAML
+// reads as quoted strings and flow sequences.
+func field(b *strings.Builder, key string, value interface{}) {
+	b.WriteString(key + ": ")
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(value) // Ends the line
+}
+
+func writeMessage(b *strings.Builder, msg normalize.Message) {
+	heading := "User"
+	if msg.Role == "assistant" {
+		heading = "Claude"
+	}
+	if msg.Timestamp != nil {
+		heading += " · " + msg.Timestamp.Format("15:04")
+	}
+	fmt.Fprintf(b, "\n## %s\n", heading)
+
+	for _, block := range msg.Content {
+		switch block.Type {
+		case "text":
+			writeText(b, block.Text)
+		case "tool_use":
+			writeToolCall(b, block.Tool)
+		case "tool_result":
+			writeResult(b, block.Result)
+		case "image":
+			b.WriteString("\n_[image]_\n")
+		}
+		// Thinking is left out, as in the viewer's default view
+	}
+}
+
+// writeText writes prose as it is, since it is Markdown already, except for
+// the markup Claude Code wraps around slash commands
+func writeText(b *strings.Builder, text string) {
+	text = strings.TrimSpace(text)
+	switch {
+	case strings.HasPrefix(text, "<local-command-caveat>"):
+		return
+	case commandName.MatchString(text):
+		command := strings.TrimSpace(commandName.FindStringSubmatch(text)[1])
+		if m := commandArgs.FindStringSubmatch(text); m != nil && strings.TrimSpace(m[1]) != "" {
+			command += " " + strings.TrimSpace(m[1])
+		}
+		b.WriteString("\n" + codeSpan(command) + "\n")
+		return
+	case commandOutput.MatchString(text):
+		if output := strings.TrimSpace(commandOutput.FindStringSubmatch(text)[1]); output != "" {
+			b.WriteString("\n" + codeBlock(output))
+		}
+		return
+	}
+	b.WriteString("\n" + escapeShortcodes(text) + "\n")
+}
+
+func writeToolCall(b *strings.Builder, call *normalize.ToolCall) {
+	fmt.Fprintf(b, "\n**%s**", call.Name)
+	if summary := session.InputSummary(call.Input); summary != "" {
+		if strings.Contains(summary, "\n") {
+			b.WriteString("\n\n" + codeBlock(summary))
+		} else {
+			b.WriteString(" " + codeSpan(summary) + "\n")
+		}
+	} else {
+		b.WriteString("\n")
+	}
+	if call.Result != nil {
+		writeResult(b, call.Result)
+	}
+}
+
+func writeResult(b *strings.Builder, result *normalize.Result) {
+	text := strings.TrimRight(result.Text, "\n")
+	if result.IsError {
+		b.WriteString("\n_Failed:_\n")
+	}
+	if text != "" {
+		b.WriteString("\n" + codeBlock(text))
+	}
+}
+
+// codeBlock fences text with more backticks than it contains in a row.
+// Terminal color codes are dropped.
+func codeBlock(text string) string {
+	text = ansiCodes.ReplaceAllString(text, "")
+	fence := strings.Repeat("`", max(3, longestRun(text)+1))
+	return fence + "text\n" + escapeShortcodes(text) + "\n" + fence + "\n"
+}
+
+func codeSpan(text string) string {
+	ticks := strings.Repeat("`", longestRun(text)+1)
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		text = " " + text + " "
+	}
+	return ticks + escapeShortcodes(text) + ticks
+}
+
+func longestRun(text string) int {
+	n := 0
+	for _, run := range backtickRuns.FindAllString(text, -1) {
+		n = max(n, len(run))
+	}
+	return n
+}
+
+// escapeShortcodes comments out Hugo shortcode delimiters, which Hugo
+// expands even inside code blocks; it then shows them literally
+func escapeShortcodes(text string) string {
+	return strings.NewReplacer("{{<", "{{</*", ">}}", "*/>}}", "{{%", "{{%/*", "%}}", "*/%}}").Replace(text)
+}
+
+// description is the first prompt, flattened and shortened
+func description(sess *session.Session) string {
+	for _, msg := range session.GetUserPrompts(sess) {
+		text := strings.Join(strings.Fields(session.ExtractText(&msg)), " ")
+		if text == "" {
+			continue
+		}
+		if r := []rune(text); len(r) > maxDescriptionLen {
+			text = string(r[:maxDescriptionLen]) + "..."
+		}
+		return text
+	}
+	return ""
+}
+
+func slug(text, fallback string) string {
+	s := strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(text), "-"), "-")
+	if len(s) > 60 {
+		s = strings.TrimRight(s[:60], "-")
+	}
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
@@ -0,0 +1,62 @@
+package site
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+const sample = `{"type":"summary","summary":"Fix the {{< ref >}} shortcode"}
+{"type":"user","uuid":"u1","cwd":"/home/alice/code/My App","gitBranch":"main","message":{"role":"user","content":"Why does the page break?"},"timestamp":"2025-01-15T10:00:00Z"}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"role":"assistant","model":"claude-sonnet-4","content":[{"type":"thinking","thinking":"hmm"},{"type":"text","text":"Let me check."},{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"cat ` + "`" + `page.md` + "`" + `"}}]},"timestamp":"2025-01-15T10:01:00Z"}
+{"type":"user","uuid":"u2","parentUuid":"a1","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"` + "```" + `go\n\u001b[31mfmt\u001b[0m\n` + "```" + `"}]},"timestamp":"2025-01-15T10:01:30Z"}
+{"type":"user","uuid":"u3","parentUuid":"u2","message":{"role":"user","content":"<command-name>/compact</command-name>\n<command-args>keep tests</command-args>"},"timestamp":"2025-01-15T10:05:00Z"}
+`
+
+func TestBuild(t *testing.T) {
+	sess, err := session.Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := Build(sess, "0123456789abcdef")
+
+	if page.Path != "my-app/2025-01-15-fix-the-ref-shortcode-01234567.md" {
+		t.Errorf("Unexpected path %q", page.Path)
+	}
+
+	content := string(page.Content)
+	for _, want := range []string{
+		"---\ntitle: \"Fix the {{< ref >}} shortcode\"\n",
+		"date: 2025-01-15T10:00:00Z\n",
+		"lastmod: 2025-01-15T10:05:00Z\n",
+		`description: "Why does the page break?"`,
+		`project: "My App"`,
+		`tags: ["My App"]`,
+		`git_branch: "main"`,
+		"render_with_liquid: false\n---\n",
+		"## User · 10:00\n\nWhy does the page break?\n",
+		"## Claude · 10:01\n\nLet me check.\n",
+		"**Bash** `` cat `page.md` ``\n",
+		"````text\n```go\nfmt\n```\n````\n",
+		"`/compact keep tests`",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Expected page to contain %q, got:\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "hmm") {
+		t.Error("Expected thinking to be left out")
+	}
+	if strings.Contains(content, "<command-name>") {
+		t.Error("Expected command markup to be replaced")
+	}
+}
+
+func TestEscapeShortcodes(t *testing.T) {
+	got := escapeShortcodes(`{{< highlight go >}} and {{% notice %}}`)
+	want := `{{</* highlight go */>}} and {{%/* notice */%}}`
+	if got != want {
+		t.Errorf("escapeShortcodes = %q, want %q", got, want)
+	}
+}
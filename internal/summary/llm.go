@@ -0,0 +1,164 @@
+package summary
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+// Defaults for the model-backed providers
+const (
+	DefaultOllamaURL      = "http://localhost:11434"
+	DefaultOllamaModel    = "llama3.2"
+	DefaultAnthropicModel = "claude-3-5-haiku-latest"
+)
+
+// anthropicURL is the Messages API endpoint, replaced in tests
+var anthropicURL = "https://api.anthropic.com/v1/messages"
+
+// Limits on how much of a session is sent to a model
+const (
+	maxPrompts     = 5
+	maxPromptChars = 500
+)
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// prompt asks a model for a title, quoting the session's first prompts
+func prompt(sess *session.Session) string {
+	var b strings.Builder
+	b.WriteString("Write a title of at most eight words for this Claude Code session, based on the user's requests below. Reply with the title only.\n")
+	n := 0
+	for _, msg := range session.GetUserPrompts(sess) {
+		text := strings.TrimSpace(session.ExtractText(&msg))
+		if text == "" {
+			continue
+		}
+		if r := []rune(text); len(r) > maxPromptChars {
+			text = string(r[:maxPromptChars]) + "..."
+		}
+		b.WriteString("\n<request>\n" + text + "\n</request>\n")
+		if n++; n == maxPrompts {
+			break
+		}
+	}
+	if n == 0 {
+		return ""
+	}
+	return b.String()
+}
+
+// Ollama asks a local model served by Ollama
+type Ollama struct {
+	URL   string
+	Model string
+}
+
+func (*Ollama) Name() string { return ProviderOllama }
+func (*Ollama) remote()      {}
+
+func (o *Ollama) Summarize(sess *session.Session) (string, error) {
+	text := prompt(sess)
+	if text == "" {
+		return "", nil
+	}
+	url, model := o.URL, o.Model
+	if url == "" {
+		url = DefaultOllamaURL
+	}
+	if model == "" {
+		model = DefaultOllamaModel
+	}
+
+	var resp struct {
+		Response string `json:"response"`
+	}
+	body := map[string]interface{}{"model": model, "prompt": text, "stream": false}
+	if err := postJSON(strings.TrimSuffix(url, "/")+"/api/generate", nil, body, &resp); err != nil {
+		return "", err
+	}
+	return resp.Response, nil
+}
+
+// Anthropic asks a Claude model through the Anthropic API
+type Anthropic struct {
+	Key   string
+	Model string
+}
+
+func (*Anthropic) Name() string { return ProviderAnthropic }
+func (*Anthropic) remote()      {}
+
+func (a *Anthropic) Summarize(sess *session.Session) (string, error) {
+	text := prompt(sess)
+	if text == "" {
+		return "", nil
+	}
+	model := a.Model
+	if model == "" {
+		model = DefaultAnthropicModel
+	}
+
+	var resp struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	headers := map[string]string{"x-api-key": a.Key, "anthropic-version": "2023-06-01"}
+	body := map[string]interface{}{
+		"model":      model,
+		"max_tokens": 40,
+		"messages":   []map[string]string{{"role": "user", "content": text}},
+	}
+	if err := postJSON(anthropicURL, headers, body, &resp); err != nil {
+		return "", err
+	}
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			return block.Text, nil
+		}
+	}
+	return "", errors.New("no text in response")
+}
+
+// postJSON sends body as JSON and decodes the JSON reply into out
+func postJSON(url string, headers map[string]string, body, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package summary
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+// Provider names, as used in the config file
+const (
+	ProviderEntries      = "entries"
+	ProviderFirstMessage = "first-message"
+	ProviderOllama       = "ollama"
+	ProviderAnthropic    = "anthropic"
+)
+
+// Providers lists the available providers
+var Providers = []string{ProviderEntries, ProviderFirstMessage, ProviderOllama, ProviderAnthropic}
+
+// DefaultProviders is the order used when the config doesn't pick one:
+// Claude Code's own summaries, then the first prompt
+var DefaultProviders = []string{ProviderEntries, ProviderFirstMessage}
+
+// maxTitleLen caps titles from any provider, matching the first-prompt title
+const maxTitleLen = 80
+
+// Provider titles a session for the picker and archive listings. An empty
+// title means the provider has nothing to offer and the next one is tried.
+type Provider interface {
+	Name() string
+	Summarize(sess *session.Session) (string, error)
+}
+
+// remote is implemented by providers that call a model. They are slow or
+// cost money, so their titles are cached.
+type remote interface {
+	remote()
+}
+
+// Settings configures the model-backed providers
+type Settings struct {
+	OllamaURL      string
+	OllamaModel    string
+	AnthropicKey   string
+	AnthropicModel string
+}
+
+// Entries uses the summary entries Claude Code writes into sessions
+type Entries struct{}
+
+func (Entries) Name() string { return ProviderEntries }
+
+func (Entries) Summarize(sess *session.Session) (string, error) {
+	if sess.Metadata == nil {
+		return "", nil
+	}
+	return sess.Metadata.Title, nil
+}
+
+// FirstMessage uses the first meaningful prompt
+type FirstMessage struct{}
+
+func (FirstMessage) Name() string { return ProviderFirstMessage }
+
+func (FirstMessage) Summarize(sess *session.Session) (string, error) {
+	return session.FirstPromptTitle(sess), nil
+}
+
+// New builds the providers named in names, in order
+func New(names []string, settings Settings) ([]Provider, error) {
+	if len(names) == 0 {
+		names = DefaultProviders
+	}
+	var providers []Provider
+	for _, name := range names {
+		switch name {
+		case ProviderEntries:
+			providers = append(providers, Entries{})
+		case ProviderFirstMessage:
+			providers = append(providers, FirstMessage{})
+		case ProviderOllama:
+			providers = append(providers, &Ollama{URL: settings.OllamaURL, Model: settings.OllamaModel})
+		case ProviderAnthropic:
+			if settings.AnthropicKey == "" {
+				return nil, errors.New("the anthropic summary provider needs ANTHROPIC_API_KEY")
+			}
+			providers = append(providers, &Anthropic{Key: settings.AnthropicKey, Model: settings.AnthropicModel})
+		default:
+			return nil, fmt.Errorf("unknown summary provider %q (available: %s)", name, strings.Join(Providers, ", "))
+		}
+	}
+	return providers, nil
+}
+
+// Chain tries providers in order and keeps the first title one offers.
+// Titles from model-backed providers are cached in a file, keyed by the
+// session's content, so each session is only summarized once per change.
+// It is safe for concurrent use.
+type Chain struct {
+	providers []Provider
+	cachePath string
+
+	// OnError is called when a provider fails; the chain moves on to the
+	// next provider either way
+	OnError func(provider string, err error)
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// NewChain returns a chain over providers, caching in cachePath ("" for no
+// cache)
+func NewChain(providers []Provider, cachePath string) *Chain {
+	return &Chain{providers: providers, cachePath: cachePath}
+}
+
+// Title returns the first title a provider offers, or "" if none does
+func (c *Chain) Title(path string, sess *session.Session) string {
+	for _, p := range c.providers {
+		_, isRemote := p.(remote)
+		var key string
+		if isRemote {
+			key = cacheKey(p.Name(), path)
+			if title, ok := c.cached(key); ok {
+				if title != "" {
+					return title
+				}
+				continue
+			}
+		}
+
+		title, err := p.Summarize(sess)
+		if err != nil {
+			if c.OnError != nil {
+				c.OnError(p.Name(), err)
+			}
+			continue
+		}
+		title = clean(title)
+		if isRemote && key != "" {
+			c.store(key, title)
+		}
+		if title != "" {
+			return title
+		}
+	}
+	return ""
+}
+
+// clean flattens a title to one line and caps its length
+func clean(title string) string {
+	title = strings.Join(strings.Fields(title), " ")
+	title = strings.Trim(title, `"'`)
+	if r := []rune(title); len(r) > maxTitleLen {
+		title = string(r[:maxTitleLen]) + "..."
+	}
+	return title
+}
+
+// cacheKey identifies a provider's title for the current content of a
+// session file. Empty if the file can't be read.
+func cacheKey(provider, path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return provider + ":" + hex.EncodeToString(sum[:])
+}
+
+func (c *Chain) cached(key string) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadCache()
+	title, ok := c.cache[key]
+	return title, ok
+}
+
+func (c *Chain) store(key, title string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadCache()
+	c.cache[key] = title
+	if c.cachePath == "" {
+		return
+	}
+	data, err := json.MarshalIndent(c.cache, "", "  ")
+	if err != nil {
+		return
+	}
+	// Best effort: a lost cache only means summarizing again
+	if err := os.MkdirAll(filepath.Dir(c.cachePath), 0755); err == nil {
+		tmp := c.cachePath + ".tmp"
+		if os.WriteFile(tmp, data, 0644) == nil {
+			os.Rename(tmp, c.cachePath)
+		}
+	}
+}
+
+// loadCache reads the cache file once. Callers hold c.mu.
+func (c *Chain) loadCache() {
+	if c.cache != nil {
+		return
+	}
+	c.cache = make(map[string]string)
+	if c.cachePath == "" {
+		return
+	}
+	if data, err := os.ReadFile(c.cachePath); err == nil {
+		json.Unmarshal(data, &c.cache)
+	}
+}
@@ -0,0 +1,110 @@
+package summary
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+const titledSession = `{"type":"summary","summary":"Fix login redirect","leafUuid":"u1"}
+{"type":"user","uuid":"u1","message":{"role":"user","content":"The login page loops forever"}}`
+
+const untitledSession = `{"type":"user","uuid":"u1","message":{"role":"user","content":"The login page loops forever"}}`
+
+// countingProvider is a model-backed provider that counts its calls
+type countingProvider struct {
+	title string
+	err   error
+	calls int
+}
+
+func (p *countingProvider) Name() string { return "counting" }
+func (p *countingProvider) remote()      {}
+func (p *countingProvider) Summarize(*session.Session) (string, error) {
+	p.calls++
+	return p.title, p.err
+}
+
+func parse(t *testing.T, data string) *session.Session {
+	t.Helper()
+	sess, err := session.Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	return sess
+}
+
+func TestChainFallsThrough(t *testing.T) {
+	providers, err := New(nil, Settings{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	chain := NewChain(providers, "")
+
+	if got := chain.Title("", parse(t, titledSession)); got != "Fix login redirect" {
+		t.Errorf("Expected the summary entry, got %q", got)
+	}
+	if got := chain.Title("", parse(t, untitledSession)); got != "The login page loops forever" {
+		t.Errorf("Expected the first prompt, got %q", got)
+	}
+
+	if _, err := New([]string{"magic"}, Settings{}); err == nil {
+		t.Error("Expected an error for an unknown provider")
+	}
+}
+
+func TestChainCachesRemoteTitles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "s.jsonl")
+	os.WriteFile(path, []byte(untitledSession), 0644)
+	cachePath := filepath.Join(dir, "summaries.json")
+
+	failing := &countingProvider{err: errors.New("offline")}
+	model := &countingProvider{title: "  \"Login loop fix\"\n"}
+	var failures []string
+	chain := NewChain([]Provider{failing, model, FirstMessage{}}, cachePath)
+	chain.OnError = func(provider string, err error) { failures = append(failures, provider) }
+
+	sess := parse(t, untitledSession)
+	if got := chain.Title(path, sess); got != "Login loop fix" {
+		t.Errorf("Expected the cleaned model title, got %q", got)
+	}
+	if len(failures) != 1 {
+		t.Errorf("Expected the failing provider to be reported, got %v", failures)
+	}
+
+	// A new chain reads the title back from the cache file
+	again := &countingProvider{title: "Something else"}
+	if got := NewChain([]Provider{again}, cachePath).Title(path, sess); got != "Login loop fix" || again.calls != 0 {
+		t.Errorf("Expected the cached title without a call, got %q after %d calls", got, again.calls)
+	}
+}
+
+func TestAnthropic(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "key" {
+			t.Errorf("Expected the API key header, got %q", r.Header.Get("x-api-key"))
+		}
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Model != DefaultAnthropicModel {
+			t.Errorf("Expected the default model, got %q", body.Model)
+		}
+		w.Write([]byte(`{"content":[{"type":"text","text":"Login redirect loop"}]}`))
+	}))
+	defer server.Close()
+	anthropicURL = server.URL
+
+	title, err := (&Anthropic{Key: "key"}).Summarize(parse(t, untitledSession))
+	if err != nil || title != "Login redirect loop" {
+		t.Errorf("Expected the model's title, got %q (%v)", title, err)
+	}
+}
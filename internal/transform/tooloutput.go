@@ -0,0 +1,75 @@
+package transform
+
+import "fmt"
+
+// OmittedOutput replaces tool output dropped by DropToolOutput
+const OmittedOutput = "(output omitted)"
+
+// DropToolOutput removes the content of every tool result while keeping
+// the tool calls themselves
+func DropToolOutput() Filter {
+	return func(entry Entry) bool {
+		delete(entry, "toolUseResult")
+		for _, b := range contentBlocks(entry) {
+			block, ok := b.(map[string]interface{})
+			if ok && block["type"] == "tool_result" {
+				block["content"] = OmittedOutput
+			}
+		}
+		return true
+	}
+}
+
+// LimitToolOutput truncates tool result text to at most limit characters
+func LimitToolOutput(limit int) Filter {
+	return func(entry Entry) bool {
+		if raw, ok := entry["toolUseResult"]; ok {
+			entry["toolUseResult"] = truncateStrings(raw, limit)
+		}
+		for _, b := range contentBlocks(entry) {
+			block, ok := b.(map[string]interface{})
+			if !ok || block["type"] != "tool_result" {
+				continue
+			}
+			switch content := block["content"].(type) {
+			case string:
+				block["content"] = truncate(content, limit)
+			case []interface{}:
+				for _, item := range content {
+					if m, ok := item.(map[string]interface{}); ok {
+						if text, ok := m["text"].(string); ok {
+							m["text"] = truncate(text, limit)
+						}
+					}
+				}
+			}
+		}
+		return true
+	}
+}
+
+// truncate shortens s to limit characters, noting how much was removed
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return fmt.Sprintf("%s\n... (%d more characters truncated)", string(runes[:limit]), len(runes)-limit)
+}
+
+// truncateStrings truncates every string within a decoded JSON value
+func truncateStrings(v interface{}, limit int) interface{} {
+	switch val := v.(type) {
+	case string:
+		return truncate(val, limit)
+	case []interface{}:
+		for i, item := range val {
+			val[i] = truncateStrings(item, limit)
+		}
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = truncateStrings(item, limit)
+		}
+	}
+	return v
+}
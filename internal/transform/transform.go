@@ -0,0 +1,201 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// Entry is a decoded JSONL session entry
+type Entry = map[string]interface{}
+
+// Filter inspects and optionally modifies an entry in place, returning
+// false to drop the entry from the output
+type Filter func(entry Entry) bool
+
+// Apply runs the filters over every JSONL line. Lines that aren't JSON
+// objects, and lines no filter changes, are passed through unchanged; in
+// lines a filter changes, only the changed values are re-encoded, so keys
+// keep their order and numbers their digits.
+func Apply(data []byte, filters ...Filter) []byte {
+	if len(filters) == 0 {
+		return data
+	}
+
+	var out [][]byte
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		var entry Entry
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.UseNumber()
+		if err := dec.Decode(&entry); err != nil || entry == nil {
+			out = append(out, line)
+			continue
+		}
+
+		keep := true
+		for _, f := range filters {
+			if !f(entry) {
+				keep = false
+				break
+			}
+		}
+		if !keep {
+			continue
+		}
+
+		encoded, _, err := reencode(line, entry)
+		if err != nil {
+			out = append(out, line)
+			continue
+		}
+		out = append(out, encoded)
+	}
+
+	return bytes.Join(out, []byte("\n"))
+}
+
+// reencode encodes v, decoded from raw and perhaps changed since, reusing
+// raw for every part left as it was. Objects keep raw's key order, with
+// added keys at the end. It also reports whether anything changed.
+func reencode(raw []byte, v interface{}) ([]byte, bool, error) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		keys, values, ok := objectFields(raw)
+		if !ok {
+			break
+		}
+		changed := len(keys) != len(val)
+		var buf bytes.Buffer
+		buf.WriteByte('{')
+		seen := make(map[string]bool, len(keys))
+		for i, k := range keys {
+			item, ok := val[k]
+			if !ok {
+				changed = true
+				continue
+			}
+			seen[k] = true
+			encoded, c, err := reencode(values[i], item)
+			if err != nil {
+				return nil, false, err
+			}
+			changed = changed || c
+			if err := writeField(&buf, k, encoded); err != nil {
+				return nil, false, err
+			}
+		}
+		var added []string
+		for k := range val {
+			if !seen[k] {
+				added = append(added, k)
+			}
+		}
+		sort.Strings(added)
+		for _, k := range added {
+			changed = true
+			encoded, err := marshal(val[k])
+			if err != nil {
+				return nil, false, err
+			}
+			if err := writeField(&buf, k, encoded); err != nil {
+				return nil, false, err
+			}
+		}
+		buf.WriteByte('}')
+		if !changed {
+			return raw, false, nil
+		}
+		return buf.Bytes(), true, nil
+
+	case []interface{}:
+		var items []json.RawMessage
+		if json.Unmarshal(raw, &items) != nil || len(items) != len(val) {
+			break
+		}
+		changed := false
+		encoded := make([][]byte, len(val))
+		for i, item := range val {
+			var c bool
+			var err error
+			if encoded[i], c, err = reencode(items[i], item); err != nil {
+				return nil, false, err
+			}
+			changed = changed || c
+		}
+		if !changed {
+			return raw, false, nil
+		}
+		return append(append([]byte("["), bytes.Join(encoded, []byte(","))...), ']'), true, nil
+
+	default:
+		var orig interface{}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		if dec.Decode(&orig) == nil && orig == v {
+			return raw, false, nil
+		}
+	}
+	encoded, err := marshal(v)
+	return encoded, true, err
+}
+
+// objectFields splits a JSON object into its keys and raw values, in order
+func objectFields(raw []byte) ([]string, []json.RawMessage, bool) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, nil, false
+	}
+	var keys []string
+	var values []json.RawMessage
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, false
+		}
+		key, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, nil, false
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	return keys, values, true
+}
+
+// writeField writes "key":value to an object being built
+func writeField(buf *bytes.Buffer, key string, value []byte) error {
+	if buf.Len() > 1 {
+		buf.WriteByte(',')
+	}
+	k, err := marshal(key)
+	if err != nil {
+		return err
+	}
+	buf.Write(k)
+	buf.WriteByte(':')
+	buf.Write(value)
+	return nil
+}
+
+// marshal encodes v without HTML escaping, matching Claude Code's own output
+func marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// contentBlocks returns the content block list of an entry, handling both
+// the nested message format and the older flat format
+func contentBlocks(entry Entry) []interface{} {
+	if msg, ok := entry["message"].(map[string]interface{}); ok {
+		blocks, _ := msg["content"].([]interface{})
+		return blocks
+	}
+	blocks, _ := entry["content"].([]interface{})
+	return blocks
+}
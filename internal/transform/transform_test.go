@@ -0,0 +1,95 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const toolSession = `{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"ls"}}]}}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"file1\nfile2\nfile3"}]},"toolUseResult":{"stdout":"file1\nfile2\nfile3"}}
+not json`
+
+func TestApplyNoFilters(t *testing.T) {
+	data := []byte(toolSession)
+	if out := Apply(data); string(out) != toolSession {
+		t.Errorf("Expected data unchanged without filters, got %s", out)
+	}
+}
+
+func TestApplyDropsEntries(t *testing.T) {
+	dropAssistant := func(entry Entry) bool {
+		return entry["type"] != "assistant"
+	}
+
+	out := string(Apply([]byte(toolSession), dropAssistant))
+	lines := strings.Split(out, "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d: %s", len(lines), out)
+	}
+	if lines[1] != "not json" {
+		t.Errorf("Expected non-JSON line to pass through, got '%s'", lines[1])
+	}
+}
+
+func TestApplyKeepsLayout(t *testing.T) {
+	untouched := `{"z":1,"a":12345678901234567890,"html":"<b>","n":1.50}`
+	data := untouched + "\n" + `{"type":"user","uuid":"u1","toolUseResult":{"stdout":"x"},"size":9007199254740993}`
+	out := string(Apply([]byte(data), DropToolOutput()))
+
+	lines := strings.Split(out, "\n")
+	if lines[0] != untouched {
+		t.Errorf("Expected an untouched line byte for byte, got %s", lines[0])
+	}
+	if want := `{"type":"user","uuid":"u1","size":9007199254740993}`; lines[1] != want {
+		t.Errorf("Expected a changed line to keep key order and numbers, got %s, want %s", lines[1], want)
+	}
+}
+
+func TestDropToolOutput(t *testing.T) {
+	out := string(Apply([]byte(toolSession), DropToolOutput()))
+
+	if strings.Contains(out, "file1") {
+		t.Errorf("Expected tool output to be removed, got %s", out)
+	}
+	if strings.Contains(out, "toolUseResult") {
+		t.Errorf("Expected toolUseResult to be removed, got %s", out)
+	}
+	if !strings.Contains(out, `"command":"ls"`) {
+		t.Errorf("Expected tool call to be kept, got %s", out)
+	}
+}
+
+func TestLimitToolOutput(t *testing.T) {
+	out := string(Apply([]byte(toolSession), LimitToolOutput(5)))
+
+	if strings.Contains(out, "file2") {
+		t.Errorf("Expected tool output to be truncated, got %s", out)
+	}
+	if !strings.Contains(out, "12 more characters truncated") {
+		t.Errorf("Expected truncation note, got %s", out)
+	}
+}
+
+func TestTimeWindow(t *testing.T) {
+	data := `{"type":"summary","summary":"Login fix"}
+{"type":"user","timestamp":"2024-06-01T13:59:00Z","message":{"role":"user","content":"before"}}
+{"type":"user","timestamp":"2024-06-01T14:30:00Z","message":{"role":"user","content":"during"}}
+{"type":"user","timestamp":"2024-06-01T15:31:00Z","message":{"role":"user","content":"after"}}`
+
+	from := time.Date(2024, 6, 1, 14, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 6, 1, 15, 30, 0, 0, time.UTC)
+	out := string(Apply([]byte(data), TimeWindow(from, to)))
+
+	if strings.Contains(out, "before") || strings.Contains(out, "after") {
+		t.Errorf("Expected entries outside the window dropped, got %s", out)
+	}
+	if !strings.Contains(out, "during") || !strings.Contains(out, "Login fix") {
+		t.Errorf("Expected entries inside the window and untimed entries kept, got %s", out)
+	}
+
+	out = string(Apply([]byte(data), TimeWindow(from, time.Time{})))
+	if !strings.Contains(out, "after") {
+		t.Errorf("Expected a zero bound to be open, got %s", out)
+	}
+}
@@ -0,0 +1,23 @@
+package transform
+
+import "time"
+
+// TimeWindow drops entries timestamped before from or after to. A zero
+// bound is open, and entries without a timestamp (such as summaries) are
+// kept.
+func TimeWindow(from, to time.Time) Filter {
+	return func(entry Entry) bool {
+		raw, _ := entry["timestamp"].(string)
+		t, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return true
+		}
+		if !from.IsZero() && t.Before(from) {
+			return false
+		}
+		if !to.IsZero() && t.After(to) {
+			return false
+		}
+		return true
+	}
+}
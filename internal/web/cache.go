@@ -0,0 +1,116 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// cacheEntry records when a cached session was fetched, and the version of
+// it the API listed, if known
+type cacheEntry struct {
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	Fetched   time.Time `json:"fetched"`
+}
+
+// current reports whether the cached copy is the version the API last
+// updated at updatedAt
+func (e cacheEntry) current(updatedAt time.Time) bool {
+	if !e.UpdatedAt.IsZero() {
+		return e.UpdatedAt.Equal(updatedAt)
+	}
+	return !updatedAt.After(e.Fetched)
+}
+
+// CacheDir returns where fetched sessions are cached
+func CacheDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("getting cache directory: %w", err)
+	}
+	return filepath.Join(base, "claude-session-export", "sessions"), nil
+}
+
+// FetchSessionCached returns a session from the cache if it hasn't been
+// updated since it was fetched, and otherwise fetches and caches it.
+// updatedAt is the session's last update from FetchSessions; when zero,
+// it's looked up only if there's a cached copy. refresh always fetches.
+func FetchSessionCached(sessionID string, updatedAt time.Time, refresh bool) (data []byte, cached bool, err error) {
+	dir, dirErr := CacheDir()
+	if dirErr != nil || !cacheableID(sessionID) {
+		data, err = FetchSession(sessionID)
+		return data, false, err
+	}
+	dataPath := filepath.Join(dir, sessionID+".jsonl")
+	metaPath := filepath.Join(dir, sessionID+".json")
+
+	if !refresh {
+		if entry, ok := readCacheEntry(metaPath); ok {
+			if updatedAt.IsZero() {
+				updatedAt = listedUpdatedAt(sessionID)
+			}
+			if !updatedAt.IsZero() && entry.current(updatedAt) {
+				if data, err := os.ReadFile(dataPath); err == nil {
+					return data, true, nil
+				}
+			}
+		}
+	}
+
+	fetched := time.Now()
+	data, err = FetchSession(sessionID)
+	if err != nil {
+		return nil, false, err
+	}
+	// A session that can't be cached can still be exported
+	writeCache(dir, dataPath, metaPath, data, cacheEntry{UpdatedAt: updatedAt, Fetched: fetched})
+	return data, false, nil
+}
+
+// cacheableID rejects IDs that aren't safe as file names
+func cacheableID(id string) bool {
+	return id != "" && id != "." && id != ".." && !strings.ContainsAny(id, `/\:`)
+}
+
+// listedUpdatedAt finds when the API last updated a session, or zero
+func listedUpdatedAt(sessionID string) time.Time {
+	sessions, err := FetchSessions()
+	if err != nil {
+		return time.Time{}
+	}
+	for _, s := range sessions {
+		if s.ID == sessionID {
+			return s.UpdatedAt
+		}
+	}
+	return time.Time{}
+}
+
+func readCacheEntry(path string) (cacheEntry, bool) {
+	var entry cacheEntry
+	data, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(data, &entry) != nil {
+		return entry, false
+	}
+	return entry, true
+}
+
+// writeCache saves a fetched session. The entry is written last, so a
+// partial write is never taken for a cached copy.
+func writeCache(dir, dataPath, metaPath string, data []byte, entry cacheEntry) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	os.Remove(metaPath)
+	if err := os.WriteFile(dataPath, data, 0600); err != nil {
+		return err
+	}
+	meta, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(metaPath, meta, 0600)
+}
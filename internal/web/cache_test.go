@@ -0,0 +1,63 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFetchSessionCached(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("CLAUDE_ACCESS_TOKEN", "token")
+	t.Setenv("CLAUDE_ORG_UUID", "org")
+
+	updated := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	fetches, lists := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/organizations/org/chat_conversations" {
+			lists++
+			fmt.Fprintf(w, `{"sessions":[{"id":"s1","updated_at":%q}]}`, updated.Format(time.RFC3339))
+			return
+		}
+		fetches++
+		fmt.Fprintf(w, `{"n":%d}`, fetches)
+	}))
+	defer server.Close()
+	apiBaseURL = server.URL
+
+	fetch := func(updatedAt time.Time, refresh bool) (string, bool) {
+		t.Helper()
+		data, cached, err := FetchSessionCached("s1", updatedAt, refresh)
+		if err != nil {
+			t.Fatalf("FetchSessionCached failed: %v", err)
+		}
+		return string(data), cached
+	}
+
+	if data, cached := fetch(updated, false); cached || data != `{"n":1}` {
+		t.Errorf("First fetch: got %s, cached %v", data, cached)
+	}
+	if data, cached := fetch(updated, false); !cached || data != `{"n":1}` || fetches != 1 {
+		t.Errorf("Expected the cached copy, got %s after %d fetches", data, fetches)
+	}
+	if _, cached := fetch(updated, true); cached || fetches != 2 {
+		t.Error("Expected --refresh to fetch again")
+	}
+	if _, cached := fetch(updated.Add(time.Hour), false); cached || fetches != 3 {
+		t.Error("Expected an updated session to be fetched again")
+	}
+
+	// Without the update time, the session list says whether it's current
+	updated = updated.Add(time.Hour)
+	if _, cached := fetch(time.Time{}, false); !cached || lists != 1 {
+		t.Errorf("Expected the cached copy after listing, cached %v, %d lists", cached, lists)
+	}
+	updated = updated.Add(time.Hour)
+	if _, cached := fetch(time.Time{}, false); cached || fetches != 4 {
+		t.Error("Expected a session listed as updated to be fetched again")
+	}
+}
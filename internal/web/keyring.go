@@ -0,0 +1,161 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// The access token saved by auth login, under this service and account in
+// the OS credential store
+const (
+	keyringService = "claude-session-export"
+	keyringAccount = "claude-api-token"
+)
+
+// ErrNoKeyring is returned when the OS credential store can't be reached
+var ErrNoKeyring = errors.New("no credential store found: install secret-tool (libsecret) or set CLAUDE_ACCESS_TOKEN")
+
+// errNotStored is returned by lookups when no token is saved
+var errNotStored = errors.New("no token stored")
+
+// KeyringName names the credential store tokens are saved in on this OS
+func KeyringName() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "macOS Keychain"
+	case "windows":
+		return "Windows Credential Manager"
+	default:
+		return "Secret Service"
+	}
+}
+
+// StoreToken saves the access token in the OS credential store, replacing
+// any saved before
+func StoreToken(token string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		_, err := keyringCommand("", "security", "add-generic-password", "-U", "-s", keyringService, "-a", keyringAccount, "-w", token)
+		return err
+	case "windows":
+		_, err := keyringCommand(token, "powershell", "-NoProfile", "-NonInteractive", "-Command", credManagerScript+credWriteScript)
+		return err
+	default:
+		_, err := keyringCommand(token, "secret-tool", "store", "--label", "claude-session-export API token", "service", keyringService, "account", keyringAccount)
+		return err
+	}
+}
+
+// storedToken reads the access token saved by StoreToken
+func storedToken() (string, error) {
+	var out string
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		out, err = keyringCommand("", "security", "find-generic-password", "-s", keyringService, "-a", keyringAccount, "-w")
+	case "windows":
+		out, err = keyringCommand("", "powershell", "-NoProfile", "-NonInteractive", "-Command", credManagerScript+credReadScript)
+	default:
+		out, err = keyringCommand("", "secret-tool", "lookup", "service", keyringService, "account", keyringAccount)
+	}
+	if err != nil {
+		return "", err
+	}
+	if out == "" {
+		return "", errNotStored
+	}
+	return out, nil
+}
+
+// DeleteToken removes the access token saved by StoreToken
+func DeleteToken() error {
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		_, err = keyringCommand("", "security", "delete-generic-password", "-s", keyringService, "-a", keyringAccount)
+	case "windows":
+		_, err = keyringCommand("", "powershell", "-NoProfile", "-NonInteractive", "-Command", credManagerScript+credDeleteScript)
+	default:
+		_, err = keyringCommand("", "secret-tool", "clear", "service", keyringService, "account", keyringAccount)
+	}
+	return err
+}
+
+// keyringCommand runs a credential store CLI with the secret, if any, on
+// its stdin, and returns its trimmed output. Lookups of missing entries
+// fail like any other error.
+func keyringCommand(stdin, name string, args ...string) (string, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return "", ErrNoKeyring
+	}
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Env = append(os.Environ(), "CSE_CRED_TARGET="+keyringService+":"+keyringAccount)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %s", KeyringName(), msg)
+		}
+		return "", errNotStored
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// Windows has no CLI that reads saved passwords back, so these PowerShell
+// snippets call the Credential Manager API directly. The target name comes
+// from CSE_CRED_TARGET and the secret from stdin.
+const credManagerScript = `
+$ErrorActionPreference = 'Stop'
+Add-Type -Namespace CSE -Name Cred -MemberDefinition @'
+[StructLayout(LayoutKind.Sequential, CharSet = CharSet.Unicode)]
+public struct CREDENTIAL {
+	public int Flags; public int Type; public string TargetName; public string Comment;
+	public System.Runtime.InteropServices.ComTypes.FILETIME LastWritten;
+	public int CredentialBlobSize; public IntPtr CredentialBlob; public int Persist;
+	public int AttributeCount; public IntPtr Attributes; public string TargetAlias; public string UserName;
+}
+[DllImport("advapi32.dll", SetLastError = true, CharSet = CharSet.Unicode)]
+public static extern bool CredRead(string target, int type, int flags, out IntPtr cred);
+[DllImport("advapi32.dll", SetLastError = true, CharSet = CharSet.Unicode)]
+public static extern bool CredWrite(ref CREDENTIAL cred, int flags);
+[DllImport("advapi32.dll", SetLastError = true, CharSet = CharSet.Unicode)]
+public static extern bool CredDelete(string target, int type, int flags);
+[DllImport("advapi32.dll")]
+public static extern void CredFree(IntPtr cred);
+'@
+$target = $env:CSE_CRED_TARGET
+`
+
+const credReadScript = `
+$p = [IntPtr]::Zero
+if (-not [CSE.Cred]::CredRead($target, 1, 0, [ref]$p)) { exit 1 }
+$c = [Runtime.InteropServices.Marshal]::PtrToStructure($p, [type][CSE.Cred+CREDENTIAL])
+[Console]::Out.Write([Runtime.InteropServices.Marshal]::PtrToStringUni($c.CredentialBlob, $c.CredentialBlobSize / 2))
+[CSE.Cred]::CredFree($p)
+`
+
+const credWriteScript = `
+$bytes = [Text.Encoding]::Unicode.GetBytes([Console]::In.ReadToEnd().Trim())
+$c = New-Object CSE.Cred+CREDENTIAL
+$c.Type = 1
+$c.TargetName = $target
+$c.UserName = 'claude-api-token'
+$c.Persist = 2
+$c.CredentialBlobSize = $bytes.Length
+$c.CredentialBlob = [Runtime.InteropServices.Marshal]::AllocHGlobal($bytes.Length)
+[Runtime.InteropServices.Marshal]::Copy($bytes, 0, $c.CredentialBlob, $bytes.Length)
+$ok = [CSE.Cred]::CredWrite([ref]$c, 0)
+[Runtime.InteropServices.Marshal]::FreeHGlobal($c.CredentialBlob)
+if (-not $ok) { throw "CredWrite failed: $([Runtime.InteropServices.Marshal]::GetLastWin32Error())" }
+`
+
+const credDeleteScript = `
+if (-not [CSE.Cred]::CredDelete($target, 1, 0)) { exit 1 }
+`
@@ -0,0 +1,54 @@
+package web
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestKeyringSecretService(t *testing.T) {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("Secret Service is the Linux and BSD backend")
+	}
+	// A stand-in for secret-tool keeping the secret in a file
+	dir := t.TempDir()
+	script := `#!/bin/sh
+case "$1" in
+store) cat > "$FAKE_STORE" ;;
+lookup) cat "$FAKE_STORE" 2>/dev/null || exit 1 ;;
+clear) rm -f "$FAKE_STORE" ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, "secret-tool"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("FAKE_STORE", filepath.Join(dir, "store"))
+	t.Setenv("CLAUDE_ACCESS_TOKEN", "")
+	t.Setenv("HOME", dir)
+
+	if _, _, err := AccessToken(); err == nil {
+		t.Fatal("Expected no token before login")
+	}
+	if err := StoreToken("sk-secret"); err != nil {
+		t.Fatalf("StoreToken failed: %v", err)
+	}
+	token, source, err := AccessToken()
+	if err != nil || token != "sk-secret" || source != SourceKeyring {
+		t.Errorf("Got %q from %q (%v), want the stored token", token, source, err)
+	}
+
+	// The environment still wins
+	t.Setenv("CLAUDE_ACCESS_TOKEN", "from-env")
+	if _, source, _ := AccessToken(); source != SourceEnv {
+		t.Errorf("Expected the environment first, got %q", source)
+	}
+
+	if err := DeleteToken(); err != nil {
+		t.Fatalf("DeleteToken failed: %v", err)
+	}
+	if _, err := storedToken(); err == nil {
+		t.Error("Expected no token after logout")
+	}
+}
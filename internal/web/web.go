@@ -7,26 +7,39 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
-)
 
-const (
-	apiBaseURL = "https://api.claude.ai"
+	"github.com/robzolkos/claude-session-export/internal/httpretry"
 )
 
+// apiBaseURL is the Claude API, replaced in tests
+var apiBaseURL = "https://api.claude.ai"
+
+// Timeout bounds each API request; every retry gets its own
+var Timeout = 30 * time.Second
+
+// pageSize is how many sessions FetchSessions asks for at a time
+const pageSize = 100
+
 // Config represents Claude configuration
 type Config struct {
 	OrgUUID string `json:"org_uuid"`
 }
 
-// SessionsResponse represents the API response for sessions
+// SessionsResponse represents the API response for sessions. Long lists
+// come a page at a time, continued from NextCursor or, with HasMore, from
+// the next offset.
 type SessionsResponse struct {
-	Sessions []SessionMeta `json:"sessions"`
+	Sessions   []SessionMeta `json:"sessions"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+	HasMore    bool          `json:"has_more,omitempty"`
 }
 
 // SessionMeta represents session metadata from the API
@@ -35,99 +48,147 @@ type SessionMeta struct {
 	Name      string    `json:"name"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	MessageCount int `json:"message_count,omitempty"`
 }
 
 // FetchSession fetches a session from the Claude API
 func FetchSession(sessionID string) ([]byte, error) {
-	token, err := getAccessToken()
-	if err != nil {
-		return nil, fmt.Errorf("getting access token: %w", err)
-	}
-
-	orgUUID, err := getOrgUUID()
-	if err != nil {
-		return nil, fmt.Errorf("getting org UUID: %w", err)
-	}
-
-	url := fmt.Sprintf("%s/api/organizations/%s/chat_conversations/%s/full", apiBaseURL, orgUUID, sessionID)
-
-	req, err := http.NewRequest("GET", url, nil)
+	token, orgUUID, err := credentials()
 	if err != nil {
 		return nil, err
 	}
+	return get(token, fmt.Sprintf("/api/organizations/%s/chat_conversations/%s/full", orgUUID, sessionID), nil)
+}
 
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+// FetchSessions fetches all sessions from the Claude API, following pages
+// until the API says there are no more
+func FetchSessions() ([]SessionMeta, error) {
+	token, orgUUID, err := credentials()
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	endpoint := fmt.Sprintf("/api/organizations/%s/chat_conversations", orgUUID)
+
+	var sessions []SessionMeta
+	seen := make(map[string]bool)
+	query := url.Values{"limit": {strconv.Itoa(pageSize)}}
+	for {
+		body, err := get(token, endpoint, query)
+		if err != nil {
+			return nil, err
+		}
+		var page SessionsResponse
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("parsing sessions: %w", err)
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
-	}
+		added := 0
+		for _, s := range page.Sessions {
+			if !seen[s.ID] {
+				seen[s.ID] = true
+				sessions = append(sessions, s)
+				added++
+			}
+		}
+		// A page with nothing new means the API ignored the paging
+		if added == 0 {
+			return sessions, nil
+		}
 
-	return io.ReadAll(resp.Body)
+		query = url.Values{"limit": {strconv.Itoa(pageSize)}}
+		switch {
+		case page.NextCursor != "":
+			query.Set("cursor", page.NextCursor)
+		case page.HasMore:
+			query.Set("offset", strconv.Itoa(len(sessions)))
+		default:
+			return sessions, nil
+		}
+	}
 }
 
-// FetchSessions fetches all sessions from the Claude API
-func FetchSessions() ([]SessionMeta, error) {
-	token, err := getAccessToken()
-	if err != nil {
-		return nil, fmt.Errorf("getting access token: %w", err)
+// credentials finds the access token and organization to call the API with
+func credentials() (token, orgUUID string, err error) {
+	if token, err = getAccessToken(); err != nil {
+		return "", "", fmt.Errorf("getting access token: %w", err)
 	}
-
-	orgUUID, err := getOrgUUID()
-	if err != nil {
-		return nil, fmt.Errorf("getting org UUID: %w", err)
+	if orgUUID, err = getOrgUUID(); err != nil {
+		return "", "", fmt.Errorf("getting org UUID: %w", err)
 	}
+	return token, orgUUID, nil
+}
 
-	url := fmt.Sprintf("%s/api/organizations/%s/chat_conversations", apiBaseURL, orgUUID)
+// get makes a GET request to the API, retrying rate limits, server errors
+// and dropped connections
+func get(token, endpoint string, query url.Values) ([]byte, error) {
+	return httpretry.Do(func() ([]byte, error) {
+		return send(token, endpoint, query)
+	})
+}
 
-	req, err := http.NewRequest("GET", url, nil)
+// send makes one request, returning the response body on success
+func send(token, endpoint string, query url.Values) ([]byte, error) {
+	target := apiBaseURL + endpoint
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequest("GET", target, nil)
 	if err != nil {
 		return nil, err
 	}
-
 	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := &http.Client{Timeout: Timeout}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, httpretry.Backoff(err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, httpretry.Backoff(fmt.Errorf("reading response: %w", err))
 	}
-
-	var sessionsResp SessionsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&sessionsResp); err != nil {
-		return nil, err
+	if resp.StatusCode == http.StatusOK {
+		return body, nil
 	}
-
-	return sessionsResp.Sessions, nil
+	return nil, httpretry.Status(resp, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body)))
 }
 
-// getAccessToken retrieves the access token from keychain (macOS) or config
+// Where an access token was found, for auth status
+const (
+	SourceEnv      = "CLAUDE_ACCESS_TOKEN"
+	SourceKeyring  = "keyring"
+	SourceKeychain = "Claude's macOS Keychain entry"
+	SourceConfig   = "~/.claude.json"
+)
+
+// getAccessToken retrieves the access token from the environment, the one
+// saved by auth login, the macOS keychain or config
 func getAccessToken() (string, error) {
+	token, _, err := AccessToken()
+	return token, err
+}
+
+// AccessToken finds the access token and says where it came from
+func AccessToken() (token, source string, err error) {
 	// Try environment variable first
 	if token := os.Getenv("CLAUDE_ACCESS_TOKEN"); token != "" {
-		return token, nil
+		return token, SourceEnv, nil
+	}
+
+	// Then the token saved by auth login
+	if token, err := storedToken(); err == nil {
+		return token, SourceKeyring, nil
 	}
 
 	// Try macOS keychain
 	if runtime.GOOS == "darwin" {
 		token, err := getFromKeychain("api.claude.ai", "Claude")
 		if err == nil && token != "" {
-			return token, nil
+			return token, SourceKeychain, nil
 		}
 	}
 
@@ -137,12 +198,22 @@ func getAccessToken() (string, error) {
 		var config map[string]interface{}
 		if json.Unmarshal(data, &config) == nil {
 			if token, ok := config["access_token"].(string); ok && token != "" {
-				return token, nil
+				return token, SourceConfig, nil
 			}
 		}
 	}
 
-	return "", errors.New("no access token found. Set CLAUDE_ACCESS_TOKEN or authenticate with Claude")
+	return "", "", errors.New("no access token found. Set CLAUDE_ACCESS_TOKEN, run 'claude-session-export auth login' or authenticate with Claude")
+}
+
+// CheckToken makes a small API call with token, to see that it works
+func CheckToken(token string) error {
+	orgUUID, err := getOrgUUID()
+	if err != nil {
+		return fmt.Errorf("getting org UUID: %w", err)
+	}
+	_, err = get(token, fmt.Sprintf("/api/organizations/%s/chat_conversations", orgUUID), url.Values{"limit": {"1"}})
+	return err
 }
 
 // getOrgUUID retrieves the organization UUID from config
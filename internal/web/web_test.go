@@ -0,0 +1,93 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/httpretry"
+)
+
+func TestFetchSessionsPages(t *testing.T) {
+	t.Setenv("CLAUDE_ACCESS_TOKEN", "token")
+	t.Setenv("CLAUDE_ORG_UUID", "org")
+
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/organizations/org/chat_conversations" || r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Unexpected request %s", r.URL)
+		}
+		queries = append(queries, r.URL.RawQuery)
+		switch {
+		case r.URL.Query().Get("cursor") == "c2":
+			fmt.Fprint(w, `{"sessions":[{"id":"s3"}],"has_more":true}`)
+		case r.URL.Query().Get("offset") == "3":
+			// Repeats a session, then ends
+			fmt.Fprint(w, `{"sessions":[{"id":"s3"},{"id":"s4"}]}`)
+		default:
+			fmt.Fprint(w, `{"sessions":[{"id":"s1"},{"id":"s2"}],"next_cursor":"c2"}`)
+		}
+	}))
+	defer server.Close()
+	apiBaseURL = server.URL
+
+	sessions, err := FetchSessions()
+	if err != nil {
+		t.Fatalf("FetchSessions failed: %v", err)
+	}
+	if len(sessions) != 4 || sessions[3].ID != "s4" {
+		t.Errorf("Expected 4 sessions, got %+v", sessions)
+	}
+	if len(queries) != 3 || queries[1] != "cursor=c2&limit=100" || queries[2] != "limit=100&offset=3" {
+		t.Errorf("Unexpected page requests %q", queries)
+	}
+}
+
+func TestFetchSessionRetries(t *testing.T) {
+	t.Setenv("CLAUDE_ACCESS_TOKEN", "token")
+	t.Setenv("CLAUDE_ORG_UUID", "org")
+	var waited []time.Duration
+	httpretry.Sleep = func(d time.Duration) { waited = append(waited, d) }
+	defer func() { httpretry.Sleep = time.Sleep }()
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch calls {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 3:
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			fmt.Fprint(w, `{"type":"user"}`)
+		}
+	}))
+	defer server.Close()
+	apiBaseURL = server.URL
+
+	data, err := FetchSession("s1")
+	if err != nil {
+		t.Fatalf("FetchSession failed: %v", err)
+	}
+	if string(data) != `{"type":"user"}` {
+		t.Errorf("Unexpected body %q", data)
+	}
+	if want := []time.Duration{time.Second, 2 * time.Second, 7 * time.Second}; fmt.Sprint(waited) != fmt.Sprint(want) {
+		t.Errorf("Waited %v, want %v", waited, want)
+	}
+
+	// Client errors aren't retried
+	calls, waited = 0, nil
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	})
+	if _, err := FetchSession("missing"); err == nil || calls != 1 {
+		t.Errorf("Expected one failed request, got %d (%v)", calls, err)
+	}
+}
@@ -0,0 +1,157 @@
+package webhook
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/robzolkos/claude-session-export/internal/httpretry"
+)
+
+// ErrNoURL is returned when no webhook URL is configured
+var ErrNoURL = errors.New("no webhook URL: use --webhook-url, or set webhook.url in the config file")
+
+var httpClient = httpretry.NewClient()
+
+// Options says where and how to send an export
+type Options struct {
+	URL       string
+	Headers   http.Header // Added to every request, e.g. Authorization
+	SessionID string      // Sent as X-Claude-Session-Id
+	UserAgent string
+}
+
+// Result is the receiving service's answer
+type Result struct {
+	Status int
+	// Location is where the service says the export can be found: the
+	// Location header, or a url field in a JSON reply. Empty if neither.
+	Location string
+}
+
+// ParseHeader parses a "Name: value" header
+func ParseHeader(s string) (string, string, error) {
+	name, value, ok := strings.Cut(s, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.ContainsAny(name, " \t") {
+		return "", "", fmt.Errorf("header %q should look like \"Name: value\"", s)
+	}
+	return http.CanonicalHeaderKey(name), strings.TrimSpace(value), nil
+}
+
+// Send POSTs the file at path as the request body. The file name is given
+// in Content-Disposition and the type is taken from its extension. Every
+// attempt carries the file's SHA-256 as X-Claude-Delivery-Id, so a
+// receiver can drop a retried delivery it already accepted.
+func Send(path string, opts Options) (*Result, error) {
+	if !strings.HasPrefix(opts.URL, "http://") && !strings.HasPrefix(opts.URL, "https://") {
+		return nil, fmt.Errorf("webhook URL %q must start with http:// or https://", opts.URL)
+	}
+	id, err := deliveryID(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return httpretry.Do(func() (*Result, error) {
+		return send(path, id, opts)
+	})
+}
+
+// deliveryID is the hex SHA-256 of the file at path
+func deliveryID(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", fmt.Errorf("hashing %s: %w", filepath.Base(path), err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// send makes one request, retried by Send when the status says waiting
+// may help
+func send(path, id string, opts Options) (*Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, opts.URL, f)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", contentType(path))
+	req.Header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filepath.Base(path)}))
+	req.Header.Set("X-Claude-Delivery-Id", id)
+	if opts.SessionID != "" {
+		req.Header.Set("X-Claude-Session-Id", opts.SessionID)
+	}
+	if opts.UserAgent != "" {
+		req.Header.Set("User-Agent", opts.UserAgent)
+	}
+	for name, values := range opts.Headers {
+		req.Header[name] = values
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("sending to %s: %w", opts.URL, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		result := &Result{Status: resp.StatusCode, Location: resp.Header.Get("Location")}
+		if result.Location == "" {
+			var reply struct {
+				URL string `json:"url"`
+			}
+			if json.Unmarshal(body, &reply) == nil {
+				result.Location = reply.URL
+			}
+		}
+		return result, nil
+	}
+
+	message := strings.TrimSpace(string(body))
+	if len(message) > 200 {
+		message = message[:200] + "..."
+	}
+	err = fmt.Errorf("%s answered %s", opts.URL, resp.Status)
+	if message != "" {
+		err = fmt.Errorf("%w: %s", err, message)
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("%w (check the headers carrying credentials)", err)
+	}
+	return nil, httpretry.Status(resp, err)
+}
+
+func contentType(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".zip":
+		return "application/zip"
+	case ".jsonl":
+		return "application/x-ndjson"
+	case ".json":
+		return "application/json"
+	}
+	return "application/octet-stream"
+}
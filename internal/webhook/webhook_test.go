@@ -0,0 +1,130 @@
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robzolkos/claude-session-export/internal/httpretry"
+)
+
+func writeExport(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	os.WriteFile(path, []byte(content), 0644)
+	return path
+}
+
+func TestSend(t *testing.T) {
+	var got *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"url":"https://archive.acme.dev/t/9"}`))
+	}))
+	defer server.Close()
+
+	result, err := Send(writeExport(t, "myapp-2024-06-01-1400.jsonl", `{"type":"user"}`), Options{
+		URL:       server.URL + "/ingest",
+		Headers:   http.Header{"Authorization": {"Bearer secret"}},
+		SessionID: "abc",
+	})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if result.Status != http.StatusCreated || result.Location != "https://archive.acme.dev/t/9" {
+		t.Errorf("Expected the URL from the reply, got %+v", result)
+	}
+	if got.Method != http.MethodPost || got.URL.Path != "/ingest" || body != `{"type":"user"}` {
+		t.Errorf("Expected the file POSTed, got %s %s %q", got.Method, got.URL.Path, body)
+	}
+	for name, want := range map[string]string{
+		"Authorization":       "Bearer secret",
+		"Content-Type":        "application/x-ndjson",
+		"Content-Disposition": `attachment; filename=myapp-2024-06-01-1400.jsonl`,
+		"X-Claude-Session-Id": "abc",
+		// SHA-256 of the body
+		"X-Claude-Delivery-Id": "7ee087eced9da588b788e9f75d9324537b106cd6936d57238ec90d03dfe639d1",
+	} {
+		if v := got.Header.Get(name); v != want {
+			t.Errorf("Expected %s %q, got %q", name, want, v)
+		}
+	}
+}
+
+func TestSend_Location(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/zip" {
+			t.Errorf("Expected a zip, got %q", r.Header.Get("Content-Type"))
+		}
+		w.Header().Set("Location", "https://tickets.acme.dev/123")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	result, err := Send(writeExport(t, "export.zip", "PK"), Options{URL: server.URL})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if result.Location != "https://tickets.acme.dev/123" {
+		t.Errorf("Expected the Location header, got %+v", result)
+	}
+}
+
+func TestSend_Errors(t *testing.T) {
+	var waited []time.Duration
+	httpretry.Sleep = func(d time.Duration) { waited = append(waited, d) }
+	defer func() { httpretry.Sleep = time.Sleep }()
+
+	calls := 0
+	var ids []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		ids = append(ids, r.Header.Get("X-Claude-Delivery-Id"))
+		if r.URL.Path == "/flaky" && calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		if r.URL.Path == "/denied" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("bad token"))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	path := writeExport(t, "s.jsonl", "{}")
+
+	if _, err := Send(path, Options{URL: server.URL + "/flaky"}); err != nil || calls != 2 || len(waited) != 1 {
+		t.Errorf("Expected one retry after a 502, got %v after %d calls", err, calls)
+	}
+	if len(ids) != 2 || ids[0] == "" || ids[0] != ids[1] {
+		t.Errorf("Expected the retry to repeat the delivery ID, got %q", ids)
+	}
+	_, err := Send(path, Options{URL: server.URL + "/denied"})
+	if err == nil || !strings.Contains(err.Error(), "bad token") || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Expected the 403 with the service's message, got %v", err)
+	}
+	if _, err := Send(path, Options{URL: "ftp://example.com"}); err == nil {
+		t.Error("Expected an error for a non-HTTP URL")
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	name, value, err := ParseHeader("x-api-key:  abc: def ")
+	if err != nil || name != "X-Api-Key" || value != "abc: def" {
+		t.Errorf("Expected X-Api-Key \"abc: def\", got %q %q %v", name, value, err)
+	}
+	for _, bad := range []string{"novalue", ": value", "bad name: x"} {
+		if _, _, err := ParseHeader(bad); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package zipaes
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+)
+
+// WinZip AES constants: method 99 marks an encrypted entry, whose extra
+// field holds the real compression method. AE-2 leaves the CRC out, since
+// the HMAC already authenticates the data.
+const (
+	methodAES   = 99
+	extraID     = 0x9901
+	vendorAE2   = 2
+	strength256 = 3
+	saltLen     = 16
+	keyLen      = 32
+	macLen      = 10
+	iterations  = 1000
+)
+
+// Writer writes a zip whose files are all encrypted with AES-256, in the
+// WinZip format that 7-Zip, WinZip, Keka and The Unarchiver open
+type Writer struct {
+	zw       *zip.Writer
+	password string
+	name     string
+	buf      *bytes.Buffer // Contents of the file being written
+}
+
+// NewWriter returns a Writer encrypting with password
+func NewWriter(w io.Writer, password string) *Writer {
+	return &Writer{zw: zip.NewWriter(w), password: password}
+}
+
+// Create adds a file, like zip.Writer.Create. Its contents are encrypted
+// when the next file is created, or on Close.
+func (w *Writer) Create(name string) (io.Writer, error) {
+	if err := w.flush(); err != nil {
+		return nil, err
+	}
+	w.name = name
+	w.buf = &bytes.Buffer{}
+	return w.buf, nil
+}
+
+// Close writes the last file and the zip's central directory
+func (w *Writer) Close() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
+
+// flush compresses, encrypts and writes the pending file
+func (w *Writer) flush() error {
+	if w.buf == nil {
+		return nil
+	}
+	plain := w.buf
+	w.buf = nil
+
+	var compressed bytes.Buffer
+	fw, err := flate.NewWriter(&compressed, flate.DefaultCompression)
+	if err != nil {
+		return err
+	}
+	if _, err := fw.Write(plain.Bytes()); err != nil {
+		return err
+	}
+	if err := fw.Close(); err != nil {
+		return err
+	}
+
+	data, err := encrypt(compressed.Bytes(), w.password)
+	if err != nil {
+		return err
+	}
+
+	extra := make([]byte, 11)
+	binary.LittleEndian.PutUint16(extra[0:], extraID)
+	binary.LittleEndian.PutUint16(extra[2:], 7)
+	binary.LittleEndian.PutUint16(extra[4:], vendorAE2)
+	copy(extra[6:], "AE")
+	extra[8] = strength256
+	binary.LittleEndian.PutUint16(extra[9:], zip.Deflate)
+
+	out, err := w.zw.CreateRaw(&zip.FileHeader{
+		Name:               w.name,
+		Method:             methodAES,
+		Flags:              0x1, // Encrypted
+		Extra:              extra,
+		CompressedSize64:   uint64(len(data)),
+		UncompressedSize64: uint64(plain.Len()),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(data)
+	return err
+}
+
+// encrypt returns the stored form of compressed data: salt, password
+// verifier, ciphertext and authentication code
+func encrypt(data []byte, password string) ([]byte, error) {
+	if password == "" {
+		return nil, errors.New("empty zip password")
+	}
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	encKey, macKey, verifier := deriveKeys(password, salt)
+
+	out := make([]byte, 0, saltLen+2+len(data)+macLen)
+	out = append(out, salt...)
+	out = append(out, verifier...)
+	ciphertext, err := ctr(encKey, data)
+	if err != nil {
+		return nil, err
+	}
+	out = append(out, ciphertext...)
+	mac := hmac.New(sha1.New, macKey)
+	mac.Write(ciphertext)
+	return append(out, mac.Sum(nil)[:macLen]...), nil
+}
+
+// deriveKeys derives the AES key, HMAC key and 2-byte password verifier
+func deriveKeys(password string, salt []byte) (encKey, macKey, verifier []byte) {
+	key := pbkdf2([]byte(password), salt, iterations, 2*keyLen+2, sha1.New)
+	return key[:keyLen], key[keyLen : 2*keyLen], key[2*keyLen:]
+}
+
+// ctr is AES in counter mode as WinZip uses it: a little-endian block
+// counter starting at 1, unlike crypto/cipher's big-endian CTR. Running it
+// again decrypts.
+func ctr(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(data))
+	counter := make([]byte, aes.BlockSize)
+	stream := make([]byte, aes.BlockSize)
+	for i := 0; i < len(data); i += aes.BlockSize {
+		for j := range counter { // Increment
+			counter[j]++
+			if counter[j] != 0 {
+				break
+			}
+		}
+		block.Encrypt(stream, counter)
+		for j := i; j < len(data) && j < i+aes.BlockSize; j++ {
+			out[j] = data[j] ^ stream[j-i]
+		}
+	}
+	return out, nil
+}
+
+// pbkdf2 derives a key as in RFC 8018
+func pbkdf2(password, salt []byte, iter, keyLen int, h func() hash.Hash) []byte {
+	prf := hmac.New(h, password)
+	var key []byte
+	var index [4]byte
+	for block := uint32(1); len(key) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(index[:], block)
+		prf.Write(index[:])
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+		for n := 1; n < iter; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for i := range t {
+				t[i] ^= u[i]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
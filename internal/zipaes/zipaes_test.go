@@ -0,0 +1,101 @@
+package zipaes
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"testing"
+)
+
+func TestPBKDF2(t *testing.T) {
+	// RFC 6070 test vectors
+	for _, tt := range []struct {
+		iter int
+		want string
+	}{
+		{1, "0c60c80f961f0e71f3a9b524af6012062fe037a6"},
+		{2, "ea6c014dc72d6f8ccd1ed92ace1d41f0d8de8957"},
+		{4096, "4b007901b765489abead49d926f721d065a429c1"},
+	} {
+		got := hex.EncodeToString(pbkdf2([]byte("password"), []byte("salt"), tt.iter, 20, sha1.New))
+		if got != tt.want {
+			t.Errorf("pbkdf2 with %d iterations = %s, want %s", tt.iter, got, tt.want)
+		}
+	}
+	got := hex.EncodeToString(pbkdf2([]byte("passwordPASSWORDpassword"), []byte("saltSALTsaltSALTsaltSALTsaltSALTsalt"), 4096, 25, sha1.New))
+	if want := "3d2eec4fe41c849b80c8d83662c0e44a8b291a964cf2f07038"; got != want {
+		t.Errorf("pbkdf2 over two blocks = %s, want %s", got, want)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	files := map[string]string{
+		"viewer.html":   "<html>" + string(bytes.Repeat([]byte("secret transcript "), 100)) + "</html>",
+		"manifest.json": `{"watermark":"x"}`,
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf, "hunter2")
+	for _, name := range []string{"viewer.html", "manifest.json"} {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(f, files[name])
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("secret")) {
+		t.Fatal("Expected no plaintext in the zip")
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Reading zip failed: %v", err)
+	}
+	if len(r.File) != 2 {
+		t.Fatalf("Expected 2 files, got %d", len(r.File))
+	}
+	for _, f := range r.File {
+		if f.Method != methodAES || f.Flags&0x1 == 0 || !bytes.Equal(f.Extra, []byte{0x01, 0x99, 7, 0, 2, 0, 'A', 'E', 3, 8, 0}) {
+			t.Errorf("Expected %s marked as AES-256, got method %d, flags %x, extra %x", f.Name, f.Method, f.Flags, f.Extra)
+		}
+		raw, err := f.OpenRaw()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(raw)
+		salt, verifier := data[:saltLen], data[saltLen:saltLen+2]
+		ciphertext, code := data[saltLen+2:len(data)-macLen], data[len(data)-macLen:]
+
+		encKey, macKey, want := deriveKeys("hunter2", salt)
+		if !bytes.Equal(verifier, want) {
+			t.Errorf("%s: wrong password verifier", f.Name)
+		}
+		mac := hmac.New(sha1.New, macKey)
+		mac.Write(ciphertext)
+		if !bytes.Equal(code, mac.Sum(nil)[:macLen]) {
+			t.Errorf("%s: wrong authentication code", f.Name)
+		}
+		compressed, _ := ctr(encKey, ciphertext)
+		plain, err := io.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+		if err != nil {
+			t.Fatalf("%s: inflating failed: %v", f.Name, err)
+		}
+		if string(plain) != files[f.Name] || f.UncompressedSize64 != uint64(len(plain)) {
+			t.Errorf("%s: got %q", f.Name, plain)
+		}
+	}
+}
+
+func TestEmptyPassword(t *testing.T) {
+	w := NewWriter(io.Discard, "")
+	w.Create("a.txt")
+	if err := w.Close(); err == nil {
+		t.Error("Expected an error for an empty password")
+	}
+}
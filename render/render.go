@@ -0,0 +1,330 @@
+// Package render writes standalone session viewer pages, for embedding
+// the viewer in other Go programs. Sessions are given as Claude Code's
+// JSONL, which the viewer parses in the browser, so every entry it can
+// show reaches it as written.
+package render
+
+import (
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/robzolkos/claude-session-export/internal/session"
+)
+
+// ViewerHTML is the standalone session viewer page
+//
+//go:embed viewer.html
+var ViewerHTML []byte
+
+// Options controls how a session page is rendered
+type Options struct {
+	// Title replaces the page title (default: "Session Viewer")
+	Title string
+
+	// Theme selects a color palette (see Themes; default: dark)
+	Theme string
+
+	// ShowMeta shows meta, hook and API error annotations by default
+	ShowMeta bool
+
+	// NoEmoji replaces emoji icons with plain text
+	NoEmoji bool
+
+	// Header and Footer are HTML snippets (e.g. a logo or confidentiality
+	// notice) inserted at the top and bottom of the page as-is
+	Header string
+	Footer string
+
+	// Watermark is text repeated diagonally across the page
+	Watermark string
+
+	// FlagsURL is where the viewer loads and saves reviewer flags. Without
+	// it flags are kept in the browser and exported as a sidecar file.
+	FlagsURL string
+
+	// Pricing adds to or replaces the viewer's built-in model prices
+	Pricing Pricing
+
+	// Highlight marks a search query's matches and opens the page at the
+	// first one
+	Highlight *Highlight
+
+	// MaxBlockSize is how many characters of a tool's output are shown
+	// before a button for the rest (default: the viewer's own limits)
+	MaxBlockSize int
+
+	// NoTruncate shows tool output in full
+	NoTruncate bool
+
+	// CommitURLTemplate links the session's commits, with {hash} replaced
+	// by each commit's hash
+	CommitURLTemplate string
+
+	// IssueURLTemplate and MergeRequestURLTemplate link #N and !N
+	// references, with {n} replaced by the number
+	IssueURLTemplate        string
+	MergeRequestURLTemplate string
+
+	// BlobURLTemplate links the files under BlobRoot in tool headers, with
+	// {path} replaced by the file's path relative to BlobRoot
+	BlobURLTemplate string
+	BlobRoot        string
+
+	// EditorURLTemplate links file paths in tool headers to open in an
+	// editor instead, with {path} and {line} filled in (see Editors)
+	EditorURLTemplate string
+
+	// IndexURL is the page the g i shortcut goes back to (default: the top
+	// of the page)
+	IndexURL string
+
+	// PreviewImage is the URL of the page's link preview card, added as
+	// og:image with the page's title
+	PreviewImage string
+
+	// NoIndex asks search engines not to index the page or follow its links
+	NoIndex bool
+}
+
+// Pricing maps model names to their prices per million tokens
+type Pricing = session.Pricing
+
+// ModelPrice is one model's price per million tokens
+type ModelPrice = session.ModelPrice
+
+// Renderer writes a page for the session JSONL read from session
+type Renderer interface {
+	Render(w io.Writer, session io.Reader, opts Options) error
+}
+
+// RendererFunc lets an ordinary function be used as a Renderer
+type RendererFunc func(w io.Writer, session io.Reader, opts Options) error
+
+// Render calls f(w, session, opts)
+func (f RendererFunc) Render(w io.Writer, session io.Reader, opts Options) error {
+	return f(w, session, opts)
+}
+
+// Viewer renders the standalone viewer page with RenderTo
+var Viewer Renderer = RendererFunc(RenderTo)
+
+// Highlight is a search query to mark in the viewer
+type Highlight struct {
+	Query         string `json:"query"`
+	Regex         bool   `json:"regex,omitempty"`
+	CaseSensitive bool   `json:"caseSensitive,omitempty"`
+}
+
+// Themes lists the available color palettes
+var Themes = []string{"dark", "colorblind", "high-contrast", "plain"}
+
+// Editors maps the editors file paths can open in to their URLs
+var Editors = map[string]string{
+	"vscode": "vscode://file{path}:{line}",
+	"cursor": "cursor://file{path}:{line}",
+	"zed":    "zed://file{path}:{line}",
+	"idea":   "idea://open?file={path}&line={line}",
+}
+
+// ValidTheme reports whether name is one of Themes
+func ValidTheme(name string) bool {
+	for _, t := range Themes {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// dataMarker is where the encoded session is streamed into the page
+const dataMarker = "{{SESSION_DATA}}"
+
+var (
+	templateOnce   sync.Once
+	templatePrefix string
+	templateSuffix string
+)
+
+// localTemplate splits the viewer, prepared to load an embedded session,
+// around the point where the session data goes
+func localTemplate() (string, string) {
+	templateOnce.Do(func() {
+		page := string(ViewerHTML)
+
+		// Remove the URL input form
+		page = strings.Replace(page,
+			`<div class="url-form">`,
+			`<div class="url-form" style="display:none;">`, 1)
+
+		// Load the embedded data directly (no fetch needed). It's base64
+		// encoded to avoid escaping issues, and decoded back to UTF-8 text.
+		localLoadScript := `
+	<script>
+		window.LOCAL_MODE = true;
+		window.EMBEDDED_SESSION = new TextDecoder().decode(
+			Uint8Array.from(atob("` + dataMarker + `"), c => c.charCodeAt(0)));
+		window.addEventListener('DOMContentLoaded', function() {
+			try {
+				// Parse and render the embedded session data
+				parseJsonl(window.EMBEDDED_SESSION);
+				calculateActiveTime();
+				renderStats();
+				renderMessages();
+				document.getElementById('session-stats').classList.add('visible');
+			} catch (err) {
+				document.getElementById('status').textContent = 'Error: ' + err.message;
+				document.getElementById('status').className = 'status error';
+			}
+		});
+	</script>`
+
+		// Insert before </head>
+		page = strings.Replace(page, "</head>", localLoadScript+"</head>", 1)
+
+		// Remove the URL param auto-load at the end since we handle it ourselves
+		page = strings.Replace(page,
+			`// URL param support (from query string or injected by CLI)
+		const params = new URLSearchParams(window.location.search);
+		const urlParam = params.get('url') || window.GIST_URL;
+		if (urlParam) {
+			document.getElementById('gist-url').value = urlParam;
+			loadSession();
+		}`,
+			`// Local mode - loading handled by LOCAL_MODE script`, 1)
+
+		templatePrefix, templateSuffix, _ = strings.Cut(page, dataMarker)
+	})
+	return templatePrefix, templateSuffix
+}
+
+// RenderTo writes a standalone viewer page for the session JSONL read from
+// session. The session is encoded as it is copied, so the page is never
+// held in memory as a whole.
+func RenderTo(w io.Writer, session io.Reader, opts Options) error {
+	prefix, suffix := localTemplate()
+	if opts.Theme != "" && opts.Theme != "dark" {
+		prefix = strings.Replace(prefix, `<html lang="en">`,
+			`<html lang="en" data-theme="`+html.EscapeString(opts.Theme)+`">`, 1)
+	}
+	if opts.ShowMeta {
+		prefix = strings.Replace(prefix, "window.LOCAL_MODE = true;",
+			"window.LOCAL_MODE = true;\n\t\twindow.SHOW_META = true;", 1)
+	}
+	if opts.NoEmoji {
+		prefix = strings.Replace(prefix, "window.LOCAL_MODE = true;",
+			"window.LOCAL_MODE = true;\n\t\twindow.NO_EMOJI = true;", 1)
+	}
+	if len(opts.Pricing) > 0 {
+		// Marshal escapes <, > and &, so this can't close the script
+		pricing, err := json.Marshal(opts.Pricing)
+		if err != nil {
+			return fmt.Errorf("encoding pricing: %w", err)
+		}
+		prefix = strings.Replace(prefix, "window.LOCAL_MODE = true;",
+			"window.LOCAL_MODE = true;\n\t\twindow.PRICING = "+string(pricing)+";", 1)
+	}
+	if opts.FlagsURL != "" {
+		url, _ := json.Marshal(opts.FlagsURL)
+		prefix = strings.Replace(prefix, "window.LOCAL_MODE = true;",
+			"window.LOCAL_MODE = true;\n\t\twindow.FLAGS_URL = "+string(url)+";", 1)
+	}
+	if opts.Watermark != "" {
+		text, _ := json.Marshal(opts.Watermark)
+		prefix = strings.Replace(prefix, "window.LOCAL_MODE = true;",
+			"window.LOCAL_MODE = true;\n\t\twindow.WATERMARK = "+string(text)+";", 1)
+	}
+	if opts.CommitURLTemplate != "" {
+		url, _ := json.Marshal(opts.CommitURLTemplate)
+		prefix = strings.Replace(prefix, "window.LOCAL_MODE = true;",
+			"window.LOCAL_MODE = true;\n\t\twindow.COMMIT_URL = "+string(url)+";", 1)
+	}
+	if opts.IssueURLTemplate != "" {
+		url, _ := json.Marshal(opts.IssueURLTemplate)
+		prefix = strings.Replace(prefix, "window.LOCAL_MODE = true;",
+			"window.LOCAL_MODE = true;\n\t\twindow.ISSUE_URL = "+string(url)+";", 1)
+	}
+	if opts.MergeRequestURLTemplate != "" {
+		url, _ := json.Marshal(opts.MergeRequestURLTemplate)
+		prefix = strings.Replace(prefix, "window.LOCAL_MODE = true;",
+			"window.LOCAL_MODE = true;\n\t\twindow.MERGE_REQUEST_URL = "+string(url)+";", 1)
+	}
+	if opts.BlobURLTemplate != "" && opts.BlobRoot != "" {
+		url, _ := json.Marshal(opts.BlobURLTemplate)
+		root, _ := json.Marshal(opts.BlobRoot)
+		prefix = strings.Replace(prefix, "window.LOCAL_MODE = true;",
+			"window.LOCAL_MODE = true;\n\t\twindow.BLOB_URL = "+string(url)+";\n\t\twindow.BLOB_ROOT = "+string(root)+";", 1)
+	}
+	if opts.EditorURLTemplate != "" {
+		url, _ := json.Marshal(opts.EditorURLTemplate)
+		prefix = strings.Replace(prefix, "window.LOCAL_MODE = true;",
+			"window.LOCAL_MODE = true;\n\t\twindow.EDITOR_URL = "+string(url)+";", 1)
+	}
+	if opts.IndexURL != "" {
+		url, _ := json.Marshal(opts.IndexURL)
+		prefix = strings.Replace(prefix, "window.LOCAL_MODE = true;",
+			"window.LOCAL_MODE = true;\n\t\twindow.INDEX_URL = "+string(url)+";", 1)
+	}
+	if opts.Highlight != nil && opts.Highlight.Query != "" {
+		query, _ := json.Marshal(opts.Highlight)
+		prefix = strings.Replace(prefix, "window.LOCAL_MODE = true;",
+			"window.LOCAL_MODE = true;\n\t\twindow.HIGHLIGHT = "+string(query)+";", 1)
+	}
+	if opts.NoTruncate || opts.MaxBlockSize > 0 {
+		size := opts.MaxBlockSize
+		if opts.NoTruncate {
+			size = 0
+		}
+		prefix = strings.Replace(prefix, "window.LOCAL_MODE = true;",
+			fmt.Sprintf("window.LOCAL_MODE = true;\n\t\twindow.MAX_BLOCK_SIZE = %d;", size), 1)
+	}
+	if opts.Title != "" {
+		prefix = strings.Replace(prefix, "<title>Session Viewer</title>",
+			"<title>"+html.EscapeString(opts.Title)+"</title>", 1)
+	}
+	if opts.NoIndex {
+		prefix = strings.Replace(prefix, "</title>",
+			"</title>\n\t<meta name=\"robots\" content=\"noindex, nofollow\">", 1)
+	}
+	if opts.PreviewImage != "" {
+		title := opts.Title
+		if title == "" {
+			title = "Claude Code session"
+		}
+		prefix = strings.Replace(prefix, "</title>", "</title>\n"+
+			"\t<meta property=\"og:type\" content=\"article\">\n"+
+			"\t<meta property=\"og:title\" content=\""+html.EscapeString(title)+"\">\n"+
+			"\t<meta property=\"og:image\" content=\""+html.EscapeString(opts.PreviewImage)+"\">\n"+
+			"\t<meta name=\"twitter:card\" content=\"summary_large_image\">", 1)
+	}
+
+	// The page body follows the embedded data
+	if opts.Header != "" {
+		suffix = strings.Replace(suffix, "<body>",
+			"<body>\n\t<div class=\"branding branding-header\">"+opts.Header+"</div>", 1)
+	}
+	if opts.Footer != "" {
+		suffix = strings.Replace(suffix, "</body>",
+			"\t<div class=\"branding branding-footer\">"+opts.Footer+"</div>\n</body>", 1)
+	}
+
+	if _, err := io.WriteString(w, prefix); err != nil {
+		return err
+	}
+
+	enc := base64.NewEncoder(base64.StdEncoding, w)
+	if _, err := io.Copy(enc, session); err != nil {
+		return fmt.Errorf("encoding session: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, suffix)
+	return err
+}
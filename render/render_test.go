@@ -0,0 +1,155 @@
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestRenderToEmbedsSession(t *testing.T) {
+	data := `{"type":"user","message":{"role":"user","content":"héllo"}}` + "\n"
+
+	var buf bytes.Buffer
+	if err := RenderTo(&buf, strings.NewReader(data), Options{Title: "Fix <login>"}); err != nil {
+		t.Fatalf("RenderTo failed: %v", err)
+	}
+	page := buf.String()
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(data))
+	if !strings.Contains(page, `atob("`+encoded+`")`) {
+		t.Error("Expected session embedded as base64")
+	}
+	if strings.Contains(page, dataMarker) {
+		t.Error("Expected data marker to be replaced")
+	}
+	if !strings.Contains(page, "<title>Fix &lt;login&gt;</title>") {
+		t.Error("Expected escaped custom title")
+	}
+	if !strings.Contains(page, "window.LOCAL_MODE = true") {
+		t.Error("Expected local mode script")
+	}
+
+	buf.Reset()
+	RenderTo(&buf, strings.NewReader(data), Options{Theme: "colorblind"})
+	if !strings.Contains(buf.String(), `<html lang="en" data-theme="colorblind">`) {
+		t.Error("Expected theme set on the html element")
+	}
+	if strings.Contains(buf.String(), "window.SHOW_META = true") {
+		t.Error("Expected meta hidden by default")
+	}
+
+	buf.Reset()
+	RenderTo(&buf, strings.NewReader(data), Options{ShowMeta: true})
+	if !strings.Contains(buf.String(), "window.SHOW_META = true") {
+		t.Error("Expected meta shown when requested")
+	}
+
+	buf.Reset()
+	RenderTo(&buf, strings.NewReader(data), Options{NoEmoji: true})
+	if !strings.Contains(buf.String(), "window.NO_EMOJI = true") {
+		t.Error("Expected plain-text mode when emoji are turned off")
+	}
+
+	buf.Reset()
+	RenderTo(&buf, strings.NewReader(data), Options{Header: "<b>ACME</b>", Footer: "Confidential"})
+	page = buf.String()
+	if !strings.Contains(page, `<body>
+	<div class="branding branding-header"><b>ACME</b></div>`) {
+		t.Error("Expected header snippet at the top of the body")
+	}
+	if !strings.Contains(page, `<div class="branding branding-footer">Confidential</div>
+</body>`) {
+		t.Error("Expected footer snippet at the end of the body")
+	}
+
+	buf.Reset()
+	RenderTo(&buf, strings.NewReader(data), Options{Watermark: `CONFIDENTIAL – "ACME"`})
+	if !strings.Contains(buf.String(), `window.WATERMARK = "CONFIDENTIAL – \"ACME\"";`) {
+		t.Error("Expected watermark text passed to the viewer")
+	}
+
+	buf.Reset()
+	RenderTo(&buf, strings.NewReader(data), Options{FlagsURL: "/api/archives/a/sessions/s1/flags"})
+	if !strings.Contains(buf.String(), `window.FLAGS_URL = "/api/archives/a/sessions/s1/flags";`) {
+		t.Error("Expected flags URL passed to the viewer")
+	}
+
+	buf.Reset()
+	RenderTo(&buf, strings.NewReader(data), Options{CommitURLTemplate: "https://git.example.com/team/app/commit/{hash}"})
+	if !strings.Contains(buf.String(), `window.COMMIT_URL = "https://git.example.com/team/app/commit/{hash}";`) {
+		t.Error("Expected commit URL template passed to the viewer")
+	}
+
+	buf.Reset()
+	RenderTo(&buf, strings.NewReader(data), Options{IssueURLTemplate: "https://gitlab.com/a/b/-/issues/{n}", MergeRequestURLTemplate: "https://gitlab.com/a/b/-/merge_requests/{n}"})
+	if !strings.Contains(buf.String(), `window.ISSUE_URL = "https://gitlab.com/a/b/-/issues/{n}";`) ||
+		!strings.Contains(buf.String(), `window.MERGE_REQUEST_URL = "https://gitlab.com/a/b/-/merge_requests/{n}";`) {
+		t.Error("Expected issue and merge request URL templates passed to the viewer")
+	}
+
+	buf.Reset()
+	RenderTo(&buf, strings.NewReader(data), Options{BlobURLTemplate: "https://github.com/a/b/blob/main/{path}", BlobRoot: "/home/alice/b"})
+	if !strings.Contains(buf.String(), `window.BLOB_URL = "https://github.com/a/b/blob/main/{path}";`) ||
+		!strings.Contains(buf.String(), `window.BLOB_ROOT = "/home/alice/b";`) {
+		t.Error("Expected blob URL template and root passed to the viewer")
+	}
+
+	buf.Reset()
+	RenderTo(&buf, strings.NewReader(data), Options{EditorURLTemplate: Editors["idea"]})
+	if !strings.Contains(buf.String(), `window.EDITOR_URL = "idea://open?file={path}\u0026line={line}";`) {
+		t.Error("Expected editor URL template passed to the viewer")
+	}
+
+	buf.Reset()
+	RenderTo(&buf, strings.NewReader(data), Options{Title: "Fix <the> tests", PreviewImage: "https://acme.test/a.preview.png"})
+	if !strings.Contains(buf.String(), `<meta property="og:title" content="Fix &lt;the&gt; tests">`) ||
+		!strings.Contains(buf.String(), `<meta property="og:image" content="https://acme.test/a.preview.png">`) {
+		t.Error("Expected og:title and og:image tags for the preview image")
+	}
+
+	buf.Reset()
+	RenderTo(&buf, strings.NewReader(data), Options{NoIndex: true})
+	if !strings.Contains(buf.String(), `<meta name="robots" content="noindex, nofollow">`) {
+		t.Error("Expected a robots meta tag")
+	}
+
+	buf.Reset()
+	RenderTo(&buf, strings.NewReader(data), Options{IndexURL: "/u/alice/"})
+	if !strings.Contains(buf.String(), `window.INDEX_URL = "/u/alice/";`) {
+		t.Error("Expected index URL passed to the viewer")
+	}
+
+	buf.Reset()
+	RenderTo(&buf, strings.NewReader(data), Options{Highlight: &Highlight{Query: `</script>\d+`, Regex: true}})
+	if !strings.Contains(buf.String(), `window.HIGHLIGHT = {"query":"\u003c/script\u003e\\d+","regex":true};`) {
+		t.Error("Expected the escaped search query passed to the viewer")
+	}
+
+	buf.Reset()
+	RenderTo(&buf, strings.NewReader(data), Options{MaxBlockSize: 5000})
+	if !strings.Contains(buf.String(), "window.MAX_BLOCK_SIZE = 5000;") {
+		t.Error("Expected the block size passed to the viewer")
+	}
+	buf.Reset()
+	RenderTo(&buf, strings.NewReader(data), Options{NoTruncate: true})
+	if !strings.Contains(buf.String(), "window.MAX_BLOCK_SIZE = 0;") {
+		t.Error("Expected truncation turned off in the viewer")
+	}
+}
+
+func TestViewerMatchesRenderTo(t *testing.T) {
+	data := `{"type":"user","message":{"role":"user","content":"hi"}}`
+	opts := Options{Title: "Greeting", Theme: "plain"}
+
+	var want, got bytes.Buffer
+	if err := RenderTo(&want, strings.NewReader(data), opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := Viewer.Render(&got, strings.NewReader(data), opts); err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != want.String() {
+		t.Error("Expected Viewer to render the same page as RenderTo")
+	}
+}